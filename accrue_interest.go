@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"github.com/AbdRaqeeb/simple_bank/interest"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/rs/zerolog/log"
+	"io"
+	"time"
+)
+
+const accrueInterestUsage = "usage: simple_bank accrue-interest [-date YYYY-MM-DD]"
+
+// runAccrueInterest executes the accrue-interest subcommand, accruing a day of interest on the savings accounts
+// and posting the month on its last day. It writes the report of the run to out as JSON
+func runAccrueInterest(config util.Config, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("accrue-interest", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	day := flags.String("date", "", "UTC day to accrue, yesterday when empty")
+
+	err := flags.Parse(args)
+	if err != nil || flags.NArg() != 0 {
+		return errors.New(accrueInterestUsage)
+	}
+
+	date := time.Now().UTC().AddDate(0, 0, -1)
+	if *day != "" {
+		date, err = time.Parse("2006-01-02", *day)
+		if err != nil {
+			return errors.New(accrueInterestUsage)
+		}
+	}
+
+	store, _, err := newStore(config)
+	if err != nil {
+		return err
+	}
+
+	report, err := interest.NewJob(store, log.Logger).Run(context.Background(), date)
+	if err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("date", report.Date).
+		Int("accounts", report.Accounts).
+		Int("accrued", report.Accrued).
+		Int("posted", report.Posted).
+		Int("failed", report.Failed).
+		Msg("interest accrued")
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(report)
+	if err != nil {
+		return err
+	}
+
+	if report.Failed > 0 {
+		return errors.New("interest failed for some accounts, rerun the day to complete them")
+	}
+
+	return nil
+}
@@ -3,15 +3,31 @@ package api
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/token"
+	"github.com/AbdRaqeeb/simple_bank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
 	"net/http"
 )
 
+// accountResponse is an account with its balance formatted in the major unit of its currency
+type accountResponse struct {
+	db.Account
+	BalanceFormatted string `json:"balance_formatted"`
+}
+
+func newAccountResponse(account db.Account) accountResponse {
+	return accountResponse{
+		Account:          account,
+		BalanceFormatted: util.NewMoney(account.Balance, account.Currency).Decimal(),
+	}
+}
+
 type createAccountRequest struct {
-	Owner    string `json:"owner" binding:"required"`
-	Currency string `json:"currency" binding:"required,currency"`
+	Currency    string `json:"currency" binding:"required,currency"`
+	ProductCode string `json:"product_code" binding:"max=64"`
 }
 
 type getAccountRequest struct {
@@ -23,6 +39,7 @@ type listAccountsRequest struct {
 	Size int32 `form:"size" binding:"required,min=5,max=10"`
 }
 
+// createAccount opens an account of a product for the authenticated user, checking accounts when no product is given
 func (server *Server) createAccount(ctx *gin.Context) {
 	var req createAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -30,29 +47,51 @@ func (server *Server) createAccount(ctx *gin.Context) {
 		return
 	}
 
+	if req.ProductCode == "" {
+		req.ProductCode = db.AccountProductChecking
+	}
+
+	product, err := server.store.GetAccountProduct(ctx.Request.Context(), req.ProductCode)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errAccountProductNotFound))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !db.ProductAllowsCurrency(product, req.Currency) {
+		err = fmt.Errorf("account product [%s] does not support currency %s", product.Code, req.Currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	arg := db.CreateAccountParams{
-		Owner:    req.Owner,
-		Currency: req.Currency,
-		Balance:  0,
+		Owner:       payload.Username,
+		Currency:    req.Currency,
+		Balance:     0,
+		ProductCode: product.Code,
 	}
 
-	account, err := server.store.CreateAccount(ctx, arg)
+	account, err := server.store.CreateAccountTx(ctx.Request.Context(), arg)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-			case "unique_violation":
-				ctx.JSON(http.StatusForbidden, errorResponse(errors.New("owner has an account with the currency type")))
-				return
-			case "foreign_key_violation":
-				ctx.JSON(http.StatusBadRequest, errorResponse(errors.New("owner does not exist")))
-				return
-			}
+		if errors.Is(err, db.ErrAccountExists) {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errors.New("owner does not exist")))
+			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, account)
+	ctx.JSON(http.StatusCreated, newAccountResponse(account))
 }
 
 func (server *Server) getAccount(ctx *gin.Context) {
@@ -62,7 +101,7 @@ func (server *Server) getAccount(ctx *gin.Context) {
 		return
 	}
 
-	account, err := server.store.GetAccount(ctx, req.ID)
+	account, err := server.store.GetAccount(ctx.Request.Context(), req.ID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
@@ -73,7 +112,13 @@ func (server *Server) getAccount(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != payload.Username {
+		ctx.JSON(http.StatusForbidden, errorResponse(errAccountNotOwned))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
 }
 
 func (server *Server) listAccounts(ctx *gin.Context) {
@@ -83,16 +128,50 @@ func (server *Server) listAccounts(ctx *gin.Context) {
 		return
 	}
 
+	payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	arg := db.ListAccountsParams{
+		Owner:  payload.Username,
 		Offset: (req.Page - 1) * req.Size,
 		Limit:  req.Size,
 	}
 
-	account, err := server.store.ListAccounts(ctx, arg)
+	accounts, err := server.store.ListAccounts(ctx.Request.Context(), arg)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	rsp := make([]accountResponse, len(accounts))
+	for i, account := range accounts {
+		rsp[i] = newAccountResponse(account)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// freezeAccount stops an account from sending and receiving transfers, it is restricted to admins
+func (server *Server) freezeAccount(ctx *gin.Context) {
+	var req getAccountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.FreezeAccountTx(ctx.Request.Context(), req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		if errors.Is(err, db.ErrAccountAlreadyFrozen) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
 }
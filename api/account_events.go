@@ -0,0 +1,252 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/token"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	lastEventIDHeader = "Last-Event-ID"
+
+	// replayPageSize is how many entries are read at once when a stream catches up
+	replayPageSize = 100
+)
+
+var (
+	errAccountNotOwned    = errors.New("account doesn't belong to the authenticated user")
+	errInvalidLastEventID = errors.New("Last-Event-ID must be an entry sequence")
+)
+
+type accountEventsRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// entryEvent is the data of entry events, the id of the event is the sequence of the entry in its account
+type entryEvent struct {
+	ID        int64     `json:"id"`
+	AccountID int64     `json:"account_id"`
+	Sequence  int64     `json:"sequence"`
+	Amount    int64     `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// balanceEvent is the data of balance events
+type balanceEvent struct {
+	AccountID        int64  `json:"account_id"`
+	Balance          int64  `json:"balance"`
+	BalanceFormatted string `json:"balance_formatted"`
+	Currency         string `json:"currency"`
+}
+
+// streamAccountEvents streams the entries of an account of the authenticated user as server-sent events,
+// each followed by the balance of the account. Event ids are the sequences of the entries in the account, which
+// follow the order their transactions commit in unlike entry ids. A reconnecting client sends the last one it
+// received as Last-Event-ID and first receives the entries it missed. The stream starts with the balance
+func (server *Server) streamAccountEvents(ctx *gin.Context) {
+	var req accountEventsRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var lastSequence int64
+	resume := ctx.GetHeader(lastEventIDHeader) != ""
+	if resume {
+		var err error
+		lastSequence, err = strconv.ParseInt(ctx.GetHeader(lastEventIDHeader), 10, 64)
+		if err != nil || lastSequence < 0 {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidLastEventID))
+			return
+		}
+	}
+
+	requestCtx := ctx.Request.Context()
+
+	account, err := server.store.GetAccount(requestCtx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != payload.Username {
+		ctx.JSON(http.StatusForbidden, errorResponse(errAccountNotOwned))
+		return
+	}
+
+	// subscribe before catching up so no entry committed in between is missed
+	entries, err := server.store.SubscribeEntries(requestCtx, account.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrEntryNotificationsDisabled) {
+			ctx.JSON(http.StatusServiceUnavailable, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !resume {
+		lastSequence, err = server.store.GetLastEntrySequence(requestCtx, account.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	// entries sent while catching up may be received again from the subscription
+	sent := make(map[int64]bool)
+
+	for resume {
+		missed, err := server.store.ListEntriesAfter(requestCtx, db.ListEntriesAfterParams{
+			AccountID: account.ID,
+			Sequence:  lastSequence,
+			Limit:     replayPageSize,
+		})
+		if err != nil {
+			logStreamError(ctx, err)
+			return
+		}
+
+		for _, entry := range missed {
+			if writeEntryEvent(ctx.Writer, entry) != nil {
+				return
+			}
+			sent[entry.ID] = true
+			lastSequence = entry.Sequence
+		}
+
+		resume = len(missed) == replayPageSize
+	}
+
+	if server.writeBalanceEvent(ctx, account.ID, lastSequence) != nil {
+		return
+	}
+
+	var heartbeat <-chan time.Time
+	if server.config.EventStreamHeartbeatInterval > 0 {
+		ticker := time.NewTicker(server.config.EventStreamHeartbeatInterval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	for {
+		select {
+		case entry, ok := <-entries:
+			// the subscription ends when entries may have been missed, the client reconnects and catches up
+			if !ok {
+				return
+			}
+
+			if sent[entry.ID] {
+				continue
+			}
+
+			if writeEntryEvent(ctx.Writer, entry) != nil {
+				return
+			}
+
+			if entry.Sequence > lastSequence {
+				lastSequence = entry.Sequence
+			}
+
+			if server.writeBalanceEvent(ctx, account.ID, lastSequence) != nil {
+				return
+			}
+		case <-heartbeat:
+			_, err = io.WriteString(ctx.Writer, ": heartbeat\n\n")
+			if err != nil {
+				return
+			}
+			ctx.Writer.Flush()
+		case <-requestCtx.Done():
+			return
+		case <-server.streamsClosing:
+			return
+		}
+	}
+}
+
+// writeBalanceEvent sends the current balance of an account, with the sequence of the last entry streamed so far
+// so a client reconnecting before it receives any entry does not miss the entries committed meanwhile
+func (server *Server) writeBalanceEvent(ctx *gin.Context, accountID int64, lastSequence int64) error {
+	account, err := server.store.GetAccount(ctx.Request.Context(), accountID)
+	if err != nil {
+		logStreamError(ctx, err)
+		return err
+	}
+
+	id := ""
+	if lastSequence > 0 {
+		id = strconv.FormatInt(lastSequence, 10)
+	}
+
+	return writeEvent(ctx.Writer, id, "balance", balanceEvent{
+		AccountID:        account.ID,
+		Balance:          account.Balance,
+		BalanceFormatted: util.NewMoney(account.Balance, account.Currency).Decimal(),
+		Currency:         account.Currency,
+	})
+}
+
+// logStreamError logs an error ending a stream, unless the client went away
+func logStreamError(ctx *gin.Context, err error) {
+	if ctx.Request.Context().Err() == nil {
+		zerolog.Ctx(ctx.Request.Context()).Error().Err(err).Msg("cannot stream account events")
+	}
+}
+
+func writeEntryEvent(w gin.ResponseWriter, entry db.Entry) error {
+	return writeEvent(w, strconv.FormatInt(entry.Sequence, 10), "entry", entryEvent{
+		ID:        entry.ID,
+		AccountID: entry.AccountID,
+		Sequence:  entry.Sequence,
+		Amount:    entry.Amount,
+		CreatedAt: entry.CreatedAt,
+	})
+}
+
+// writeEvent sends a server-sent event, an event without id leaves the last event id of the client unchanged
+func writeEvent(w gin.ResponseWriter, id string, event string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if id != "" {
+		_, err = fmt.Fprintf(w, "id: %s\n", id)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, body)
+	if err != nil {
+		return err
+	}
+
+	w.Flush()
+	return nil
+}
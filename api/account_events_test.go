@@ -0,0 +1,378 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	mockdb "github.com/AbdRaqeeb/simple_bank/db/mock"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// closedEntries is a subscription that already ended, so streams stop once they caught up
+func closedEntries() <-chan db.Entry {
+	ch := make(chan db.Entry)
+	close(ch)
+	return ch
+}
+
+func TestStreamAccountEventsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount()
+	account.Owner = user.Username
+
+	entry1 := db.Entry{ID: 4, AccountID: account.ID, Sequence: 4, Amount: 10, CreatedAt: time.Now()}
+	entry2 := db.Entry{ID: 5, AccountID: account.ID, Sequence: 5, Amount: -3, CreatedAt: time.Now()}
+
+	// the transaction of the entry with the lower id committed last
+	committedFirst := db.Entry{ID: 12, AccountID: account.ID, Sequence: 6, Amount: 7, CreatedAt: time.Now()}
+	committedLast := db.Entry{ID: 11, AccountID: account.ID, Sequence: 7, Amount: -2, CreatedAt: time.Now()}
+
+	testCases := []struct {
+		name          string
+		lastEventID   string
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(2).Return(account, nil)
+				store.EXPECT().SubscribeEntries(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(closedEntries(), nil)
+				store.EXPECT().GetLastEntrySequence(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(int64(5), nil)
+				store.EXPECT().ListEntriesAfter(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+
+				expected := fmt.Sprintf("id: 5\nevent: balance\ndata: {\"account_id\":%d,\"balance\":%d,\"balance_formatted\":%q,\"currency\":%q}\n\n",
+					account.ID, account.Balance, util.NewMoney(account.Balance, account.Currency).Decimal(), account.Currency)
+				require.Equal(t, expected, recorder.Body.String())
+			},
+		},
+		{
+			name:        "Resume",
+			lastEventID: "3",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(2).Return(account, nil)
+				store.EXPECT().SubscribeEntries(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(closedEntries(), nil)
+				store.EXPECT().GetLastEntrySequence(gomock.Any(), gomock.Any()).Times(0)
+
+				arg := db.ListEntriesAfterParams{AccountID: account.ID, Sequence: 3, Limit: replayPageSize}
+				store.EXPECT().ListEntriesAfter(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]db.Entry{entry1, entry2}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				events := readEvents(t, bufio.NewReader(recorder.Body), 3)
+				require.Equal(t, []string{"4", "5", "5"}, []string{events[0].id, events[1].id, events[2].id})
+				require.Equal(t, []string{"entry", "entry", "balance"}, []string{events[0].event, events[1].event, events[2].event})
+
+				var entry entryEvent
+				require.NoError(t, json.Unmarshal([]byte(events[1].data), &entry))
+				require.Equal(t, entry2.Amount, entry.Amount)
+			},
+		},
+		{
+			name: "Entries Committed Out Of ID Order",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				entries := make(chan db.Entry, 2)
+				entries <- committedFirst
+				entries <- committedLast
+				close(entries)
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(4).Return(account, nil)
+				store.EXPECT().SubscribeEntries(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(entries, nil)
+				store.EXPECT().GetLastEntrySequence(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(int64(5), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				// a client disconnecting after any of these resumes after the last entry it received
+				events := readEvents(t, bufio.NewReader(recorder.Body), 5)
+				require.Equal(t, []string{"5", "6", "6", "7", "7"}, []string{events[0].id, events[1].id, events[2].id, events[3].id, events[4].id})
+				require.Equal(t, committedLast.ID, mustParseEntry(t, events[3].data).ID)
+			},
+		},
+		{
+			name:        "Resume After Entries Committed Out Of ID Order",
+			lastEventID: "6",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(2).Return(account, nil)
+				store.EXPECT().SubscribeEntries(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(closedEntries(), nil)
+
+				// the entry committed last is found although its id is lower than the one the client received
+				arg := db.ListEntriesAfterParams{AccountID: account.ID, Sequence: committedFirst.Sequence, Limit: replayPageSize}
+				store.EXPECT().ListEntriesAfter(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]db.Entry{committedLast}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				events := readEvents(t, bufio.NewReader(recorder.Body), 2)
+				require.Equal(t, []string{"entry", "balance"}, []string{events[0].event, events[1].event})
+				require.Equal(t, []string{"7", "7"}, []string{events[0].id, events[1].id})
+				require.Equal(t, committedLast.ID, mustParseEntry(t, events[0].data).ID)
+			},
+		},
+		{
+			name:        "Invalid Last Event ID",
+			lastEventID: "abc",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "No Authorization",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Not Owner",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, util.RandomOwner(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().SubscribeEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "Not Found",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Notifications Disabled",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().SubscribeEntries(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(nil, db.ErrEntryNotificationsDisabled)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(_ context.Context, username string) (db.User, error) {
+				authenticated := user
+				authenticated.Username = username
+				return authenticated, nil
+			})
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/events", account.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			if tc.lastEventID != "" {
+				request.Header.Set(lastEventIDHeader, tc.lastEventID)
+			}
+
+			tc.setupAuth(t, request, server)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// sentEvent is a server-sent event read by readEvents
+type sentEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// readEvents reads n events from a stream, skipping comments
+func readEvents(t *testing.T, reader *bufio.Reader, n int) []sentEvent {
+	var events []sentEvent
+	var event sentEvent
+
+	for len(events) < n {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "":
+			if event != (sentEvent{}) {
+				events = append(events, event)
+			}
+			event = sentEvent{}
+		case strings.HasPrefix(line, "id: "):
+			event.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+
+	return events
+}
+
+func TestStreamAccountEventsWithMemoryStore(t *testing.T) {
+	store := db.NewMemoryStore()
+	server := newTestServer(t, store)
+	server.config.EventStreamHeartbeatInterval = 10 * time.Millisecond
+
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	ctx := context.Background()
+
+	account := createRandomMemoryAccount(t, store)
+	other := createRandomMemoryAccount(t, store)
+
+	transfer := func(from, to int64, amount int64) {
+		_, err := store.TransferTx(ctx, db.TransferTxParams{FromAccountID: from, ToAccountID: to, Amount: amount})
+		require.NoError(t, err)
+	}
+
+	// an entry from before the stream is not sent again
+	transfer(account.ID, other.ID, 10)
+
+	openStream := func(lastEventID string) (*http.Response, *bufio.Reader) {
+		request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/accounts/%d/events", httpServer.URL, account.ID), nil)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, account.Owner, time.Minute)
+		if lastEventID != "" {
+			request.Header.Set(lastEventIDHeader, lastEventID)
+		}
+
+		response, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		return response, bufio.NewReader(response.Body)
+	}
+
+	response, reader := openStream("")
+
+	events := readEvents(t, reader, 1)
+	require.Equal(t, "balance", events[0].event)
+	require.JSONEq(t, fmt.Sprintf(`{"account_id":%d,"balance":90,"balance_formatted":"0.90","currency":"USD"}`, account.ID), events[0].data)
+	firstSequence := events[0].id
+	require.NotEmpty(t, firstSequence)
+
+	// entries of other accounts are not streamed
+	transfer(other.ID, createRandomMemoryAccount(t, store).ID, 5)
+	transfer(other.ID, account.ID, 25)
+
+	events = readEvents(t, reader, 2)
+	require.Equal(t, "entry", events[0].event)
+	require.Equal(t, "balance", events[1].event)
+	require.Equal(t, events[0].id, events[1].id)
+
+	var entry entryEvent
+	require.NoError(t, json.Unmarshal([]byte(events[0].data), &entry))
+	require.Equal(t, int64(25), entry.Amount)
+	require.Equal(t, account.ID, entry.AccountID)
+	require.JSONEq(t, fmt.Sprintf(`{"account_id":%d,"balance":115,"balance_formatted":"1.15","currency":"USD"}`, account.ID), events[1].data)
+
+	response.Body.Close()
+
+	// entries committed while disconnected are sent on reconnect
+	transfer(account.ID, other.ID, 15)
+
+	response, reader = openStream(firstSequence)
+	defer response.Body.Close()
+
+	events = readEvents(t, reader, 3)
+	require.Equal(t, []string{"entry", "entry", "balance"}, []string{events[0].event, events[1].event, events[2].event})
+	require.Equal(t, entry.ID, mustParseEntry(t, events[0].data).ID)
+	require.Equal(t, int64(-15), mustParseEntry(t, events[1].data).Amount)
+	require.JSONEq(t, fmt.Sprintf(`{"account_id":%d,"balance":100,"balance_formatted":"1.00","currency":"USD"}`, account.ID), events[2].data)
+
+	// heartbeats keep the stream open until the server shuts down
+	time.Sleep(30 * time.Millisecond)
+	require.NoError(t, server.Shutdown(ctx))
+
+	_, err := reader.ReadString('\n')
+	for err == nil {
+		_, err = reader.ReadString('\n')
+	}
+}
+
+func mustParseEntry(t *testing.T, data string) entryEvent {
+	var entry entryEvent
+	require.NoError(t, json.Unmarshal([]byte(data), &entry))
+	return entry
+}
+
+// createRandomMemoryAccount creates an account with a new owner
+func createRandomMemoryAccount(t *testing.T, store db.Store) db.Account {
+	ctx := context.Background()
+
+	user, _ := randomUser(t)
+	_, err := store.CreateUser(ctx, db.CreateUserParams{
+		Username:       user.Username,
+		HashedPassword: user.HashedPassword,
+		FullName:       user.FullName,
+		Email:          user.Email,
+	})
+	require.NoError(t, err)
+
+	account, err := store.CreateAccount(ctx, db.CreateAccountParams{Owner: user.Username, Balance: 100, Currency: util.USD, ProductCode: db.AccountProductChecking})
+	require.NoError(t, err)
+
+	return account
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"net/http"
+	"time"
+)
+
+var errAccountProductNotFound = errors.New("account product not found")
+
+type accountProductResponse struct {
+	Code                 string    `json:"code"`
+	Name                 string    `json:"name"`
+	Currencies           []string  `json:"currencies"`
+	OverdraftLimit       int64     `json:"overdraft_limit"`
+	FeeSchedule          *string   `json:"fee_schedule"`
+	CanInitiateTransfers bool      `json:"can_initiate_transfers"`
+	MultiplePerCurrency  bool      `json:"multiple_per_currency"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+func newAccountProductResponse(product db.AccountProduct) accountProductResponse {
+	rsp := accountProductResponse{
+		Code:                 product.Code,
+		Name:                 product.Name,
+		Currencies:           product.Currencies,
+		OverdraftLimit:       product.OverdraftLimit,
+		CanInitiateTransfers: product.CanInitiateTransfers,
+		MultiplePerCurrency:  product.MultiplePerCurrency,
+		CreatedAt:            product.CreatedAt,
+	}
+
+	if rsp.Currencies == nil {
+		rsp.Currencies = []string{}
+	}
+
+	if product.FeeSchedule.Valid {
+		rsp.FeeSchedule = &product.FeeSchedule.String
+	}
+
+	return rsp
+}
+
+type createAccountProductRequest struct {
+	Code                 string   `json:"code" binding:"required,max=64"`
+	Name                 string   `json:"name" binding:"required"`
+	Currencies           []string `json:"currencies" binding:"max=50,dive,currency"`
+	OverdraftLimit       int64    `json:"overdraft_limit" binding:"min=0"`
+	FeeSchedule          string   `json:"fee_schedule" binding:"max=64"`
+	CanInitiateTransfers bool     `json:"can_initiate_transfers"`
+	MultiplePerCurrency  bool     `json:"multiple_per_currency"`
+}
+
+// createAccountProduct adds a product accounts can be opened with, it is restricted to admins.
+// A product without currencies can be opened in any currency
+func (server *Server) createAccountProduct(ctx *gin.Context) {
+	var req createAccountProductRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.CreateAccountProductParams{
+		Code:                 req.Code,
+		Name:                 req.Name,
+		Currencies:           req.Currencies,
+		OverdraftLimit:       req.OverdraftLimit,
+		FeeSchedule:          sql.NullString{String: req.FeeSchedule, Valid: req.FeeSchedule != ""},
+		CanInitiateTransfers: req.CanInitiateTransfers,
+		MultiplePerCurrency:  req.MultiplePerCurrency,
+	}
+
+	if arg.Currencies == nil {
+		arg.Currencies = []string{}
+	}
+
+	product, err := server.store.CreateAccountProduct(ctx.Request.Context(), arg)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			ctx.JSON(http.StatusForbidden, errorResponse(errors.New("account product with the code exists")))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, newAccountProductResponse(product))
+}
+
+func (server *Server) listAccountProducts(ctx *gin.Context) {
+	products, err := server.store.ListAccountProducts(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]accountProductResponse, len(products))
+	for i, product := range products {
+		rsp[i] = newAccountProductResponse(product)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
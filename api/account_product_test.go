@@ -0,0 +1,204 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	mockdb "github.com/AbdRaqeeb/simple_bank/db/mock"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateAccountProductAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = util.AdminRole
+
+	product := db.AccountProduct{
+		Code:                 "premium",
+		Name:                 "Premium",
+		Currencies:           []string{util.USD, util.CAD},
+		OverdraftLimit:       50000,
+		FeeSchedule:          sql.NullString{String: "premium-2024", Valid: true},
+		CanInitiateTransfers: true,
+	}
+
+	body := func() gin.H {
+		return gin.H{
+			"code":                   product.Code,
+			"name":                   product.Name,
+			"currencies":             product.Currencies,
+			"overdraft_limit":        product.OverdraftLimit,
+			"fee_schedule":           product.FeeSchedule.String,
+			"can_initiate_transfers": product.CanInitiateTransfers,
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: body(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().CreateAccountProduct(gomock.Any(), gomock.Eq(db.CreateAccountProductParams{
+					Code:                 product.Code,
+					Name:                 product.Name,
+					Currencies:           product.Currencies,
+					OverdraftLimit:       product.OverdraftLimit,
+					FeeSchedule:          product.FeeSchedule,
+					CanInitiateTransfers: product.CanInitiateTransfers,
+				})).Times(1).Return(product, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var rsp accountProductResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, newAccountProductResponse(product), rsp)
+				require.Equal(t, "premium-2024", *rsp.FeeSchedule)
+			},
+		},
+		{
+			name: "Any Currency",
+			body: gin.H{"code": "basic", "name": "Basic"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().CreateAccountProduct(gomock.Any(), gomock.Eq(db.CreateAccountProductParams{
+					Code:       "basic",
+					Name:       "Basic",
+					Currencies: []string{},
+				})).Times(1).Return(db.AccountProduct{Code: "basic", Name: "Basic", Currencies: []string{}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var rsp accountProductResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Empty(t, rsp.Currencies)
+				require.Nil(t, rsp.FeeSchedule)
+			},
+		},
+		{
+			name: "Unsupported Currency",
+			body: func() gin.H {
+				b := body()
+				b["currencies"] = []string{util.USD, "XYZ"}
+				return b
+			}(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().CreateAccountProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Negative Overdraft Limit",
+			body: func() gin.H {
+				b := body()
+				b["overdraft_limit"] = -1
+				return b
+			}(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().CreateAccountProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Duplicate Code",
+			body: body(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().CreateAccountProduct(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.AccountProduct{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "Not Admin",
+			body: body(),
+			buildStubs: func(store *mockdb.MockStore) {
+				depositor := admin
+				depositor.Role = util.DepositorRole
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(depositor, nil)
+				store.EXPECT().CreateAccountProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/account-products", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, admin.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListAccountProductsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	products := []db.AccountProduct{
+		{Code: db.AccountProductChecking, Name: "Checking", Currencies: []string{}, CanInitiateTransfers: true},
+		{Code: db.AccountProductEscrow, Name: "Escrow", Currencies: []string{util.USD}, MultiplePerCurrency: true},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).AnyTimes().Return(user, nil)
+	store.EXPECT().ListAccountProducts(gomock.Any()).Times(1).Return(products, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/account-products", nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp []accountProductResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Equal(t, []accountProductResponse{newAccountProductResponse(products[0]), newAccountProductResponse(products[1])}, rsp)
+}
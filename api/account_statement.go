@@ -0,0 +1,99 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/AbdRaqeeb/simple_bank/camt"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/token"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+// maxStatementDays is the longest period of a statement
+const maxStatementDays = 366
+
+const statementDateFormat = "2006-01-02"
+
+var (
+	errStatementPeriod     = errors.New("from must not be after to")
+	errStatementPeriodLong = fmt.Errorf("statements cover at most %d days", maxStatementDays)
+)
+
+type accountStatementURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type accountStatementQuery struct {
+	From time.Time `form:"from" binding:"required" time_format:"2006-01-02" time_utc:"1"`
+	To   time.Time `form:"to" binding:"required" time_format:"2006-01-02" time_utc:"1"`
+}
+
+// getAccountStatement exports the entries of an account of the authenticated user as a camt.053 statement.
+// from and to are days in UTC, both included
+func (server *Server) getAccountStatement(ctx *gin.Context) {
+	var uri accountStatementURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req accountStatementQuery
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.To.Before(req.From) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errStatementPeriod))
+		return
+	}
+
+	// the period ends at the start of the day after to
+	end := req.To.AddDate(0, 0, 1)
+	if end.After(req.From.AddDate(0, 0, maxStatementDays)) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errStatementPeriodLong))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx.Request.Context(), uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != payload.Username {
+		ctx.JSON(http.StatusForbidden, errorResponse(errAccountNotOwned))
+		return
+	}
+
+	statement, err := server.store.AccountStatementTx(ctx.Request.Context(), db.AccountStatementParams{
+		AccountID: account.ID,
+		From:      req.From,
+		To:        end,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	var body bytes.Buffer
+	err = camt.WriteStatement(&body, camt.NewHeader(), statement)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	filename := fmt.Sprintf("statement-%d-%s-%s.xml", account.ID, req.From.Format(statementDateFormat), req.To.Format(statementDateFormat))
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Data(http.StatusOK, "application/xml", body.Bytes())
+}
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"github.com/AbdRaqeeb/simple_bank/camt"
+	mockdb "github.com/AbdRaqeeb/simple_bank/db/mock"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestGetAccountStatementAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount()
+	account.Owner = user.Username
+
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	statement := db.AccountStatement{
+		Account:        account,
+		OwnerName:      user.FullName,
+		From:           from,
+		To:             from.AddDate(0, 1, 0),
+		OpeningBalance: account.Balance,
+		ClosingBalance: account.Balance,
+	}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: url.Values{"from": {"2026-09-01"}, "to": {"2026-09-30"}},
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				arg := db.AccountStatementParams{AccountID: account.ID, From: statement.From, To: statement.To}
+				store.EXPECT().AccountStatementTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(statement, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "application/xml", recorder.Header().Get("Content-Type"))
+
+				filename := fmt.Sprintf("statement-%d-2026-09-01-2026-09-30.xml", account.ID)
+				require.Equal(t, fmt.Sprintf("attachment; filename=%q", filename), recorder.Header().Get("Content-Disposition"))
+
+				var document struct {
+					XMLName xml.Name `xml:"Document"`
+					ID      string   `xml:"BkToCstmrStmt>Stmt>Id"`
+				}
+				require.NoError(t, xml.Unmarshal(recorder.Body.Bytes(), &document))
+				require.Equal(t, camt.Namespace053, document.XMLName.Space)
+				require.Equal(t, fmt.Sprintf("%d-20260901", account.ID), document.ID)
+			},
+		},
+		{
+			name:  "Single Day",
+			query: url.Values{"from": {"2026-09-01"}, "to": {"2026-09-01"}},
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				arg := db.AccountStatementParams{AccountID: account.ID, From: from, To: from.AddDate(0, 0, 1)}
+				store.EXPECT().AccountStatementTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(statement, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "Missing Period",
+			query: url.Values{"from": {"2026-09-01"}},
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().AccountStatementTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "Invalid Date",
+			query: url.Values{"from": {"01/09/2026"}, "to": {"2026-09-30"}},
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "From After To",
+			query: url.Values{"from": {"2026-09-30"}, "to": {"2026-09-01"}},
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "Period Too Long",
+			query: url.Values{"from": {"2025-01-01"}, "to": {"2026-01-02"}},
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "No Authorization",
+			query: url.Values{"from": {"2026-09-01"}, "to": {"2026-09-30"}},
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:  "Not Owner",
+			query: url.Values{"from": {"2026-09-01"}, "to": {"2026-09-30"}},
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().AccountStatementTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "Not Found",
+			query: url.Values{"from": {"2026-09-01"}, "to": {"2026-09-30"}},
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().AccountStatementTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:  "Internal Error",
+			query: url.Values{"from": {"2026-09-01"}, "to": {"2026-09-30"}},
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().AccountStatementTx(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountStatement{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(_ context.Context, username string) (db.User, error) {
+				authenticated := user
+				authenticated.Username = username
+				return authenticated, nil
+			})
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/statement?%s", account.ID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetAccountStatementWithMemoryStore(t *testing.T) {
+	store := db.NewMemoryStore()
+	server := newTestServer(t, store)
+	ctx := context.Background()
+
+	account := createRandomMemoryAccount(t, store)
+	other := createRandomMemoryAccount(t, store)
+
+	_, err := store.TransferTx(ctx, db.TransferTxParams{FromAccountID: account.ID, ToAccountID: other.ID, Amount: 30})
+	require.NoError(t, err)
+	_, err = store.TransferTx(ctx, db.TransferTxParams{FromAccountID: other.ID, ToAccountID: account.ID, Amount: 5})
+	require.NoError(t, err)
+
+	today := time.Now().UTC().Format(statementDateFormat)
+	recorder := httptest.NewRecorder()
+	url := fmt.Sprintf("/accounts/%d/statement?from=%s&to=%s", account.ID, today, today)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, account.Owner, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var document struct {
+		Balances []string `xml:"BkToCstmrStmt>Stmt>Bal>Amt"`
+		Entries  []struct {
+			Amount      string `xml:"Amt"`
+			CreditDebit string `xml:"CdtDbtInd"`
+			Creditor    string `xml:"NtryDtls>TxDtls>RltdPties>Cdtr>Nm"`
+		} `xml:"BkToCstmrStmt>Stmt>Ntry"`
+	}
+	require.NoError(t, xml.Unmarshal(recorder.Body.Bytes(), &document))
+
+	// the account was created with a balance of 1.00, then sent 0.30 and received 0.05
+	require.Equal(t, []string{"1.00", "0.75"}, document.Balances)
+	require.Len(t, document.Entries, 2)
+	require.Equal(t, "0.30", document.Entries[0].Amount)
+	require.Equal(t, "DBIT", document.Entries[0].CreditDebit)
+	require.NotEmpty(t, document.Entries[0].Creditor)
+	require.Equal(t, "0.05", document.Entries[1].Amount)
+	require.Equal(t, "CRDT", document.Entries[1].CreditDebit)
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyFlowWithMemoryStore(t *testing.T) {
+	store := db.NewMemoryStore()
+	server := newTestServer(t, store)
+
+	user, _ := randomUser(t)
+	_, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       user.Username,
+		HashedPassword: user.HashedPassword,
+		FullName:       user.FullName,
+		Email:          user.Email,
+	})
+	require.NoError(t, err)
+
+	send := func(method string, url string, body gin.H, setupAuth func(request *http.Request)) *httptest.ResponseRecorder {
+		var data []byte
+		if body != nil {
+			data, err = json.Marshal(body)
+			require.NoError(t, err)
+		}
+
+		request, err := http.NewRequest(method, url, bytes.NewReader(data))
+		require.NoError(t, err)
+		setupAuth(request)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	withToken := func(request *http.Request) {
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+	}
+
+	withAPIKey := func(key string) func(request *http.Request) {
+		return func(request *http.Request) {
+			request.Header.Set(apiKeyHeaderKey, key)
+		}
+	}
+
+	// invalid requests
+	recorder := send(http.MethodPost, "/users/me/api-keys", gin.H{"name": "payroll", "scopes": []string{}}, withToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = send(http.MethodPost, "/users/me/api-keys", gin.H{"name": "payroll", "scopes": []string{"unknown"}}, withToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = send(http.MethodPost, "/users/me/api-keys", gin.H{
+		"name":       "payroll",
+		"scopes":     []string{util.TransfersWriteScope},
+		"expires_at": time.Now().Add(-time.Minute),
+	}, withToken)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = send(http.MethodPost, "/users/me/api-keys", gin.H{
+		"name":       "payroll",
+		"scopes":     []string{util.UsersReadScope, util.UsersWriteScope, util.TransfersWriteScope},
+		"expires_at": time.Now().Add(time.Hour),
+	}, withToken)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	var created createAPIKeyResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+	require.NotEmpty(t, created.Key)
+	require.Contains(t, created.Key, created.APIKey.Prefix)
+	require.NotNil(t, created.APIKey.ExpiresAt)
+	require.Nil(t, created.APIKey.LastUsedAt)
+
+	// the key authenticates requests with its scopes and records its last use
+	recorder = send(http.MethodGet, "/users/me", nil, withAPIKey(created.Key))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = send(http.MethodGet, "/users/me/api-keys", nil, withAPIKey(created.Key))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var listed []apiKeyResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &listed))
+	require.Len(t, listed, 1)
+	require.Equal(t, created.APIKey.ID, listed[0].ID)
+	require.NotNil(t, listed[0].LastUsedAt)
+
+	// api keys have no auth time, high-value transfers always require a step-up
+	recorder = send(http.MethodPost, "/transfers", gin.H{
+		"from_account_id": 1,
+		"to_account_id":   2,
+		"amount":          5000,
+		"currency":        util.USD,
+	}, withAPIKey(created.Key))
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Contains(t, recorder.Header().Get("WWW-Authenticate"), "insufficient_user_authentication")
+
+	// a key cannot grant scopes its caller does not hold
+	recorder = send(http.MethodPost, "/users/me/api-keys", gin.H{
+		"name":   "escalation",
+		"scopes": []string{util.AccountsWriteScope},
+	}, withAPIKey(created.Key))
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = send(http.MethodPost, "/users/me/api-keys", gin.H{
+		"name":   "readonly",
+		"scopes": []string{util.UsersReadScope},
+	}, withAPIKey(created.Key))
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	var readOnly createAPIKeyResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &readOnly))
+	require.Nil(t, readOnly.APIKey.ExpiresAt)
+
+	recorder = send(http.MethodPost, "/users/me/api-keys", gin.H{"name": "x", "scopes": []string{util.UsersReadScope}}, withAPIKey(readOnly.Key))
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// revoked keys are rejected and cannot be revoked again
+	url := fmt.Sprintf("/users/me/api-keys/%d", created.APIKey.ID)
+	recorder = send(http.MethodDelete, url, nil, withToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = send(http.MethodDelete, url, nil, withToken)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = send(http.MethodGet, "/users/me", nil, withAPIKey(created.Key))
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = send(http.MethodGet, "/users/me/api-keys", nil, withToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &listed))
+	require.Len(t, listed, 1)
+	require.Equal(t, readOnly.APIKey.ID, listed[0].ID)
+
+	// expired keys are rejected
+	expiredKey, prefix, err := util.NewAPIKey()
+	require.NoError(t, err)
+	_, err = store.CreateAPIKey(context.Background(), db.CreateAPIKeyParams{
+		Username:  user.Username,
+		Name:      "expired",
+		Prefix:    prefix,
+		KeyHash:   util.HashSecretToken(expiredKey),
+		Scopes:    []string{util.UsersReadScope},
+		ExpiresAt: sql.NullTime{Time: time.Now().Add(-time.Second), Valid: true},
+	})
+	require.NoError(t, err)
+
+	recorder = send(http.MethodGet, "/users/me", nil, withAPIKey(expiredKey))
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = send(http.MethodGet, "/users/me", nil, withAPIKey("sbk_unknown"))
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+var errInvalidVerificationToken = errors.New("email verification token is invalid or has expired")
+
+// sendVerificationEmail sends a new verification token to the email of the authenticated user
+func (server *Server) sendVerificationEmail(ctx *gin.Context) {
+	user := ctx.MustGet(authorizationUserKey).(db.User)
+
+	if user.IsEmailVerified {
+		ctx.JSON(http.StatusConflict, errorResponse(errors.New("email is already verified")))
+		return
+	}
+
+	err := server.sendEmailVerification(ctx.Request.Context(), user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
+// sendEmailVerification stores the hash of a new verification token for the current email of the user
+// and emails the token to it
+func (server *Server) sendEmailVerification(ctx context.Context, user db.User) error {
+	verificationToken, err := util.NewSecretToken()
+	if err != nil {
+		return err
+	}
+
+	_, err = server.store.CreateEmailVerificationToken(ctx, db.CreateEmailVerificationTokenParams{
+		Username:  user.Username,
+		Email:     user.Email,
+		TokenHash: util.HashSecretToken(verificationToken),
+		ExpiresAt: time.Now().Add(server.config.EmailVerificationTokenDuration),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create email verification token: %w", err)
+	}
+
+	body := fmt.Sprintf(
+		"Hi %s,\n\nUse this token to verify your email, it expires in %s:\n\n%s\n",
+		user.FullName, server.config.EmailVerificationTokenDuration, verificationToken,
+	)
+
+	return server.emailer.SendEmail(ctx, user.Email, "Verify your email", body)
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func (server *Server) verifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.VerifyEmailTx(ctx.Request.Context(), util.HashSecretToken(req.Token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidVerificationToken))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+const (
+	statusOK           = "ok"
+	statusUnavailable  = "unavailable"
+	statusShuttingDown = "shutting_down"
+
+	readinessTimeout = 2 * time.Second
+)
+
+type dependencyStatus struct {
+	Status  string `json:"status"`
+	Version int64  `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type readinessResponse struct {
+	Status string                      `json:"status"`
+	Checks map[string]dependencyStatus `json:"checks,omitempty"`
+}
+
+// liveness reports that the process is up and able to serve http requests
+func (server *Server) liveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": statusOK})
+}
+
+// readiness reports whether the server can take traffic, checking every dependency it needs
+func (server *Server) readiness(ctx *gin.Context) {
+	if server.isShuttingDown() {
+		ctx.JSON(http.StatusServiceUnavailable, readinessResponse{Status: statusShuttingDown})
+		return
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	res := readinessResponse{
+		Status: statusOK,
+		Checks: map[string]dependencyStatus{
+			"database":   server.checkDatabase(checkCtx),
+			"migrations": server.checkMigrations(checkCtx),
+		},
+	}
+
+	code := http.StatusOK
+	for _, check := range res.Checks {
+		if check.Status != statusOK {
+			res.Status = statusUnavailable
+			code = http.StatusServiceUnavailable
+		}
+	}
+
+	ctx.JSON(code, res)
+}
+
+func (server *Server) checkDatabase(ctx context.Context) dependencyStatus {
+	if err := server.store.Ping(ctx); err != nil {
+		return dependencyStatus{Status: statusUnavailable, Error: err.Error()}
+	}
+
+	return dependencyStatus{Status: statusOK}
+}
+
+func (server *Server) checkMigrations(ctx context.Context) dependencyStatus {
+	version, dirty, err := server.store.MigrationVersion(ctx)
+	if err != nil {
+		return dependencyStatus{Status: statusUnavailable, Error: err.Error()}
+	}
+
+	if dirty {
+		return dependencyStatus{Status: statusUnavailable, Version: version, Error: "last migration failed and left the schema dirty"}
+	}
+
+	if version != server.schemaVersion {
+		err = fmt.Errorf("expected schema version %d", server.schemaVersion)
+		return dependencyStatus{Status: statusUnavailable, Version: version, Error: err.Error()}
+	}
+
+	return dependencyStatus{Status: statusOK, Version: version}
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/AbdRaqeeb/simple_bank/db/migration"
+	mockdb "github.com/AbdRaqeeb/simple_bank/db/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLivenessAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/healthz", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestReadinessAPI(t *testing.T) {
+	latestVersion, err := migration.LatestVersion()
+	require.NoError(t, err)
+
+	schemaVersion := int64(latestVersion)
+
+	testCases := []struct {
+		name          string
+		shuttingDown  bool
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().MigrationVersion(gomock.Any()).Times(1).Return(schemaVersion, false, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				res := requireBodyReadiness(t, recorder)
+				require.Equal(t, statusOK, res.Status)
+				require.Equal(t, statusOK, res.Checks["database"].Status)
+				require.Equal(t, statusOK, res.Checks["migrations"].Status)
+			},
+		},
+		{
+			name: "Database Unavailable",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(sql.ErrConnDone)
+				store.EXPECT().MigrationVersion(gomock.Any()).Times(1).Return(int64(0), false, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				res := requireBodyReadiness(t, recorder)
+				require.Equal(t, statusUnavailable, res.Status)
+				require.Equal(t, statusUnavailable, res.Checks["database"].Status)
+			},
+		},
+		{
+			name: "Migration Behind",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().MigrationVersion(gomock.Any()).Times(1).Return(schemaVersion-1, false, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				res := requireBodyReadiness(t, recorder)
+				require.Equal(t, statusOK, res.Checks["database"].Status)
+				require.Equal(t, statusUnavailable, res.Checks["migrations"].Status)
+				require.Equal(t, schemaVersion-1, res.Checks["migrations"].Version)
+			},
+		},
+		{
+			name: "Migration Dirty",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().MigrationVersion(gomock.Any()).Times(1).Return(schemaVersion, true, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				res := requireBodyReadiness(t, recorder)
+				require.Equal(t, statusUnavailable, res.Checks["migrations"].Status)
+			},
+		},
+		{
+			name:         "Shutting Down",
+			shuttingDown: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(0)
+				store.EXPECT().MigrationVersion(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				res := requireBodyReadiness(t, recorder)
+				require.Equal(t, statusShuttingDown, res.Status)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			if tc.shuttingDown {
+				// no http server has been started, so shutdown only flips readiness
+				require.NoError(t, server.Shutdown(context.Background()))
+			}
+
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/readyz", nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+	server.config.ShutdownDelay = time.Minute
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := server.Shutdown(ctx)
+	require.True(t, errors.Is(err, context.Canceled))
+	require.True(t, server.isShuttingDown())
+}
+
+func requireBodyReadiness(t *testing.T, recorder *httptest.ResponseRecorder) readinessResponse {
+	var res readinessResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	require.NoError(t, err)
+
+	return res
+}
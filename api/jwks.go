@@ -0,0 +1,19 @@
+package api
+
+import (
+	"github.com/AbdRaqeeb/simple_bank/token"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// jwks publishes the public keys access tokens are signed with, so other services can verify them.
+// The set is empty when tokens are signed with a symmetric key, which must never be shared
+func (server *Server) jwks(ctx *gin.Context) {
+	jwks := token.JWKS{Keys: []token.JWK{}}
+	if maker, ok := server.tokenMaker.(token.KeySetMaker); ok {
+		jwks = maker.KeySet().JWKS()
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, jwks)
+}
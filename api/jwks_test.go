@@ -0,0 +1,70 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	mockdb "github.com/AbdRaqeeb/simple_bank/db/mock"
+	"github.com/AbdRaqeeb/simple_bank/token"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJWKSAPI(t *testing.T) {
+	_, signingKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keySet, err := token.NewKeySet("key-1", signingKey)
+	require.NoError(t, err)
+
+	keySetMaker, err := token.NewPasetoPublicMaker(keySet)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		tokenMaker func(server *Server) token.Maker
+		keyIDs     []string
+	}{
+		{
+			name:       "Symmetric Key",
+			tokenMaker: func(server *Server) token.Maker { return server.tokenMaker },
+			keyIDs:     []string{},
+		},
+		{
+			name:       "Key Set",
+			tokenMaker: func(server *Server) token.Maker { return keySetMaker },
+			keyIDs:     []string{"key-1"},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server := newTestServer(t, mockdb.NewMockStore(ctrl))
+			server.tokenMaker = tc.tokenMaker(server)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			var jwks token.JWKS
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &jwks))
+
+			keyIDs := []string{}
+			for _, key := range jwks.Keys {
+				keyIDs = append(keyIDs, key.KeyID)
+			}
+			require.Equal(t, tc.keyIDs, keyIDs)
+		})
+	}
+}
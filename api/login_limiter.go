@@ -0,0 +1,153 @@
+package api
+
+import (
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"sync"
+	"time"
+)
+
+// loginBackoff returns how long after the last failed login another attempt is allowed.
+// The delay doubles with every failure, starting at baseDelay and capped at maxDelay
+func loginBackoff(failures int, baseDelay, maxDelay time.Duration) time.Duration {
+	if failures <= 0 || baseDelay <= 0 {
+		return 0
+	}
+
+	if maxDelay < baseDelay {
+		maxDelay = baseDelay
+	}
+
+	delay := baseDelay
+	for i := 1; i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	return delay
+}
+
+// userLoginRetryAfter returns how long the user has to wait before the next login attempt,
+// either because the account is locked or because of the delay after the last failure
+func userLoginRetryAfter(config util.Config, user db.User, now time.Time) time.Duration {
+	var wait time.Duration
+
+	if user.LockedUntil.Valid {
+		wait = user.LockedUntil.Time.Sub(now)
+	}
+
+	if user.LastFailedLoginAt.Valid {
+		delay := loginBackoff(int(user.FailedLoginAttempts), config.LoginBackoffBaseDelay, config.LoginBackoffMaxDelay)
+		if backoffWait := user.LastFailedLoginAt.Time.Add(delay).Sub(now); backoffWait > wait {
+			wait = backoffWait
+		}
+	}
+
+	if wait < 0 {
+		return 0
+	}
+
+	return wait
+}
+
+// ipLoginAttempts are the failed logins of a client ip within the current window
+type ipLoginAttempts struct {
+	failures    int
+	windowStart time.Time
+	lastFailure time.Time
+}
+
+// ipLoginLimiter tracks failed logins per client ip in memory. It slows down and then blocks
+// clients guessing passwords across many usernames, which the per user lockout cannot see
+type ipLoginLimiter struct {
+	mu sync.Mutex
+
+	maxAttempts int
+	window      time.Duration
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+
+	now       func() time.Time
+	lastPrune time.Time
+	attempts  map[string]*ipLoginAttempts
+}
+
+func newIPLoginLimiter(config util.Config) *ipLoginLimiter {
+	return &ipLoginLimiter{
+		maxAttempts: config.LoginIPMaxAttempts,
+		window:      config.LoginIPWindow,
+		baseDelay:   config.LoginBackoffBaseDelay,
+		maxDelay:    config.LoginBackoffMaxDelay,
+		now:         time.Now,
+		attempts:    make(map[string]*ipLoginAttempts),
+	}
+}
+
+// retryAfter returns how long the ip has to wait before the next login attempt
+func (limiter *ipLoginLimiter) retryAfter(ip string) time.Duration {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := limiter.now()
+
+	attempts, ok := limiter.attempts[ip]
+	if !ok {
+		return 0
+	}
+
+	windowEnd := attempts.windowStart.Add(limiter.window)
+	if !now.Before(windowEnd) {
+		delete(limiter.attempts, ip)
+		return 0
+	}
+
+	if limiter.maxAttempts > 0 && attempts.failures >= limiter.maxAttempts {
+		return windowEnd.Sub(now)
+	}
+
+	wait := attempts.lastFailure.Add(loginBackoff(attempts.failures, limiter.baseDelay, limiter.maxDelay)).Sub(now)
+	if wait < 0 {
+		return 0
+	}
+
+	return wait
+}
+
+// recordFailure counts a failed login of the ip in the current window
+func (limiter *ipLoginLimiter) recordFailure(ip string) {
+	if limiter.window <= 0 {
+		return
+	}
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := limiter.now()
+	limiter.prune(now)
+
+	attempts, ok := limiter.attempts[ip]
+	if !ok || !now.Before(attempts.windowStart.Add(limiter.window)) {
+		attempts = &ipLoginAttempts{windowStart: now}
+		limiter.attempts[ip] = attempts
+	}
+
+	attempts.failures++
+	attempts.lastFailure = now
+}
+
+// prune drops the ips whose window has ended, at most once per window
+func (limiter *ipLoginLimiter) prune(now time.Time) {
+	if now.Sub(limiter.lastPrune) < limiter.window {
+		return
+	}
+	limiter.lastPrune = now
+
+	for ip, attempts := range limiter.attempts {
+		if !now.Before(attempts.windowStart.Add(limiter.window)) {
+			delete(limiter.attempts, ip)
+		}
+	}
+}
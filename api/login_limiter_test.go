@@ -0,0 +1,88 @@
+package api
+
+import (
+	"database/sql"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestLoginBackoff(t *testing.T) {
+	require.Zero(t, loginBackoff(0, time.Second, time.Minute))
+	require.Zero(t, loginBackoff(3, 0, time.Minute))
+
+	require.Equal(t, time.Second, loginBackoff(1, time.Second, 10*time.Second))
+	require.Equal(t, 2*time.Second, loginBackoff(2, time.Second, 10*time.Second))
+	require.Equal(t, 8*time.Second, loginBackoff(4, time.Second, 10*time.Second))
+	require.Equal(t, 10*time.Second, loginBackoff(5, time.Second, 10*time.Second))
+	require.Equal(t, 10*time.Second, loginBackoff(1000, time.Second, 10*time.Second))
+
+	// a missing cap keeps the base delay
+	require.Equal(t, time.Second, loginBackoff(5, time.Second, 0))
+}
+
+func TestUserLoginRetryAfter(t *testing.T) {
+	config := util.Config{
+		LoginBackoffBaseDelay: time.Second,
+		LoginBackoffMaxDelay:  time.Minute,
+	}
+	now := time.Now()
+
+	require.Zero(t, userLoginRetryAfter(config, db.User{}, now))
+
+	user := db.User{
+		FailedLoginAttempts: 2,
+		LastFailedLoginAt:   sql.NullTime{Time: now.Add(-time.Second), Valid: true},
+	}
+	require.Equal(t, time.Second, userLoginRetryAfter(config, user, now))
+	require.Zero(t, userLoginRetryAfter(config, user, now.Add(time.Second)))
+
+	user.LockedUntil = sql.NullTime{Time: now.Add(time.Hour), Valid: true}
+	require.Equal(t, time.Hour, userLoginRetryAfter(config, user, now))
+	require.Zero(t, userLoginRetryAfter(config, user, now.Add(2*time.Hour)))
+}
+
+func TestIPLoginLimiter(t *testing.T) {
+	now := time.Now()
+
+	limiter := newIPLoginLimiter(util.Config{
+		LoginIPMaxAttempts:    3,
+		LoginIPWindow:         time.Minute,
+		LoginBackoffBaseDelay: time.Second,
+		LoginBackoffMaxDelay:  4 * time.Second,
+	})
+	limiter.now = func() time.Time { return now }
+
+	ip := "10.0.0.1"
+	require.Zero(t, limiter.retryAfter(ip))
+
+	limiter.recordFailure(ip)
+	require.Equal(t, time.Second, limiter.retryAfter(ip))
+	require.Zero(t, limiter.retryAfter("10.0.0.2"))
+
+	now = now.Add(time.Second)
+	require.Zero(t, limiter.retryAfter(ip))
+
+	limiter.recordFailure(ip)
+	require.Equal(t, 2*time.Second, limiter.retryAfter(ip))
+
+	// the ip is blocked until its window ends once the attempts are exhausted
+	limiter.recordFailure(ip)
+	require.Equal(t, 59*time.Second, limiter.retryAfter(ip))
+
+	now = now.Add(59 * time.Second)
+	require.Zero(t, limiter.retryAfter(ip))
+	require.Empty(t, limiter.attempts)
+}
+
+func TestIPLoginLimiterDisabled(t *testing.T) {
+	limiter := newIPLoginLimiter(util.Config{})
+
+	for i := 0; i < 10; i++ {
+		limiter.recordFailure("10.0.0.1")
+	}
+
+	require.Zero(t, limiter.retryAfter("10.0.0.1"))
+}
@@ -1,11 +1,15 @@
 package api
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
 	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
 	"github.com/AbdRaqeeb/simple_bank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -18,8 +22,21 @@ func TestMain(m *testing.M) {
 func newTestServer(t *testing.T, store db.Store) *Server {
 
 	config := util.Config{
-		TokenSymmetricKey:   util.RandomString(32),
 		AccessTokenDuration: time.Minute,
+		LogLevel:            "disabled",
+
+		TokenSymmetricKeyBase64: randomKeyBase64(t),
+		TokenIssuer:             "simple_bank",
+		TokenAudience:           "simple_bank",
+
+		PasswordResetTokenDuration:     time.Minute,
+		EmailVerificationTokenDuration: time.Minute,
+		TotpIssuer:                     "SimpleBank",
+		TotpEncryptionKeyBase64:        randomKeyBase64(t),
+		LoginChallengeDuration:         time.Minute,
+		StepUpMaxAge:                   time.Minute,
+		StepUpTransferThresholds:       "USD:1000,CAD:1000",
+		WebhookEncryptionKeyBase64:     randomKeyBase64(t),
 	}
 
 	server, err := NewServer(config, store)
@@ -27,3 +44,44 @@ func newTestServer(t *testing.T, store db.Store) *Server {
 
 	return server
 }
+
+// randomKeyBase64 generates a random 32 byte symmetric key, base64 encoded
+func randomKeyBase64(t *testing.T) string {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// sentEmail is an email captured by testEmailer
+type sentEmail struct {
+	to      string
+	subject string
+	body    string
+}
+
+// testEmailer records emails instead of sending them
+type testEmailer struct {
+	emails []sentEmail
+}
+
+func (emailer *testEmailer) SendEmail(ctx context.Context, to, subject, body string) error {
+	emailer.emails = append(emailer.emails, sentEmail{to: to, subject: subject, body: body})
+	return nil
+}
+
+// lastToken returns the secret token of the last email, it is the only line of the body without spaces
+func (emailer *testEmailer) lastToken(t *testing.T) string {
+	require.NotEmpty(t, emailer.emails)
+
+	body := emailer.emails[len(emailer.emails)-1].body
+	for _, line := range strings.Split(body, "\n") {
+		if line != "" && !strings.Contains(line, " ") {
+			return line
+		}
+	}
+
+	require.FailNow(t, "email does not contain a token", body)
+	return ""
+}
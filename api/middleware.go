@@ -0,0 +1,281 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/token"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	tracerName = "github.com/AbdRaqeeb/simple_bank/api"
+
+	requestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
+
+	authorizationHeaderKey  = "authorization"
+	authorizationTypeBearer = "bearer"
+
+	// apiKeyHeaderKey carries the api keys of machine clients that cannot log in interactively
+	apiKeyHeaderKey = "X-API-Key"
+
+	// authorizationPayloadKey is the gin context key holding the *token.Payload of an authenticated request
+	authorizationPayloadKey = "authorization_payload"
+	// authorizationUserKey is the gin context key holding the db.User of an authenticated request
+	authorizationUserKey = "authorization_user"
+)
+
+var (
+	errTokenRevoked         = errors.New("token was issued before the password was changed")
+	errInvalidAPIKey        = errors.New("api key is invalid or has been revoked")
+	errAPIKeyExpired        = errors.New("api key has expired")
+	errMissingAuthorization = errors.New("authorization header is not provided")
+)
+
+// authMiddleware requires a valid bearer token, or an api key in the X-API-Key header when no
+// authorization header is sent, and stores its payload and user in the gin context.
+// Tokens and api keys issued before the user last changed their password are rejected
+func authMiddleware(tokenMaker token.Maker, store db.Store) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload *token.Payload
+		var ok bool
+		if apiKey := ctx.GetHeader(apiKeyHeaderKey); apiKey != "" && ctx.GetHeader(authorizationHeaderKey) == "" {
+			payload, ok = authenticateAPIKey(ctx, store, apiKey)
+		} else {
+			payload, ok = authenticateBearer(ctx, tokenMaker)
+		}
+		if !ok {
+			return
+		}
+
+		user, err := store.GetUser(ctx.Request.Context(), payload.Username)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(token.ErrorInvalidToken))
+				return
+			}
+
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if payload.IssuedAt.Before(user.PasswordChangedAt) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errTokenRevoked))
+			return
+		}
+
+		ctx.Set(authorizationPayloadKey, payload)
+		ctx.Set(authorizationUserKey, user)
+		ctx.Next()
+	}
+}
+
+// authenticateBearer verifies the access token of the authorization header
+func authenticateBearer(ctx *gin.Context, tokenMaker token.Maker) (*token.Payload, bool) {
+	authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
+	if authorizationHeader == "" {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errMissingAuthorization))
+		return nil, false
+	}
+
+	fields := strings.Fields(authorizationHeader)
+	if len(fields) != 2 {
+		err := errors.New("invalid authorization header format")
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		return nil, false
+	}
+
+	if strings.ToLower(fields[0]) != authorizationTypeBearer {
+		err := fmt.Errorf("unsupported authorization type %s", fields[0])
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		return nil, false
+	}
+
+	payload, err := tokenMaker.VerifyToken(fields[1])
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		return nil, false
+	}
+
+	if payload.Type != token.TypeAccess {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(token.ErrorInvalidToken))
+		return nil, false
+	}
+
+	return payload, true
+}
+
+// authenticateAPIKey looks an api key up by its hash and turns it into an access payload with the
+// scopes of the key. The payload has no auth time, so high-value transfers always require a step-up
+func authenticateAPIKey(ctx *gin.Context, store db.Store, key string) (*token.Payload, bool) {
+	apiKey, err := store.GetAPIKeyByHash(ctx.Request.Context(), util.HashSecretToken(key))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errInvalidAPIKey))
+			return nil, false
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+		return nil, false
+	}
+
+	if apiKey.RevokedAt.Valid {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errInvalidAPIKey))
+		return nil, false
+	}
+
+	now := time.Now()
+	if apiKey.ExpiresAt.Valid && !now.Before(apiKey.ExpiresAt.Time) {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errAPIKeyExpired))
+		return nil, false
+	}
+
+	err = store.UpdateAPIKeyLastUsed(ctx.Request.Context(), apiKey.ID)
+	if err != nil {
+		zerolog.Ctx(ctx.Request.Context()).Warn().Err(err).Int64("api_key_id", apiKey.ID).Msg("cannot record api key use")
+	}
+
+	payload := &token.Payload{
+		Type:      token.TypeAccess,
+		Username:  apiKey.Username,
+		Scopes:    apiKey.Scopes,
+		IssuedAt:  apiKey.CreatedAt,
+		NotBefore: apiKey.CreatedAt,
+		ExpiredAt: apiKey.ExpiresAt.Time,
+	}
+
+	return payload, true
+}
+
+// requireRole rejects authenticated users without the given role, it must run after authMiddleware
+func requireRole(role string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user := ctx.MustGet(authorizationUserKey).(db.User)
+		if user.Role != role {
+			err := fmt.Errorf("requires the %s role", role)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// requireScope rejects tokens that do not grant the given scope, it must run after authMiddleware
+func requireScope(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		if !payload.HasScope(scope) {
+			err := fmt.Errorf("requires the %s scope", scope)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// tracingMiddleware starts a server span for every request and stores it in the request context
+func tracingMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		request := ctx.Request
+		parentCtx := otel.GetTextMapPropagator().Extract(request.Context(), propagation.HeaderCarrier(request.Header))
+
+		spanName := ctx.FullPath()
+		if spanName == "" {
+			spanName = fmt.Sprintf("HTTP %s route not found", request.Method)
+		}
+
+		spanCtx, span := otel.Tracer(tracerName).Start(parentCtx, spanName,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest("", ctx.FullPath(), request)...),
+		)
+		defer span.End()
+
+		ctx.Request = request.WithContext(spanCtx)
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// requestLoggerMiddleware accepts or generates a request id, stores a request scoped logger
+// in the request context and writes an access log entry once the request completes
+func requestLoggerMiddleware(logger zerolog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		requestID := ctx.GetHeader(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		ctx.Header(requestIDHeader, requestID)
+
+		logContext := logger.With().Str("request_id", requestID)
+		if spanContext := trace.SpanContextFromContext(ctx.Request.Context()); spanContext.HasTraceID() {
+			logContext = logContext.Str("trace_id", spanContext.TraceID().String())
+		}
+		requestLogger := logContext.Logger()
+
+		ctx.Request = ctx.Request.WithContext(requestLogger.WithContext(ctx.Request.Context()))
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+
+		event := requestLogger.Info()
+		if status >= http.StatusInternalServerError {
+			event = requestLogger.Error()
+		}
+
+		if payload, ok := ctx.Get(authorizationPayloadKey); ok {
+			if p, ok := payload.(*token.Payload); ok {
+				event = event.Str("username", p.Username)
+			}
+		}
+
+		if len(ctx.Errors) > 0 {
+			event = event.Str("errors", ctx.Errors.String())
+		}
+
+		event.
+			Str("method", ctx.Request.Method).
+			Str("path", ctx.Request.URL.Path).
+			Int("status", status).
+			Dur("latency", time.Since(start)).
+			Str("client_ip", ctx.ClientIP()).
+			Msg("request completed")
+	}
+}
+
+// validRequestID reports whether a client supplied request id is safe to log and echo back
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range requestID {
+		isAlphanumeric := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !isAlphanumeric && c != '-' && c != '_' && c != '.' {
+			return false
+		}
+	}
+
+	return true
+}
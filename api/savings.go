@@ -0,0 +1,185 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"net/http"
+	"time"
+)
+
+var errSavingsProductNotFound = errors.New("savings product not found")
+
+type savingsProductResponse struct {
+	Code             string    `json:"code"`
+	Name             string    `json:"name"`
+	Currency         string    `json:"currency"`
+	AnnualRateBps    int32     `json:"annual_rate_bps"`
+	DayCount         string    `json:"day_count"`
+	ExpenseAccountID int64     `json:"expense_account_id"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func newSavingsProductResponse(product db.SavingsProduct) savingsProductResponse {
+	return savingsProductResponse{
+		Code:             product.Code,
+		Name:             product.Name,
+		Currency:         product.Currency,
+		AnnualRateBps:    product.AnnualRateBps,
+		DayCount:         product.DayCount,
+		ExpenseAccountID: product.ExpenseAccountID,
+		CreatedAt:        product.CreatedAt,
+	}
+}
+
+type createSavingsProductRequest struct {
+	Code             string `json:"code" binding:"required,max=64"`
+	Name             string `json:"name" binding:"required"`
+	Currency         string `json:"currency" binding:"required,currency"`
+	AnnualRateBps    int32  `json:"annual_rate_bps" binding:"min=0,max=10000"`
+	DayCount         string `json:"day_count" binding:"required,oneof=actual/365 actual/360 actual/actual"`
+	ExpenseAccountID int64  `json:"expense_account_id" binding:"required,min=1"`
+}
+
+// createSavingsProduct adds a savings product paying interest from a system account of its currency,
+// it is restricted to admins
+func (server *Server) createSavingsProduct(ctx *gin.Context) {
+	var req createSavingsProductRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.validAccountCurrency(ctx, req.ExpenseAccountID, req.Currency); !valid {
+		return
+	}
+
+	product, err := server.store.CreateSavingsProduct(ctx.Request.Context(), db.CreateSavingsProductParams{
+		Code:             req.Code,
+		Name:             req.Name,
+		Currency:         req.Currency,
+		AnnualRateBps:    req.AnnualRateBps,
+		DayCount:         req.DayCount,
+		ExpenseAccountID: req.ExpenseAccountID,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			ctx.JSON(http.StatusForbidden, errorResponse(errors.New("savings product with the code exists")))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, newSavingsProductResponse(product))
+}
+
+func (server *Server) listSavingsProducts(ctx *gin.Context) {
+	products, err := server.store.ListSavingsProducts(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]savingsProductResponse, len(products))
+	for i, product := range products {
+		rsp[i] = newSavingsProductResponse(product)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type savingsAccountResponse struct {
+	AccountID   int64     `json:"account_id"`
+	ProductCode string    `json:"product_code"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type openSavingsAccountRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type openSavingsAccountBody struct {
+	ProductCode string `json:"product_code" binding:"required"`
+}
+
+// openSavingsAccount makes a savings account of the authenticated user earn the interest of a savings product of its
+// currency, interest accrues from the day it is opened
+func (server *Server) openSavingsAccount(ctx *gin.Context) {
+	var req openSavingsAccountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var body openSavingsAccountBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx.Request.Context(), req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != payload.Username {
+		ctx.JSON(http.StatusForbidden, errorResponse(errAccountNotOwned))
+		return
+	}
+
+	if account.ProductCode != db.AccountProductSavings {
+		err = fmt.Errorf("account [%d] is a %s account, only savings accounts earn interest", account.ID, account.ProductCode)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	product, err := server.store.GetSavingsProduct(ctx.Request.Context(), body.ProductCode)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errSavingsProductNotFound))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if product.Currency != account.Currency {
+		err = fmt.Errorf("savings product [%s] currency mismatch: %s vs %s", product.Code, product.Currency, account.Currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	savings, err := server.store.CreateSavingsAccount(ctx.Request.Context(), db.CreateSavingsAccountParams{
+		AccountID:   account.ID,
+		ProductCode: product.Code,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			ctx.JSON(http.StatusForbidden, errorResponse(errors.New("account is already a savings account")))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, savingsAccountResponse{
+		AccountID:   savings.AccountID,
+		ProductCode: savings.ProductCode,
+		CreatedAt:   savings.CreatedAt,
+	})
+}
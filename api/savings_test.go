@@ -0,0 +1,350 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	mockdb "github.com/AbdRaqeeb/simple_bank/db/mock"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateSavingsProductAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = util.AdminRole
+
+	expense := randomAccount()
+	expense.Currency = util.USD
+
+	product := db.SavingsProduct{
+		Code:             "savings",
+		Name:             "Savings",
+		Currency:         util.USD,
+		AnnualRateBps:    425,
+		DayCount:         db.DayCountActual365,
+		ExpenseAccountID: expense.ID,
+	}
+
+	body := func() gin.H {
+		return gin.H{
+			"code":               product.Code,
+			"name":               product.Name,
+			"currency":           product.Currency,
+			"annual_rate_bps":    product.AnnualRateBps,
+			"day_count":          product.DayCount,
+			"expense_account_id": product.ExpenseAccountID,
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: body(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(expense.ID)).Times(1).Return(expense, nil)
+				store.EXPECT().CreateSavingsProduct(gomock.Any(), gomock.Eq(db.CreateSavingsProductParams{
+					Code:             product.Code,
+					Name:             product.Name,
+					Currency:         product.Currency,
+					AnnualRateBps:    product.AnnualRateBps,
+					DayCount:         product.DayCount,
+					ExpenseAccountID: product.ExpenseAccountID,
+				})).Times(1).Return(product, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var rsp savingsProductResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, newSavingsProductResponse(product), rsp)
+			},
+		},
+		{
+			name: "Unsupported Day Count",
+			body: func() gin.H {
+				b := body()
+				b["day_count"] = "30/360"
+				return b
+			}(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().CreateSavingsProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Expense Account Currency Mismatch",
+			body: body(),
+			buildStubs: func(store *mockdb.MockStore) {
+				other := expense
+				other.Currency = util.CAD
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(expense.ID)).Times(1).Return(other, nil)
+				store.EXPECT().CreateSavingsProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Duplicate Code",
+			body: body(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(expense.ID)).Times(1).Return(expense, nil)
+				store.EXPECT().CreateSavingsProduct(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.SavingsProduct{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "Not Admin",
+			body: body(),
+			buildStubs: func(store *mockdb.MockStore) {
+				depositor := admin
+				depositor.Role = util.DepositorRole
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(depositor, nil)
+				store.EXPECT().CreateSavingsProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/savings-products", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, admin.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestOpenSavingsAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	account := randomAccount()
+	account.Owner = user.Username
+	account.Currency = util.USD
+	account.ProductCode = db.AccountProductSavings
+
+	product := db.SavingsProduct{Code: "savings", Currency: util.USD, AnnualRateBps: 425, DayCount: db.DayCountActual360, ExpenseAccountID: 1}
+
+	testCases := []struct {
+		name          string
+		accountID     int64
+		productCode   string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "OK",
+			accountID:   account.ID,
+			productCode: product.Code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetSavingsProduct(gomock.Any(), gomock.Eq(product.Code)).Times(1).Return(product, nil)
+				store.EXPECT().CreateSavingsAccount(gomock.Any(), gomock.Eq(db.CreateSavingsAccountParams{
+					AccountID:   account.ID,
+					ProductCode: product.Code,
+				})).Times(1).Return(db.SavingsAccount{AccountID: account.ID, ProductCode: product.Code}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var rsp savingsAccountResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, account.ID, rsp.AccountID)
+				require.Equal(t, product.Code, rsp.ProductCode)
+			},
+		},
+		{
+			name:        "Missing Product Code",
+			accountID:   account.ID,
+			productCode: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Account Not Found",
+			accountID:   account.ID,
+			productCode: product.Code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().CreateSavingsAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "Other Owner",
+			accountID:   account.ID,
+			productCode: product.Code,
+			buildStubs: func(store *mockdb.MockStore) {
+				other := account
+				other.Owner = util.RandomOwner()
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(other, nil)
+				store.EXPECT().CreateSavingsAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:        "Not Savings Account",
+			accountID:   account.ID,
+			productCode: product.Code,
+			buildStubs: func(store *mockdb.MockStore) {
+				checking := account
+				checking.ProductCode = db.AccountProductChecking
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(checking, nil)
+				store.EXPECT().GetSavingsProduct(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSavingsAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Product Not Found",
+			accountID:   account.ID,
+			productCode: "missing",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetSavingsProduct(gomock.Any(), gomock.Eq("missing")).Times(1).Return(db.SavingsProduct{}, sql.ErrNoRows)
+				store.EXPECT().CreateSavingsAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "Currency Mismatch",
+			accountID:   account.ID,
+			productCode: product.Code,
+			buildStubs: func(store *mockdb.MockStore) {
+				other := product
+				other.Currency = util.NGN
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetSavingsProduct(gomock.Any(), gomock.Eq(product.Code)).Times(1).Return(other, nil)
+				store.EXPECT().CreateSavingsAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Already Savings Account",
+			accountID:   account.ID,
+			productCode: product.Code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetSavingsProduct(gomock.Any(), gomock.Eq(product.Code)).Times(1).Return(product, nil)
+				store.EXPECT().CreateSavingsAccount(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.SavingsAccount{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).AnyTimes().Return(user, nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"product_code": tc.productCode})
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d/savings", tc.accountID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListSavingsProductsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	products := []db.SavingsProduct{
+		{Code: "bonus", Currency: util.CAD, AnnualRateBps: 500, DayCount: db.DayCountActualActual, ExpenseAccountID: 2},
+		{Code: "savings", Currency: util.USD, AnnualRateBps: 425, DayCount: db.DayCountActual365, ExpenseAccountID: 1},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).AnyTimes().Return(user, nil)
+	store.EXPECT().ListSavingsProducts(gomock.Any()).Times(1).Return(products, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/savings-products", nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp []savingsProductResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Equal(t, []savingsProductResponse{newSavingsProductResponse(products[0]), newSavingsProductResponse(products[1])}, rsp)
+}
@@ -1,13 +1,23 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"github.com/AbdRaqeeb/simple_bank/db/migration"
 	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/mail"
 	"github.com/AbdRaqeeb/simple_bank/token"
 	"github.com/AbdRaqeeb/simple_bank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Server serves http requests for the banking service
@@ -16,20 +26,79 @@ type Server struct {
 	store      db.Store
 	router     *gin.Engine
 	tokenMaker token.Maker
+	logger     zerolog.Logger
+	emailer    mail.Emailer
+
+	loginLimiter     *ipLoginLimiter
+	totpEncryptor    *util.Encryptor
+	webhookEncryptor *util.Encryptor
+
+	// stepUpThresholds maps a currency to the transfer amount above which a recent authentication is required
+	stepUpThresholds map[string]int64
+
+	// schemaVersion is the migration version the database must be at to serve traffic
+	schemaVersion int64
+
+	mu           sync.Mutex
+	httpServer   *http.Server
+	shuttingDown int32
+
+	// streamsClosing is closed on shutdown to end event streams, which would otherwise keep it waiting
+	streamsClosing   chan struct{}
+	closeStreamsOnce sync.Once
 }
 
 // NewServer creates a new HTTP server and setup routing
 func NewServer(config util.Config, store db.Store) (*Server, error) {
 	//token maker
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	tokenMaker, err := token.NewMaker(config)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
 
+	logger, err := util.NewLogger(config.LogLevel)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create logger: %w", err)
+	}
+
+	emailer, err := mail.NewEmailer(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create emailer: %w", err)
+	}
+
+	totpEncryptor, err := util.LoadEncryptor("TOTP_ENCRYPTION_KEY", config.TotpEncryptionKeyFile, config.TotpEncryptionKeyBase64)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create totp encryptor: %w", err)
+	}
+
+	webhookEncryptor, err := util.LoadEncryptor("WEBHOOK_ENCRYPTION_KEY", config.WebhookEncryptionKeyFile, config.WebhookEncryptionKeyBase64)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create webhook encryptor: %w", err)
+	}
+
+	stepUpThresholds, err := util.ParseCurrencyAmounts(config.StepUpTransferThresholds)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse step-up transfer thresholds: %w", err)
+	}
+
+	schemaVersion, err := migration.LatestVersion()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read schema version: %w", err)
+	}
+
 	server := &Server{
-		config:     config,
-		store:      store,
-		tokenMaker: tokenMaker,
+		config:           config,
+		store:            store,
+		tokenMaker:       tokenMaker,
+		logger:           logger,
+		emailer:          emailer,
+		loginLimiter:     newIPLoginLimiter(config),
+		totpEncryptor:    totpEncryptor,
+		webhookEncryptor: webhookEncryptor,
+		schemaVersion:    int64(schemaVersion),
+
+		stepUpThresholds: stepUpThresholds,
+		streamsClosing:   make(chan struct{}),
 	}
 
 	// register validator
@@ -37,28 +106,153 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 		_ = v.RegisterValidation("currency", validCurrency)
 	}
 
-	server.setupRouter()
+	err = server.setupRouter()
+	if err != nil {
+		return nil, err
+	}
+
 	return server, nil
 }
 
-func (server *Server) setupRouter() {
-	router := gin.Default()
+// trustedProxies splits the comma separated TRUSTED_PROXIES, no proxy is trusted when it is empty
+func trustedProxies(s string) []string {
+	var proxies []string
+	for _, proxy := range strings.Split(s, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+func (server *Server) setupRouter() error {
+	router := gin.New()
+
+	// the login and step-up limits are per client ip, only trusted proxies may set it with X-Forwarded-For
+	err := router.SetTrustedProxies(trustedProxies(server.config.TrustedProxies))
+	if err != nil {
+		return fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+
+	router.Use(gin.Recovery(), tracingMiddleware(), requestLoggerMiddleware(server.logger))
+
+	// probes
+	router.GET("/healthz", server.liveness)
+	router.GET("/readyz", server.readiness)
+
+	// public keys of the access tokens
+	router.GET("/.well-known/jwks.json", server.jwks)
 
 	// endpoints
 	router.POST("/users", server.createUser)
 	router.POST("/users/login", server.loginUser)
+	router.POST("/users/login/totp", server.loginTOTP)
+	router.POST("/users/password/forgot", server.forgotPassword)
+	router.POST("/users/password/reset", server.resetPassword)
+	router.POST("/users/verify-email", server.verifyEmail)
+
+	auth := authMiddleware(server.tokenMaker, server.store)
+
+	userReadRoutes := router.Group("/").Use(auth, requireScope(util.UsersReadScope))
+	userReadRoutes.GET("/users/me", server.getCurrentUser)
+	userReadRoutes.GET("/users/me/api-keys", server.listAPIKeys)
+
+	userWriteRoutes := router.Group("/").Use(auth, requireScope(util.UsersWriteScope))
+	userWriteRoutes.PATCH("/users/me", server.updateCurrentUser)
+	userWriteRoutes.PATCH("/users/me/password", server.changePassword)
+	userWriteRoutes.POST("/users/me/totp", server.enrollTOTP)
+	userWriteRoutes.POST("/users/me/totp/confirm", server.confirmTOTP)
+	userWriteRoutes.POST("/users/me/reauthenticate", server.reauthenticate)
+	userWriteRoutes.POST("/users/verify-email/send", server.sendVerificationEmail)
+	userWriteRoutes.POST("/users/me/api-keys", server.createAPIKey)
+	userWriteRoutes.DELETE("/users/me/api-keys/:id", server.revokeAPIKey)
+
+	transferRoutes := router.Group("/").Use(auth, requireScope(util.TransfersWriteScope))
+	transferRoutes.POST("/transfers", server.createTransfer)
+	transferRoutes.POST("/transfers/batch", server.createTransferBatch)
+	transferRoutes.POST("/transfers/import", server.importTransfers)
+
+	transferReadRoutes := router.Group("/").Use(auth, requireScope(util.TransfersReadScope))
+	transferReadRoutes.GET("/transfers/batch/:id", server.getTransferBatch)
+
+	webhookReadRoutes := router.Group("/").Use(auth, requireScope(util.WebhooksReadScope))
+	webhookReadRoutes.GET("/webhooks", server.listWebhookSubscriptions)
+	webhookReadRoutes.GET("/webhooks/:id/deliveries", server.listWebhookDeliveries)
+
+	webhookWriteRoutes := router.Group("/").Use(auth, requireScope(util.WebhooksWriteScope))
+	webhookWriteRoutes.POST("/webhooks", server.createWebhookSubscription)
+	webhookWriteRoutes.DELETE("/webhooks/:id", server.deleteWebhookSubscription)
+	webhookWriteRoutes.POST("/webhooks/:id/deliveries/:delivery_id/replay", server.replayWebhookDelivery)
+
+	accountReadRoutes := router.Group("/").Use(auth, requireScope(util.AccountsReadScope))
+	accountReadRoutes.GET("/accounts/:id", server.getAccount)
+	accountReadRoutes.GET("/accounts", server.listAccounts)
+	accountReadRoutes.GET("/accounts/:id/events", server.streamAccountEvents)
+	accountReadRoutes.GET("/accounts/:id/statement", server.getAccountStatement)
+	accountReadRoutes.GET("/savings-products", server.listSavingsProducts)
+	accountReadRoutes.GET("/account-products", server.listAccountProducts)
+
+	accountWriteRoutes := router.Group("/").Use(auth, requireScope(util.AccountsWriteScope))
+	accountWriteRoutes.POST("/accounts", server.createAccount)
+	accountWriteRoutes.POST("/accounts/:id/savings", server.openSavingsAccount)
 
-	router.POST("/accounts", server.createAccount)
-	router.POST("/transfers", server.createTransfer)
-	router.GET("/accounts/:id", server.getAccount)
-	router.GET("/accounts", server.listAccounts)
+	adminRoutes := router.Group("/").Use(auth, requireRole(util.AdminRole))
+	adminRoutes.GET("/users/:username", server.getUser)
+	adminRoutes.POST("/users/:username/unlock", server.unlockUser)
+	adminRoutes.POST("/accounts/:id/freeze", server.freezeAccount)
+	adminRoutes.POST("/savings-products", server.createSavingsProduct)
+	adminRoutes.POST("/account-products", server.createAccountProduct)
 
 	server.router = router
+	return nil
 }
 
-// Start runs the http server on a specific address
+// Start runs the http server on a specific address until Shutdown is called
 func (server *Server) Start(address string) error {
-	return server.router.Run(address)
+	server.mu.Lock()
+	server.httpServer = &http.Server{
+		Addr:    address,
+		Handler: server.router,
+	}
+	server.mu.Unlock()
+
+	err := server.httpServer.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// Shutdown marks the server as not ready, waits for the configured delay so load balancers
+// stop routing to it, then ends event streams, stops accepting connections and waits for in-flight requests
+func (server *Server) Shutdown(ctx context.Context) error {
+	atomic.StoreInt32(&server.shuttingDown, 1)
+
+	select {
+	case <-time.After(server.config.ShutdownDelay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	server.closeStreamsOnce.Do(func() {
+		close(server.streamsClosing)
+	})
+
+	server.mu.Lock()
+	httpServer := server.httpServer
+	server.mu.Unlock()
+
+	if httpServer == nil {
+		return nil
+	}
+
+	return httpServer.Shutdown(ctx)
+}
+
+func (server *Server) isShuttingDown() bool {
+	return atomic.LoadInt32(&server.shuttingDown) == 1
 }
 
 // errorResponse --> format error into json format
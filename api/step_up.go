@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/token"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+	"time"
+)
+
+var (
+	errStepUpRequired        = errors.New("this operation requires a recent authentication, reauthenticate and retry")
+	errMissingReauthenticate = errors.New("either password or code is required")
+)
+
+// checkStepUp makes sure a transfer above the threshold of its currency comes from a user who
+// authenticated recently. Otherwise it rejects the request with a step-up challenge (RFC 9470)
+// the client answers by calling /users/me/reauthenticate
+func (server *Server) checkStepUp(ctx *gin.Context, currency string, amount int64) bool {
+	threshold, ok := server.stepUpThresholds[currency]
+	if !ok || amount <= threshold {
+		return true
+	}
+
+	payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if time.Since(payload.AuthTime) <= server.config.StepUpMaxAge {
+		return true
+	}
+
+	maxAge := int(math.Ceil(server.config.StepUpMaxAge.Seconds()))
+	ctx.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_user_authentication", max_age=%d`, maxAge))
+	ctx.JSON(http.StatusUnauthorized, errorResponse(errStepUpRequired))
+	return false
+}
+
+type reauthenticateRequest struct {
+	Password string `json:"password" binding:"omitempty,min=6"`
+	Code     string `json:"code" binding:"omitempty,numeric,len=6"`
+}
+
+// reauthenticate lets an authenticated user prove their password or a TOTP code again and returns
+// an access token with a fresh auth time. Failures are throttled like failed logins
+func (server *Server) reauthenticate(ctx *gin.Context) {
+	var req reauthenticateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.Password == "" && req.Code == "" {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errMissingReauthenticate))
+		return
+	}
+
+	user := ctx.MustGet(authorizationUserKey).(db.User)
+
+	if req.Password == "" && !user.TotpEnabled {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errTOTPNotEnrolled))
+		return
+	}
+
+	clientIP := ctx.ClientIP()
+	if wait := server.loginLimiter.retryAfter(clientIP); wait > 0 {
+		abortTooManyLogins(ctx, wait)
+		return
+	}
+
+	if wait := userLoginRetryAfter(server.config, user, time.Now()); wait > 0 {
+		abortTooManyLogins(ctx, wait)
+		return
+	}
+
+	var ok bool
+	var err error
+	failure := errInvalidCredentials
+	if req.Password != "" {
+		ok = util.CheckPassword(req.Password, user.HashedPassword) == nil
+	} else {
+		failure = errInvalidTOTPCode
+		ok, err = server.useTOTPCode(ctx, user, req.Code)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	if !ok {
+		server.loginLimiter.recordFailure(clientIP)
+
+		err = server.recordFailedLogin(ctx.Request.Context(), user.Username)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusUnauthorized, errorResponse(failure))
+		return
+	}
+
+	server.completeLogin(ctx, user)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReauthenticateAPIWithMemoryStore(t *testing.T) {
+	store := db.NewMemoryStore()
+	server := newTestServer(t, store)
+
+	user, password := randomUser(t)
+	_, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       user.Username,
+		HashedPassword: user.HashedPassword,
+		FullName:       user.FullName,
+		Email:          user.Email,
+	})
+	require.NoError(t, err)
+
+	reauthenticate := func(body gin.H) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/users/me/reauthenticate", bytes.NewReader(data))
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := reauthenticate(gin.H{})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// a code is only accepted from users with TOTP enabled
+	recorder = reauthenticate(gin.H{"code": "123456"})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = reauthenticate(gin.H{"password": "wrong-password"})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	failed, err := store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failed.FailedLoginAttempts)
+
+	recorder = reauthenticate(gin.H{"password": password})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var res loginUserResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+
+	payload, err := server.tokenMaker.VerifyToken(res.AccessToken)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), payload.AuthTime, time.Second)
+
+	reset, err := store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Zero(t, reset.FailedLoginAttempts)
+}
@@ -0,0 +1,284 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/token"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+// recoveryCodeCount is how many recovery codes a user receives when confirming TOTP
+const recoveryCodeCount = 10
+
+var (
+	errTOTPEnabled         = errors.New("two-factor authentication is already enabled")
+	errTOTPNotEnrolled     = errors.New("two-factor authentication is not enrolled")
+	errInvalidTOTPCode     = errors.New("invalid two-factor code")
+	errInvalidChallenge    = errors.New("login challenge is invalid or has expired")
+	errMissingSecondFactor = errors.New("either code or recovery_code is required")
+)
+
+type enrollTOTPResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+// enrollTOTP generates a new TOTP secret for the authenticated user. It is not used for logins
+// until the user confirms it with a code, enrolling again replaces an unconfirmed secret
+func (server *Server) enrollTOTP(ctx *gin.Context) {
+	user := ctx.MustGet(authorizationUserKey).(db.User)
+
+	if user.TotpEnabled {
+		ctx.JSON(http.StatusConflict, errorResponse(errTOTPEnabled))
+		return
+	}
+
+	secret, err := util.NewTOTPSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	encryptedSecret, err := server.totpEncryptor.Encrypt(secret)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, err = server.store.SetUserTOTPSecret(ctx.Request.Context(), db.SetUserTOTPSecretParams{
+		Username:   user.Username,
+		TotpSecret: sql.NullString{String: encryptedSecret, Valid: true},
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, enrollTOTPResponse{
+		Secret:     secret,
+		OtpauthURI: util.TOTPURI(server.config.TotpIssuer, user.Username, secret),
+	})
+}
+
+type confirmTOTPRequest struct {
+	Code string `json:"code" binding:"required,numeric,len=6"`
+}
+
+type confirmTOTPResponse struct {
+	RecoveryCodes []string     `json:"recovery_codes"`
+	User          userResponse `json:"user"`
+}
+
+// confirmTOTP enables TOTP once the user sends a valid code for the enrolled secret and returns
+// the recovery codes, which are only ever shown in this response
+func (server *Server) confirmTOTP(ctx *gin.Context) {
+	var req confirmTOTPRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user := ctx.MustGet(authorizationUserKey).(db.User)
+
+	if user.TotpEnabled {
+		ctx.JSON(http.StatusConflict, errorResponse(errTOTPEnabled))
+		return
+	}
+
+	if !user.TotpSecret.Valid {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errTOTPNotEnrolled))
+		return
+	}
+
+	step, ok, err := server.validateTOTP(user, req.Code)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidTOTPCode))
+		return
+	}
+
+	recoveryCodes, err := util.NewRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	codeHashes := make([]string, len(recoveryCodes))
+	for i, code := range recoveryCodes {
+		codeHashes[i] = util.HashSecretToken(code)
+	}
+
+	user, err = server.store.ConfirmTOTPTx(ctx.Request.Context(), db.ConfirmTOTPTxParams{
+		Username:           user.Username,
+		Step:               step,
+		RecoveryCodeHashes: codeHashes,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidTOTPCode))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, confirmTOTPResponse{
+		RecoveryCodes: recoveryCodes,
+		User:          newUserResponse(user),
+	})
+}
+
+// validateTOTP checks a code against the enrolled secret of the user and returns the matched time step
+func (server *Server) validateTOTP(user db.User, code string) (int64, bool, error) {
+	secret, err := server.totpEncryptor.Decrypt(user.TotpSecret.String)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return util.ValidateTOTP(secret, code, time.Now())
+}
+
+type loginChallengeResponse struct {
+	TotpRequired   bool   `json:"totp_required"`
+	ChallengeToken string `json:"challenge_token"`
+}
+
+// sendLoginChallenge answers the password step of a login for a user with TOTP enabled
+func (server *Server) sendLoginChallenge(ctx *gin.Context, user db.User) {
+	challengeToken, err := server.tokenMaker.CreateChallengeToken(user.Username, server.config.LoginChallengeDuration)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, loginChallengeResponse{
+		TotpRequired:   true,
+		ChallengeToken: challengeToken,
+	})
+}
+
+type loginTOTPRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"omitempty,numeric,len=6"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+// loginTOTP exchanges a login challenge and a TOTP or recovery code for an access token.
+// Wrong codes count as failed logins, so guessing codes is throttled like guessing passwords
+func (server *Server) loginTOTP(ctx *gin.Context) {
+	var req loginTOTPRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.Code == "" && req.RecoveryCode == "" {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errMissingSecondFactor))
+		return
+	}
+
+	payload, err := server.tokenMaker.VerifyToken(req.ChallengeToken)
+	if err != nil || payload.Type != token.TypeLoginChallenge {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidChallenge))
+		return
+	}
+
+	clientIP := ctx.ClientIP()
+	if wait := server.loginLimiter.retryAfter(clientIP); wait > 0 {
+		abortTooManyLogins(ctx, wait)
+		return
+	}
+
+	user, err := server.store.GetUser(ctx.Request.Context(), payload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidChallenge))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// the password may have been changed since the challenge was issued
+	if payload.IssuedAt.Before(user.PasswordChangedAt) || !user.TotpEnabled {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidChallenge))
+		return
+	}
+
+	if wait := userLoginRetryAfter(server.config, user, time.Now()); wait > 0 {
+		abortTooManyLogins(ctx, wait)
+		return
+	}
+
+	var ok bool
+	if req.Code != "" {
+		ok, err = server.useTOTPCode(ctx, user, req.Code)
+	} else {
+		ok, err = server.useRecoveryCode(ctx, user, req.RecoveryCode)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !ok {
+		server.loginLimiter.recordFailure(clientIP)
+
+		err = server.recordFailedLogin(ctx.Request.Context(), user.Username)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidTOTPCode))
+		return
+	}
+
+	server.completeLogin(ctx, user)
+}
+
+// useTOTPCode validates a code and consumes its time step so it cannot be replayed
+func (server *Server) useTOTPCode(ctx *gin.Context, user db.User, code string) (bool, error) {
+	step, ok, err := server.validateTOTP(user, code)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	_, err = server.store.UseTOTPStep(ctx.Request.Context(), db.UseTOTPStepParams{
+		Username:     user.Username,
+		TotpLastStep: step,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// useRecoveryCode consumes one of the recovery codes of the user
+func (server *Server) useRecoveryCode(ctx *gin.Context, user db.User, code string) (bool, error) {
+	_, err := server.store.UseTOTPRecoveryCode(ctx.Request.Context(), db.UseTOTPRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: util.HashSecretToken(util.NormalizeRecoveryCode(code)),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	mockdb "github.com/AbdRaqeeb/simple_bank/db/mock"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/token"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestTOTPFlowWithMemoryStore enrolls a user in TOTP and logs in with a code and a recovery code
+func TestTOTPFlowWithMemoryStore(t *testing.T) {
+	store := db.NewMemoryStore()
+	server := newTestServer(t, store)
+
+	user, password := randomUser(t)
+	_, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       user.Username,
+		HashedPassword: user.HashedPassword,
+		FullName:       user.FullName,
+		Email:          user.Email,
+	})
+	require.NoError(t, err)
+
+	serve := func(method, url string, body gin.H, accessToken string) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(method, url, bytes.NewReader(data))
+		require.NoError(t, err)
+		if accessToken != "" {
+			request.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" "+accessToken)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	login := func() loginChallengeResponse {
+		recorder := serve(http.MethodPost, "/users/login", gin.H{"username": user.Username, "password": password}, "")
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var res loginChallengeResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+		return res
+	}
+
+	recorder := serve(http.MethodPost, "/users/login", gin.H{"username": user.Username, "password": password}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var loginRes loginUserResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &loginRes))
+	require.NotEmpty(t, loginRes.AccessToken)
+
+	// enroll
+	recorder = serve(http.MethodPost, "/users/me/totp", nil, loginRes.AccessToken)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+	var enrollRes enrollTOTPResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &enrollRes))
+	require.Contains(t, enrollRes.OtpauthURI, "otpauth://totp/")
+
+	stored, err := store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.NotEqual(t, enrollRes.Secret, stored.TotpSecret.String)
+
+	// confirm with the code of the previous time step so the current one is left for the login
+	step := util.TOTPStep(time.Now())
+	code, err := util.TOTPCode(enrollRes.Secret, step-1)
+	require.NoError(t, err)
+
+	recorder = serve(http.MethodPost, "/users/me/totp/confirm", gin.H{"code": code}, loginRes.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var confirmRes confirmTOTPResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &confirmRes))
+	require.Len(t, confirmRes.RecoveryCodes, recoveryCodeCount)
+	require.True(t, confirmRes.User.TotpEnabled)
+
+	// the password alone only yields a challenge, which cannot access the api
+	challenge := login()
+	require.True(t, challenge.TotpRequired)
+
+	recorder = serve(http.MethodGet, "/users/me", nil, challenge.ChallengeToken)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	// the code used for the confirmation cannot be replayed
+	recorder = serve(http.MethodPost, "/users/login/totp", gin.H{"challenge_token": challenge.ChallengeToken, "code": code}, "")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	code, err = util.TOTPCode(enrollRes.Secret, step)
+	require.NoError(t, err)
+
+	recorder = serve(http.MethodPost, "/users/login/totp", gin.H{"challenge_token": challenge.ChallengeToken, "code": code}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &loginRes))
+
+	recorder = serve(http.MethodGet, "/users/me", nil, loginRes.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// recovery codes work once
+	recoveryCode := confirmRes.RecoveryCodes[0]
+	challenge = login()
+	recorder = serve(http.MethodPost, "/users/login/totp", gin.H{"challenge_token": challenge.ChallengeToken, "recovery_code": recoveryCode}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = serve(http.MethodPost, "/users/login/totp", gin.H{"challenge_token": challenge.ChallengeToken, "recovery_code": recoveryCode}, "")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	// enrolling again is refused while enabled
+	recorder = serve(http.MethodPost, "/users/me/totp", nil, loginRes.AccessToken)
+	require.Equal(t, http.StatusConflict, recorder.Code)
+}
+
+func TestLoginTOTPAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user.TotpEnabled = true
+	user.TotpSecret = sql.NullString{String: util.RandomString(32), Valid: true}
+
+	testCases := []struct {
+		name          string
+		body          func(t *testing.T, tokenMaker token.Maker) gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Access Token As Challenge",
+			body: func(t *testing.T, tokenMaker token.Maker) gin.H {
+				accessToken, err := tokenMaker.CreateToken(user.Username, time.Minute)
+				require.NoError(t, err)
+				return gin.H{"challenge_token": accessToken, "code": "123456"}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Expired Challenge",
+			body: func(t *testing.T, tokenMaker token.Maker) gin.H {
+				challengeToken, err := tokenMaker.CreateChallengeToken(user.Username, -time.Minute)
+				require.NoError(t, err)
+				return gin.H{"challenge_token": challengeToken, "code": "123456"}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Missing Second Factor",
+			body: func(t *testing.T, tokenMaker token.Maker) gin.H {
+				challengeToken, err := tokenMaker.CreateChallengeToken(user.Username, time.Minute)
+				require.NoError(t, err)
+				return gin.H{"challenge_token": challengeToken}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Password Changed Since Challenge",
+			body: func(t *testing.T, tokenMaker token.Maker) gin.H {
+				challengeToken, err := tokenMaker.CreateChallengeToken(user.Username, time.Minute)
+				require.NoError(t, err)
+				return gin.H{"challenge_token": challengeToken, "code": "123456"}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				changed := user
+				changed.PasswordChangedAt = time.Now().Add(time.Second)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(changed, nil)
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Locked User",
+			body: func(t *testing.T, tokenMaker token.Maker) gin.H {
+				challengeToken, err := tokenMaker.CreateChallengeToken(user.Username, time.Minute)
+				require.NoError(t, err)
+				return gin.H{"challenge_token": challengeToken, "recovery_code": "abcd-efgh-ijkl-mnop"}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				locked := user
+				locked.LockedUntil = sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true}
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(locked, nil)
+				store.EXPECT().UseTOTPRecoveryCode(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			},
+		},
+		{
+			name: "Wrong Recovery Code",
+			body: func(t *testing.T, tokenMaker token.Maker) gin.H {
+				challengeToken, err := tokenMaker.CreateChallengeToken(user.Username, time.Minute)
+				require.NoError(t, err)
+				return gin.H{"challenge_token": challengeToken, "recovery_code": "abcd-efgh-ijkl-mnop"}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				failed := user
+				failed.FailedLoginAttempts = 1
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UseTOTPRecoveryCode(gomock.Any(), gomock.Eq(db.UseTOTPRecoveryCodeParams{
+					Username: user.Username,
+					CodeHash: util.HashSecretToken("abcd-efgh-ijkl-mnop"),
+				})).Times(1).Return(db.TotpRecoveryCode{}, sql.ErrNoRows)
+				store.EXPECT().RecordFailedLogin(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(failed, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Internal Server Error",
+			body: func(t *testing.T, tokenMaker token.Maker) gin.H {
+				challengeToken, err := tokenMaker.CreateChallengeToken(user.Username, time.Minute)
+				require.NoError(t, err)
+				return gin.H{"challenge_token": challengeToken, "recovery_code": "abcd-efgh-ijkl-mnop"}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body(t, server.tokenMaker))
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/login/totp", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -2,8 +2,11 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/token"
+	"github.com/AbdRaqeeb/simple_bank/util"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
@@ -15,6 +18,45 @@ type createTransferRequest struct {
 	Currency      string `json:"currency" binding:"required,currency"`
 }
 
+// transferResponse is a transfer with its amount formatted in the major unit of its currency
+type transferResponse struct {
+	db.Transfer
+	AmountFormatted string `json:"amount_formatted"`
+}
+
+// entryResponse is an entry with its amount formatted in the major unit of its currency
+type entryResponse struct {
+	db.Entry
+	AmountFormatted string `json:"amount_formatted"`
+}
+
+type transferTxResponse struct {
+	Transfer    transferResponse `json:"transfer"`
+	FromAccount accountResponse  `json:"from_account"`
+	ToAccount   accountResponse  `json:"to_account"`
+	FromEntry   entryResponse    `json:"from_entry"`
+	ToEntry     entryResponse    `json:"to_entry"`
+}
+
+func newTransferTxResponse(result db.TransferTxResult, currency string) transferTxResponse {
+	return transferTxResponse{
+		Transfer: transferResponse{
+			Transfer:        result.Transfer,
+			AmountFormatted: util.NewMoney(result.Transfer.Amount, currency).Decimal(),
+		},
+		FromAccount: newAccountResponse(result.FromAccount),
+		ToAccount:   newAccountResponse(result.ToAccount),
+		FromEntry: entryResponse{
+			Entry:           result.FromEntry,
+			AmountFormatted: util.NewMoney(result.FromEntry.Amount, currency).Decimal(),
+		},
+		ToEntry: entryResponse{
+			Entry:           result.ToEntry,
+			AmountFormatted: util.NewMoney(result.ToEntry.Amount, currency).Decimal(),
+		},
+	}
+}
+
 func (server *Server) createTransfer(ctx *gin.Context) {
 	var req createTransferRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -22,6 +64,10 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
+	if !server.checkStepUp(ctx, req.Currency, req.Amount) {
+		return
+	}
+
 	arg := db.TransferTxParams{
 		FromAccountID: req.FromAccountID,
 		ToAccountID:   req.ToAccountID,
@@ -29,41 +75,74 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 	}
 
 	// check currency type of from_account
-	if !server.validAccountCurrency(ctx, req.FromAccountID, req.Currency) {
+	fromAccount, valid := server.validAccountCurrency(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != payload.Username {
+		ctx.JSON(http.StatusForbidden, errorResponse(errAccountNotOwned))
 		return
 	}
 
 	// check currency type of to_account
-	if !server.validAccountCurrency(ctx, req.ToAccountID, req.Currency) {
+	toAccount, valid := server.validAccountCurrency(ctx, req.ToAccountID, req.Currency)
+	if !valid {
 		return
 	}
 
-	result, err := server.store.TransferTx(ctx, arg)
+	if _, err := util.NewMoney(toAccount.Balance, toAccount.Currency).Add(util.NewMoney(req.Amount, req.Currency)); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(fmt.Errorf("account [%d]: %w", toAccount.ID, err)))
+		return
+	}
+
+	result, err := server.store.TransferTx(ctx.Request.Context(), arg)
 	if err != nil {
+		if db.IsRetryableError(err) {
+			ctx.JSON(http.StatusServiceUnavailable, errorResponse(errors.New("transfer conflicted with concurrent transfers, please retry")))
+			return
+		}
+
+		if errors.Is(err, db.ErrTransfersNotAllowed) {
+			ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("account [%d]: %w", fromAccount.ID, err)))
+			return
+		}
+
+		if errors.Is(err, db.ErrAccountFrozen) {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(fmt.Errorf("account [%d]: %w", fromAccount.ID, err)))
+			return
+		}
+
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, result)
+	ctx.JSON(http.StatusOK, newTransferTxResponse(result, req.Currency))
 }
 
-func (server *Server) validAccountCurrency(ctx *gin.Context, accountID int64, currency string) bool {
-	account, err := server.store.GetAccount(ctx, accountID)
+func (server *Server) validAccountCurrency(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx.Request.Context(), accountID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return false
+			return account, false
 		}
 
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return false
+		return account, false
 	}
 
 	if account.Currency != currency {
 		err = fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
-		return false
+		return account, false
 	}
 
-	return true
+	return account, true
 }
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/token"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+var (
+	errTransferBatchNotFound = errors.New("transfer batch not found")
+	errTransferBatchTotal    = errors.New("total amount of the batch is too large")
+)
+
+type transferBatchItemRequest struct {
+	ToAccountID int64 `json:"to_account_id" binding:"required,min=1"`
+	Amount      int64 `json:"amount" binding:"required,gt=0"`
+}
+
+type createTransferBatchRequest struct {
+	FromAccountID int64                      `json:"from_account_id" binding:"required,min=1"`
+	Currency      string                     `json:"currency" binding:"required,currency"`
+	Mode          string                     `json:"mode" binding:"required,oneof=atomic best_effort"`
+	Transfers     []transferBatchItemRequest `json:"transfers" binding:"required,min=1,max=1000,dive"`
+}
+
+type transferBatchItemResponse struct {
+	Position        int32   `json:"position"`
+	ToAccountID     int64   `json:"to_account_id"`
+	Amount          int64   `json:"amount"`
+	AmountFormatted string  `json:"amount_formatted"`
+	TransferID      *int64  `json:"transfer_id"`
+	Error           *string `json:"error"`
+}
+
+type transferBatchResponse struct {
+	ID                   int64                       `json:"id"`
+	FromAccountID        int64                       `json:"from_account_id"`
+	Mode                 string                      `json:"mode"`
+	Status               string                      `json:"status"`
+	ItemCount            int32                       `json:"item_count"`
+	TotalAmount          int64                       `json:"total_amount"`
+	TotalAmountFormatted string                      `json:"total_amount_formatted"`
+	Currency             string                      `json:"currency"`
+	SucceededCount       int32                       `json:"succeeded_count"`
+	CreatedAt            time.Time                   `json:"created_at"`
+	Items                []transferBatchItemResponse `json:"items"`
+}
+
+// newTransferBatchResponse returns a batch with its amounts formatted in the currency of the source account
+func newTransferBatchResponse(batch db.TransferBatch, items []db.TransferBatchItem, currency string) transferBatchResponse {
+	rsp := transferBatchResponse{
+		ID:                   batch.ID,
+		FromAccountID:        batch.FromAccountID,
+		Mode:                 batch.Mode,
+		Status:               batch.Status,
+		ItemCount:            batch.ItemCount,
+		TotalAmount:          batch.TotalAmount,
+		TotalAmountFormatted: util.NewMoney(batch.TotalAmount, currency).Decimal(),
+		Currency:             currency,
+		SucceededCount:       batch.SucceededCount,
+		CreatedAt:            batch.CreatedAt,
+		Items:                make([]transferBatchItemResponse, len(items)),
+	}
+
+	for i := range items {
+		item := items[i]
+		rsp.Items[i] = transferBatchItemResponse{
+			Position:        item.Position,
+			ToAccountID:     item.ToAccountID,
+			Amount:          item.Amount,
+			AmountFormatted: util.NewMoney(item.Amount, currency).Decimal(),
+		}
+
+		if item.TransferID.Valid {
+			rsp.Items[i].TransferID = &item.TransferID.Int64
+		}
+
+		if item.Error.Valid {
+			rsp.Items[i].Error = &item.Error.String
+		}
+	}
+
+	return rsp
+}
+
+// createTransferBatch sends a list of transfers from an account of the authenticated user. The whole batch is
+// validated before anything is transferred, then atomic batches transfer every item or none and best_effort
+// batches transfer each item on its own. The batch is recorded with the result of every item
+func (server *Server) createTransferBatch(ctx *gin.Context) {
+	var req createTransferBatchRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	total := util.NewMoney(0, req.Currency)
+	for _, item := range req.Transfers {
+		var err error
+		total, err = total.Add(util.NewMoney(item.Amount, req.Currency))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errTransferBatchTotal))
+			return
+		}
+	}
+
+	if !server.checkStepUp(ctx, req.Currency, total.Amount) {
+		return
+	}
+
+	fromAccount, valid := server.validAccountCurrency(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != payload.Username {
+		ctx.JSON(http.StatusForbidden, errorResponse(errAccountNotOwned))
+		return
+	}
+
+	product, err := server.store.GetAccountProduct(ctx.Request.Context(), fromAccount.ProductCode)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !product.CanInitiateTransfers {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("account [%d]: %w", fromAccount.ID, db.ErrTransfersNotAllowed)))
+		return
+	}
+
+	if fromAccount.Frozen {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("account [%d]: %w", fromAccount.ID, db.ErrAccountFrozen)))
+		return
+	}
+
+	if db.AvailableBalance(fromAccount.Balance, product) < total.Amount {
+		balance := util.NewMoney(fromAccount.Balance, fromAccount.Currency)
+		overdraft := util.NewMoney(product.OverdraftLimit, fromAccount.Currency)
+		err = fmt.Errorf("%w: balance %s, overdraft limit %s, batch total %s", db.ErrInsufficientFunds, balance, overdraft, total)
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+
+	arg := db.TransferBatchTxParams{
+		Owner:         payload.Username,
+		FromAccountID: fromAccount.ID,
+		Mode:          req.Mode,
+		Items:         make([]db.TransferBatchItemParams, len(req.Transfers)),
+	}
+
+	// every destination is looked up once
+	checked := make(map[int64]bool)
+	for i, item := range req.Transfers {
+		if item.ToAccountID == fromAccount.ID {
+			ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("transfers[%d]: cannot transfer to the source account", i)))
+			return
+		}
+
+		if !checked[item.ToAccountID] {
+			toAccount, err := server.store.GetAccount(ctx.Request.Context(), item.ToAccountID)
+			if err != nil {
+				if err == sql.ErrNoRows {
+					ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("transfers[%d]: account [%d] not found", i, item.ToAccountID)))
+					return
+				}
+
+				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+
+			if toAccount.Currency != req.Currency {
+				err = fmt.Errorf("transfers[%d]: account [%d] currency mismatch: %s vs %s", i, toAccount.ID, toAccount.Currency, req.Currency)
+				ctx.JSON(http.StatusBadRequest, errorResponse(err))
+				return
+			}
+
+			checked[toAccount.ID] = true
+		}
+
+		arg.Items[i] = db.TransferBatchItemParams{ToAccountID: item.ToAccountID, Amount: item.Amount}
+	}
+
+	result, err := server.store.TransferBatchTx(ctx.Request.Context(), arg)
+	if err != nil {
+		if db.IsRetryableError(err) {
+			ctx.JSON(http.StatusServiceUnavailable, errorResponse(errors.New("transfer batch conflicted with concurrent transfers, please retry")))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferBatchResponse(result.Batch, result.Items, req.Currency))
+}
+
+type getTransferBatchRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getTransferBatch returns a batch of the authenticated user with the result of every item
+func (server *Server) getTransferBatch(ctx *gin.Context) {
+	var req getTransferBatchRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	batch, err := server.store.GetTransferBatch(ctx.Request.Context(), req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errTransferBatchNotFound))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if batch.Owner != payload.Username {
+		ctx.JSON(http.StatusNotFound, errorResponse(errTransferBatchNotFound))
+		return
+	}
+
+	items, err := server.store.ListTransferBatchItems(ctx.Request.Context(), batch.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// batches do not record their currency, it is the one of the source account
+	fromAccount, err := server.store.GetAccount(ctx.Request.Context(), batch.FromAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferBatchResponse(batch, items, fromAccount.Currency))
+}
@@ -0,0 +1,481 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	mockdb "github.com/AbdRaqeeb/simple_bank/db/mock"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateTransferBatchAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	source := db.Account{ID: 1, Owner: user.Username, Balance: 100, Currency: util.USD, ProductCode: db.AccountProductChecking}
+	checking := db.AccountProduct{Code: db.AccountProductChecking, CanInitiateTransfers: true}
+	accountOne := db.Account{ID: 2, Owner: util.RandomOwner(), Currency: util.USD}
+	accountTwo := db.Account{ID: 3, Owner: util.RandomOwner(), Currency: util.USD}
+	accountCAD := db.Account{ID: 4, Owner: util.RandomOwner(), Currency: util.CAD}
+
+	transfers := []gin.H{
+		{"to_account_id": accountOne.ID, "amount": 30},
+		{"to_account_id": accountTwo.ID, "amount": 20},
+		{"to_account_id": accountOne.ID, "amount": 10},
+	}
+
+	body := func(mode string, transfers []gin.H) gin.H {
+		return gin.H{
+			"from_account_id": source.ID,
+			"currency":        util.USD,
+			"mode":            mode,
+			"transfers":       transfers,
+		}
+	}
+
+	stubAccounts := func(store *mockdb.MockStore, accounts ...db.Account) {
+		for _, account := range accounts {
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+		}
+	}
+
+	arg := db.TransferBatchTxParams{
+		Owner:         user.Username,
+		FromAccountID: source.ID,
+		Mode:          db.TransferBatchBestEffort,
+		Items: []db.TransferBatchItemParams{
+			{ToAccountID: accountOne.ID, Amount: 30},
+			{ToAccountID: accountTwo.ID, Amount: 20},
+			{ToAccountID: accountOne.ID, Amount: 10},
+		},
+	}
+
+	batch := db.TransferBatch{
+		ID:             7,
+		Owner:          user.Username,
+		FromAccountID:  source.ID,
+		Mode:           db.TransferBatchBestEffort,
+		Status:         db.TransferBatchPartiallyCompleted,
+		ItemCount:      3,
+		TotalAmount:    60,
+		SucceededCount: 2,
+		CreatedAt:      time.Now(),
+	}
+
+	result := db.TransferBatchTxResult{
+		Batch: batch,
+		Items: []db.TransferBatchItem{
+			{BatchID: batch.ID, Position: 0, ToAccountID: accountOne.ID, Amount: 30, TransferID: sql.NullInt64{Int64: 11, Valid: true}},
+			{BatchID: batch.ID, Position: 1, ToAccountID: accountTwo.ID, Amount: 20, Error: sql.NullString{String: "insufficient funds", Valid: true}},
+			{BatchID: batch.ID, Position: 2, ToAccountID: accountOne.ID, Amount: 10, TransferID: sql.NullInt64{Int64: 12, Valid: true}},
+		},
+		Transfers: []db.TransferTxResult{
+			{Transfer: db.Transfer{ID: 11, FromAccountID: source.ID, ToAccountID: accountOne.ID, Amount: 30}},
+			{Transfer: db.Transfer{ID: 12, FromAccountID: source.ID, ToAccountID: accountOne.ID, Amount: 10}},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: body(db.TransferBatchBestEffort, transfers),
+			buildStubs: func(store *mockdb.MockStore) {
+				// each destination is looked up once
+				stubAccounts(store, source, accountOne, accountTwo)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferBatchResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, batch.ID, rsp.ID)
+				require.Equal(t, db.TransferBatchPartiallyCompleted, rsp.Status)
+				require.Equal(t, int32(2), rsp.SucceededCount)
+				require.Len(t, rsp.Items, 3)
+				require.Equal(t, int64(11), *rsp.Items[0].TransferID)
+				require.Nil(t, rsp.Items[0].Error)
+				require.Nil(t, rsp.Items[1].TransferID)
+				require.Equal(t, "insufficient funds", *rsp.Items[1].Error)
+			},
+		},
+		{
+			name: "Invalid Mode",
+			body: body("eventually", transfers),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "No Transfers",
+			body: body(db.TransferBatchAtomic, []gin.H{}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Invalid Amount",
+			body: body(db.TransferBatchAtomic, []gin.H{{"to_account_id": accountOne.ID, "amount": -5}}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Total Overflow",
+			body: body(db.TransferBatchAtomic, []gin.H{
+				{"to_account_id": accountOne.ID, "amount": int64(1) << 62},
+				{"to_account_id": accountTwo.ID, "amount": int64(1) << 62},
+			}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Source Not Owned",
+			body: body(db.TransferBatchAtomic, transfers),
+			buildStubs: func(store *mockdb.MockStore) {
+				notOwned := source
+				notOwned.Owner = util.RandomOwner()
+				stubAccounts(store, notOwned)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "Insufficient Funds",
+			body: body(db.TransferBatchAtomic, append(transfers, gin.H{"to_account_id": accountTwo.ID, "amount": 41})),
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store, source)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "Overdraft Limit",
+			body: body(db.TransferBatchAtomic, append(transfers, gin.H{"to_account_id": accountTwo.ID, "amount": 41})),
+			buildStubs: func(store *mockdb.MockStore) {
+				overdrawn := source
+				overdrawn.ProductCode = "overdraft"
+				stubAccounts(store, overdrawn, accountOne, accountTwo)
+				store.EXPECT().GetAccountProduct(gomock.Any(), gomock.Eq("overdraft")).Times(1).
+					Return(db.AccountProduct{Code: "overdraft", OverdraftLimit: 1, CanInitiateTransfers: true}, nil)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(1).Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Cannot Initiate Transfers",
+			body: body(db.TransferBatchAtomic, transfers),
+			buildStubs: func(store *mockdb.MockStore) {
+				escrow := source
+				escrow.ProductCode = db.AccountProductEscrow
+				stubAccounts(store, escrow)
+				store.EXPECT().GetAccountProduct(gomock.Any(), gomock.Eq(db.AccountProductEscrow)).Times(1).
+					Return(db.AccountProduct{Code: db.AccountProductEscrow, MultiplePerCurrency: true}, nil)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), db.ErrTransfersNotAllowed.Error())
+			},
+		},
+		{
+			name: "Source Frozen",
+			body: body(db.TransferBatchAtomic, transfers),
+			buildStubs: func(store *mockdb.MockStore) {
+				frozen := source
+				frozen.Frozen = true
+				stubAccounts(store, frozen)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), db.ErrAccountFrozen.Error())
+			},
+		},
+		{
+			name: "Transfer To Source",
+			body: body(db.TransferBatchAtomic, []gin.H{{"to_account_id": source.ID, "amount": 10}}),
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store, source)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Destination Not Found",
+			body: body(db.TransferBatchAtomic, transfers),
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store, source)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(accountOne.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Destination Currency Mismatch",
+			body: body(db.TransferBatchAtomic, []gin.H{
+				{"to_account_id": accountOne.ID, "amount": 10},
+				{"to_account_id": accountCAD.ID, "amount": 10},
+			}),
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store, source, accountOne, accountCAD)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "transfers[1]")
+			},
+		},
+		{
+			name: "Serialization Failure",
+			body: body(db.TransferBatchBestEffort, transfers),
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store, source, accountOne, accountTwo)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.TransferBatchTxResult{}, &pq.Error{Code: "40001"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+			},
+		},
+		{
+			name: "Internal Error",
+			body: body(db.TransferBatchBestEffort, transfers),
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store, source, accountOne, accountTwo)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.TransferBatchTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).AnyTimes().Return(user, nil)
+			store.EXPECT().GetAccountProduct(gomock.Any(), gomock.Eq(db.AccountProductChecking)).AnyTimes().Return(checking, nil)
+			tc.buildStubs(store)
+
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers/batch", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetTransferBatchAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	batch := db.TransferBatch{ID: 7, Owner: user.Username, FromAccountID: 1, Mode: db.TransferBatchAtomic, Status: db.TransferBatchCompleted, ItemCount: 1, TotalAmount: 10, SucceededCount: 1}
+	items := []db.TransferBatchItem{
+		{BatchID: batch.ID, Position: 0, ToAccountID: 2, Amount: 10, TransferID: sql.NullInt64{Int64: 11, Valid: true}},
+	}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(batch, nil)
+				store.EXPECT().ListTransferBatchItems(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(items, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(batch.FromAccountID)).Times(1).
+					Return(db.Account{ID: batch.FromAccountID, Owner: user.Username, Currency: util.USD}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferBatchResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, newTransferBatchResponse(batch, items, util.USD), rsp)
+				require.Equal(t, util.USD, rsp.Currency)
+				require.Equal(t, "0.10", rsp.TotalAmountFormatted)
+				require.Equal(t, "0.10", rsp.Items[0].AmountFormatted)
+			},
+		},
+		{
+			name: "Not Found",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(db.TransferBatch{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Other Owner",
+			buildStubs: func(store *mockdb.MockStore) {
+				other := batch
+				other.Owner = util.RandomOwner()
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(other, nil)
+				store.EXPECT().ListTransferBatchItems(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Internal Error",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(db.TransferBatch{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).AnyTimes().Return(user, nil)
+			tc.buildStubs(store)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/transfers/batch/%d", batch.ID), nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestTransferBatchAPIWithMemoryStore(t *testing.T) {
+	store := db.NewMemoryStore()
+	server := newTestServer(t, store)
+
+	source := createRandomMemoryAccount(t, store)
+	accountOne := createRandomMemoryAccount(t, store)
+	accountTwo := createRandomMemoryAccount(t, store)
+
+	sendBatch := func(mode string, amounts ...int64) transferBatchResponse {
+		transfers := make([]gin.H, len(amounts))
+		for i, amount := range amounts {
+			to := accountOne.ID
+			if i%2 == 1 {
+				to = accountTwo.ID
+			}
+			transfers[i] = gin.H{"to_account_id": to, "amount": amount}
+		}
+
+		data, err := json.Marshal(gin.H{
+			"from_account_id": source.ID,
+			"currency":        util.USD,
+			"mode":            mode,
+			"transfers":       transfers,
+		})
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, "/transfers/batch", bytes.NewReader(data))
+		require.NoError(t, err)
+
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, source.Owner, time.Minute)
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var rsp transferBatchResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		return rsp
+	}
+
+	rsp := sendBatch(db.TransferBatchAtomic, 30, 20)
+	require.Equal(t, db.TransferBatchCompleted, rsp.Status)
+	require.Equal(t, int64(50), rsp.TotalAmount)
+
+	rsp = sendBatch(db.TransferBatchBestEffort, 25, 25)
+	require.Equal(t, db.TransferBatchCompleted, rsp.Status)
+
+	balances := func() []int64 {
+		var balances []int64
+		for _, id := range []int64{source.ID, accountOne.ID, accountTwo.ID} {
+			account, err := store.GetAccount(context.Background(), id)
+			require.NoError(t, err)
+			balances = append(balances, account.Balance)
+		}
+		return balances
+	}
+	require.Equal(t, []int64{0, 155, 145}, balances())
+
+	// the batch can be read back by its owner
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/transfers/batch/%d", rsp.ID), nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, source.Owner, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var found transferBatchResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &found))
+	require.Equal(t, rsp.ID, found.ID)
+	require.Len(t, found.Items, 2)
+	require.NotNil(t, found.Items[1].TransferID)
+}
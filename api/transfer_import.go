@@ -0,0 +1,77 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"github.com/AbdRaqeeb/simple_bank/paymentfile"
+	"github.com/AbdRaqeeb/simple_bank/token"
+	"github.com/gin-gonic/gin"
+	"io"
+	"math"
+	"net/http"
+)
+
+// maxPaymentFileSize is the largest payment file accepted by importTransfers
+const maxPaymentFileSize = 10 << 20
+
+var errPaymentFileTooLarge = errors.New("payment file is too large")
+
+type importTransfersRequest struct {
+	Format string `form:"format" binding:"required,oneof=csv pain.001"`
+	DryRun bool   `form:"dry_run"`
+}
+
+// importTransfers reads a CSV or pain.001 payment file from the request body and submits its transfers from
+// accounts of the authenticated user. Rows are validated and transferred one by one, the report has the
+// outcome of every row. With dry_run nothing is transferred
+func (server *Server) importTransfers(ctx *gin.Context) {
+	var req importTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	file, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxPaymentFileSize+1))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if len(file) > maxPaymentFileSize {
+		ctx.JSON(http.StatusRequestEntityTooLarge, errorResponse(errPaymentFileTooLarge))
+		return
+	}
+
+	instructions, rowErrors, err := paymentfile.Parse(req.Format, bytes.NewReader(file))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the whole file needs a recent authentication when it sends more than the threshold of a currency
+	totals := make(map[string]int64)
+	for _, instruction := range instructions {
+		total := totals[instruction.Currency]
+		if instruction.Amount > math.MaxInt64-total {
+			totals[instruction.Currency] = math.MaxInt64
+			continue
+		}
+		totals[instruction.Currency] = total + instruction.Amount
+	}
+
+	if !req.DryRun {
+		for currency, total := range totals {
+			if !server.checkStepUp(ctx, currency, total) {
+				return
+			}
+		}
+	}
+
+	payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	report := paymentfile.Import(ctx.Request.Context(), server.store, instructions, rowErrors, paymentfile.Options{
+		Owner:  payload.Username,
+		DryRun: req.DryRun,
+	})
+
+	ctx.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/paymentfile"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestImportTransfersAPI(t *testing.T) {
+	store := db.NewMemoryStore()
+	server := newTestServer(t, store)
+
+	source := createRandomMemoryAccount(t, store)
+	accountOne := createRandomMemoryAccount(t, store)
+	accountTwo := createRandomMemoryAccount(t, store)
+
+	file := fmt.Sprintf("reference,from_account_id,to_account_id,amount,currency\n"+
+		"r1,%d,%d,0.30,USD\n"+
+		"r2,%d,%d,abc,USD\n"+
+		"r3,%d,%d,0.20,USD\n"+
+		"r4,%d,%d,0.10,USD\n",
+		source.ID, accountOne.ID,
+		source.ID, accountTwo.ID,
+		source.ID, accountTwo.ID,
+		accountOne.ID, accountTwo.ID,
+	)
+
+	testCases := []struct {
+		name          string
+		query         string
+		body          string
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "Dry Run",
+			query: "format=csv&dry_run=true",
+			body:  file,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var report paymentfile.Report
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+				require.Equal(t, 4, report.Total)
+				require.Equal(t, 2, report.Valid)
+				require.Equal(t, 2, report.Rejected)
+
+				account, err := store.GetAccount(context.Background(), source.ID)
+				require.NoError(t, err)
+				require.Equal(t, source.Balance, account.Balance)
+			},
+		},
+		{
+			name:  "OK",
+			query: "format=csv",
+			body:  file,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var report paymentfile.Report
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+				require.Equal(t, 2, report.Submitted)
+				require.Equal(t, 2, report.Rejected)
+
+				// rows of accounts of other users are rejected
+				require.Equal(t, "r4", report.Rows[3].Reference)
+				require.Equal(t, paymentfile.RowRejected, report.Rows[3].Status)
+				require.Contains(t, report.Rows[3].Error, "does not belong")
+
+				account, err := store.GetAccount(context.Background(), source.ID)
+				require.NoError(t, err)
+				require.Equal(t, source.Balance-50, account.Balance)
+			},
+		},
+		{
+			name:  "Unsupported Format",
+			query: "format=mt101",
+			body:  file,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "Invalid File",
+			query: "format=csv",
+			body:  "from_account_id,amount\n1,10\n",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "File Too Large",
+			query: "format=csv",
+			body:  strings.Repeat("a", maxPaymentFileSize+1),
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, "/transfers/import?"+tc.query, bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, source.Owner, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	mockdb "github.com/AbdRaqeeb/simple_bank/db/mock"
@@ -9,23 +10,30 @@ import (
 	"github.com/AbdRaqeeb/simple_bank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
 	accountOne := randomAccount()
 	accountTwo := randomAccount()
 	accountThree := randomAccount()
 
 	currencyOne := util.CAD
-	currencyTwo := util.NAR
+	currencyTwo := util.NGN
 
 	accountOne.Currency = currencyOne
 	accountTwo.Currency = currencyOne
 	accountThree.Currency = currencyTwo
+	accountOne.Owner = user.Username
+	accountThree.Owner = user.Username
 
 	amount := int64(10)
 
@@ -52,10 +60,51 @@ func TestTransferAPI(t *testing.T) {
 					ToAccountID:   accountTwo.ID,
 					Amount:        amount,
 				}
-				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(args)).Times(1)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(args)).Times(1).Return(db.TransferTxResult{
+					Transfer:    db.Transfer{ID: 1, FromAccountID: accountOne.ID, ToAccountID: accountTwo.ID, Amount: amount},
+					FromAccount: accountOne,
+					ToAccount:   accountTwo,
+					FromEntry:   db.Entry{ID: 1, AccountID: accountOne.ID, Amount: -amount},
+					ToEntry:     db.Entry{ID: 2, AccountID: accountTwo.ID, Amount: amount},
+				}, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, recorder.Code, http.StatusOK)
+
+				var rsp transferTxResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, amount, rsp.Transfer.Amount)
+				require.Equal(t, "0.10", rsp.Transfer.AmountFormatted)
+				require.Equal(t, "-0.10", rsp.FromEntry.AmountFormatted)
+				require.Equal(t, "0.10", rsp.ToEntry.AmountFormatted)
+				require.Equal(t, util.NewMoney(accountTwo.Balance, currencyOne).Decimal(), rsp.ToAccount.BalanceFormatted)
+
+				var fields map[string]map[string]interface{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &fields))
+				require.Equal(t, "0.10", fields["transfer"]["amount_formatted"])
+				require.Equal(t, "-0.10", fields["from_entry"]["amount_formatted"])
+				require.Equal(t, "0.10", fields["to_entry"]["amount_formatted"])
+				require.Equal(t, rsp.ToAccount.BalanceFormatted, fields["to_account"]["balance_formatted"])
+			},
+		},
+		{
+			name: "Balance Overflow",
+			body: gin.H{
+				"from_account_id": accountOne.ID,
+				"to_account_id":   accountTwo.ID,
+				"amount":          amount,
+				"currency":        currencyOne,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				full := accountTwo
+				full.Balance = math.MaxInt64 - amount + 1
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(accountOne.ID)).Times(1).Return(accountOne, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(accountTwo.ID)).Times(1).Return(full, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
 			},
 		},
 		{
@@ -158,6 +207,24 @@ func TestTransferAPI(t *testing.T) {
 				require.Equal(t, recorder.Code, http.StatusBadRequest)
 			},
 		},
+		{
+			name: "Account Not Owned",
+			body: gin.H{
+				"from_account_id": accountTwo.ID,
+				"to_account_id":   accountOne.ID,
+				"amount":          amount,
+				"currency":        currencyOne,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(accountTwo.ID)).Times(1).Return(accountTwo, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(accountOne.ID)).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), errAccountNotOwned.Error())
+			},
+		},
 		{
 			name: "TransTXError",
 			body: gin.H{
@@ -181,6 +248,75 @@ func TestTransferAPI(t *testing.T) {
 				require.Equal(t, recorder.Code, http.StatusInternalServerError)
 			},
 		},
+		{
+			name: "Serialization Failure",
+			body: gin.H{
+				"from_account_id": accountOne.ID,
+				"to_account_id":   accountTwo.ID,
+				"amount":          amount,
+				"currency":        currencyOne,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(accountOne.ID)).Times(1).Return(accountOne, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(accountTwo.ID)).Times(1).Return(accountTwo, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, &pq.Error{Code: "40001"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, recorder.Code, http.StatusServiceUnavailable)
+			},
+		},
+		{
+			name: "Insufficient Funds",
+			body: gin.H{
+				"from_account_id": accountOne.ID,
+				"to_account_id":   accountTwo.ID,
+				"amount":          amount,
+				"currency":        currencyOne,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(accountOne.ID)).Times(1).Return(accountOne, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(accountTwo.ID)).Times(1).Return(accountTwo, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "Transfers Not Allowed",
+			body: gin.H{
+				"from_account_id": accountOne.ID,
+				"to_account_id":   accountTwo.ID,
+				"amount":          amount,
+				"currency":        currencyOne,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(accountOne.ID)).Times(1).Return(accountOne, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(accountTwo.ID)).Times(1).Return(accountTwo, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrTransfersNotAllowed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "Account Frozen",
+			body: gin.H{
+				"from_account_id": accountOne.ID,
+				"to_account_id":   accountTwo.ID,
+				"amount":          amount,
+				"currency":        currencyOne,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(accountOne.ID)).Times(1).Return(accountOne, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(accountTwo.ID)).Times(1).Return(accountTwo, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrAccountFrozen)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), db.ErrAccountFrozen.Error())
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -194,6 +330,7 @@ func TestTransferAPI(t *testing.T) {
 
 			server := newTestServer(t, store)
 
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).AnyTimes().Return(user, nil)
 			tc.buildStubs(store)
 
 			url := "/transfers"
@@ -207,6 +344,150 @@ func TestTransferAPI(t *testing.T) {
 			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 			require.NoError(t, err)
 
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestTransferAPIWithMemoryStore(t *testing.T) {
+	store := db.NewMemoryStore()
+	server := newTestServer(t, store)
+
+	createAccount := func(currency string, balance int64) db.Account {
+		user, _ := randomUser(t)
+		_, err := store.CreateUser(context.Background(), db.CreateUserParams{
+			Username:       user.Username,
+			HashedPassword: user.HashedPassword,
+			FullName:       user.FullName,
+			Email:          user.Email,
+		})
+		require.NoError(t, err)
+
+		account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+			Owner:       user.Username,
+			Balance:     balance,
+			Currency:    currency,
+			ProductCode: db.AccountProductChecking,
+		})
+		require.NoError(t, err)
+		return account
+	}
+
+	accountOne := createAccount(util.USD, 100)
+	accountTwo := createAccount(util.USD, 100)
+
+	data, err := json.Marshal(gin.H{
+		"from_account_id": accountOne.ID,
+		"to_account_id":   accountTwo.ID,
+		"amount":          40,
+		"currency":        util.USD,
+	})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, accountOne.Owner, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	updatedOne, err := store.GetAccount(context.Background(), accountOne.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(60), updatedOne.Balance)
+
+	updatedTwo, err := store.GetAccount(context.Background(), accountTwo.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(140), updatedTwo.Balance)
+}
+
+func TestTransferStepUpAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	accountOne := randomAccount()
+	accountTwo := randomAccount()
+	accountOne.Currency = util.USD
+	accountTwo.Currency = util.USD
+	accountOne.Owner = user.Username
+
+	testCases := []struct {
+		name          string
+		amount        int64
+		stepUpMaxAge  time.Duration
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:         "Below Threshold",
+			amount:       1000,
+			stepUpMaxAge: 0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(accountOne.ID)).Times(1).Return(accountOne, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(accountTwo.ID)).Times(1).Return(accountTwo, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:         "Recent Authentication",
+			amount:       1001,
+			stepUpMaxAge: time.Minute,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(accountOne.ID)).Times(1).Return(accountOne, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(accountTwo.ID)).Times(1).Return(accountTwo, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:         "Step Up Required",
+			amount:       1001,
+			stepUpMaxAge: 0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Header().Get("WWW-Authenticate"), "insufficient_user_authentication")
+				require.Contains(t, recorder.Body.String(), errStepUpRequired.Error())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.config.StepUpMaxAge = tc.stepUpMaxAge
+
+			data, err := json.Marshal(gin.H{
+				"from_account_id": accountOne.ID,
+				"to_account_id":   accountTwo.ID,
+				"amount":          tc.amount,
+				"currency":        util.USD,
+			})
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(t, recorder)
 		})
@@ -1,13 +1,18 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
 	"github.com/AbdRaqeeb/simple_bank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+	"math"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 )
 
@@ -22,6 +27,9 @@ type userResponse struct {
 	Username          string    `json:"username"`
 	FullName          string    `json:"fullName"`
 	Email             string    `json:"email"`
+	IsEmailVerified   bool      `json:"isEmailVerified"`
+	TotpEnabled       bool      `json:"totpEnabled"`
+	Role              string    `json:"role"`
 	PasswordChangedAt time.Time `json:"passwordChangedAt"`
 	CreatedAt         time.Time `json:"createdAt"`
 }
@@ -31,6 +39,9 @@ func newUserResponse(user db.User) userResponse {
 		Username:          user.Username,
 		Email:             user.Email,
 		FullName:          user.FullName,
+		IsEmailVerified:   user.IsEmailVerified,
+		TotpEnabled:       user.TotpEnabled,
+		Role:              user.Role,
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
 	}
@@ -56,7 +67,7 @@ func (server *Server) createUser(ctx *gin.Context) {
 		HashedPassword: hashedPassword,
 	}
 
-	user, err := server.store.CreateUser(ctx, arg)
+	user, err := server.store.CreateUserTx(ctx.Request.Context(), arg)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code.Name() {
@@ -69,6 +80,12 @@ func (server *Server) createUser(ctx *gin.Context) {
 		return
 	}
 
+	// the account is usable without a verified email, a failed delivery can be retried by the user
+	err = server.sendEmailVerification(ctx.Request.Context(), user)
+	if err != nil {
+		zerolog.Ctx(ctx.Request.Context()).Error().Err(err).Str("username", user.Username).Msg("cannot send verification email")
+	}
+
 	createdUser := newUserResponse(user)
 
 	ctx.JSON(http.StatusCreated, createdUser)
@@ -84,6 +101,15 @@ type loginUserResponse struct {
 	User        userResponse `json:"user"`
 }
 
+var (
+	errInvalidCredentials = errors.New("invalid username or password")
+	errTooManyLogins      = errors.New("too many failed login attempts, try again later")
+
+	// dummyPasswordHash is compared against for unknown usernames, so they take as long as wrong passwords
+	dummyPasswordHash     string
+	dummyPasswordHashOnce sync.Once
+)
+
 func (server *Server) loginUser(ctx *gin.Context) {
 	var req loginUserRequest
 
@@ -92,10 +118,22 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
-	user, err := server.store.GetUser(ctx, req.Username)
+	clientIP := ctx.ClientIP()
+	if wait := server.loginLimiter.retryAfter(clientIP); wait > 0 {
+		abortTooManyLogins(ctx, wait)
+		return
+	}
+
+	user, err := server.store.GetUser(ctx.Request.Context(), req.Username)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			dummyPasswordHashOnce.Do(func() {
+				dummyPasswordHash, _ = util.HashPassword(util.RandomString(32))
+			})
+			_ = util.CheckPassword(req.Password, dummyPasswordHash)
+
+			server.loginLimiter.recordFailure(clientIP)
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
 			return
 		}
 
@@ -103,13 +141,49 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
+	// the password is compared before the lockout is checked and locked users get the same response as a wrong
+	// password, so neither the status nor the timing tells whether a username exists
 	err = util.CheckPassword(req.Password, user.HashedPassword)
+
+	if userLoginRetryAfter(server.config, user, time.Now()) > 0 {
+		server.loginLimiter.recordFailure(clientIP)
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		server.loginLimiter.recordFailure(clientIP)
+
+		err = server.recordFailedLogin(ctx.Request.Context(), user.Username)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+
+	if user.TotpEnabled {
+		server.sendLoginChallenge(ctx, user)
 		return
 	}
 
-	accessToken, err := server.tokenMaker.CreateToken(req.Username, server.config.AccessTokenDuration)
+	server.completeLogin(ctx, user)
+}
+
+// completeLogin clears the failed logins of a user who passed every login step and issues an access token
+func (server *Server) completeLogin(ctx *gin.Context, user db.User) {
+	var err error
+	if user.FailedLoginAttempts > 0 || user.LockedUntil.Valid {
+		user, err = server.store.ResetLoginAttempts(ctx.Request.Context(), user.Username)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	accessToken, err := server.tokenMaker.CreateToken(user.Username, server.config.AccessTokenDuration, util.UserScopes...)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
@@ -121,5 +195,150 @@ func (server *Server) loginUser(ctx *gin.Context) {
 	}
 
 	ctx.JSON(http.StatusOK, res)
-	return
+}
+
+// recordFailedLogin counts a failed login and locks the user once the configured attempts are exhausted
+func (server *Server) recordFailedLogin(ctx context.Context, username string) error {
+	user, err := server.store.RecordFailedLogin(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	if server.config.LoginMaxAttempts <= 0 || user.FailedLoginAttempts < server.config.LoginMaxAttempts {
+		return nil
+	}
+
+	lockedUntil := time.Now().Add(server.config.LoginLockoutDuration)
+	_, err = server.store.LockUser(ctx, db.LockUserParams{
+		Username:    username,
+		LockedUntil: sql.NullTime{Time: lockedUntil, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	zerolog.Ctx(ctx).Warn().
+		Str("username", username).
+		Int32("failed_login_attempts", user.FailedLoginAttempts).
+		Time("locked_until", lockedUntil).
+		Msg("user locked after failed logins")
+
+	return nil
+}
+
+// abortTooManyLogins rejects a login attempt and tells the client when to retry
+func abortTooManyLogins(ctx *gin.Context, wait time.Duration) {
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	ctx.JSON(http.StatusTooManyRequests, errorResponse(errTooManyLogins))
+}
+
+// getCurrentUser returns the profile of the authenticated user
+func (server *Server) getCurrentUser(ctx *gin.Context) {
+	user := ctx.MustGet(authorizationUserKey).(db.User)
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+type updateUserRequest struct {
+	FullName *string `json:"full_name" binding:"omitempty,min=1"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+}
+
+// updateCurrentUser changes the full name or email of the authenticated user.
+// A new email is unverified until the user confirms it with the token sent to it
+func (server *Server) updateCurrentUser(ctx *gin.Context) {
+	var req updateUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.FullName == nil && req.Email == nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errors.New("full_name or email is required")))
+		return
+	}
+
+	user := ctx.MustGet(authorizationUserKey).(db.User)
+
+	arg := db.UpdateUserParams{
+		Username: user.Username,
+		FullName: user.FullName,
+		Email:    user.Email,
+	}
+	if req.FullName != nil {
+		arg.FullName = *req.FullName
+	}
+	if req.Email != nil {
+		arg.Email = *req.Email
+	}
+
+	updatedUser, err := server.store.UpdateUser(ctx.Request.Context(), arg)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				ctx.JSON(http.StatusForbidden, errorResponse(errors.New("user with email exists")))
+				return
+			}
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if updatedUser.Email != user.Email {
+		err = server.sendEmailVerification(ctx.Request.Context(), updatedUser)
+		if err != nil {
+			zerolog.Ctx(ctx.Request.Context()).Error().Err(err).Str("username", updatedUser.Username).Msg("cannot send verification email")
+		}
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(updatedUser))
+}
+
+type getUserRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// unlockUser clears the failed logins and lockout of a user, it is restricted to admins
+func (server *Server) unlockUser(ctx *gin.Context) {
+	var req getUserRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.ResetLoginAttempts(ctx.Request.Context(), req.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// getUser returns the profile of any user, it is restricted to admins
+func (server *Server) getUser(ctx *gin.Context) {
+	var req getUserRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUser(ctx.Request.Context(), req.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
+// validCurrency accepts the ISO 4217 codes of the enabled currencies, see util.EnableCurrencies
 var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
 	if currency, ok := fieldLevel.Field().Interface().(string); ok {
 		return util.IsSupportedCurrency(currency)
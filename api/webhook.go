@@ -0,0 +1,304 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/token"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/AbdRaqeeb/simple_bank/webhook"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// webhookSecretPrefix starts every webhook signing secret so it is easy to recognise
+const webhookSecretPrefix = "whsec_"
+
+var (
+	errWebhookURLScheme = errors.New("url must use http or https")
+	errWebhookNotFound  = errors.New("webhook subscription not found")
+)
+
+type webhookSubscriptionResponse struct {
+	ID         int64     `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func newWebhookSubscriptionResponse(subscription db.WebhookSubscription) webhookSubscriptionResponse {
+	return webhookSubscriptionResponse{
+		ID:         subscription.ID,
+		URL:        subscription.Url,
+		EventTypes: subscription.EventTypes,
+		CreatedAt:  subscription.CreatedAt,
+	}
+}
+
+type webhookDeliveryResponse struct {
+	ID             int64           `json:"id"`
+	EventID        uuid.UUID       `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	LastStatusCode *int32          `json:"last_status_code"`
+	LastError      *string         `json:"last_error"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+func newWebhookDeliveryResponse(delivery db.WebhookDelivery) webhookDeliveryResponse {
+	rsp := webhookDeliveryResponse{
+		ID:          delivery.ID,
+		EventID:     delivery.EventID,
+		EventType:   delivery.EventType,
+		Payload:     delivery.Payload,
+		Status:      delivery.Status,
+		Attempts:    delivery.Attempts,
+		DeliveredAt: nullTimePointer(delivery.DeliveredAt),
+		CreatedAt:   delivery.CreatedAt,
+	}
+
+	// only pending deliveries are attempted again
+	if delivery.Status == db.WebhookDeliveryPending {
+		rsp.NextAttemptAt = &delivery.NextAttemptAt
+	}
+
+	if delivery.LastStatusCode.Valid {
+		rsp.LastStatusCode = &delivery.LastStatusCode.Int32
+	}
+
+	if delivery.LastError.Valid {
+		rsp.LastError = &delivery.LastError.String
+	}
+
+	return rsp
+}
+
+type createWebhookSubscriptionRequest struct {
+	URL        string   `json:"url" binding:"required,url,max=2048"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,required"`
+}
+
+type createWebhookSubscriptionResponse struct {
+	// Secret signs every delivery, it is only ever shown in this response
+	Secret       string                      `json:"secret"`
+	Subscription webhookSubscriptionResponse `json:"subscription"`
+}
+
+// createWebhookSubscription subscribes a url to events of the authenticated user and returns the
+// secret receivers verify the signature of deliveries with. Unless private networks are allowed,
+// urls resolving to loopback, private or reserved addresses are refused
+func (server *Server) createWebhookSubscription(ctx *gin.Context) {
+	var req createWebhookSubscriptionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errWebhookURLScheme))
+		return
+	}
+
+	if !server.config.WebhookAllowPrivateNetworks {
+		if err := webhook.ValidateURL(ctx.Request.Context(), net.DefaultResolver, req.URL); err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	for _, eventType := range req.EventTypes {
+		if !webhook.IsSupportedEventType(eventType) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("unsupported event type %s", eventType)))
+			return
+		}
+	}
+
+	secretToken, err := util.NewSecretToken()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	secret := webhookSecretPrefix + secretToken
+
+	encryptedSecret, err := server.webhookEncryptor.Encrypt(secret)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	subscription, err := server.store.CreateWebhookSubscription(ctx.Request.Context(), db.CreateWebhookSubscriptionParams{
+		Username:   payload.Username,
+		Url:        req.URL,
+		EventTypes: req.EventTypes,
+		Secret:     encryptedSecret,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, createWebhookSubscriptionResponse{
+		Secret:       secret,
+		Subscription: newWebhookSubscriptionResponse(subscription),
+	})
+}
+
+// listWebhookSubscriptions returns the webhook subscriptions of the authenticated user
+func (server *Server) listWebhookSubscriptions(ctx *gin.Context) {
+	payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	subscriptions, err := server.store.ListWebhookSubscriptions(ctx.Request.Context(), payload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]webhookSubscriptionResponse, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		rsp = append(rsp, newWebhookSubscriptionResponse(subscription))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type webhookSubscriptionRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// deleteWebhookSubscription stops the deliveries of a subscription and deletes its delivery history
+func (server *Server) deleteWebhookSubscription(ctx *gin.Context) {
+	var req webhookSubscriptionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	_, err := server.store.DeleteWebhookSubscription(ctx.Request.Context(), db.DeleteWebhookSubscriptionParams{
+		ID:       req.ID,
+		Username: payload.Username,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errWebhookNotFound))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+type listWebhookDeliveriesRequest struct {
+	Page int32 `form:"page" binding:"required,min=1"`
+	Size int32 `form:"size" binding:"required,min=5,max=100"`
+}
+
+// listWebhookDeliveries returns the deliveries of a subscription, newest first
+func (server *Server) listWebhookDeliveries(ctx *gin.Context) {
+	var uri webhookSubscriptionRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listWebhookDeliveriesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !server.ownsWebhookSubscription(ctx, uri.ID) {
+		return
+	}
+
+	deliveries, err := server.store.ListWebhookDeliveries(ctx.Request.Context(), db.ListWebhookDeliveriesParams{
+		SubscriptionID: uri.ID,
+		Limit:          req.Size,
+		Offset:         (req.Page - 1) * req.Size,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]webhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		rsp = append(rsp, newWebhookDeliveryResponse(delivery))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type replayWebhookDeliveryRequest struct {
+	ID         int64 `uri:"id" binding:"required,min=1"`
+	DeliveryID int64 `uri:"delivery_id" binding:"required,min=1"`
+}
+
+// replayWebhookDelivery schedules a delivery to be sent again right away with a fresh attempt count,
+// whatever its status. The event keeps its id so receivers can recognise it
+func (server *Server) replayWebhookDelivery(ctx *gin.Context) {
+	var req replayWebhookDeliveryRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !server.ownsWebhookSubscription(ctx, req.ID) {
+		return
+	}
+
+	delivery, err := server.store.ReplayWebhookDelivery(ctx.Request.Context(), db.ReplayWebhookDeliveryParams{
+		ID:             req.DeliveryID,
+		SubscriptionID: req.ID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, newWebhookDeliveryResponse(delivery))
+}
+
+// ownsWebhookSubscription responds with not found unless the subscription belongs to the authenticated user,
+// so the subscriptions of other users cannot be discovered
+func (server *Server) ownsWebhookSubscription(ctx *gin.Context, id int64) bool {
+	subscription, err := server.store.GetWebhookSubscription(ctx.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errWebhookNotFound))
+			return false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if subscription.Username != payload.Username {
+		ctx.JSON(http.StatusNotFound, errorResponse(errWebhookNotFound))
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/outbox"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/AbdRaqeeb/simple_bank/webhook"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookAPIWithMemoryStore(t *testing.T) {
+	store := db.NewMemoryStore()
+	server := newTestServer(t, store)
+
+	newUser := func() db.User {
+		user, _ := randomUser(t)
+		created, err := store.CreateUser(context.Background(), db.CreateUserParams{
+			Username:       user.Username,
+			HashedPassword: user.HashedPassword,
+			FullName:       user.FullName,
+			Email:          user.Email,
+		})
+		require.NoError(t, err)
+		return created
+	}
+
+	user := newUser()
+	otherUser := newUser()
+
+	send := func(method string, url string, body gin.H, username string) *httptest.ResponseRecorder {
+		var data []byte
+		if body != nil {
+			var err error
+			data, err = json.Marshal(body)
+			require.NoError(t, err)
+		}
+
+		request, err := http.NewRequest(method, url, bytes.NewReader(data))
+		require.NoError(t, err)
+		if username != "" {
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, username, time.Minute)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// a public address, so creating the subscription does not depend on dns
+	hookURL := "https://93.184.216.34/hook"
+
+	// invalid subscriptions
+	recorder := send(http.MethodPost, "/webhooks", gin.H{"url": "ftp://example.com/hook", "event_types": []string{webhook.EventAccountCreated}}, user.Username)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	for _, privateURL := range []string{"http://127.0.0.1:8080/hook", "http://localhost/hook", "http://10.0.0.1/hook", "http://169.254.169.254/latest/meta-data"} {
+		recorder = send(http.MethodPost, "/webhooks", gin.H{"url": privateURL, "event_types": []string{webhook.EventAccountCreated}}, user.Username)
+		require.Equal(t, http.StatusBadRequest, recorder.Code, privateURL)
+		require.Contains(t, recorder.Body.String(), webhook.ErrDisallowedAddress.Error())
+	}
+
+	recorder = send(http.MethodPost, "/webhooks", gin.H{"url": "https://example.com/hook", "event_types": []string{"account.deleted"}}, user.Username)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = send(http.MethodPost, "/webhooks", gin.H{"url": "https://example.com/hook", "event_types": []string{}}, user.Username)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = send(http.MethodPost, "/webhooks", gin.H{"url": "https://example.com/hook", "event_types": []string{webhook.EventAccountCreated}}, "")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = send(http.MethodPost, "/webhooks", gin.H{
+		"url":         hookURL,
+		"event_types": []string{webhook.EventAccountCreated, webhook.EventTransferCreated},
+	}, user.Username)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	var created createWebhookSubscriptionResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+	require.True(t, strings.HasPrefix(created.Secret, webhookSecretPrefix))
+	require.Equal(t, hookURL, created.Subscription.URL)
+
+	// the secret is stored encrypted
+	stored, err := store.GetWebhookSubscription(context.Background(), created.Subscription.ID)
+	require.NoError(t, err)
+	require.NotEqual(t, created.Secret, stored.Secret)
+	secret, err := server.webhookEncryptor.Decrypt(stored.Secret)
+	require.NoError(t, err)
+	require.Equal(t, created.Secret, secret)
+
+	recorder = send(http.MethodGet, "/webhooks", nil, user.Username)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NotContains(t, recorder.Body.String(), created.Secret)
+
+	var subscriptions []webhookSubscriptionResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &subscriptions))
+	require.Len(t, subscriptions, 1)
+
+	// creating an account queues an event for its owner only
+	recorder = send(http.MethodPost, "/accounts", gin.H{"currency": util.USD}, user.Username)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	recorder = send(http.MethodPost, "/accounts", gin.H{"currency": util.USD}, otherUser.Username)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	// the events are queued from the outbox
+	relay, err := outbox.NewRelay(util.Config{LogLevel: "disabled", OutboxBatchSize: 100}, store, webhook.NewPublisher(store))
+	require.NoError(t, err)
+	_, err = relay.PublishPending(context.Background())
+	require.NoError(t, err)
+
+	deliveriesURL := fmt.Sprintf("/webhooks/%d/deliveries?page=1&size=5", created.Subscription.ID)
+	recorder = send(http.MethodGet, deliveriesURL, nil, user.Username)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var deliveries []webhookDeliveryResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &deliveries))
+	require.Len(t, deliveries, 1)
+	require.Equal(t, webhook.EventAccountCreated, deliveries[0].EventType)
+	require.Equal(t, db.WebhookDeliveryPending, deliveries[0].Status)
+	require.NotNil(t, deliveries[0].NextAttemptAt)
+
+	var event webhook.Event
+	require.NoError(t, json.Unmarshal(deliveries[0].Payload, &event))
+	require.Equal(t, deliveries[0].EventID, event.ID)
+
+	var account db.AccountCreatedEvent
+	require.NoError(t, json.Unmarshal(event.Data, &account))
+	require.Equal(t, user.Username, account.Owner)
+
+	// subscriptions of other users are not found
+	recorder = send(http.MethodGet, deliveriesURL, nil, otherUser.Username)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	replayURL := fmt.Sprintf("/webhooks/%d/deliveries/%d/replay", created.Subscription.ID, deliveries[0].ID)
+	recorder = send(http.MethodPost, replayURL, nil, otherUser.Username)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = send(http.MethodPost, replayURL, nil, user.Username)
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+
+	recorder = send(http.MethodPost, fmt.Sprintf("/webhooks/%d/deliveries/%d/replay", created.Subscription.ID, deliveries[0].ID+100), nil, user.Username)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	subscriptionURL := fmt.Sprintf("/webhooks/%d", created.Subscription.ID)
+	recorder = send(http.MethodDelete, subscriptionURL, nil, otherUser.Username)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = send(http.MethodDelete, subscriptionURL, nil, user.Username)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+
+	recorder = send(http.MethodDelete, subscriptionURL, nil, user.Username)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = send(http.MethodGet, "/webhooks", nil, user.Username)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &subscriptions))
+	require.Empty(t, subscriptions)
+}
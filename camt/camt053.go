@@ -0,0 +1,278 @@
+// Package camt renders account statements as ISO 20022 bank-to-customer cash management messages
+package camt
+
+import (
+	"encoding/xml"
+	"fmt"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/google/uuid"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Namespace053 is the namespace of the camt.053.001.02 statements written by WriteStatement
+const Namespace053 = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// Codes of the camt.053 messages
+const (
+	balanceOpening = "OPBD"
+	balanceClosing = "CLBD"
+	credit         = "CRDT"
+	debit          = "DBIT"
+	entryBooked    = "BOOK"
+)
+
+// Header identifies a statement message
+type Header struct {
+	MessageID string
+	CreatedAt time.Time
+}
+
+// NewHeader returns the header of a new message, created now with a random id
+func NewHeader() Header {
+	return Header{
+		MessageID: strings.ReplaceAll(uuid.NewString(), "-", ""),
+		CreatedAt: time.Now(),
+	}
+}
+
+type document struct {
+	XMLName   xml.Name                `xml:"Document"`
+	Namespace string                  `xml:"xmlns,attr"`
+	Statement bankToCustomerStatement `xml:"BkToCstmrStmt"`
+}
+
+type bankToCustomerStatement struct {
+	MessageID string        `xml:"GrpHdr>MsgId"`
+	CreatedAt string        `xml:"GrpHdr>CreDtTm"`
+	Statement accountReport `xml:"Stmt"`
+}
+
+type accountReport struct {
+	ID        string    `xml:"Id"`
+	CreatedAt string    `xml:"CreDtTm"`
+	From      string    `xml:"FrToDt>FrDtTm"`
+	To        string    `xml:"FrToDt>ToDtTm"`
+	Account   account   `xml:"Acct"`
+	Balances  []balance `xml:"Bal"`
+	Summary   summary   `xml:"TxsSummry"`
+	Entries   []entry   `xml:"Ntry"`
+}
+
+type account struct {
+	ID       string `xml:"Id>Othr>Id"`
+	Currency string `xml:"Ccy,omitempty"`
+	Owner    *party `xml:"Ownr,omitempty"`
+}
+
+type party struct {
+	Name string `xml:"Nm"`
+}
+
+type amount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type balance struct {
+	Type        string `xml:"Tp>CdOrPrtry>Cd"`
+	Amount      amount `xml:"Amt"`
+	CreditDebit string `xml:"CdtDbtInd"`
+	DateTime    string `xml:"Dt>DtTm"`
+}
+
+type summary struct {
+	Entries       entriesSum `xml:"TtlNtries"`
+	CreditEntries entriesSum `xml:"TtlCdtNtries"`
+	DebitEntries  entriesSum `xml:"TtlDbtNtries"`
+}
+
+type entriesSum struct {
+	Count int    `xml:"NbOfNtries"`
+	Sum   string `xml:"Sum"`
+}
+
+type entry struct {
+	Reference       string          `xml:"NtryRef"`
+	Amount          amount          `xml:"Amt"`
+	CreditDebit     string          `xml:"CdtDbtInd"`
+	Status          string          `xml:"Sts"`
+	BookingDateTime string          `xml:"BookgDt>DtTm"`
+	ValueDateTime   string          `xml:"ValDt>DtTm"`
+	ServicerRef     string          `xml:"AcctSvcrRef"`
+	TransactionCode transactionCode `xml:"BkTxCd"`
+	Details         *entryDetails   `xml:"NtryDtls,omitempty"`
+}
+
+type transactionCode struct {
+	Domain    string `xml:"Domn>Cd"`
+	Family    string `xml:"Domn>Fmly>Cd"`
+	SubFamily string `xml:"Domn>Fmly>SubFmlyCd"`
+}
+
+type entryDetails struct {
+	TransactionID string         `xml:"TxDtls>Refs>TxId"`
+	Parties       relatedParties `xml:"TxDtls>RltdPties"`
+}
+
+type relatedParties struct {
+	Debtor          *party  `xml:"Dbtr,omitempty"`
+	DebtorAccount   account `xml:"DbtrAcct"`
+	Creditor        *party  `xml:"Cdtr,omitempty"`
+	CreditorAccount account `xml:"CdtrAcct"`
+}
+
+// WriteStatement writes statement as a camt.053.001.02 bank-to-customer statement. The period is reported from
+// statement.From to statement.To, with the opening and closing booked balances and an entry for each entry of the
+// account. Entries written by a transfer are issued or received credit transfers whose related parties are the
+// accounts of the transfer and their owners, the others are miscellaneous account management entries
+func WriteStatement(w io.Writer, header Header, statement db.AccountStatement) error {
+	currency := statement.Account.Currency
+
+	report := accountReport{
+		ID:        fmt.Sprintf("%d-%s", statement.Account.ID, statement.From.UTC().Format("20060102")),
+		CreatedAt: formatDateTime(header.CreatedAt),
+		From:      formatDateTime(statement.From),
+		To:        formatDateTime(statement.To),
+		Account:   newAccount(statement.Account.ID, currency, statement.OwnerName),
+		Balances: []balance{
+			newBalance(balanceOpening, statement.OpeningBalance, currency, statement.From),
+			newBalance(balanceClosing, statement.ClosingBalance, currency, statement.To),
+		},
+		Entries: make([]entry, len(statement.Entries)),
+	}
+
+	var credits, debits int64
+	for i, statementEntry := range statement.Entries {
+		report.Entries[i] = newEntry(statement.Account, statement.OwnerName, statementEntry)
+
+		if statementEntry.Entry.Amount < 0 {
+			report.Summary.DebitEntries.Count++
+			debits -= statementEntry.Entry.Amount
+		} else {
+			report.Summary.CreditEntries.Count++
+			credits += statementEntry.Entry.Amount
+		}
+	}
+
+	report.Summary.Entries = entriesSum{Count: len(statement.Entries), Sum: formatAmount(credits+debits, currency)}
+	report.Summary.CreditEntries.Sum = formatAmount(credits, currency)
+	report.Summary.DebitEntries.Sum = formatAmount(debits, currency)
+
+	doc := document{
+		Namespace: Namespace053,
+		Statement: bankToCustomerStatement{
+			MessageID: header.MessageID,
+			CreatedAt: formatDateTime(header.CreatedAt),
+			Statement: report,
+		},
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(doc)
+}
+
+func newAccount(id int64, currency string, ownerName string) account {
+	return account{
+		ID:       strconv.FormatInt(id, 10),
+		Currency: currency,
+		Owner:    newParty(ownerName),
+	}
+}
+
+// newParty returns nil for an empty name, as parties have no element but Nm and it must not be empty
+func newParty(name string) *party {
+	if name == "" {
+		return nil
+	}
+	return &party{Name: name}
+}
+
+func newBalance(balanceType string, value int64, currency string, at time.Time) balance {
+	return balance{
+		Type:        balanceType,
+		Amount:      amount{Currency: currency, Value: formatAmount(abs(value), currency)},
+		CreditDebit: creditDebit(value),
+		DateTime:    formatDateTime(at),
+	}
+}
+
+func newEntry(statementAccount db.Account, ownerName string, statementEntry db.StatementEntry) entry {
+	value := statementEntry.Entry.Amount
+	reference := strconv.FormatInt(statementEntry.Entry.ID, 10)
+
+	ntry := entry{
+		Reference:       reference,
+		Amount:          amount{Currency: statementAccount.Currency, Value: formatAmount(abs(value), statementAccount.Currency)},
+		CreditDebit:     creditDebit(value),
+		Status:          entryBooked,
+		BookingDateTime: formatDateTime(statementEntry.Entry.CreatedAt),
+		ValueDateTime:   formatDateTime(statementEntry.Entry.CreatedAt),
+		ServicerRef:     reference,
+		// miscellaneous account management, the entries that are not transfers
+		TransactionCode: transactionCode{Domain: "ACMT", Family: "MDOP", SubFamily: "OTHR"},
+	}
+
+	counterparty := statementEntry.Counterparty
+	if counterparty == nil {
+		return ntry
+	}
+
+	own := newAccount(statementAccount.ID, "", "")
+	other := newAccount(counterparty.AccountID, "", "")
+	details := &entryDetails{TransactionID: strconv.FormatInt(statementEntry.Entry.TransferID.Int64, 10)}
+
+	// book transfers between accounts of the bank, issued for debits and received for credits
+	if value < 0 {
+		ntry.TransactionCode = transactionCode{Domain: "PMNT", Family: "ICDT", SubFamily: "BOOK"}
+		details.Parties = relatedParties{
+			Debtor:          newParty(ownerName),
+			DebtorAccount:   own,
+			Creditor:        newParty(counterparty.Name),
+			CreditorAccount: other,
+		}
+	} else {
+		ntry.TransactionCode = transactionCode{Domain: "PMNT", Family: "RCDT", SubFamily: "BOOK"}
+		details.Parties = relatedParties{
+			Debtor:          newParty(counterparty.Name),
+			DebtorAccount:   other,
+			Creditor:        newParty(ownerName),
+			CreditorAccount: own,
+		}
+	}
+
+	ntry.Details = details
+	return ntry
+}
+
+func creditDebit(value int64) string {
+	if value < 0 {
+		return debit
+	}
+	return credit
+}
+
+func abs(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// formatAmount writes an amount in minor units as a decimal number of the major unit of currency
+func formatAmount(value int64, currency string) string {
+	return util.NewMoney(value, currency).Decimal()
+}
+
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
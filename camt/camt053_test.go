@@ -0,0 +1,195 @@
+package camt
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/xml"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+var testHeader = Header{
+	MessageID: "2f1c9d6a0b7e4e3f9a8b7c6d5e4f3a2b",
+	CreatedAt: time.Date(2026, 10, 2, 6, 0, 0, 0, time.UTC),
+}
+
+func testStatement() db.AccountStatement {
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	return db.AccountStatement{
+		Account:        db.Account{ID: 7, Owner: "acme", Balance: 30000, Currency: "USD"},
+		OwnerName:      "Acme Ltd",
+		From:           from,
+		To:             from.AddDate(0, 1, 0),
+		OpeningBalance: 10000,
+		ClosingBalance: 8955,
+		Entries: []db.StatementEntry{
+			{
+				Entry: db.Entry{
+					ID:         21,
+					AccountID:  7,
+					Amount:     -2550,
+					CreatedAt:  from.Add(36 * time.Hour),
+					TransferID: sql.NullInt64{Int64: 11, Valid: true},
+				},
+				Counterparty: &db.StatementParty{AccountID: 8, Owner: "jane", Name: "Jane Roe"},
+			},
+			{
+				Entry: db.Entry{
+					ID:         24,
+					AccountID:  7,
+					Amount:     1000,
+					CreatedAt:  from.Add(72 * time.Hour),
+					TransferID: sql.NullInt64{Int64: 12, Valid: true},
+				},
+				Counterparty: &db.StatementParty{AccountID: 9, Owner: "john"},
+			},
+			{
+				Entry: db.Entry{ID: 30, AccountID: 7, Amount: 505, CreatedAt: from.Add(96 * time.Hour)},
+			},
+		},
+	}
+}
+
+// parsedStatement reads back the parts of a camt.053 statement the tests check
+type parsedStatement struct {
+	XMLName   xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:camt.053.001.02 Document"`
+	MessageID string   `xml:"BkToCstmrStmt>GrpHdr>MsgId"`
+	Stmt      struct {
+		ID       string `xml:"Id"`
+		Account  string `xml:"Acct>Id>Othr>Id"`
+		Currency string `xml:"Acct>Ccy"`
+		Owner    string `xml:"Acct>Ownr>Nm"`
+		Balances []struct {
+			Type        string `xml:"Tp>CdOrPrtry>Cd"`
+			Amount      string `xml:"Amt"`
+			CreditDebit string `xml:"CdtDbtInd"`
+		} `xml:"Bal"`
+		Entries       int    `xml:"TxsSummry>TtlNtries>NbOfNtries"`
+		CreditSum     string `xml:"TxsSummry>TtlCdtNtries>Sum"`
+		DebitSum      string `xml:"TxsSummry>TtlDbtNtries>Sum"`
+		StatementRows []struct {
+			Reference   string `xml:"NtryRef"`
+			Amount      string `xml:"Amt"`
+			CreditDebit string `xml:"CdtDbtInd"`
+			Family      string `xml:"BkTxCd>Domn>Fmly>Cd"`
+			TxID        string `xml:"NtryDtls>TxDtls>Refs>TxId"`
+			Debtor      string `xml:"NtryDtls>TxDtls>RltdPties>Dbtr>Nm"`
+			DebtorAcct  string `xml:"NtryDtls>TxDtls>RltdPties>DbtrAcct>Id>Othr>Id"`
+			Creditor    string `xml:"NtryDtls>TxDtls>RltdPties>Cdtr>Nm"`
+			CreditorAcc string `xml:"NtryDtls>TxDtls>RltdPties>CdtrAcct>Id>Othr>Id"`
+		} `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+func TestWriteStatement(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteStatement(&buf, testHeader, testStatement()))
+
+	var parsed parsedStatement
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &parsed))
+
+	require.Equal(t, testHeader.MessageID, parsed.MessageID)
+	require.Equal(t, "7-20260901", parsed.Stmt.ID)
+	require.Equal(t, "7", parsed.Stmt.Account)
+	require.Equal(t, "USD", parsed.Stmt.Currency)
+	require.Equal(t, "Acme Ltd", parsed.Stmt.Owner)
+
+	require.Len(t, parsed.Stmt.Balances, 2)
+	require.Equal(t, "OPBD", parsed.Stmt.Balances[0].Type)
+	require.Equal(t, "100.00", parsed.Stmt.Balances[0].Amount)
+	require.Equal(t, "CRDT", parsed.Stmt.Balances[0].CreditDebit)
+	require.Equal(t, "CLBD", parsed.Stmt.Balances[1].Type)
+	require.Equal(t, "89.55", parsed.Stmt.Balances[1].Amount)
+
+	require.Equal(t, 3, parsed.Stmt.Entries)
+	require.Equal(t, "15.05", parsed.Stmt.CreditSum)
+	require.Equal(t, "25.50", parsed.Stmt.DebitSum)
+
+	rows := parsed.Stmt.StatementRows
+	require.Len(t, rows, 3)
+
+	require.Equal(t, "21", rows[0].Reference)
+	require.Equal(t, "25.50", rows[0].Amount)
+	require.Equal(t, "DBIT", rows[0].CreditDebit)
+	require.Equal(t, "ICDT", rows[0].Family)
+	require.Equal(t, "11", rows[0].TxID)
+	require.Equal(t, "Acme Ltd", rows[0].Debtor)
+	require.Equal(t, "7", rows[0].DebtorAcct)
+	require.Equal(t, "Jane Roe", rows[0].Creditor)
+	require.Equal(t, "8", rows[0].CreditorAcc)
+
+	require.Equal(t, "10.00", rows[1].Amount)
+	require.Equal(t, "CRDT", rows[1].CreditDebit)
+	require.Equal(t, "RCDT", rows[1].Family)
+	require.Empty(t, rows[1].Debtor)
+	require.Equal(t, "9", rows[1].DebtorAcct)
+	require.Equal(t, "Acme Ltd", rows[1].Creditor)
+	require.Equal(t, "7", rows[1].CreditorAcc)
+
+	require.Equal(t, "5.05", rows[2].Amount)
+	require.Equal(t, "MDOP", rows[2].Family)
+	require.Empty(t, rows[2].TxID)
+}
+
+func TestWriteStatementNegativeBalance(t *testing.T) {
+	statement := testStatement()
+	statement.OpeningBalance = -5
+	statement.Entries = nil
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteStatement(&buf, testHeader, statement))
+
+	var parsed parsedStatement
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &parsed))
+
+	require.Equal(t, "0.05", parsed.Stmt.Balances[0].Amount)
+	require.Equal(t, "DBIT", parsed.Stmt.Balances[0].CreditDebit)
+	require.Zero(t, parsed.Stmt.Entries)
+	require.Empty(t, parsed.Stmt.StatementRows)
+}
+
+// TestWriteStatementSchema validates statements against testdata/camt.053.001.02.subset.xsd with xmllint
+func TestWriteStatementSchema(t *testing.T) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint is not installed")
+	}
+
+	empty := testStatement()
+	empty.OwnerName = ""
+	empty.Entries = nil
+
+	for name, statement := range map[string]db.AccountStatement{"Entries": testStatement(), "Empty": empty} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, WriteStatement(&buf, testHeader, statement))
+
+			cmd := exec.Command(xmllint, "--noout", "--schema", "testdata/camt.053.001.02.subset.xsd", "-")
+			cmd.Stdin = &buf
+			output, err := cmd.CombinedOutput()
+			require.NoError(t, err, string(output))
+		})
+	}
+}
+
+func TestWriteStatementExponent(t *testing.T) {
+	statement := testStatement()
+	statement.Account.Currency = "JPY"
+	statement.OpeningBalance = 1500
+	statement.Entries = statement.Entries[:1]
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteStatement(&buf, testHeader, statement))
+
+	var parsed parsedStatement
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &parsed))
+
+	// yen have no minor unit
+	require.Equal(t, "1500", parsed.Stmt.Balances[0].Amount)
+	require.Equal(t, "2550", parsed.Stmt.StatementRows[0].Amount)
+	require.Equal(t, "2550", parsed.Stmt.DebitSum)
+}
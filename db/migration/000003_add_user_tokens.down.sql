@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "email_verification_tokens";
+
+DROP TABLE IF EXISTS "password_reset_tokens";
+
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "is_email_verified";
@@ -0,0 +1,32 @@
+ALTER TABLE "users" ADD COLUMN "is_email_verified" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE "password_reset_tokens" (
+    "id"         bigserial PRIMARY KEY,
+    "username"   varchar        NOT NULL,
+    "token_hash" varchar UNIQUE NOT NULL,
+    "expires_at" timestamptz    NOT NULL,
+    "used_at"    timestamptz,
+    "created_at" timestamptz    NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "email_verification_tokens" (
+    "id"         bigserial PRIMARY KEY,
+    "username"   varchar        NOT NULL,
+    "email"      varchar        NOT NULL,
+    "token_hash" varchar UNIQUE NOT NULL,
+    "expires_at" timestamptz    NOT NULL,
+    "used_at"    timestamptz,
+    "created_at" timestamptz    NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "password_reset_tokens" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "email_verification_tokens" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "password_reset_tokens" ("username");
+
+CREATE INDEX ON "email_verification_tokens" ("username");
+
+COMMENT ON COLUMN "password_reset_tokens"."token_hash" IS 'sha256 of the token sent to the user';
+
+COMMENT ON COLUMN "email_verification_tokens"."token_hash" IS 'sha256 of the token sent to the user';
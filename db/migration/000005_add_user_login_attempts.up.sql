@@ -0,0 +1,5 @@
+ALTER TABLE "users" ADD COLUMN "failed_login_attempts" integer NOT NULL DEFAULT 0;
+
+ALTER TABLE "users" ADD COLUMN "last_failed_login_at" timestamptz;
+
+ALTER TABLE "users" ADD COLUMN "locked_until" timestamptz;
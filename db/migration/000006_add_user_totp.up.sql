@@ -0,0 +1,23 @@
+ALTER TABLE "users" ADD COLUMN "totp_secret" varchar;
+
+ALTER TABLE "users" ADD COLUMN "totp_enabled" boolean NOT NULL DEFAULT false;
+
+ALTER TABLE "users" ADD COLUMN "totp_last_step" bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE "totp_recovery_codes" (
+    "id"         bigserial PRIMARY KEY,
+    "username"   varchar     NOT NULL,
+    "code_hash"  varchar     NOT NULL,
+    "used_at"    timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "totp_recovery_codes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE UNIQUE INDEX ON "totp_recovery_codes" ("username", "code_hash");
+
+COMMENT ON COLUMN "users"."totp_secret" IS 'encrypted with the TOTP encryption key';
+
+COMMENT ON COLUMN "users"."totp_last_step" IS 'last accepted time step, codes cannot be replayed';
+
+COMMENT ON COLUMN "totp_recovery_codes"."code_hash" IS 'sha256 of the recovery code';
@@ -0,0 +1,22 @@
+CREATE TABLE "api_keys" (
+    "id"           bigserial PRIMARY KEY,
+    "username"     varchar     NOT NULL,
+    "name"         varchar     NOT NULL,
+    "prefix"       varchar     NOT NULL,
+    "key_hash"     varchar     NOT NULL,
+    "scopes"       varchar[]   NOT NULL,
+    "expires_at"   timestamptz,
+    "last_used_at" timestamptz,
+    "revoked_at"   timestamptz,
+    "created_at"   timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "api_keys" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "api_keys" ("username");
+
+CREATE UNIQUE INDEX ON "api_keys" ("key_hash");
+
+COMMENT ON COLUMN "api_keys"."prefix" IS 'public part of the key, shown to identify it';
+
+COMMENT ON COLUMN "api_keys"."key_hash" IS 'sha256 of the whole key';
@@ -0,0 +1,37 @@
+CREATE TABLE "webhook_subscriptions" (
+    "id"          bigserial PRIMARY KEY,
+    "username"    varchar     NOT NULL,
+    "url"         varchar     NOT NULL,
+    "event_types" varchar[]   NOT NULL,
+    "secret"      varchar     NOT NULL,
+    "created_at"  timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "webhook_deliveries" (
+    "id"               bigserial PRIMARY KEY,
+    "subscription_id"  bigint      NOT NULL,
+    "event_id"         uuid        NOT NULL,
+    "event_type"       varchar     NOT NULL,
+    "payload"          jsonb       NOT NULL,
+    "status"           varchar     NOT NULL DEFAULT 'pending',
+    "attempts"         integer     NOT NULL DEFAULT 0,
+    "next_attempt_at"  timestamptz NOT NULL DEFAULT (now()),
+    "last_status_code" integer,
+    "last_error"       varchar,
+    "delivered_at"     timestamptz,
+    "created_at"       timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "webhook_subscriptions" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("subscription_id") REFERENCES "webhook_subscriptions" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "webhook_subscriptions" ("username");
+
+CREATE INDEX ON "webhook_deliveries" ("subscription_id");
+
+CREATE INDEX ON "webhook_deliveries" ("next_attempt_at") WHERE "status" = 'pending';
+
+COMMENT ON COLUMN "webhook_subscriptions"."secret" IS 'encrypted with the webhook encryption key';
+
+COMMENT ON COLUMN "webhook_deliveries"."status" IS 'pending, succeeded or dead';
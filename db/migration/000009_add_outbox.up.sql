@@ -0,0 +1,13 @@
+CREATE TABLE "outbox" (
+    "id"             bigserial PRIMARY KEY,
+    "aggregate_type" varchar     NOT NULL,
+    "aggregate_id"   varchar     NOT NULL,
+    "event_type"     varchar     NOT NULL,
+    "payload"        jsonb       NOT NULL,
+    "created_at"     timestamptz NOT NULL DEFAULT (now()),
+    "published_at"   timestamptz
+);
+
+CREATE INDEX ON "outbox" ("id") WHERE "published_at" IS NULL;
+
+COMMENT ON COLUMN "outbox"."id" IS 'events of an aggregate are published in id order';
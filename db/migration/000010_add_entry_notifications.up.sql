@@ -0,0 +1,15 @@
+CREATE FUNCTION notify_entry() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('entries', json_build_object(
+        'id', NEW.id,
+        'account_id', NEW.account_id,
+        'amount', NEW.amount,
+        'created_at', NEW.created_at
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- notifications are delivered when the inserting transaction commits, and not at all when it rolls back
+CREATE TRIGGER "entries_notify" AFTER INSERT ON "entries"
+FOR EACH ROW EXECUTE PROCEDURE notify_entry();
@@ -0,0 +1,39 @@
+CREATE TABLE "transfer_batches" (
+    "id"              bigserial PRIMARY KEY,
+    "owner"           varchar     NOT NULL,
+    "from_account_id" bigint      NOT NULL,
+    "mode"            varchar     NOT NULL,
+    "status"          varchar     NOT NULL DEFAULT 'pending',
+    "item_count"      integer     NOT NULL,
+    "total_amount"    bigint      NOT NULL,
+    "succeeded_count" integer     NOT NULL DEFAULT 0,
+    "created_at"      timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "transfer_batch_items" (
+    "id"            bigserial PRIMARY KEY,
+    "batch_id"      bigint  NOT NULL,
+    "position"      integer NOT NULL,
+    "to_account_id" bigint  NOT NULL,
+    "amount"        bigint  NOT NULL,
+    "transfer_id"   bigint,
+    "error"         varchar
+);
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("batch_id") REFERENCES "transfer_batches" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "transfer_batches" ("owner");
+
+CREATE UNIQUE INDEX ON "transfer_batch_items" ("batch_id", "position");
+
+COMMENT ON COLUMN "transfer_batches"."mode" IS 'atomic or best_effort';
+
+COMMENT ON COLUMN "transfer_batches"."status" IS 'pending, completed, partially_completed or failed';
+
+COMMENT ON COLUMN "transfer_batch_items"."transfer_id" IS 'set when the item was transferred, error is set otherwise';
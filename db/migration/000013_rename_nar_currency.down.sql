@@ -0,0 +1,2 @@
+-- every naira account goes back to NAR, including the ones opened after the rename
+UPDATE "accounts" SET "currency" = 'NAR' WHERE "currency" = 'NGN';
@@ -0,0 +1,2 @@
+-- NAR is not an ISO 4217 code, naira accounts use NGN
+UPDATE "accounts" SET "currency" = 'NGN' WHERE "currency" = 'NAR';
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "interest_postings";
+
+DROP TABLE IF EXISTS "interest_accruals";
+
+DROP TABLE IF EXISTS "savings_accounts";
+
+DROP TABLE IF EXISTS "savings_products";
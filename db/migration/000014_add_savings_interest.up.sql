@@ -0,0 +1,61 @@
+CREATE TABLE "savings_products" (
+    "code"               varchar PRIMARY KEY,
+    "name"               varchar     NOT NULL,
+    "currency"           varchar     NOT NULL,
+    "annual_rate_bps"    integer     NOT NULL,
+    "day_count"          varchar     NOT NULL,
+    "expense_account_id" bigint      NOT NULL,
+    "created_at"         timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "savings_accounts" (
+    "account_id"   bigint PRIMARY KEY,
+    "product_code" varchar     NOT NULL,
+    "created_at"   timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "interest_accruals" (
+    "account_id"      bigint      NOT NULL,
+    "accrual_date"    date        NOT NULL,
+    "balance"         bigint      NOT NULL,
+    "annual_rate_bps" integer     NOT NULL,
+    "day_count"       varchar     NOT NULL,
+    "amount_micros"   bigint      NOT NULL,
+    "created_at"      timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("account_id", "accrual_date")
+);
+
+CREATE TABLE "interest_postings" (
+    "account_id"  bigint      NOT NULL,
+    "period"      date        NOT NULL,
+    "amount"      bigint      NOT NULL,
+    "transfer_id" bigint,
+    "created_at"  timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("account_id", "period")
+);
+
+ALTER TABLE "savings_products" ADD FOREIGN KEY ("expense_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "savings_accounts" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "savings_accounts" ADD FOREIGN KEY ("product_code") REFERENCES "savings_products" ("code");
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_postings" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_postings" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+COMMENT ON COLUMN "savings_products"."annual_rate_bps" IS 'annual interest rate in basis points, 425 is 4.25%';
+
+COMMENT ON COLUMN "savings_products"."day_count" IS 'actual/365, actual/360 or actual/actual';
+
+COMMENT ON COLUMN "savings_products"."expense_account_id" IS 'system account interest is paid from';
+
+COMMENT ON COLUMN "interest_accruals"."balance" IS 'end of day balance the interest accrued on';
+
+COMMENT ON COLUMN "interest_accruals"."amount_micros" IS 'accrued interest in millionths of the minor unit';
+
+COMMENT ON COLUMN "interest_postings"."period" IS 'first day of the month the interest was posted for';
+
+COMMENT ON COLUMN "interest_postings"."transfer_id" IS 'transfer from the expense account, not set when nothing was due';
@@ -0,0 +1,17 @@
+-- owners may have opened several accounts in a currency since the constraint was dropped, the constraint cannot
+-- come back until those accounts are closed or merged by hand
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM "accounts" GROUP BY "owner", "currency" HAVING count(*) > 1
+    ) THEN
+        RAISE EXCEPTION 'cannot restore owner_currency_key: some owners have more than one account in a currency';
+    END IF;
+END;
+$$;
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "product_code";
+
+ALTER TABLE IF EXISTS "accounts" ADD CONSTRAINT "owner_currency_key" UNIQUE ("owner", "currency");
+
+DROP TABLE IF EXISTS "account_products";
@@ -0,0 +1,34 @@
+CREATE TABLE "account_products" (
+    "code"                   varchar PRIMARY KEY,
+    "name"                   varchar     NOT NULL,
+    "currencies"             varchar[]   NOT NULL DEFAULT '{}',
+    "overdraft_limit"        bigint      NOT NULL DEFAULT 0,
+    "fee_schedule"           varchar,
+    "can_initiate_transfers" boolean     NOT NULL DEFAULT true,
+    "multiple_per_currency"  boolean     NOT NULL DEFAULT false,
+    "created_at"             timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "account_products" ADD CONSTRAINT "account_products_overdraft_limit_check" CHECK ("overdraft_limit" >= 0);
+
+INSERT INTO "account_products" ("code", "name", "can_initiate_transfers", "multiple_per_currency") VALUES
+    ('checking', 'Checking', true, false),
+    ('savings', 'Savings', true, false),
+    ('business', 'Business', true, true),
+    ('escrow', 'Escrow', false, true);
+
+-- existing accounts were all opened as checking accounts
+ALTER TABLE "accounts" ADD COLUMN "product_code" varchar NOT NULL DEFAULT 'checking';
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("product_code") REFERENCES "account_products" ("code");
+
+-- accounts of products without multiple_per_currency are unique per owner, currency and product, CreateAccountTx checks it
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "owner_currency_key";
+
+COMMENT ON COLUMN "account_products"."currencies" IS 'currencies accounts can be opened in, any currency when empty';
+
+COMMENT ON COLUMN "account_products"."overdraft_limit" IS 'how far below zero the balance may go, in the minor unit of the account currency';
+
+COMMENT ON COLUMN "account_products"."fee_schedule" IS 'reference of the fee schedule charged to the accounts';
+
+COMMENT ON COLUMN "account_products"."multiple_per_currency" IS 'owners may open more than one account of the product in a currency';
@@ -0,0 +1,2 @@
+-- frozen accounts can neither send nor receive transfers
+ALTER TABLE "accounts" ADD COLUMN "frozen" boolean NOT NULL DEFAULT false;
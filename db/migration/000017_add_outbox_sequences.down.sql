@@ -0,0 +1,3 @@
+COMMENT ON COLUMN "outbox"."id" IS 'events of an aggregate are published in id order';
+ALTER TABLE IF EXISTS "outbox" DROP COLUMN IF EXISTS "sequence";
+DROP TABLE IF EXISTS "outbox_sequences";
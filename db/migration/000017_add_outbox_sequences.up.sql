@@ -0,0 +1,26 @@
+-- the last sequence handed out per aggregate, the row stays locked until the transaction writing the event ends
+CREATE TABLE "outbox_sequences" (
+    "aggregate_type" varchar NOT NULL,
+    "aggregate_id"   varchar NOT NULL,
+    "last_sequence"  bigint  NOT NULL,
+    PRIMARY KEY ("aggregate_type", "aggregate_id")
+);
+
+ALTER TABLE "outbox" ADD COLUMN "sequence" bigint;
+
+UPDATE "outbox" SET "sequence" = numbered."sequence"
+FROM (
+    SELECT "id", row_number() OVER (PARTITION BY "aggregate_type", "aggregate_id" ORDER BY "id") AS "sequence"
+    FROM "outbox"
+) AS numbered
+WHERE "outbox"."id" = numbered."id";
+
+ALTER TABLE "outbox" ALTER COLUMN "sequence" SET NOT NULL;
+
+INSERT INTO "outbox_sequences" ("aggregate_type", "aggregate_id", "last_sequence")
+SELECT "aggregate_type", "aggregate_id", max("sequence") FROM "outbox" GROUP BY "aggregate_type", "aggregate_id";
+
+CREATE UNIQUE INDEX ON "outbox" ("aggregate_type", "aggregate_id", "sequence");
+
+COMMENT ON COLUMN "outbox"."id" IS 'unique, increases with every event';
+COMMENT ON COLUMN "outbox"."sequence" IS 'position of the event in its aggregate, events of an aggregate are published in sequence order';
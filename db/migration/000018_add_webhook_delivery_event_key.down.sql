@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "webhook_deliveries_subscription_id_event_id_key";
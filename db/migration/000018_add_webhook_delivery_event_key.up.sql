@@ -0,0 +1,2 @@
+-- an event is queued once per subscription, so publishing it again from the outbox is a no-op
+CREATE UNIQUE INDEX "webhook_deliveries_subscription_id_event_id_key" ON "webhook_deliveries" ("subscription_id", "event_id");
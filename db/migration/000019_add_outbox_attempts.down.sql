@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "outbox_unpublished_sequence_idx";
+ALTER TABLE IF EXISTS "outbox" DROP COLUMN IF EXISTS "last_error";
+ALTER TABLE IF EXISTS "outbox" DROP COLUMN IF EXISTS "next_attempt_at";
+ALTER TABLE IF EXISTS "outbox" DROP COLUMN IF EXISTS "attempts";
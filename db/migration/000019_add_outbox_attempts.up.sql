@@ -0,0 +1,8 @@
+ALTER TABLE "outbox" ADD COLUMN "attempts" integer NOT NULL DEFAULT 0;
+ALTER TABLE "outbox" ADD COLUMN "next_attempt_at" timestamptz NOT NULL DEFAULT (now());
+ALTER TABLE "outbox" ADD COLUMN "last_error" varchar;
+
+-- finds the unpublished events of an aggregate before the one a relay claims
+CREATE INDEX "outbox_unpublished_sequence_idx" ON "outbox" ("aggregate_type", "aggregate_id", "sequence") WHERE "published_at" IS NULL;
+
+COMMENT ON COLUMN "outbox"."next_attempt_at" IS 'not published before, pushed back while a relay publishes the event and after failed attempts';
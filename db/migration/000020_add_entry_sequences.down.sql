@@ -0,0 +1,14 @@
+CREATE OR REPLACE FUNCTION notify_entry() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('entries', json_build_object(
+        'id', NEW.id,
+        'account_id', NEW.account_id,
+        'amount', NEW.amount,
+        'created_at', NEW.created_at
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "sequence";
+DROP TABLE IF EXISTS "entry_sequences";
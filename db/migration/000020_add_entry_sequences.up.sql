@@ -0,0 +1,36 @@
+-- the last sequence handed out per account, the row stays locked until the transaction writing the entry ends
+CREATE TABLE "entry_sequences" (
+    "account_id"    bigint PRIMARY KEY,
+    "last_sequence" bigint NOT NULL
+);
+
+ALTER TABLE "entries" ADD COLUMN "sequence" bigint;
+
+UPDATE "entries" SET "sequence" = numbered."sequence"
+FROM (
+    SELECT "id", row_number() OVER (PARTITION BY "account_id" ORDER BY "id") AS "sequence"
+    FROM "entries"
+) AS numbered
+WHERE "entries"."id" = numbered."id";
+
+ALTER TABLE "entries" ALTER COLUMN "sequence" SET NOT NULL;
+
+INSERT INTO "entry_sequences" ("account_id", "last_sequence")
+SELECT "account_id", max("sequence") FROM "entries" GROUP BY "account_id";
+
+CREATE UNIQUE INDEX ON "entries" ("account_id", "sequence");
+
+CREATE OR REPLACE FUNCTION notify_entry() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('entries', json_build_object(
+        'id', NEW.id,
+        'account_id', NEW.account_id,
+        'sequence', NEW.sequence,
+        'amount', NEW.amount,
+        'created_at', NEW.created_at
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON COLUMN "entries"."sequence" IS 'position of the entry in its account, the entries of an account commit in sequence order';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "accounts_owner_currency_product_code_key";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "multiple_per_currency";
@@ -0,0 +1,14 @@
+-- accounts copy multiple_per_currency from their product so the database enforces one account per owner,
+-- currency and product for the products without it. Products are never updated once created
+ALTER TABLE "accounts" ADD COLUMN "multiple_per_currency" boolean;
+
+UPDATE "accounts" SET "multiple_per_currency" = "account_products"."multiple_per_currency"
+FROM "account_products"
+WHERE "accounts"."product_code" = "account_products"."code";
+
+ALTER TABLE "accounts" ALTER COLUMN "multiple_per_currency" SET NOT NULL;
+
+CREATE UNIQUE INDEX "accounts_owner_currency_product_code_key" ON "accounts" ("owner", "currency", "product_code")
+WHERE NOT "multiple_per_currency";
+
+COMMENT ON COLUMN "accounts"."multiple_per_currency" IS 'multiple_per_currency of the account product when the account was opened';
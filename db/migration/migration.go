@@ -0,0 +1,119 @@
+package migration
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"io/fs"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Migrator applies the migrations embedded in the binary.
+// The postgres driver holds a pg_advisory_lock for the duration of every operation,
+// so replicas migrating the same database at startup wait for each other instead of racing
+type Migrator struct {
+	migrate *migrate.Migrate
+}
+
+// New creates a migrator for the database at databaseURL
+func New(databaseURL string) (*Migrator, error) {
+	src, err := iofs.New(files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read embedded migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create migrator: %w", err)
+	}
+
+	return &Migrator{migrate: m}, nil
+}
+
+// Up applies all pending migrations, or only the next steps migrations when steps is positive
+func (m *Migrator) Up(steps int) error {
+	var err error
+	if steps > 0 {
+		err = m.migrate.Steps(steps)
+	} else {
+		err = m.migrate.Up()
+	}
+
+	return ignoreNoChange(err)
+}
+
+// Down rolls back the last steps migrations, or every migration when steps is not positive
+func (m *Migrator) Down(steps int) error {
+	var err error
+	if steps > 0 {
+		err = m.migrate.Steps(-steps)
+	} else {
+		err = m.migrate.Down()
+	}
+
+	return ignoreNoChange(err)
+}
+
+// Version returns the currently applied version and whether the last migration failed midway
+func (m *Migrator) Version() (version uint, dirty bool, err error) {
+	version, dirty, err = m.migrate.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+
+	return
+}
+
+// Force sets the recorded version without running any migration, used to recover a dirty schema
+func (m *Migrator) Force(version int) error {
+	return m.migrate.Force(version)
+}
+
+// Close releases the migrator's database connection
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.migrate.Close()
+	if srcErr != nil {
+		return srcErr
+	}
+
+	return dbErr
+}
+
+// LatestVersion returns the version of the newest embedded migration
+func LatestVersion() (uint, error) {
+	src, err := iofs.New(files, ".")
+	if err != nil {
+		return 0, fmt.Errorf("cannot read embedded migrations: %w", err)
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		version = next
+	}
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,31 @@
+package migration
+
+import (
+	"github.com/stretchr/testify/require"
+	"io/fs"
+	"testing"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	names, err := fs.Glob(files, "*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, names)
+
+	// every migration must be reversible
+	ups, err := fs.Glob(files, "*.up.sql")
+	require.NoError(t, err)
+
+	downs, err := fs.Glob(files, "*.down.sql")
+	require.NoError(t, err)
+	require.Equal(t, len(ups), len(downs))
+}
+
+func TestLatestVersion(t *testing.T) {
+	ups, err := fs.Glob(files, "*.up.sql")
+	require.NoError(t, err)
+
+	// migrations are numbered sequentially from 1
+	version, err := LatestVersion()
+	require.NoError(t, err)
+	require.Equal(t, uint(len(ups)), version)
+}
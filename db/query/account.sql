@@ -2,9 +2,11 @@
 INSERT INTO accounts (
     owner,
     balance,
-    currency
+    currency,
+    product_code,
+    multiple_per_currency
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4, COALESCE((SELECT multiple_per_currency FROM account_products WHERE code = $4), false)
 ) RETURNING *;
 
 -- name: GetAccount :one
@@ -17,9 +19,10 @@ WHERE id = $1 LIMIT 1 FOR NO KEY UPDATE;
 
 -- name: ListAccounts :many
 SELECT * FROM accounts
-ORDER BY owner
-LIMIT $1
-OFFSET $2;
+WHERE owner = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
 
 -- name: UpdateAccount :one
 UPDATE accounts
@@ -33,5 +36,11 @@ SET balance = balance + sqlc.arg(amount)
 WHERE id = sqlc.arg(id)
 RETURNING *;
 
+-- name: FreezeAccount :one
+UPDATE accounts
+SET frozen = true
+WHERE id = $1
+RETURNING *;
+
 -- name: DeleteAccount :exec
 DELETE FROM accounts WHERE id = $1;
\ No newline at end of file
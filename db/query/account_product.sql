@@ -0,0 +1,20 @@
+-- name: CreateAccountProduct :one
+INSERT INTO account_products (
+    code,
+    name,
+    currencies,
+    overdraft_limit,
+    fee_schedule,
+    can_initiate_transfers,
+    multiple_per_currency
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetAccountProduct :one
+SELECT * FROM account_products
+WHERE code = $1 LIMIT 1;
+
+-- name: ListAccountProducts :many
+SELECT * FROM account_products
+ORDER BY code;
@@ -1,10 +1,24 @@
 -- name: CreateEntry :one
+WITH next AS (
+    INSERT INTO entry_sequences (
+        account_id,
+        last_sequence
+    ) VALUES (
+        $1, 1
+    )
+    ON CONFLICT (account_id) DO UPDATE
+    SET last_sequence = entry_sequences.last_sequence + 1
+    RETURNING last_sequence
+)
 INSERT INTO entries (
     account_id,
-    amount
-) VALUES (
-    $1, $2
-) RETURNING *;
+    amount,
+    transfer_id,
+    sequence
+)
+SELECT $1, $2, $3, last_sequence
+FROM next
+RETURNING *;
 
 -- name: GetEntry :one
 SELECT * FROM entries
@@ -15,4 +29,23 @@ SELECT * FROM entries
 WHERE account_id = $1
 ORDER BY id
 LIMIT $2
-OFFSET $3;
\ No newline at end of file
+OFFSET $3;
+
+-- name: ListEntriesAfter :many
+SELECT * FROM entries
+WHERE account_id = $1 AND sequence > $2
+ORDER BY sequence
+LIMIT $3;
+
+-- name: GetLastEntrySequence :one
+SELECT COALESCE(MAX(sequence), 0)::bigint AS last_sequence FROM entries
+WHERE account_id = $1;
+
+-- name: ListEntriesBetween :many
+SELECT * FROM entries
+WHERE account_id = sqlc.arg(account_id) AND created_at >= sqlc.arg(from_time) AND created_at < sqlc.arg(to_time)
+ORDER BY id;
+
+-- name: SumEntriesSince :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total FROM entries
+WHERE account_id = $1 AND created_at >= $2;
@@ -0,0 +1,44 @@
+-- name: CreateInterestAccrual :one
+INSERT INTO interest_accruals (
+    account_id,
+    accrual_date,
+    balance,
+    annual_rate_bps,
+    day_count,
+    amount_micros
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (account_id, accrual_date) DO NOTHING
+RETURNING *;
+
+-- name: SumInterestAccruals :one
+SELECT COALESCE(SUM(amount_micros), 0)::bigint AS total
+FROM interest_accruals
+WHERE account_id = $1 AND accrual_date < $2;
+
+-- name: CountInterestAccruals :one
+SELECT count(*) FROM interest_accruals
+WHERE account_id = sqlc.arg(account_id) AND accrual_date >= sqlc.arg(from_date) AND accrual_date < sqlc.arg(to_date);
+
+-- name: CreateInterestPosting :one
+INSERT INTO interest_postings (
+    account_id,
+    period,
+    amount
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (account_id, period) DO NOTHING
+RETURNING *;
+
+-- name: SetInterestPostingTransfer :one
+UPDATE interest_postings
+SET transfer_id = $3
+WHERE account_id = $1 AND period = $2
+RETURNING *;
+
+-- name: SumInterestPostings :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM interest_postings
+WHERE account_id = $1;
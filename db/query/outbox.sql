@@ -0,0 +1,54 @@
+-- name: CreateOutboxEvent :one
+WITH next AS (
+    INSERT INTO outbox_sequences (
+        aggregate_type,
+        aggregate_id,
+        last_sequence
+    ) VALUES (
+        $1, $2, 1
+    )
+    ON CONFLICT (aggregate_type, aggregate_id) DO UPDATE
+    SET last_sequence = outbox_sequences.last_sequence + 1
+    RETURNING last_sequence
+)
+INSERT INTO outbox (
+    aggregate_type,
+    aggregate_id,
+    sequence,
+    event_type,
+    payload
+)
+SELECT $1, $2, last_sequence, $3, $4
+FROM next
+RETURNING *;
+
+-- name: ClaimOutboxEvents :many
+UPDATE outbox
+SET next_attempt_at = sqlc.arg(locked_until)
+WHERE id IN (
+    SELECT id FROM outbox AS head
+    WHERE head.published_at IS NULL AND head.next_attempt_at <= now() AND NOT EXISTS (
+        SELECT 1 FROM outbox AS earlier
+        WHERE earlier.aggregate_type = head.aggregate_type
+        AND earlier.aggregate_id = head.aggregate_id
+        AND earlier.published_at IS NULL
+        AND earlier.sequence < head.sequence
+    )
+    ORDER BY head.id
+    LIMIT sqlc.arg(limit)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkOutboxEventsPublished :exec
+UPDATE outbox
+SET published_at = now()
+WHERE id = ANY(sqlc.arg(ids)::bigint[]);
+
+-- name: RecordOutboxEventFailure :exec
+UPDATE outbox
+SET
+    attempts = attempts + 1,
+    next_attempt_at = $2,
+    last_error = $3
+WHERE id = $1;
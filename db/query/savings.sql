@@ -0,0 +1,37 @@
+-- name: CreateSavingsProduct :one
+INSERT INTO savings_products (
+    code,
+    name,
+    currency,
+    annual_rate_bps,
+    day_count,
+    expense_account_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetSavingsProduct :one
+SELECT * FROM savings_products
+WHERE code = $1 LIMIT 1;
+
+-- name: ListSavingsProducts :many
+SELECT * FROM savings_products
+ORDER BY code;
+
+-- name: CreateSavingsAccount :one
+INSERT INTO savings_accounts (
+    account_id,
+    product_code
+) VALUES (
+    $1, $2
+) RETURNING *;
+
+-- name: GetSavingsAccount :one
+SELECT * FROM savings_accounts
+WHERE account_id = $1 LIMIT 1;
+
+-- name: ListSavingsAccounts :many
+SELECT * FROM savings_accounts
+WHERE account_id > $1
+ORDER BY account_id
+LIMIT $2;
@@ -18,4 +18,8 @@ WHERE
         to_account_id = $2
 ORDER BY id
 LIMIT $3
-OFFSET $4;
\ No newline at end of file
+OFFSET $4;
+-- name: ListTransfersByIDs :many
+SELECT * FROM transfers
+WHERE id = ANY(sqlc.arg(ids)::bigint[])
+ORDER BY id;
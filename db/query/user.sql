@@ -10,4 +10,70 @@ INSERT INTO users (
 
 -- name: GetUser :one
 SELECT * FROM users
-WHERE username = $1 LIMIT 1;
\ No newline at end of file
+WHERE username = $1 LIMIT 1;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = $2,
+    password_changed_at = $3
+WHERE username = $1
+RETURNING *;
+
+-- name: VerifyUserEmail :one
+UPDATE users
+SET is_email_verified = true
+WHERE username = $1 AND email = $2
+RETURNING *;
+
+-- name: UpdateUser :one
+UPDATE users
+SET full_name = $2,
+    is_email_verified = is_email_verified AND email = $3,
+    email = $3
+WHERE username = $1
+RETURNING *;
+
+-- name: RecordFailedLogin :one
+UPDATE users
+SET failed_login_attempts = failed_login_attempts + 1,
+    last_failed_login_at = now()
+WHERE username = $1
+RETURNING *;
+
+-- name: LockUser :one
+UPDATE users
+SET locked_until = $2
+WHERE username = $1
+RETURNING *;
+
+-- name: ResetLoginAttempts :one
+UPDATE users
+SET failed_login_attempts = 0,
+    last_failed_login_at = NULL,
+    locked_until = NULL
+WHERE username = $1
+RETURNING *;
+
+-- name: SetUserTOTPSecret :one
+UPDATE users
+SET totp_secret = $2,
+    totp_enabled = false,
+    totp_last_step = 0
+WHERE username = $1
+RETURNING *;
+
+-- name: EnableUserTOTP :one
+UPDATE users
+SET totp_enabled = true
+WHERE username = $1 AND totp_secret IS NOT NULL
+RETURNING *;
+
+-- name: UseTOTPStep :one
+UPDATE users
+SET totp_last_step = $2
+WHERE username = $1 AND totp_last_step < $2
+RETURNING *;
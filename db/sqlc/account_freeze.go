@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	// ErrAccountFrozen is returned when a transfer would move money from or to a frozen account
+	ErrAccountFrozen = errors.New("account is frozen")
+	// ErrAccountAlreadyFrozen is returned when freezing an account that is frozen
+	ErrAccountAlreadyFrozen = errors.New("account is already frozen")
+)
+
+// checkTransferAccounts returns ErrAccountFrozen when either account of a transfer is frozen
+func checkTransferAccounts(fromAccount Account, toAccount Account) error {
+	if fromAccount.Frozen || toAccount.Frozen {
+		return ErrAccountFrozen
+	}
+	return nil
+}
+
+// FreezeAccountTx freezes an account and writes account.frozen to the outbox in the same transaction. The update
+// locks the account, so transfers running concurrently either finish before it or see the account frozen.
+// ErrAccountAlreadyFrozen is returned when the account is frozen and sql.ErrNoRows when it does not exist
+func (store *SQLStore) FreezeAccountTx(ctx context.Context, id int64) (Account, error) {
+	var account Account
+
+	ctx, span := startTxSpan(ctx, "FreezeAccountTx", attribute.Int64("account.id", id))
+	defer span.End()
+
+	err := store.execTx(ctx, sql.LevelDefault, func(q *Queries) error {
+		current, err := q.GetAccountForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if current.Frozen {
+			return ErrAccountAlreadyFrozen
+		}
+
+		account, err = q.FreezeAccount(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		event, err := newAccountFrozenEvent(account)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateOutboxEvent(ctx, event)
+		return err
+	})
+	recordError(span, err)
+
+	return account, err
+}
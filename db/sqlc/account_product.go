@@ -0,0 +1,60 @@
+package db
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	// AccountProductChecking is the product accounts are opened with unless another one is chosen
+	AccountProductChecking = "checking"
+	// AccountProductSavings accounts can earn interest of a savings product
+	AccountProductSavings = "savings"
+	// AccountProductBusiness accounts can be opened more than once per owner and currency
+	AccountProductBusiness = "business"
+	// AccountProductEscrow accounts hold funds for others and cannot initiate transfers
+	AccountProductEscrow = "escrow"
+)
+
+var (
+	// ErrAccountExists is returned when the owner has an account of the product in the currency and the product
+	// does not allow more than one
+	ErrAccountExists = errors.New("owner has an account of the product with the currency type")
+	// ErrTransfersNotAllowed is returned when the product of the source account cannot initiate transfers
+	ErrTransfersNotAllowed = errors.New("account cannot initiate transfers")
+)
+
+// ProductAllowsCurrency returns if accounts of a product can be opened in a currency
+func ProductAllowsCurrency(product AccountProduct, currency string) bool {
+	if len(product.Currencies) == 0 {
+		return true
+	}
+
+	for _, allowed := range product.Currencies {
+		if allowed == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// AvailableBalance returns how much an account of a product can transfer with a balance, the overdraft limit included
+func AvailableBalance(balance int64, product AccountProduct) int64 {
+	if balance > math.MaxInt64-product.OverdraftLimit {
+		return math.MaxInt64
+	}
+	return balance + product.OverdraftLimit
+}
+
+// checkTransferSource returns the error transferring amount from an account of a product with a balance fails with
+func checkTransferSource(balance int64, amount int64, product AccountProduct) error {
+	if !product.CanInitiateTransfers {
+		return ErrTransfersNotAllowed
+	}
+
+	if AvailableBalance(balance, product) < amount {
+		return ErrInsufficientFunds
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
+)
+
+// AccountStatementParams is the period of a statement, entries created at From up to but excluding To are listed
+type AccountStatementParams struct {
+	AccountID int64     `json:"account_id"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+}
+
+// StatementParty is an account on the other side of a transfer
+type StatementParty struct {
+	AccountID int64  `json:"account_id"`
+	Owner     string `json:"owner"`
+	Name      string `json:"name"`
+}
+
+// StatementEntry is an entry of a statement, Counterparty is set for the entries written by a transfer
+type StatementEntry struct {
+	Entry        Entry           `json:"entry"`
+	Counterparty *StatementParty `json:"counterparty"`
+}
+
+// AccountStatement is an account with its balances around a period and the entries booked within it
+type AccountStatement struct {
+	Account        Account          `json:"account"`
+	OwnerName      string           `json:"owner_name"`
+	From           time.Time        `json:"from"`
+	To             time.Time        `json:"to"`
+	OpeningBalance int64            `json:"opening_balance"`
+	ClosingBalance int64            `json:"closing_balance"`
+	Entries        []StatementEntry `json:"entries"`
+}
+
+// AccountStatementTx reads the statement of an account within a repeatable read transaction so the balances and
+// the entries are of the same snapshot. The closing balance is the current balance less the entries created since
+// the end of the period, the opening balance is the closing balance less the entries of the period.
+// sql.ErrNoRows is returned when the account does not exist
+func (store *SQLStore) AccountStatementTx(ctx context.Context, arg AccountStatementParams) (AccountStatement, error) {
+	var statement AccountStatement
+
+	ctx, span := startTxSpan(ctx, "AccountStatementTx", attribute.Int64("statement.account_id", arg.AccountID))
+	defer span.End()
+
+	err := store.execTx(ctx, sql.LevelRepeatableRead, func(q *Queries) error {
+		var err error
+		statement, err = accountStatement(ctx, q, arg)
+		return err
+	})
+	recordError(span, err)
+
+	return statement, err
+}
+
+func accountStatement(ctx context.Context, q *Queries, arg AccountStatementParams) (AccountStatement, error) {
+	statement := AccountStatement{From: arg.From, To: arg.To}
+
+	var err error
+	statement.Account, err = q.GetAccount(ctx, arg.AccountID)
+	if err != nil {
+		return statement, err
+	}
+
+	since, err := q.SumEntriesSince(ctx, SumEntriesSinceParams{AccountID: arg.AccountID, CreatedAt: arg.To})
+	if err != nil {
+		return statement, err
+	}
+
+	entries, err := q.ListEntriesBetween(ctx, ListEntriesBetweenParams{AccountID: arg.AccountID, FromTime: arg.From, ToTime: arg.To})
+	if err != nil {
+		return statement, err
+	}
+
+	var transferIDs []int64
+	for _, entry := range entries {
+		if entry.TransferID.Valid {
+			transferIDs = append(transferIDs, entry.TransferID.Int64)
+		}
+	}
+
+	transfers, err := q.ListTransfersByIDs(ctx, transferIDs)
+	if err != nil {
+		return statement, err
+	}
+
+	parties := newStatementParties(statement.Account)
+	for _, transfer := range transfers {
+		for _, id := range []int64{transfer.FromAccountID, transfer.ToAccountID} {
+			if _, ok := parties.accounts[id]; ok {
+				continue
+			}
+
+			account, err := q.GetAccount(ctx, id)
+			if err != nil {
+				return statement, err
+			}
+			parties.accounts[id] = account
+		}
+	}
+
+	for _, account := range parties.accounts {
+		if _, ok := parties.names[account.Owner]; ok {
+			continue
+		}
+
+		user, err := q.GetUser(ctx, account.Owner)
+		if err != nil {
+			return statement, err
+		}
+		parties.names[user.Username] = user.FullName
+	}
+
+	statement.fill(since, entries, transfers, parties)
+	return statement, nil
+}
+
+// statementParties are the accounts of the transfers of a statement and the full names of their owners
+type statementParties struct {
+	accounts map[int64]Account
+	names    map[string]string
+}
+
+func newStatementParties(account Account) statementParties {
+	return statementParties{
+		accounts: map[int64]Account{account.ID: account},
+		names:    make(map[string]string),
+	}
+}
+
+// fill sets the balances and the entries of statement, since is the sum of the entries created after the period
+func (statement *AccountStatement) fill(since int64, entries []Entry, transfers []Transfer, parties statementParties) {
+	statement.OwnerName = parties.names[statement.Account.Owner]
+	statement.ClosingBalance = statement.Account.Balance - since
+	statement.OpeningBalance = statement.ClosingBalance
+
+	byID := make(map[int64]Transfer, len(transfers))
+	for _, transfer := range transfers {
+		byID[transfer.ID] = transfer
+	}
+
+	statement.Entries = make([]StatementEntry, len(entries))
+	for i, entry := range entries {
+		statement.OpeningBalance -= entry.Amount
+		statement.Entries[i] = StatementEntry{Entry: entry}
+
+		transfer, ok := byID[entry.TransferID.Int64]
+		if !entry.TransferID.Valid || !ok {
+			continue
+		}
+
+		// the counterparty of a debit is the destination of the transfer, of a credit its source
+		counterpartyID := transfer.ToAccountID
+		if entry.Amount > 0 {
+			counterpartyID = transfer.FromAccountID
+		}
+
+		account := parties.accounts[counterpartyID]
+		statement.Entries[i].Counterparty = &StatementParty{
+			AccountID: account.ID,
+			Owner:     account.Owner,
+			Name:      parties.names[account.Owner],
+		}
+	}
+}
@@ -12,10 +12,12 @@ import (
 
 func createRandomAccount(t *testing.T) Account {
 	user := createRandomUser(t)
+	// transfers between random accounts need balances that cover them
 	arg := CreateAccountParams{
-		Owner:    user.Username,
-		Balance:  util.RandomMoney(),
-		Currency: util.RandomCurrency(),
+		Owner:       user.Username,
+		Balance:     util.RandomInt(1000, 10000),
+		Currency:    util.RandomCurrency(),
+		ProductCode: AccountProductChecking,
 	}
 
 	account, err := testQueries.CreateAccount(context.Background(), arg)
@@ -81,20 +83,48 @@ func TestDeleteAccount(t *testing.T) {
 }
 
 func TestListAccounts(t *testing.T) {
-	for i := 0; i <= 10; i++ {
-		createRandomAccount(t)
+	user := createRandomUser(t)
+	// accounts of another owner are never listed
+	createRandomAccount(t)
+
+	// business accounts can be opened more than once per currency
+	created := make([]Account, 7)
+	for i := range created {
+		account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			Owner:       user.Username,
+			Balance:     util.RandomMoney(),
+			Currency:    util.RandomCurrency(),
+			ProductCode: AccountProductBusiness,
+		})
+		require.NoError(t, err)
+		created[i] = account
 	}
 
 	arg := ListAccountsParams{
+		Owner:  user.Username,
 		Limit:  5,
-		Offset: 5,
+		Offset: 0,
 	}
 
 	accounts, err := testQueries.ListAccounts(context.Background(), arg)
 	require.NoError(t, err)
 	require.Len(t, accounts, 5)
+	for i, account := range accounts {
+		require.Equal(t, created[i].ID, account.ID)
+		require.Equal(t, user.Username, account.Owner)
+	}
 
-	for _, account := range accounts {
-		require.NotEmpty(t, account)
+	arg.Offset = 5
+	accounts, err = testQueries.ListAccounts(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, accounts, 2)
+	for i, account := range accounts {
+		require.Equal(t, created[5+i].ID, account.ID)
+		require.Equal(t, user.Username, account.Owner)
 	}
+
+	arg.Offset = 7
+	accounts, err = testQueries.ListAccounts(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, accounts)
 }
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+)
+
+// CreateAccountTx creates an account and writes account.created to the outbox in the same transaction. Unless its
+// product allows multiple accounts per currency, ErrAccountExists is returned when the owner has an account of the
+// product in the currency, which accounts_owner_currency_product_code_key enforces for concurrent calls too.
+// sql.ErrNoRows is returned when the product does not exist
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	var account Account
+
+	ctx, span := startTxSpan(ctx, "CreateAccountTx")
+	defer span.End()
+
+	err := store.execTx(ctx, sql.LevelDefault, func(q *Queries) error {
+		_, err := q.GetAccountProduct(ctx, arg.ProductCode)
+		if err != nil {
+			return err
+		}
+
+		account, err = q.CreateAccount(ctx, arg)
+		if err != nil {
+			return accountExistsError(err)
+		}
+
+		event, err := newAccountCreatedEvent(account)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateOutboxEvent(ctx, event)
+		return err
+	})
+	recordError(span, err)
+
+	return account, err
+}
+
+// accountsOwnerCurrencyProductCodeKey allows one account per owner, currency and product for the products without
+// multiple_per_currency
+const accountsOwnerCurrencyProductCodeKey = "accounts_owner_currency_product_code_key"
+
+// accountExistsError returns ErrAccountExists when err violates the one account per owner, currency and product
+// index, and err otherwise
+func accountExistsError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation && pqErr.Constraint == accountsOwnerCurrencyProductCodeKey {
+		return ErrAccountExists
+	}
+
+	return err
+}
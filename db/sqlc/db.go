@@ -27,48 +27,237 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.addAccountBalanceStmt, err = db.PrepareContext(ctx, addAccountBalance); err != nil {
 		return nil, fmt.Errorf("error preparing query AddAccountBalance: %w", err)
 	}
+	if q.claimOutboxEventsStmt, err = db.PrepareContext(ctx, claimOutboxEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimOutboxEvents: %w", err)
+	}
+	if q.claimWebhookDeliveriesStmt, err = db.PrepareContext(ctx, claimWebhookDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimWebhookDeliveries: %w", err)
+	}
+	if q.completeTransferBatchStmt, err = db.PrepareContext(ctx, completeTransferBatch); err != nil {
+		return nil, fmt.Errorf("error preparing query CompleteTransferBatch: %w", err)
+	}
+	if q.countInterestAccrualsStmt, err = db.PrepareContext(ctx, countInterestAccruals); err != nil {
+		return nil, fmt.Errorf("error preparing query CountInterestAccruals: %w", err)
+	}
+	if q.createAPIKeyStmt, err = db.PrepareContext(ctx, createAPIKey); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAPIKey: %w", err)
+	}
 	if q.createAccountStmt, err = db.PrepareContext(ctx, createAccount); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateAccount: %w", err)
 	}
+	if q.createAccountProductStmt, err = db.PrepareContext(ctx, createAccountProduct); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAccountProduct: %w", err)
+	}
+	if q.createEmailVerificationTokenStmt, err = db.PrepareContext(ctx, createEmailVerificationToken); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateEmailVerificationToken: %w", err)
+	}
 	if q.createEntryStmt, err = db.PrepareContext(ctx, createEntry); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateEntry: %w", err)
 	}
+	if q.createInterestAccrualStmt, err = db.PrepareContext(ctx, createInterestAccrual); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateInterestAccrual: %w", err)
+	}
+	if q.createInterestPostingStmt, err = db.PrepareContext(ctx, createInterestPosting); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateInterestPosting: %w", err)
+	}
+	if q.createOutboxEventStmt, err = db.PrepareContext(ctx, createOutboxEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateOutboxEvent: %w", err)
+	}
+	if q.createPasswordResetTokenStmt, err = db.PrepareContext(ctx, createPasswordResetToken); err != nil {
+		return nil, fmt.Errorf("error preparing query CreatePasswordResetToken: %w", err)
+	}
+	if q.createSavingsAccountStmt, err = db.PrepareContext(ctx, createSavingsAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateSavingsAccount: %w", err)
+	}
+	if q.createSavingsProductStmt, err = db.PrepareContext(ctx, createSavingsProduct); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateSavingsProduct: %w", err)
+	}
+	if q.createTOTPRecoveryCodeStmt, err = db.PrepareContext(ctx, createTOTPRecoveryCode); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateTOTPRecoveryCode: %w", err)
+	}
 	if q.createTransferStmt, err = db.PrepareContext(ctx, createTransfer); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateTransfer: %w", err)
 	}
+	if q.createTransferBatchStmt, err = db.PrepareContext(ctx, createTransferBatch); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateTransferBatch: %w", err)
+	}
+	if q.createTransferBatchItemStmt, err = db.PrepareContext(ctx, createTransferBatchItem); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateTransferBatchItem: %w", err)
+	}
 	if q.createUserStmt, err = db.PrepareContext(ctx, createUser); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateUser: %w", err)
 	}
+	if q.createWebhookDeliveryStmt, err = db.PrepareContext(ctx, createWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhookDelivery: %w", err)
+	}
+	if q.createWebhookSubscriptionStmt, err = db.PrepareContext(ctx, createWebhookSubscription); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhookSubscription: %w", err)
+	}
 	if q.deleteAccountStmt, err = db.PrepareContext(ctx, deleteAccount); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteAccount: %w", err)
 	}
+	if q.deleteTOTPRecoveryCodesStmt, err = db.PrepareContext(ctx, deleteTOTPRecoveryCodes); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteTOTPRecoveryCodes: %w", err)
+	}
+	if q.deleteWebhookSubscriptionStmt, err = db.PrepareContext(ctx, deleteWebhookSubscription); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteWebhookSubscription: %w", err)
+	}
+	if q.enableUserTOTPStmt, err = db.PrepareContext(ctx, enableUserTOTP); err != nil {
+		return nil, fmt.Errorf("error preparing query EnableUserTOTP: %w", err)
+	}
+	if q.freezeAccountStmt, err = db.PrepareContext(ctx, freezeAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query FreezeAccount: %w", err)
+	}
+	if q.getAPIKeyByHashStmt, err = db.PrepareContext(ctx, getAPIKeyByHash); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAPIKeyByHash: %w", err)
+	}
 	if q.getAccountStmt, err = db.PrepareContext(ctx, getAccount); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAccount: %w", err)
 	}
 	if q.getAccountForUpdateStmt, err = db.PrepareContext(ctx, getAccountForUpdate); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAccountForUpdate: %w", err)
 	}
+	if q.getAccountProductStmt, err = db.PrepareContext(ctx, getAccountProduct); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAccountProduct: %w", err)
+	}
 	if q.getEntryStmt, err = db.PrepareContext(ctx, getEntry); err != nil {
 		return nil, fmt.Errorf("error preparing query GetEntry: %w", err)
 	}
+	if q.getLastEntrySequenceStmt, err = db.PrepareContext(ctx, getLastEntrySequence); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLastEntrySequence: %w", err)
+	}
+	if q.getSavingsAccountStmt, err = db.PrepareContext(ctx, getSavingsAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSavingsAccount: %w", err)
+	}
+	if q.getSavingsProductStmt, err = db.PrepareContext(ctx, getSavingsProduct); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSavingsProduct: %w", err)
+	}
 	if q.getTransferStmt, err = db.PrepareContext(ctx, getTransfer); err != nil {
 		return nil, fmt.Errorf("error preparing query GetTransfer: %w", err)
 	}
+	if q.getTransferBatchStmt, err = db.PrepareContext(ctx, getTransferBatch); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTransferBatch: %w", err)
+	}
 	if q.getUserStmt, err = db.PrepareContext(ctx, getUser); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUser: %w", err)
 	}
+	if q.getUserByEmailStmt, err = db.PrepareContext(ctx, getUserByEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserByEmail: %w", err)
+	}
+	if q.getWebhookSubscriptionStmt, err = db.PrepareContext(ctx, getWebhookSubscription); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhookSubscription: %w", err)
+	}
+	if q.invalidatePasswordResetTokensStmt, err = db.PrepareContext(ctx, invalidatePasswordResetTokens); err != nil {
+		return nil, fmt.Errorf("error preparing query InvalidatePasswordResetTokens: %w", err)
+	}
+	if q.listAPIKeysStmt, err = db.PrepareContext(ctx, listAPIKeys); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAPIKeys: %w", err)
+	}
+	if q.listAccountProductsStmt, err = db.PrepareContext(ctx, listAccountProducts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAccountProducts: %w", err)
+	}
 	if q.listAccountsStmt, err = db.PrepareContext(ctx, listAccounts); err != nil {
 		return nil, fmt.Errorf("error preparing query ListAccounts: %w", err)
 	}
 	if q.listEntriesStmt, err = db.PrepareContext(ctx, listEntries); err != nil {
 		return nil, fmt.Errorf("error preparing query ListEntries: %w", err)
 	}
+	if q.listEntriesAfterStmt, err = db.PrepareContext(ctx, listEntriesAfter); err != nil {
+		return nil, fmt.Errorf("error preparing query ListEntriesAfter: %w", err)
+	}
+	if q.listEntriesBetweenStmt, err = db.PrepareContext(ctx, listEntriesBetween); err != nil {
+		return nil, fmt.Errorf("error preparing query ListEntriesBetween: %w", err)
+	}
+	if q.listSavingsAccountsStmt, err = db.PrepareContext(ctx, listSavingsAccounts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListSavingsAccounts: %w", err)
+	}
+	if q.listSavingsProductsStmt, err = db.PrepareContext(ctx, listSavingsProducts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListSavingsProducts: %w", err)
+	}
+	if q.listTransferBatchItemsStmt, err = db.PrepareContext(ctx, listTransferBatchItems); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTransferBatchItems: %w", err)
+	}
 	if q.listTransfersStmt, err = db.PrepareContext(ctx, listTransfers); err != nil {
 		return nil, fmt.Errorf("error preparing query ListTransfers: %w", err)
 	}
+	if q.listTransfersByIDsStmt, err = db.PrepareContext(ctx, listTransfersByIDs); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTransfersByIDs: %w", err)
+	}
+	if q.listWebhookDeliveriesStmt, err = db.PrepareContext(ctx, listWebhookDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhookDeliveries: %w", err)
+	}
+	if q.listWebhookSubscriptionsStmt, err = db.PrepareContext(ctx, listWebhookSubscriptions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhookSubscriptions: %w", err)
+	}
+	if q.listWebhookSubscriptionsForEventStmt, err = db.PrepareContext(ctx, listWebhookSubscriptionsForEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhookSubscriptionsForEvent: %w", err)
+	}
+	if q.lockUserStmt, err = db.PrepareContext(ctx, lockUser); err != nil {
+		return nil, fmt.Errorf("error preparing query LockUser: %w", err)
+	}
+	if q.markOutboxEventsPublishedStmt, err = db.PrepareContext(ctx, markOutboxEventsPublished); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkOutboxEventsPublished: %w", err)
+	}
+	if q.recordFailedLoginStmt, err = db.PrepareContext(ctx, recordFailedLogin); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordFailedLogin: %w", err)
+	}
+	if q.recordOutboxEventFailureStmt, err = db.PrepareContext(ctx, recordOutboxEventFailure); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordOutboxEventFailure: %w", err)
+	}
+	if q.recordWebhookDeliveryAttemptStmt, err = db.PrepareContext(ctx, recordWebhookDeliveryAttempt); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordWebhookDeliveryAttempt: %w", err)
+	}
+	if q.replayWebhookDeliveryStmt, err = db.PrepareContext(ctx, replayWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query ReplayWebhookDelivery: %w", err)
+	}
+	if q.resetLoginAttemptsStmt, err = db.PrepareContext(ctx, resetLoginAttempts); err != nil {
+		return nil, fmt.Errorf("error preparing query ResetLoginAttempts: %w", err)
+	}
+	if q.revokeAPIKeyStmt, err = db.PrepareContext(ctx, revokeAPIKey); err != nil {
+		return nil, fmt.Errorf("error preparing query RevokeAPIKey: %w", err)
+	}
+	if q.setInterestPostingTransferStmt, err = db.PrepareContext(ctx, setInterestPostingTransfer); err != nil {
+		return nil, fmt.Errorf("error preparing query SetInterestPostingTransfer: %w", err)
+	}
+	if q.setUserTOTPSecretStmt, err = db.PrepareContext(ctx, setUserTOTPSecret); err != nil {
+		return nil, fmt.Errorf("error preparing query SetUserTOTPSecret: %w", err)
+	}
+	if q.sumEntriesSinceStmt, err = db.PrepareContext(ctx, sumEntriesSince); err != nil {
+		return nil, fmt.Errorf("error preparing query SumEntriesSince: %w", err)
+	}
+	if q.sumInterestAccrualsStmt, err = db.PrepareContext(ctx, sumInterestAccruals); err != nil {
+		return nil, fmt.Errorf("error preparing query SumInterestAccruals: %w", err)
+	}
+	if q.sumInterestPostingsStmt, err = db.PrepareContext(ctx, sumInterestPostings); err != nil {
+		return nil, fmt.Errorf("error preparing query SumInterestPostings: %w", err)
+	}
+	if q.updateAPIKeyLastUsedStmt, err = db.PrepareContext(ctx, updateAPIKeyLastUsed); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateAPIKeyLastUsed: %w", err)
+	}
 	if q.updateAccountStmt, err = db.PrepareContext(ctx, updateAccount); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateAccount: %w", err)
 	}
+	if q.updateUserStmt, err = db.PrepareContext(ctx, updateUser); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateUser: %w", err)
+	}
+	if q.updateUserPasswordStmt, err = db.PrepareContext(ctx, updateUserPassword); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateUserPassword: %w", err)
+	}
+	if q.useEmailVerificationTokenStmt, err = db.PrepareContext(ctx, useEmailVerificationToken); err != nil {
+		return nil, fmt.Errorf("error preparing query UseEmailVerificationToken: %w", err)
+	}
+	if q.usePasswordResetTokenStmt, err = db.PrepareContext(ctx, usePasswordResetToken); err != nil {
+		return nil, fmt.Errorf("error preparing query UsePasswordResetToken: %w", err)
+	}
+	if q.useTOTPRecoveryCodeStmt, err = db.PrepareContext(ctx, useTOTPRecoveryCode); err != nil {
+		return nil, fmt.Errorf("error preparing query UseTOTPRecoveryCode: %w", err)
+	}
+	if q.useTOTPStepStmt, err = db.PrepareContext(ctx, useTOTPStep); err != nil {
+		return nil, fmt.Errorf("error preparing query UseTOTPStep: %w", err)
+	}
+	if q.verifyUserEmailStmt, err = db.PrepareContext(ctx, verifyUserEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query VerifyUserEmail: %w", err)
+	}
 	return &q, nil
 }
 
@@ -79,31 +268,146 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing addAccountBalanceStmt: %w", cerr)
 		}
 	}
+	if q.claimOutboxEventsStmt != nil {
+		if cerr := q.claimOutboxEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimOutboxEventsStmt: %w", cerr)
+		}
+	}
+	if q.claimWebhookDeliveriesStmt != nil {
+		if cerr := q.claimWebhookDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimWebhookDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.completeTransferBatchStmt != nil {
+		if cerr := q.completeTransferBatchStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing completeTransferBatchStmt: %w", cerr)
+		}
+	}
+	if q.countInterestAccrualsStmt != nil {
+		if cerr := q.countInterestAccrualsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countInterestAccrualsStmt: %w", cerr)
+		}
+	}
+	if q.createAPIKeyStmt != nil {
+		if cerr := q.createAPIKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAPIKeyStmt: %w", cerr)
+		}
+	}
 	if q.createAccountStmt != nil {
 		if cerr := q.createAccountStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createAccountStmt: %w", cerr)
 		}
 	}
+	if q.createAccountProductStmt != nil {
+		if cerr := q.createAccountProductStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAccountProductStmt: %w", cerr)
+		}
+	}
+	if q.createEmailVerificationTokenStmt != nil {
+		if cerr := q.createEmailVerificationTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createEmailVerificationTokenStmt: %w", cerr)
+		}
+	}
 	if q.createEntryStmt != nil {
 		if cerr := q.createEntryStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createEntryStmt: %w", cerr)
 		}
 	}
+	if q.createInterestAccrualStmt != nil {
+		if cerr := q.createInterestAccrualStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createInterestAccrualStmt: %w", cerr)
+		}
+	}
+	if q.createInterestPostingStmt != nil {
+		if cerr := q.createInterestPostingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createInterestPostingStmt: %w", cerr)
+		}
+	}
+	if q.createOutboxEventStmt != nil {
+		if cerr := q.createOutboxEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createOutboxEventStmt: %w", cerr)
+		}
+	}
+	if q.createPasswordResetTokenStmt != nil {
+		if cerr := q.createPasswordResetTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createPasswordResetTokenStmt: %w", cerr)
+		}
+	}
+	if q.createSavingsAccountStmt != nil {
+		if cerr := q.createSavingsAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createSavingsAccountStmt: %w", cerr)
+		}
+	}
+	if q.createSavingsProductStmt != nil {
+		if cerr := q.createSavingsProductStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createSavingsProductStmt: %w", cerr)
+		}
+	}
+	if q.createTOTPRecoveryCodeStmt != nil {
+		if cerr := q.createTOTPRecoveryCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createTOTPRecoveryCodeStmt: %w", cerr)
+		}
+	}
 	if q.createTransferStmt != nil {
 		if cerr := q.createTransferStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createTransferStmt: %w", cerr)
 		}
 	}
+	if q.createTransferBatchStmt != nil {
+		if cerr := q.createTransferBatchStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createTransferBatchStmt: %w", cerr)
+		}
+	}
+	if q.createTransferBatchItemStmt != nil {
+		if cerr := q.createTransferBatchItemStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createTransferBatchItemStmt: %w", cerr)
+		}
+	}
 	if q.createUserStmt != nil {
 		if cerr := q.createUserStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createUserStmt: %w", cerr)
 		}
 	}
+	if q.createWebhookDeliveryStmt != nil {
+		if cerr := q.createWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.createWebhookSubscriptionStmt != nil {
+		if cerr := q.createWebhookSubscriptionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookSubscriptionStmt: %w", cerr)
+		}
+	}
 	if q.deleteAccountStmt != nil {
 		if cerr := q.deleteAccountStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteAccountStmt: %w", cerr)
 		}
 	}
+	if q.deleteTOTPRecoveryCodesStmt != nil {
+		if cerr := q.deleteTOTPRecoveryCodesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteTOTPRecoveryCodesStmt: %w", cerr)
+		}
+	}
+	if q.deleteWebhookSubscriptionStmt != nil {
+		if cerr := q.deleteWebhookSubscriptionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteWebhookSubscriptionStmt: %w", cerr)
+		}
+	}
+	if q.enableUserTOTPStmt != nil {
+		if cerr := q.enableUserTOTPStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing enableUserTOTPStmt: %w", cerr)
+		}
+	}
+	if q.freezeAccountStmt != nil {
+		if cerr := q.freezeAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing freezeAccountStmt: %w", cerr)
+		}
+	}
+	if q.getAPIKeyByHashStmt != nil {
+		if cerr := q.getAPIKeyByHashStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAPIKeyByHashStmt: %w", cerr)
+		}
+	}
 	if q.getAccountStmt != nil {
 		if cerr := q.getAccountStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getAccountStmt: %w", cerr)
@@ -114,21 +418,71 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getAccountForUpdateStmt: %w", cerr)
 		}
 	}
+	if q.getAccountProductStmt != nil {
+		if cerr := q.getAccountProductStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAccountProductStmt: %w", cerr)
+		}
+	}
 	if q.getEntryStmt != nil {
 		if cerr := q.getEntryStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getEntryStmt: %w", cerr)
 		}
 	}
+	if q.getLastEntrySequenceStmt != nil {
+		if cerr := q.getLastEntrySequenceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLastEntrySequenceStmt: %w", cerr)
+		}
+	}
+	if q.getSavingsAccountStmt != nil {
+		if cerr := q.getSavingsAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSavingsAccountStmt: %w", cerr)
+		}
+	}
+	if q.getSavingsProductStmt != nil {
+		if cerr := q.getSavingsProductStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSavingsProductStmt: %w", cerr)
+		}
+	}
 	if q.getTransferStmt != nil {
 		if cerr := q.getTransferStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getTransferStmt: %w", cerr)
 		}
 	}
+	if q.getTransferBatchStmt != nil {
+		if cerr := q.getTransferBatchStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTransferBatchStmt: %w", cerr)
+		}
+	}
 	if q.getUserStmt != nil {
 		if cerr := q.getUserStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getUserStmt: %w", cerr)
 		}
 	}
+	if q.getUserByEmailStmt != nil {
+		if cerr := q.getUserByEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserByEmailStmt: %w", cerr)
+		}
+	}
+	if q.getWebhookSubscriptionStmt != nil {
+		if cerr := q.getWebhookSubscriptionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhookSubscriptionStmt: %w", cerr)
+		}
+	}
+	if q.invalidatePasswordResetTokensStmt != nil {
+		if cerr := q.invalidatePasswordResetTokensStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing invalidatePasswordResetTokensStmt: %w", cerr)
+		}
+	}
+	if q.listAPIKeysStmt != nil {
+		if cerr := q.listAPIKeysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAPIKeysStmt: %w", cerr)
+		}
+	}
+	if q.listAccountProductsStmt != nil {
+		if cerr := q.listAccountProductsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAccountProductsStmt: %w", cerr)
+		}
+	}
 	if q.listAccountsStmt != nil {
 		if cerr := q.listAccountsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listAccountsStmt: %w", cerr)
@@ -139,16 +493,166 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing listEntriesStmt: %w", cerr)
 		}
 	}
+	if q.listEntriesAfterStmt != nil {
+		if cerr := q.listEntriesAfterStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listEntriesAfterStmt: %w", cerr)
+		}
+	}
+	if q.listEntriesBetweenStmt != nil {
+		if cerr := q.listEntriesBetweenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listEntriesBetweenStmt: %w", cerr)
+		}
+	}
+	if q.listSavingsAccountsStmt != nil {
+		if cerr := q.listSavingsAccountsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listSavingsAccountsStmt: %w", cerr)
+		}
+	}
+	if q.listSavingsProductsStmt != nil {
+		if cerr := q.listSavingsProductsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listSavingsProductsStmt: %w", cerr)
+		}
+	}
+	if q.listTransferBatchItemsStmt != nil {
+		if cerr := q.listTransferBatchItemsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTransferBatchItemsStmt: %w", cerr)
+		}
+	}
 	if q.listTransfersStmt != nil {
 		if cerr := q.listTransfersStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listTransfersStmt: %w", cerr)
 		}
 	}
+	if q.listTransfersByIDsStmt != nil {
+		if cerr := q.listTransfersByIDsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTransfersByIDsStmt: %w", cerr)
+		}
+	}
+	if q.listWebhookDeliveriesStmt != nil {
+		if cerr := q.listWebhookDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhookDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.listWebhookSubscriptionsStmt != nil {
+		if cerr := q.listWebhookSubscriptionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhookSubscriptionsStmt: %w", cerr)
+		}
+	}
+	if q.listWebhookSubscriptionsForEventStmt != nil {
+		if cerr := q.listWebhookSubscriptionsForEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhookSubscriptionsForEventStmt: %w", cerr)
+		}
+	}
+	if q.lockUserStmt != nil {
+		if cerr := q.lockUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing lockUserStmt: %w", cerr)
+		}
+	}
+	if q.markOutboxEventsPublishedStmt != nil {
+		if cerr := q.markOutboxEventsPublishedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markOutboxEventsPublishedStmt: %w", cerr)
+		}
+	}
+	if q.recordFailedLoginStmt != nil {
+		if cerr := q.recordFailedLoginStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordFailedLoginStmt: %w", cerr)
+		}
+	}
+	if q.recordOutboxEventFailureStmt != nil {
+		if cerr := q.recordOutboxEventFailureStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordOutboxEventFailureStmt: %w", cerr)
+		}
+	}
+	if q.recordWebhookDeliveryAttemptStmt != nil {
+		if cerr := q.recordWebhookDeliveryAttemptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordWebhookDeliveryAttemptStmt: %w", cerr)
+		}
+	}
+	if q.replayWebhookDeliveryStmt != nil {
+		if cerr := q.replayWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing replayWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.resetLoginAttemptsStmt != nil {
+		if cerr := q.resetLoginAttemptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing resetLoginAttemptsStmt: %w", cerr)
+		}
+	}
+	if q.revokeAPIKeyStmt != nil {
+		if cerr := q.revokeAPIKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing revokeAPIKeyStmt: %w", cerr)
+		}
+	}
+	if q.setInterestPostingTransferStmt != nil {
+		if cerr := q.setInterestPostingTransferStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setInterestPostingTransferStmt: %w", cerr)
+		}
+	}
+	if q.setUserTOTPSecretStmt != nil {
+		if cerr := q.setUserTOTPSecretStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setUserTOTPSecretStmt: %w", cerr)
+		}
+	}
+	if q.sumEntriesSinceStmt != nil {
+		if cerr := q.sumEntriesSinceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sumEntriesSinceStmt: %w", cerr)
+		}
+	}
+	if q.sumInterestAccrualsStmt != nil {
+		if cerr := q.sumInterestAccrualsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sumInterestAccrualsStmt: %w", cerr)
+		}
+	}
+	if q.sumInterestPostingsStmt != nil {
+		if cerr := q.sumInterestPostingsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sumInterestPostingsStmt: %w", cerr)
+		}
+	}
+	if q.updateAPIKeyLastUsedStmt != nil {
+		if cerr := q.updateAPIKeyLastUsedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateAPIKeyLastUsedStmt: %w", cerr)
+		}
+	}
 	if q.updateAccountStmt != nil {
 		if cerr := q.updateAccountStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateAccountStmt: %w", cerr)
 		}
 	}
+	if q.updateUserStmt != nil {
+		if cerr := q.updateUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateUserStmt: %w", cerr)
+		}
+	}
+	if q.updateUserPasswordStmt != nil {
+		if cerr := q.updateUserPasswordStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateUserPasswordStmt: %w", cerr)
+		}
+	}
+	if q.useEmailVerificationTokenStmt != nil {
+		if cerr := q.useEmailVerificationTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing useEmailVerificationTokenStmt: %w", cerr)
+		}
+	}
+	if q.usePasswordResetTokenStmt != nil {
+		if cerr := q.usePasswordResetTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing usePasswordResetTokenStmt: %w", cerr)
+		}
+	}
+	if q.useTOTPRecoveryCodeStmt != nil {
+		if cerr := q.useTOTPRecoveryCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing useTOTPRecoveryCodeStmt: %w", cerr)
+		}
+	}
+	if q.useTOTPStepStmt != nil {
+		if cerr := q.useTOTPStepStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing useTOTPStepStmt: %w", cerr)
+		}
+	}
+	if q.verifyUserEmailStmt != nil {
+		if cerr := q.verifyUserEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing verifyUserEmailStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -186,43 +690,169 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                      DBTX
-	tx                      *sql.Tx
-	addAccountBalanceStmt   *sql.Stmt
-	createAccountStmt       *sql.Stmt
-	createEntryStmt         *sql.Stmt
-	createTransferStmt      *sql.Stmt
-	createUserStmt          *sql.Stmt
-	deleteAccountStmt       *sql.Stmt
-	getAccountStmt          *sql.Stmt
-	getAccountForUpdateStmt *sql.Stmt
-	getEntryStmt            *sql.Stmt
-	getTransferStmt         *sql.Stmt
-	getUserStmt             *sql.Stmt
-	listAccountsStmt        *sql.Stmt
-	listEntriesStmt         *sql.Stmt
-	listTransfersStmt       *sql.Stmt
-	updateAccountStmt       *sql.Stmt
+	db                                   DBTX
+	tx                                   *sql.Tx
+	addAccountBalanceStmt                *sql.Stmt
+	claimOutboxEventsStmt                *sql.Stmt
+	claimWebhookDeliveriesStmt           *sql.Stmt
+	completeTransferBatchStmt            *sql.Stmt
+	countInterestAccrualsStmt            *sql.Stmt
+	createAPIKeyStmt                     *sql.Stmt
+	createAccountStmt                    *sql.Stmt
+	createAccountProductStmt             *sql.Stmt
+	createEmailVerificationTokenStmt     *sql.Stmt
+	createEntryStmt                      *sql.Stmt
+	createInterestAccrualStmt            *sql.Stmt
+	createInterestPostingStmt            *sql.Stmt
+	createOutboxEventStmt                *sql.Stmt
+	createPasswordResetTokenStmt         *sql.Stmt
+	createSavingsAccountStmt             *sql.Stmt
+	createSavingsProductStmt             *sql.Stmt
+	createTOTPRecoveryCodeStmt           *sql.Stmt
+	createTransferStmt                   *sql.Stmt
+	createTransferBatchStmt              *sql.Stmt
+	createTransferBatchItemStmt          *sql.Stmt
+	createUserStmt                       *sql.Stmt
+	createWebhookDeliveryStmt            *sql.Stmt
+	createWebhookSubscriptionStmt        *sql.Stmt
+	deleteAccountStmt                    *sql.Stmt
+	deleteTOTPRecoveryCodesStmt          *sql.Stmt
+	deleteWebhookSubscriptionStmt        *sql.Stmt
+	enableUserTOTPStmt                   *sql.Stmt
+	freezeAccountStmt                    *sql.Stmt
+	getAPIKeyByHashStmt                  *sql.Stmt
+	getAccountStmt                       *sql.Stmt
+	getAccountForUpdateStmt              *sql.Stmt
+	getAccountProductStmt                *sql.Stmt
+	getEntryStmt                         *sql.Stmt
+	getLastEntrySequenceStmt             *sql.Stmt
+	getSavingsAccountStmt                *sql.Stmt
+	getSavingsProductStmt                *sql.Stmt
+	getTransferStmt                      *sql.Stmt
+	getTransferBatchStmt                 *sql.Stmt
+	getUserStmt                          *sql.Stmt
+	getUserByEmailStmt                   *sql.Stmt
+	getWebhookSubscriptionStmt           *sql.Stmt
+	invalidatePasswordResetTokensStmt    *sql.Stmt
+	listAPIKeysStmt                      *sql.Stmt
+	listAccountProductsStmt              *sql.Stmt
+	listAccountsStmt                     *sql.Stmt
+	listEntriesStmt                      *sql.Stmt
+	listEntriesAfterStmt                 *sql.Stmt
+	listEntriesBetweenStmt               *sql.Stmt
+	listSavingsAccountsStmt              *sql.Stmt
+	listSavingsProductsStmt              *sql.Stmt
+	listTransferBatchItemsStmt           *sql.Stmt
+	listTransfersStmt                    *sql.Stmt
+	listTransfersByIDsStmt               *sql.Stmt
+	listWebhookDeliveriesStmt            *sql.Stmt
+	listWebhookSubscriptionsStmt         *sql.Stmt
+	listWebhookSubscriptionsForEventStmt *sql.Stmt
+	lockUserStmt                         *sql.Stmt
+	markOutboxEventsPublishedStmt        *sql.Stmt
+	recordFailedLoginStmt                *sql.Stmt
+	recordOutboxEventFailureStmt         *sql.Stmt
+	recordWebhookDeliveryAttemptStmt     *sql.Stmt
+	replayWebhookDeliveryStmt            *sql.Stmt
+	resetLoginAttemptsStmt               *sql.Stmt
+	revokeAPIKeyStmt                     *sql.Stmt
+	setInterestPostingTransferStmt       *sql.Stmt
+	setUserTOTPSecretStmt                *sql.Stmt
+	sumEntriesSinceStmt                  *sql.Stmt
+	sumInterestAccrualsStmt              *sql.Stmt
+	sumInterestPostingsStmt              *sql.Stmt
+	updateAPIKeyLastUsedStmt             *sql.Stmt
+	updateAccountStmt                    *sql.Stmt
+	updateUserStmt                       *sql.Stmt
+	updateUserPasswordStmt               *sql.Stmt
+	useEmailVerificationTokenStmt        *sql.Stmt
+	usePasswordResetTokenStmt            *sql.Stmt
+	useTOTPRecoveryCodeStmt              *sql.Stmt
+	useTOTPStepStmt                      *sql.Stmt
+	verifyUserEmailStmt                  *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                      tx,
-		tx:                      tx,
-		addAccountBalanceStmt:   q.addAccountBalanceStmt,
-		createAccountStmt:       q.createAccountStmt,
-		createEntryStmt:         q.createEntryStmt,
-		createTransferStmt:      q.createTransferStmt,
-		createUserStmt:          q.createUserStmt,
-		deleteAccountStmt:       q.deleteAccountStmt,
-		getAccountStmt:          q.getAccountStmt,
-		getAccountForUpdateStmt: q.getAccountForUpdateStmt,
-		getEntryStmt:            q.getEntryStmt,
-		getTransferStmt:         q.getTransferStmt,
-		getUserStmt:             q.getUserStmt,
-		listAccountsStmt:        q.listAccountsStmt,
-		listEntriesStmt:         q.listEntriesStmt,
-		listTransfersStmt:       q.listTransfersStmt,
-		updateAccountStmt:       q.updateAccountStmt,
+		db:                                   tx,
+		tx:                                   tx,
+		addAccountBalanceStmt:                q.addAccountBalanceStmt,
+		claimOutboxEventsStmt:                q.claimOutboxEventsStmt,
+		claimWebhookDeliveriesStmt:           q.claimWebhookDeliveriesStmt,
+		completeTransferBatchStmt:            q.completeTransferBatchStmt,
+		countInterestAccrualsStmt:            q.countInterestAccrualsStmt,
+		createAPIKeyStmt:                     q.createAPIKeyStmt,
+		createAccountStmt:                    q.createAccountStmt,
+		createAccountProductStmt:             q.createAccountProductStmt,
+		createEmailVerificationTokenStmt:     q.createEmailVerificationTokenStmt,
+		createEntryStmt:                      q.createEntryStmt,
+		createInterestAccrualStmt:            q.createInterestAccrualStmt,
+		createInterestPostingStmt:            q.createInterestPostingStmt,
+		createOutboxEventStmt:                q.createOutboxEventStmt,
+		createPasswordResetTokenStmt:         q.createPasswordResetTokenStmt,
+		createSavingsAccountStmt:             q.createSavingsAccountStmt,
+		createSavingsProductStmt:             q.createSavingsProductStmt,
+		createTOTPRecoveryCodeStmt:           q.createTOTPRecoveryCodeStmt,
+		createTransferStmt:                   q.createTransferStmt,
+		createTransferBatchStmt:              q.createTransferBatchStmt,
+		createTransferBatchItemStmt:          q.createTransferBatchItemStmt,
+		createUserStmt:                       q.createUserStmt,
+		createWebhookDeliveryStmt:            q.createWebhookDeliveryStmt,
+		createWebhookSubscriptionStmt:        q.createWebhookSubscriptionStmt,
+		deleteAccountStmt:                    q.deleteAccountStmt,
+		deleteTOTPRecoveryCodesStmt:          q.deleteTOTPRecoveryCodesStmt,
+		deleteWebhookSubscriptionStmt:        q.deleteWebhookSubscriptionStmt,
+		enableUserTOTPStmt:                   q.enableUserTOTPStmt,
+		freezeAccountStmt:                    q.freezeAccountStmt,
+		getAPIKeyByHashStmt:                  q.getAPIKeyByHashStmt,
+		getAccountStmt:                       q.getAccountStmt,
+		getAccountForUpdateStmt:              q.getAccountForUpdateStmt,
+		getAccountProductStmt:                q.getAccountProductStmt,
+		getEntryStmt:                         q.getEntryStmt,
+		getLastEntrySequenceStmt:             q.getLastEntrySequenceStmt,
+		getSavingsAccountStmt:                q.getSavingsAccountStmt,
+		getSavingsProductStmt:                q.getSavingsProductStmt,
+		getTransferStmt:                      q.getTransferStmt,
+		getTransferBatchStmt:                 q.getTransferBatchStmt,
+		getUserStmt:                          q.getUserStmt,
+		getUserByEmailStmt:                   q.getUserByEmailStmt,
+		getWebhookSubscriptionStmt:           q.getWebhookSubscriptionStmt,
+		invalidatePasswordResetTokensStmt:    q.invalidatePasswordResetTokensStmt,
+		listAPIKeysStmt:                      q.listAPIKeysStmt,
+		listAccountProductsStmt:              q.listAccountProductsStmt,
+		listAccountsStmt:                     q.listAccountsStmt,
+		listEntriesStmt:                      q.listEntriesStmt,
+		listEntriesAfterStmt:                 q.listEntriesAfterStmt,
+		listEntriesBetweenStmt:               q.listEntriesBetweenStmt,
+		listSavingsAccountsStmt:              q.listSavingsAccountsStmt,
+		listSavingsProductsStmt:              q.listSavingsProductsStmt,
+		listTransferBatchItemsStmt:           q.listTransferBatchItemsStmt,
+		listTransfersStmt:                    q.listTransfersStmt,
+		listTransfersByIDsStmt:               q.listTransfersByIDsStmt,
+		listWebhookDeliveriesStmt:            q.listWebhookDeliveriesStmt,
+		listWebhookSubscriptionsStmt:         q.listWebhookSubscriptionsStmt,
+		listWebhookSubscriptionsForEventStmt: q.listWebhookSubscriptionsForEventStmt,
+		lockUserStmt:                         q.lockUserStmt,
+		markOutboxEventsPublishedStmt:        q.markOutboxEventsPublishedStmt,
+		recordFailedLoginStmt:                q.recordFailedLoginStmt,
+		recordOutboxEventFailureStmt:         q.recordOutboxEventFailureStmt,
+		recordWebhookDeliveryAttemptStmt:     q.recordWebhookDeliveryAttemptStmt,
+		replayWebhookDeliveryStmt:            q.replayWebhookDeliveryStmt,
+		resetLoginAttemptsStmt:               q.resetLoginAttemptsStmt,
+		revokeAPIKeyStmt:                     q.revokeAPIKeyStmt,
+		setInterestPostingTransferStmt:       q.setInterestPostingTransferStmt,
+		setUserTOTPSecretStmt:                q.setUserTOTPSecretStmt,
+		sumEntriesSinceStmt:                  q.sumEntriesSinceStmt,
+		sumInterestAccrualsStmt:              q.sumInterestAccrualsStmt,
+		sumInterestPostingsStmt:              q.sumInterestPostingsStmt,
+		updateAPIKeyLastUsedStmt:             q.updateAPIKeyLastUsedStmt,
+		updateAccountStmt:                    q.updateAccountStmt,
+		updateUserStmt:                       q.updateUserStmt,
+		updateUserPasswordStmt:               q.updateUserPasswordStmt,
+		useEmailVerificationTokenStmt:        q.useEmailVerificationTokenStmt,
+		usePasswordResetTokenStmt:            q.usePasswordResetTokenStmt,
+		useTOTPRecoveryCodeStmt:              q.useTOTPRecoveryCodeStmt,
+		useTOTPStepStmt:                      q.useTOTPStepStmt,
+		verifyUserEmailStmt:                  q.verifyUserEmailStmt,
 	}
 }
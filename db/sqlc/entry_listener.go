@@ -0,0 +1,190 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+	"sync"
+	"time"
+)
+
+const (
+	// entriesChannel is the channel the entries_notify trigger notifies new entries on
+	entriesChannel = "entries"
+
+	// entrySubscriptionBuffer is how many entries a subscriber may fall behind before it is dropped
+	entrySubscriptionBuffer = 64
+)
+
+// ErrEntryNotificationsDisabled is returned by SubscribeEntries when the store does not listen for new entries
+var ErrEntryNotificationsDisabled = errors.New("entry notifications are not enabled")
+
+// entryHub fans new entries out to the subscriptions of their account
+type entryHub struct {
+	mu            sync.Mutex
+	subscriptions map[int64]map[chan Entry]struct{}
+}
+
+func newEntryHub() *entryHub {
+	return &entryHub{subscriptions: make(map[int64]map[chan Entry]struct{})}
+}
+
+// subscribe returns a channel receiving the new entries of an account. The channel is closed once ctx is done,
+// and earlier when entries may have been missed, so subscribers catch up from the entries they last received
+func (hub *entryHub) subscribe(ctx context.Context, accountID int64) <-chan Entry {
+	ch := make(chan Entry, entrySubscriptionBuffer)
+
+	hub.mu.Lock()
+	if hub.subscriptions[accountID] == nil {
+		hub.subscriptions[accountID] = make(map[chan Entry]struct{})
+	}
+	hub.subscriptions[accountID][ch] = struct{}{}
+	hub.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		hub.remove(accountID, ch)
+	}()
+
+	return ch
+}
+
+// remove closes a subscription unless it was already closed, hub.mu must be held
+func (hub *entryHub) remove(accountID int64, ch chan Entry) {
+	if _, ok := hub.subscriptions[accountID][ch]; !ok {
+		return
+	}
+
+	delete(hub.subscriptions[accountID], ch)
+	if len(hub.subscriptions[accountID]) == 0 {
+		delete(hub.subscriptions, accountID)
+	}
+	close(ch)
+}
+
+// publish sends an entry to the subscriptions of its account without blocking,
+// a subscription too far behind is closed instead
+func (hub *entryHub) publish(entry Entry) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for ch := range hub.subscriptions[entry.AccountID] {
+		select {
+		case ch <- entry:
+		default:
+			hub.remove(entry.AccountID, ch)
+		}
+	}
+}
+
+// closeAll closes every subscription, for when notifications may have been lost
+func (hub *entryHub) closeAll() {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for accountID, subscriptions := range hub.subscriptions {
+		for ch := range subscriptions {
+			hub.remove(accountID, ch)
+		}
+	}
+}
+
+// entryNotification is the payload of the entries_notify trigger
+type entryNotification struct {
+	ID        int64     `json:"id"`
+	AccountID int64     `json:"account_id"`
+	Sequence  int64     `json:"sequence"`
+	Amount    int64     `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// EntryListener receives new entries from the entries_notify trigger over a dedicated connection
+type EntryListener struct {
+	listener *pq.Listener
+	hub      *entryHub
+	logger   zerolog.Logger
+}
+
+// NewEntryListener creates a listener connecting to the database at dataSource, it listens once Run is called
+func NewEntryListener(dataSource string, logger zerolog.Logger) *EntryListener {
+	entryListener := &EntryListener{
+		hub:    newEntryHub(),
+		logger: logger,
+	}
+
+	entryListener.listener = pq.NewListener(dataSource, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.Error().Err(err).Msg("entry listener connection failed")
+		}
+	})
+
+	return entryListener
+}
+
+// Run delivers new entries to subscribers until ctx is done, then closes the connection
+func (entryListener *EntryListener) Run(ctx context.Context) {
+	defer entryListener.hub.closeAll()
+	defer entryListener.listener.Close()
+
+	go func() {
+		// Listen blocks until the connection is established, the channel is listened again after reconnecting
+		err := entryListener.listener.Listen(entriesChannel)
+		if err != nil && !errors.Is(err, pq.ErrChannelAlreadyOpen) && ctx.Err() == nil {
+			entryListener.logger.Error().Err(err).Msg("cannot listen for entries")
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification, ok := <-entryListener.listener.Notify:
+			if !ok {
+				return
+			}
+
+			// a nil notification follows a reconnect, anything sent meanwhile was lost
+			if notification == nil {
+				entryListener.hub.closeAll()
+				continue
+			}
+
+			var payload entryNotification
+			err := json.Unmarshal([]byte(notification.Extra), &payload)
+			if err != nil {
+				entryListener.logger.Error().Err(err).Str("payload", notification.Extra).Msg("cannot decode entry notification")
+				continue
+			}
+
+			entryListener.hub.publish(Entry{
+				ID:        payload.ID,
+				AccountID: payload.AccountID,
+				Sequence:  payload.Sequence,
+				Amount:    payload.Amount,
+				CreatedAt: payload.CreatedAt,
+			})
+		}
+	}
+}
+
+// WithEntryListener makes SubscribeEntries stream the entries received by listener
+func WithEntryListener(listener *EntryListener) StoreOption {
+	return func(store *SQLStore) {
+		store.entries = listener.hub
+	}
+}
+
+// SubscribeEntries returns a channel receiving the entries of an account as they are committed.
+// The channel is closed once ctx is done, or earlier when entries may have been missed
+func (store *SQLStore) SubscribeEntries(ctx context.Context, accountID int64) (<-chan Entry, error) {
+	if store.entries == nil {
+		return nil, ErrEntryNotificationsDisabled
+	}
+
+	return store.entries.subscribe(ctx, accountID), nil
+}
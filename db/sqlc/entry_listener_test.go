@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestEntryHub(t *testing.T) {
+	hub := newEntryHub()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	entries := hub.subscribe(ctx, 1)
+	others := hub.subscribe(context.Background(), 2)
+
+	hub.publish(Entry{ID: 1, AccountID: 1, Amount: 10})
+	hub.publish(Entry{ID: 2, AccountID: 2, Amount: 20})
+
+	require.Equal(t, int64(1), (<-entries).ID)
+	require.Equal(t, int64(2), (<-others).ID)
+
+	// the subscription ends with its context
+	cancel()
+	require.Eventually(t, func() bool {
+		select {
+		case _, ok := <-entries:
+			return !ok
+		default:
+			return false
+		}
+	}, time.Second, 5*time.Millisecond)
+
+	// a subscriber too far behind is dropped
+	for i := 0; i <= entrySubscriptionBuffer; i++ {
+		hub.publish(Entry{ID: int64(i + 3), AccountID: 2})
+	}
+
+	received := 0
+	for range others {
+		received++
+	}
+	require.Equal(t, entrySubscriptionBuffer, received)
+
+	hub.mu.Lock()
+	require.Empty(t, hub.subscriptions)
+	hub.mu.Unlock()
+}
+
+func TestEntryHubCloseAll(t *testing.T) {
+	hub := newEntryHub()
+	entries := hub.subscribe(context.Background(), 1)
+
+	hub.closeAll()
+	_, ok := <-entries
+	require.False(t, ok)
+
+	// publishing after the subscription ended is a no-op
+	hub.publish(Entry{ID: 1, AccountID: 1})
+}
+
+func TestMemoryStoreSubscribeEntries(t *testing.T) {
+	store := NewMemoryStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newAccount := func() Account {
+		user, err := store.CreateUser(ctx, CreateUserParams{
+			Username:       util.RandomOwner() + util.RandomString(6),
+			HashedPassword: util.RandomString(32),
+			FullName:       util.RandomOwner(),
+			Email:          util.RandomEmail(),
+		})
+		require.NoError(t, err)
+
+		account, err := store.CreateAccount(ctx, CreateAccountParams{Owner: user.Username, Balance: 100, Currency: util.USD, ProductCode: AccountProductChecking})
+		require.NoError(t, err)
+		return account
+	}
+
+	account1 := newAccount()
+	account2 := newAccount()
+
+	entries, err := store.SubscribeEntries(ctx, account1.ID)
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(ctx, TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10})
+	require.NoError(t, err)
+
+	entry := <-entries
+	require.Equal(t, result.FromEntry.ID, entry.ID)
+	require.Equal(t, int64(-10), entry.Amount)
+
+	select {
+	case entry := <-entries:
+		t.Fatalf("unexpected entry %d of account %d", entry.ID, entry.AccountID)
+	default:
+	}
+}
+
+func TestSQLStoreSubscribeEntriesDisabled(t *testing.T) {
+	store := NewStore(testDb)
+
+	_, err := store.SubscribeEntries(context.Background(), 1)
+	require.ErrorIs(t, err, ErrEntryNotificationsDisabled)
+}
+
+func TestEntryListener(t *testing.T) {
+	listener := NewEntryListener(testDbSource, zerolog.Nop())
+	store := NewStore(testDb, WithEntryListener(listener))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.Run(ctx)
+	}()
+
+	account := createRandomAccount(t)
+	entries, err := store.SubscribeEntries(ctx, account.ID)
+	require.NoError(t, err)
+
+	// the listener connects in the background, entries created before it listens are not notified
+	var entry Entry
+	require.Eventually(t, func() bool {
+		created := createRandomEntry(t, account.ID)
+		select {
+		case entry = <-entries:
+			return entry.ID <= created.ID
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, account.ID, entry.AccountID)
+	require.NotZero(t, entry.CreatedAt)
+
+	cancel()
+	<-done
+
+	for range entries {
+	}
+}
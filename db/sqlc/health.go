@@ -0,0 +1,18 @@
+package db
+
+import (
+	"context"
+)
+
+const getMigrationVersion = `SELECT version, dirty FROM schema_migrations LIMIT 1`
+
+// Ping verifies a connection to the database is still alive
+func (store *SQLStore) Ping(ctx context.Context) error {
+	return store.db.PingContext(ctx)
+}
+
+// MigrationVersion returns the version recorded by the last migration and whether it failed midway
+func (store *SQLStore) MigrationVersion(ctx context.Context) (version int64, dirty bool, err error) {
+	err = store.db.QueryRowContext(ctx, getMigrationVersion).Scan(&version, &dirty)
+	return
+}
@@ -0,0 +1,27 @@
+package db
+
+import (
+	"context"
+	"github.com/AbdRaqeeb/simple_bank/db/migration"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	store := NewStore(testDb)
+
+	err := store.Ping(context.Background())
+	require.NoError(t, err)
+}
+
+func TestMigrationVersion(t *testing.T) {
+	store := NewStore(testDb)
+
+	version, dirty, err := store.MigrationVersion(context.Background())
+	require.NoError(t, err)
+	require.False(t, dirty)
+
+	latestVersion, err := migration.LatestVersion()
+	require.NoError(t, err)
+	require.Equal(t, int64(latestVersion), version)
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+	"time"
+)
+
+var tracer = otel.Tracer("github.com/AbdRaqeeb/simple_bank/db/sqlc")
+
+// instrumentedDB wraps a DBTX, records a span for every query executed through it
+// and logs the query with the logger carried by the context. The spans and logged durations
+// cover executing the statement, not reading the rows of :many queries
+type instrumentedDB struct {
+	db DBTX
+}
+
+func newInstrumentedDB(db DBTX) DBTX {
+	return &instrumentedDB{db: db}
+}
+
+func (t *instrumentedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span, start := startQuery(ctx, query)
+	defer span.End()
+
+	result, err := t.db.ExecContext(ctx, query, args...)
+	endQuery(ctx, span, query, start, err)
+	return result, err
+}
+
+func (t *instrumentedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return t.db.PrepareContext(ctx, query)
+}
+
+// QueryContext records a span covering the execution of the statement only. The rows are scanned after it returns
+// and *sql.Rows cannot be wrapped, so the span ends before they are read and errors met while iterating them are
+// not recorded on it, the Queries method returns them to its caller
+func (t *instrumentedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span, start := startQuery(ctx, query)
+	defer span.End()
+
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	endQuery(ctx, span, query, start, err)
+	return rows, err
+}
+
+func (t *instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span, start := startQuery(ctx, query)
+	defer span.End()
+
+	row := t.db.QueryRowContext(ctx, query, args...)
+	endQuery(ctx, span, query, start, row.Err())
+	return row
+}
+
+func startQuery(ctx context.Context, query string) (context.Context, trace.Span, time.Time) {
+	name := queryName(query)
+
+	ctx, span := tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationKey.String(name),
+			semconv.DBStatementKey.String(query),
+		),
+	)
+
+	return ctx, span, time.Now()
+}
+
+func endQuery(ctx context.Context, span trace.Span, query string, start time.Time, err error) {
+	recordError(span, err)
+
+	event := zerolog.Ctx(ctx).Debug()
+	if err != nil && err != sql.ErrNoRows {
+		event = zerolog.Ctx(ctx).Warn().Err(err)
+	}
+
+	event.
+		Str("query", queryName(query)).
+		Dur("duration", time.Since(start)).
+		Msg("db query")
+}
+
+// queryName extracts the sqlc query name from the "-- name: GetAccount :one" header,
+// so every span is named after the Queries method that issued it
+func queryName(query string) string {
+	const prefix = "-- name: "
+
+	if !strings.HasPrefix(query, prefix) {
+		return "query"
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(query, prefix))
+	if len(fields) == 0 {
+		return "query"
+	}
+
+	return fields[0]
+}
+
+// recordError marks the span as failed, sql.ErrNoRows is an expected result and not an error
+func recordError(span trace.Span, err error) {
+	if err == nil || err == sql.ErrNoRows {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// startTxSpan starts a span for a store transaction
+func startTxSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
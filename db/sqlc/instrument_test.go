@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+func TestQueryName(t *testing.T) {
+	require.Equal(t, "GetAccount", queryName(getAccount))
+	require.Equal(t, "AddAccountBalance", queryName(addAccountBalance))
+	require.Equal(t, "CreateUser", queryName(createUser))
+	require.Equal(t, "query", queryName("SELECT 1"))
+	require.Equal(t, "query", queryName("-- name: "))
+}
+
+func TestRecordError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	for _, err := range []error{nil, sql.ErrNoRows, ErrInsufficientFunds} {
+		_, span := tracer.Start(context.Background(), "TransferTx")
+		recordError(span, err)
+		span.End()
+	}
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	// no error and sql.ErrNoRows leave the span unset
+	for _, span := range spans[:2] {
+		require.Equal(t, codes.Unset, span.Status().Code)
+		require.Empty(t, span.Events())
+	}
+
+	require.Equal(t, codes.Error, spans[2].Status().Code)
+	require.Equal(t, ErrInsufficientFunds.Error(), spans[2].Status().Description)
+	require.Len(t, spans[2].Events(), 1)
+	require.Equal(t, "exception", spans[2].Events()[0].Name)
+}
+
+func TestSQLStoreTxSpanRecordsError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	// every transaction on a closed database fails to begin
+	closed, err := sql.Open("postgres", testDbSource)
+	require.NoError(t, err)
+	require.NoError(t, closed.Close())
+
+	store := NewStore(closed)
+	_, err = store.TransferTx(context.Background(), TransferTxParams{FromAccountID: 1, ToAccountID: 2, Amount: 10})
+	require.Error(t, err)
+
+	statuses := make(map[string]codes.Code)
+	for _, span := range recorder.Ended() {
+		statuses[span.Name()] = span.Status().Code
+	}
+	require.Equal(t, codes.Error, statuses["execTx"])
+	require.Equal(t, codes.Error, statuses["TransferTx"])
+}
@@ -0,0 +1,258 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"go.opentelemetry.io/otel/attribute"
+	"math/big"
+	"time"
+)
+
+const (
+	// DayCountActual365 accrues 1/365 of the annual rate every day
+	DayCountActual365 = "actual/365"
+	// DayCountActual360 accrues 1/360 of the annual rate every day
+	DayCountActual360 = "actual/360"
+	// DayCountActualActual accrues 1/365 of the annual rate every day, 1/366 in leap years
+	DayCountActualActual = "actual/actual"
+)
+
+// microsPerUnit is the number of accrued micros in a minor unit
+const microsPerUnit = 1000000
+
+// IsSupportedDayCount returns if interest can accrue with a day-count convention
+func IsSupportedDayCount(dayCount string) bool {
+	switch dayCount {
+	case DayCountActual365, DayCountActual360, DayCountActualActual:
+		return true
+	}
+	return false
+}
+
+// DailyInterestMicros returns the interest accrued on balance during date at an annual rate in basis points,
+// in millionths of the minor unit and rounded down. Negative balances earn nothing
+func DailyInterestMicros(balance int64, annualRateBps int32, dayCount string, date time.Time) (int64, error) {
+	var yearDays int64
+	switch dayCount {
+	case DayCountActual365:
+		yearDays = 365
+	case DayCountActual360:
+		yearDays = 360
+	case DayCountActualActual:
+		yearDays = int64(time.Date(date.Year(), 12, 31, 0, 0, 0, 0, time.UTC).YearDay())
+	default:
+		return 0, fmt.Errorf("unsupported day count %q", dayCount)
+	}
+
+	if balance <= 0 || annualRateBps <= 0 {
+		return 0, nil
+	}
+
+	// balance * rate / 10000 / yearDays overflows int64 for large balances before the division
+	micros := new(big.Int).Mul(big.NewInt(balance), big.NewInt(int64(annualRateBps)))
+	micros.Mul(micros, big.NewInt(microsPerUnit))
+	micros.Quo(micros, big.NewInt(10000*yearDays))
+	if !micros.IsInt64() {
+		return 0, fmt.Errorf("interest on %d: %w", balance, util.ErrAmountOverflow)
+	}
+
+	return micros.Int64(), nil
+}
+
+// interestDate returns the UTC day of t, interest accrues and posts on UTC days
+func interestDate(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// interestPeriod returns the first day of the UTC month of t
+func interestPeriod(t time.Time) time.Time {
+	year, month, _ := t.UTC().Date()
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// AccrueInterestTxParams is a savings account and the day interest accrues for
+type AccrueInterestTxParams struct {
+	AccountID int64     `json:"account_id"`
+	Date      time.Time `json:"date"`
+}
+
+// AccrueInterestTxResult is the accrual of a day, Accrued is false when the day was accrued before
+type AccrueInterestTxResult struct {
+	Accrual InterestAccrual `json:"accrual"`
+	Accrued bool            `json:"accrued"`
+}
+
+// AccrueInterestTx accrues a day of interest on the end of day balance of a savings account, the current balance less
+// the entries created since the end of the day. It runs within a repeatable read transaction so the balance and the
+// entries are of the same snapshot. A day accrues once, rerunning it leaves the first accrual unchanged.
+// sql.ErrNoRows is returned when the account is not a savings account
+func (store *SQLStore) AccrueInterestTx(ctx context.Context, arg AccrueInterestTxParams) (AccrueInterestTxResult, error) {
+	var result AccrueInterestTxResult
+
+	ctx, span := startTxSpan(ctx, "AccrueInterestTx", attribute.Int64("interest.account_id", arg.AccountID))
+	defer span.End()
+
+	err := store.execTx(ctx, sql.LevelRepeatableRead, func(q *Queries) error {
+		result = AccrueInterestTxResult{}
+
+		savings, err := q.GetSavingsAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		product, err := q.GetSavingsProduct(ctx, savings.ProductCode)
+		if err != nil {
+			return err
+		}
+
+		account, err := q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		date := interestDate(arg.Date)
+		since, err := q.SumEntriesSince(ctx, SumEntriesSinceParams{AccountID: account.ID, CreatedAt: date.AddDate(0, 0, 1)})
+		if err != nil {
+			return err
+		}
+
+		params, err := newInterestAccrualParams(account, product, date, since)
+		if err != nil {
+			return err
+		}
+
+		result.Accrual, err = q.CreateInterestAccrual(ctx, params)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		result.Accrued = err == nil
+		return err
+	})
+	recordError(span, err)
+
+	return result, err
+}
+
+func newInterestAccrualParams(account Account, product SavingsProduct, date time.Time, since int64) (CreateInterestAccrualParams, error) {
+	balance := account.Balance - since
+
+	micros, err := DailyInterestMicros(balance, product.AnnualRateBps, product.DayCount, date)
+	if err != nil {
+		return CreateInterestAccrualParams{}, err
+	}
+
+	return CreateInterestAccrualParams{
+		AccountID:     account.ID,
+		AccrualDate:   date,
+		Balance:       balance,
+		AnnualRateBps: product.AnnualRateBps,
+		DayCount:      product.DayCount,
+		AmountMicros:  micros,
+	}, nil
+}
+
+// PostInterestTxParams is a savings account and a day of the month interest is posted for
+type PostInterestTxParams struct {
+	AccountID int64     `json:"account_id"`
+	Period    time.Time `json:"period"`
+}
+
+// PostInterestTxResult is the posting of a month, Transfer is set when interest was due.
+// Posted is false when the month was posted before
+type PostInterestTxResult struct {
+	Posting  InterestPosting   `json:"posting"`
+	Transfer *TransferTxResult `json:"transfer"`
+	Posted   bool              `json:"posted"`
+}
+
+// PostInterestTx posts the interest accrued on a savings account up to the end of a month as a transfer from the
+// expense account of its product. Whole minor units are posted, the fraction left accrues into the next posting,
+// and so do the days accrued after a later month was posted. A month posts once, rerunning it transfers nothing.
+// sql.ErrNoRows is returned when the account is not a savings account
+func (store *SQLStore) PostInterestTx(ctx context.Context, arg PostInterestTxParams) (PostInterestTxResult, error) {
+	var result PostInterestTxResult
+
+	ctx, span := startTxSpan(ctx, "PostInterestTx", attribute.Int64("interest.account_id", arg.AccountID))
+	defer span.End()
+
+	err := store.execTx(ctx, store.transferIsolation, func(q *Queries) error {
+		result = PostInterestTxResult{}
+
+		// postings of different months of an account wait for each other, each posts what the others did not
+		_, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		savings, err := q.GetSavingsAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		product, err := q.GetSavingsProduct(ctx, savings.ProductCode)
+		if err != nil {
+			return err
+		}
+
+		period := interestPeriod(arg.Period)
+		accrued, err := q.SumInterestAccruals(ctx, SumInterestAccrualsParams{AccountID: savings.AccountID, AccrualDate: period.AddDate(0, 1, 0)})
+		if err != nil {
+			return err
+		}
+
+		posted, err := q.SumInterestPostings(ctx, savings.AccountID)
+		if err != nil {
+			return err
+		}
+
+		amount := interestDue(accrued, posted)
+		result.Posting, err = q.CreateInterestPosting(ctx, CreateInterestPostingParams{
+			AccountID: savings.AccountID,
+			Period:    period,
+			Amount:    amount,
+		})
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		result.Posted = true
+
+		if amount == 0 {
+			return nil
+		}
+
+		transfer, err := transferTx(ctx, q, TransferTxParams{
+			FromAccountID: product.ExpenseAccountID,
+			ToAccountID:   savings.AccountID,
+			Amount:        amount,
+		})
+		if err != nil {
+			return err
+		}
+		result.Transfer = &transfer
+
+		result.Posting, err = q.SetInterestPostingTransfer(ctx, SetInterestPostingTransferParams{
+			AccountID:  savings.AccountID,
+			Period:     period,
+			TransferID: sql.NullInt64{Int64: transfer.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+	recordError(span, err)
+
+	return result, err
+}
+
+// interestDue returns the whole minor units of the accrued micros that were not posted yet
+func interestDue(accruedMicros, posted int64) int64 {
+	due := accruedMicros/microsPerUnit - posted
+	if due < 0 {
+		return 0
+	}
+	return due
+}
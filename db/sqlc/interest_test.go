@@ -0,0 +1,57 @@
+package db
+
+import (
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDailyInterestMicros(t *testing.T) {
+	date := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+	leap := time.Date(2028, 3, 14, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name     string
+		balance  int64
+		rate     int32
+		dayCount string
+		date     time.Time
+		expected int64
+	}{
+		// 1000.00 at 3.65% is 10 cents a day over 365 days
+		{"Actual 365", 100000, 365, DayCountActual365, date, 10000000},
+		{"Actual 360", 100000, 360, DayCountActual360, date, 10000000},
+		{"Actual Actual", 100000, 365, DayCountActualActual, date, 10000000},
+		{"Actual Actual Leap Year", 100000, 366, DayCountActualActual, leap, 10000000},
+		{"Actual 365 Leap Year", 100000, 365, DayCountActual365, leap, 10000000},
+		{"Actual Actual Leap Year Day", 100000, 365, DayCountActualActual, leap, 9972677},
+		// 12.34 at 4.25% is 0.1436849... cents a day, rounded down to the micro
+		{"Rounded Down", 1234, 425, DayCountActual365, date, 143684},
+		{"Zero Balance", 0, 425, DayCountActual365, date, 0},
+		{"Negative Balance", -100000, 425, DayCountActual365, date, 0},
+		{"Zero Rate", 100000, 0, DayCountActual365, date, 0},
+		// the intermediate product does not fit in int64 but the daily interest does
+		{"Large Balance", math.MaxInt64 / 1000, 100, DayCountActual365, date, 252695124297391095},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			micros, err := DailyInterestMicros(tc.balance, tc.rate, tc.dayCount, tc.date)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, micros)
+		})
+	}
+
+	_, err := DailyInterestMicros(100, 425, "30/360", date)
+	require.Error(t, err)
+
+	_, err = DailyInterestMicros(math.MaxInt64, 10000, DayCountActual360, date)
+	require.ErrorIs(t, err, util.ErrAmountOverflow)
+}
+
+func TestInterestDue(t *testing.T) {
+	require.Equal(t, int64(0), interestDue(999999, 0))
+	require.Equal(t, int64(1), interestDue(1999999, 0))
+	require.Equal(t, int64(2), interestDue(5000000, 3))
+	require.Equal(t, int64(0), interestDue(2000000, 3))
+}
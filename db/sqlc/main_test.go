@@ -12,6 +12,7 @@ import (
 
 var testQueries *Queries
 var testDb *sql.DB
+var testDbSource string
 
 func TestMain(m *testing.M) {
 	var err error
@@ -26,6 +27,7 @@ func TestMain(m *testing.M) {
 		log.Fatal("can't connect to database", err)
 	}
 
+	testDbSource = config.DbSource
 	testQueries = New(testDb)
 
 	os.Exit(m.Run())
@@ -0,0 +1,2243 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/AbdRaqeeb/simple_bank/db/migration"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/lib/pq"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	uniqueViolation        = "23505"
+	foreignKeyViolation    = "23503"
+	checkViolation         = "23514"
+	numericValueOutOfRange = "22003"
+)
+
+// MemoryStore is a thread safe in-memory Store for tests and local development.
+// It mirrors the constraints of the postgres schema and reports violations as *pq.Error
+// with the same codes and constraint names, and missing rows as sql.ErrNoRows
+type MemoryStore struct {
+	mu sync.RWMutex
+
+	// entryHub streams new entries to SubscribeEntries like the entries_notify trigger
+	entryHub *entryHub
+
+	users     map[string]User
+	accounts  map[int64]Account
+	entries   map[int64]Entry
+	transfers map[int64]Transfer
+
+	passwordResetTokens     map[int64]PasswordResetToken
+	emailVerificationTokens map[int64]EmailVerificationToken
+	totpRecoveryCodes       map[int64]TotpRecoveryCode
+	apiKeys                 map[int64]ApiKey
+	webhookSubscriptions    map[int64]WebhookSubscription
+	webhookDeliveries       map[int64]WebhookDelivery
+	outbox                  map[int64]Outbox
+	outboxSequences         map[string]int64
+	entrySequences          map[int64]int64
+	transferBatches         map[int64]TransferBatch
+	transferBatchItems      map[int64]TransferBatchItem
+	accountProducts         map[string]AccountProduct
+	savingsProducts         map[string]SavingsProduct
+	savingsAccounts         map[int64]SavingsAccount
+	interestAccruals        map[interestKey]InterestAccrual
+	interestPostings        map[interestKey]InterestPosting
+
+	nextAccountID                int64
+	nextEntryID                  int64
+	nextTransferID               int64
+	nextPasswordResetTokenID     int64
+	nextEmailVerificationTokenID int64
+	nextTOTPRecoveryCodeID       int64
+	nextAPIKeyID                 int64
+	nextWebhookSubscriptionID    int64
+	nextWebhookDeliveryID        int64
+	nextOutboxID                 int64
+	nextTransferBatchID          int64
+	nextTransferBatchItemID      int64
+}
+
+// NewMemoryStore creates an in-memory store holding the account products seeded by the migrations
+func NewMemoryStore() Store {
+	store := &MemoryStore{
+		users:     make(map[string]User),
+		accounts:  make(map[int64]Account),
+		entries:   make(map[int64]Entry),
+		transfers: make(map[int64]Transfer),
+
+		passwordResetTokens:     make(map[int64]PasswordResetToken),
+		emailVerificationTokens: make(map[int64]EmailVerificationToken),
+		totpRecoveryCodes:       make(map[int64]TotpRecoveryCode),
+		apiKeys:                 make(map[int64]ApiKey),
+		webhookSubscriptions:    make(map[int64]WebhookSubscription),
+		webhookDeliveries:       make(map[int64]WebhookDelivery),
+		outbox:                  make(map[int64]Outbox),
+		outboxSequences:         make(map[string]int64),
+		entrySequences:          make(map[int64]int64),
+		transferBatches:         make(map[int64]TransferBatch),
+		transferBatchItems:      make(map[int64]TransferBatchItem),
+		accountProducts:         make(map[string]AccountProduct),
+		savingsProducts:         make(map[string]SavingsProduct),
+		savingsAccounts:         make(map[int64]SavingsAccount),
+		interestAccruals:        make(map[interestKey]InterestAccrual),
+		interestPostings:        make(map[interestKey]InterestPosting),
+
+		entryHub: newEntryHub(),
+	}
+
+	for _, arg := range seededAccountProducts {
+		_, _ = store.createAccountProduct(arg)
+	}
+
+	return store
+}
+
+// seededAccountProducts are the account products inserted by the account products migration
+var seededAccountProducts = []CreateAccountProductParams{
+	{Code: AccountProductChecking, Name: "Checking", Currencies: []string{}, CanInitiateTransfers: true},
+	{Code: AccountProductSavings, Name: "Savings", Currencies: []string{}, CanInitiateTransfers: true},
+	{Code: AccountProductBusiness, Name: "Business", Currencies: []string{}, CanInitiateTransfers: true, MultiplePerCurrency: true},
+	{Code: AccountProductEscrow, Name: "Escrow", Currencies: []string{}, MultiplePerCurrency: true},
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func constraintError(code pq.ErrorCode, table, constraint string) error {
+	return &pq.Error{
+		Code:       code,
+		Message:    "violates constraint " + constraint,
+		Table:      table,
+		Constraint: constraint,
+	}
+}
+
+// outOfRangeError is the error postgres reports when a balance update overflows the bigint column
+func outOfRangeError() error {
+	return &pq.Error{Code: numericValueOutOfRange, Message: "bigint out of range"}
+}
+
+// currentTime returns the current time truncated to the microsecond precision of timestamptz
+func currentTime() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// paginate returns the window of items selected by a LIMIT and OFFSET clause
+func paginate(n int, limit, offset int32) (start, end int) {
+	start = int(offset)
+	if start > n {
+		start = n
+	}
+
+	end = start + int(limit)
+	if end > n {
+		end = n
+	}
+
+	return start, end
+}
+
+func (store *MemoryStore) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.createUser(arg)
+}
+
+func (store *MemoryStore) createUser(arg CreateUserParams) (User, error) {
+	if _, ok := store.users[arg.Username]; ok {
+		return User{}, constraintError(uniqueViolation, "users", "users_pkey")
+	}
+
+	for _, user := range store.users {
+		if user.Email == arg.Email {
+			return User{}, constraintError(uniqueViolation, "users", "users_email_key")
+		}
+	}
+
+	user := User{
+		Username:          arg.Username,
+		HashedPassword:    arg.HashedPassword,
+		FullName:          arg.FullName,
+		Email:             arg.Email,
+		PasswordChangedAt: time.Time{},
+		CreatedAt:         currentTime(),
+		Role:              util.DepositorRole,
+	}
+	store.users[user.Username] = user
+
+	return user, nil
+}
+
+func (store *MemoryStore) GetUser(ctx context.Context, username string) (User, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	user, ok := store.users[username]
+	if !ok {
+		return User{}, sql.ErrNoRows
+	}
+
+	return user, nil
+}
+
+func (store *MemoryStore) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	for _, user := range store.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+
+	return User{}, sql.ErrNoRows
+}
+
+func (store *MemoryStore) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	user, ok := store.users[arg.Username]
+	if !ok {
+		return User{}, sql.ErrNoRows
+	}
+
+	for _, other := range store.users {
+		if other.Username != user.Username && other.Email == arg.Email {
+			return User{}, constraintError(uniqueViolation, "users", "users_email_key")
+		}
+	}
+
+	// a new email has to be verified again
+	user.IsEmailVerified = user.IsEmailVerified && user.Email == arg.Email
+	user.FullName = arg.FullName
+	user.Email = arg.Email
+	store.users[user.Username] = user
+
+	return user, nil
+}
+
+func (store *MemoryStore) RecordFailedLogin(ctx context.Context, username string) (User, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	user, ok := store.users[username]
+	if !ok {
+		return User{}, sql.ErrNoRows
+	}
+
+	user.FailedLoginAttempts++
+	user.LastFailedLoginAt = sql.NullTime{Time: currentTime(), Valid: true}
+	store.users[user.Username] = user
+
+	return user, nil
+}
+
+func (store *MemoryStore) LockUser(ctx context.Context, arg LockUserParams) (User, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	user, ok := store.users[arg.Username]
+	if !ok {
+		return User{}, sql.ErrNoRows
+	}
+
+	user.LockedUntil = arg.LockedUntil
+	store.users[user.Username] = user
+
+	return user, nil
+}
+
+func (store *MemoryStore) ResetLoginAttempts(ctx context.Context, username string) (User, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	user, ok := store.users[username]
+	if !ok {
+		return User{}, sql.ErrNoRows
+	}
+
+	user.FailedLoginAttempts = 0
+	user.LastFailedLoginAt = sql.NullTime{}
+	user.LockedUntil = sql.NullTime{}
+	store.users[user.Username] = user
+
+	return user, nil
+}
+
+func (store *MemoryStore) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	user, ok := store.users[arg.Username]
+	if !ok {
+		return User{}, sql.ErrNoRows
+	}
+
+	user.TotpSecret = arg.TotpSecret
+	user.TotpEnabled = false
+	user.TotpLastStep = 0
+	store.users[user.Username] = user
+
+	return user, nil
+}
+
+func (store *MemoryStore) EnableUserTOTP(ctx context.Context, username string) (User, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.enableUserTOTP(username)
+}
+
+func (store *MemoryStore) enableUserTOTP(username string) (User, error) {
+	user, ok := store.users[username]
+	if !ok || !user.TotpSecret.Valid {
+		return User{}, sql.ErrNoRows
+	}
+
+	user.TotpEnabled = true
+	store.users[user.Username] = user
+
+	return user, nil
+}
+
+func (store *MemoryStore) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (User, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.useTOTPStep(arg)
+}
+
+func (store *MemoryStore) useTOTPStep(arg UseTOTPStepParams) (User, error) {
+	user, ok := store.users[arg.Username]
+	if !ok || user.TotpLastStep >= arg.TotpLastStep {
+		return User{}, sql.ErrNoRows
+	}
+
+	user.TotpLastStep = arg.TotpLastStep
+	store.users[user.Username] = user
+
+	return user, nil
+}
+
+func (store *MemoryStore) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.updateUserPassword(arg)
+}
+
+func (store *MemoryStore) updateUserPassword(arg UpdateUserPasswordParams) (User, error) {
+	user, ok := store.users[arg.Username]
+	if !ok {
+		return User{}, sql.ErrNoRows
+	}
+
+	user.HashedPassword = arg.HashedPassword
+	user.PasswordChangedAt = arg.PasswordChangedAt
+	store.users[user.Username] = user
+
+	return user, nil
+}
+
+func (store *MemoryStore) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.verifyUserEmail(arg)
+}
+
+func (store *MemoryStore) verifyUserEmail(arg VerifyUserEmailParams) (User, error) {
+	user, ok := store.users[arg.Username]
+	if !ok || user.Email != arg.Email {
+		return User{}, sql.ErrNoRows
+	}
+
+	user.IsEmailVerified = true
+	store.users[user.Username] = user
+
+	return user, nil
+}
+
+func (store *MemoryStore) CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.users[arg.Username]; !ok {
+		return PasswordResetToken{}, constraintError(foreignKeyViolation, "password_reset_tokens", "password_reset_tokens_username_fkey")
+	}
+
+	for _, resetToken := range store.passwordResetTokens {
+		if resetToken.TokenHash == arg.TokenHash {
+			return PasswordResetToken{}, constraintError(uniqueViolation, "password_reset_tokens", "password_reset_tokens_token_hash_key")
+		}
+	}
+
+	store.nextPasswordResetTokenID++
+	resetToken := PasswordResetToken{
+		ID:        store.nextPasswordResetTokenID,
+		Username:  arg.Username,
+		TokenHash: arg.TokenHash,
+		ExpiresAt: arg.ExpiresAt,
+		CreatedAt: currentTime(),
+	}
+	store.passwordResetTokens[resetToken.ID] = resetToken
+
+	return resetToken, nil
+}
+
+func (store *MemoryStore) UsePasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.usePasswordResetToken(tokenHash)
+}
+
+func (store *MemoryStore) usePasswordResetToken(tokenHash string) (PasswordResetToken, error) {
+	now := currentTime()
+	for id, resetToken := range store.passwordResetTokens {
+		if resetToken.TokenHash != tokenHash || resetToken.UsedAt.Valid || !resetToken.ExpiresAt.After(now) {
+			continue
+		}
+
+		resetToken.UsedAt = sql.NullTime{Time: now, Valid: true}
+		store.passwordResetTokens[id] = resetToken
+		return resetToken, nil
+	}
+
+	return PasswordResetToken{}, sql.ErrNoRows
+}
+
+func (store *MemoryStore) InvalidatePasswordResetTokens(ctx context.Context, username string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.invalidatePasswordResetTokens(username)
+	return nil
+}
+
+func (store *MemoryStore) invalidatePasswordResetTokens(username string) {
+	now := currentTime()
+	for id, resetToken := range store.passwordResetTokens {
+		if resetToken.Username != username || resetToken.UsedAt.Valid {
+			continue
+		}
+
+		resetToken.UsedAt = sql.NullTime{Time: now, Valid: true}
+		store.passwordResetTokens[id] = resetToken
+	}
+}
+
+func (store *MemoryStore) CreateEmailVerificationToken(ctx context.Context, arg CreateEmailVerificationTokenParams) (EmailVerificationToken, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.users[arg.Username]; !ok {
+		return EmailVerificationToken{}, constraintError(foreignKeyViolation, "email_verification_tokens", "email_verification_tokens_username_fkey")
+	}
+
+	for _, verificationToken := range store.emailVerificationTokens {
+		if verificationToken.TokenHash == arg.TokenHash {
+			return EmailVerificationToken{}, constraintError(uniqueViolation, "email_verification_tokens", "email_verification_tokens_token_hash_key")
+		}
+	}
+
+	store.nextEmailVerificationTokenID++
+	verificationToken := EmailVerificationToken{
+		ID:        store.nextEmailVerificationTokenID,
+		Username:  arg.Username,
+		Email:     arg.Email,
+		TokenHash: arg.TokenHash,
+		ExpiresAt: arg.ExpiresAt,
+		CreatedAt: currentTime(),
+	}
+	store.emailVerificationTokens[verificationToken.ID] = verificationToken
+
+	return verificationToken, nil
+}
+
+func (store *MemoryStore) UseEmailVerificationToken(ctx context.Context, tokenHash string) (EmailVerificationToken, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.useEmailVerificationToken(tokenHash)
+}
+
+func (store *MemoryStore) useEmailVerificationToken(tokenHash string) (EmailVerificationToken, error) {
+	now := currentTime()
+	for id, verificationToken := range store.emailVerificationTokens {
+		if verificationToken.TokenHash != tokenHash || verificationToken.UsedAt.Valid || !verificationToken.ExpiresAt.After(now) {
+			continue
+		}
+
+		verificationToken.UsedAt = sql.NullTime{Time: now, Valid: true}
+		store.emailVerificationTokens[id] = verificationToken
+		return verificationToken, nil
+	}
+
+	return EmailVerificationToken{}, sql.ErrNoRows
+}
+
+func (store *MemoryStore) CreateTOTPRecoveryCode(ctx context.Context, arg CreateTOTPRecoveryCodeParams) (TotpRecoveryCode, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.createTOTPRecoveryCode(arg)
+}
+
+func (store *MemoryStore) createTOTPRecoveryCode(arg CreateTOTPRecoveryCodeParams) (TotpRecoveryCode, error) {
+	if _, ok := store.users[arg.Username]; !ok {
+		return TotpRecoveryCode{}, constraintError(foreignKeyViolation, "totp_recovery_codes", "totp_recovery_codes_username_fkey")
+	}
+
+	for _, recoveryCode := range store.totpRecoveryCodes {
+		if recoveryCode.Username == arg.Username && recoveryCode.CodeHash == arg.CodeHash {
+			return TotpRecoveryCode{}, constraintError(uniqueViolation, "totp_recovery_codes", "totp_recovery_codes_username_code_hash_idx")
+		}
+	}
+
+	store.nextTOTPRecoveryCodeID++
+	recoveryCode := TotpRecoveryCode{
+		ID:        store.nextTOTPRecoveryCodeID,
+		Username:  arg.Username,
+		CodeHash:  arg.CodeHash,
+		CreatedAt: currentTime(),
+	}
+	store.totpRecoveryCodes[recoveryCode.ID] = recoveryCode
+
+	return recoveryCode, nil
+}
+
+func (store *MemoryStore) DeleteTOTPRecoveryCodes(ctx context.Context, username string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.deleteTOTPRecoveryCodes(username)
+	return nil
+}
+
+func (store *MemoryStore) deleteTOTPRecoveryCodes(username string) {
+	for id, recoveryCode := range store.totpRecoveryCodes {
+		if recoveryCode.Username == username {
+			delete(store.totpRecoveryCodes, id)
+		}
+	}
+}
+
+func (store *MemoryStore) UseTOTPRecoveryCode(ctx context.Context, arg UseTOTPRecoveryCodeParams) (TotpRecoveryCode, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for id, recoveryCode := range store.totpRecoveryCodes {
+		if recoveryCode.Username != arg.Username || recoveryCode.CodeHash != arg.CodeHash || recoveryCode.UsedAt.Valid {
+			continue
+		}
+
+		recoveryCode.UsedAt = sql.NullTime{Time: currentTime(), Valid: true}
+		store.totpRecoveryCodes[id] = recoveryCode
+		return recoveryCode, nil
+	}
+
+	return TotpRecoveryCode{}, sql.ErrNoRows
+}
+
+func (store *MemoryStore) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.users[arg.Username]; !ok {
+		return ApiKey{}, constraintError(foreignKeyViolation, "api_keys", "api_keys_username_fkey")
+	}
+
+	for _, apiKey := range store.apiKeys {
+		if apiKey.KeyHash == arg.KeyHash {
+			return ApiKey{}, constraintError(uniqueViolation, "api_keys", "api_keys_key_hash_idx")
+		}
+	}
+
+	store.nextAPIKeyID++
+	apiKey := ApiKey{
+		ID:        store.nextAPIKeyID,
+		Username:  arg.Username,
+		Name:      arg.Name,
+		Prefix:    arg.Prefix,
+		KeyHash:   arg.KeyHash,
+		Scopes:    append([]string{}, arg.Scopes...),
+		ExpiresAt: arg.ExpiresAt,
+		CreatedAt: currentTime(),
+	}
+	store.apiKeys[apiKey.ID] = apiKey
+
+	return apiKey, nil
+}
+
+func (store *MemoryStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	for _, apiKey := range store.apiKeys {
+		if apiKey.KeyHash == keyHash {
+			return apiKey, nil
+		}
+	}
+
+	return ApiKey{}, sql.ErrNoRows
+}
+
+func (store *MemoryStore) ListAPIKeys(ctx context.Context, username string) ([]ApiKey, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	apiKeys := []ApiKey{}
+	for _, apiKey := range store.apiKeys {
+		if apiKey.Username == username && !apiKey.RevokedAt.Valid {
+			apiKeys = append(apiKeys, apiKey)
+		}
+	}
+
+	sort.Slice(apiKeys, func(i, j int) bool { return apiKeys[i].ID < apiKeys[j].ID })
+	return apiKeys, nil
+}
+
+func (store *MemoryStore) RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyParams) (ApiKey, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	apiKey, ok := store.apiKeys[arg.ID]
+	if !ok || apiKey.Username != arg.Username || apiKey.RevokedAt.Valid {
+		return ApiKey{}, sql.ErrNoRows
+	}
+
+	apiKey.RevokedAt = sql.NullTime{Time: currentTime(), Valid: true}
+	store.apiKeys[apiKey.ID] = apiKey
+
+	return apiKey, nil
+}
+
+func (store *MemoryStore) UpdateAPIKeyLastUsed(ctx context.Context, id int64) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	apiKey, ok := store.apiKeys[id]
+	if !ok {
+		return nil
+	}
+
+	now := currentTime()
+	if !apiKey.LastUsedAt.Valid || apiKey.LastUsedAt.Time.Before(now.Add(-time.Minute)) {
+		apiKey.LastUsedAt = sql.NullTime{Time: now, Valid: true}
+		store.apiKeys[id] = apiKey
+	}
+
+	return nil
+}
+
+func (store *MemoryStore) CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (WebhookSubscription, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.users[arg.Username]; !ok {
+		return WebhookSubscription{}, constraintError(foreignKeyViolation, "webhook_subscriptions", "webhook_subscriptions_username_fkey")
+	}
+
+	store.nextWebhookSubscriptionID++
+	subscription := WebhookSubscription{
+		ID:         store.nextWebhookSubscriptionID,
+		Username:   arg.Username,
+		Url:        arg.Url,
+		EventTypes: append([]string{}, arg.EventTypes...),
+		Secret:     arg.Secret,
+		CreatedAt:  currentTime(),
+	}
+	store.webhookSubscriptions[subscription.ID] = subscription
+
+	return subscription, nil
+}
+
+func (store *MemoryStore) GetWebhookSubscription(ctx context.Context, id int64) (WebhookSubscription, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	subscription, ok := store.webhookSubscriptions[id]
+	if !ok {
+		return WebhookSubscription{}, sql.ErrNoRows
+	}
+
+	return subscription, nil
+}
+
+func (store *MemoryStore) ListWebhookSubscriptions(ctx context.Context, username string) ([]WebhookSubscription, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.listWebhookSubscriptions(func(subscription WebhookSubscription) bool {
+		return subscription.Username == username
+	}), nil
+}
+
+func (store *MemoryStore) ListWebhookSubscriptionsForEvent(ctx context.Context, arg ListWebhookSubscriptionsForEventParams) ([]WebhookSubscription, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.listWebhookSubscriptions(func(subscription WebhookSubscription) bool {
+		if subscription.Username != arg.Username {
+			return false
+		}
+
+		for _, eventType := range subscription.EventTypes {
+			if eventType == arg.EventType {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (store *MemoryStore) listWebhookSubscriptions(match func(WebhookSubscription) bool) []WebhookSubscription {
+	subscriptions := []WebhookSubscription{}
+	for _, subscription := range store.webhookSubscriptions {
+		if match(subscription) {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool { return subscriptions[i].ID < subscriptions[j].ID })
+	return subscriptions
+}
+
+func (store *MemoryStore) DeleteWebhookSubscription(ctx context.Context, arg DeleteWebhookSubscriptionParams) (WebhookSubscription, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	subscription, ok := store.webhookSubscriptions[arg.ID]
+	if !ok || subscription.Username != arg.Username {
+		return WebhookSubscription{}, sql.ErrNoRows
+	}
+
+	// deliveries are deleted on cascade
+	for id, delivery := range store.webhookDeliveries {
+		if delivery.SubscriptionID == subscription.ID {
+			delete(store.webhookDeliveries, id)
+		}
+	}
+
+	delete(store.webhookSubscriptions, subscription.ID)
+	return subscription, nil
+}
+
+func (store *MemoryStore) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.webhookSubscriptions[arg.SubscriptionID]; !ok {
+		return WebhookDelivery{}, constraintError(foreignKeyViolation, "webhook_deliveries", "webhook_deliveries_subscription_id_fkey")
+	}
+
+	// like ON CONFLICT DO NOTHING, an event already queued for the subscription returns no row
+	for _, delivery := range store.webhookDeliveries {
+		if delivery.SubscriptionID == arg.SubscriptionID && delivery.EventID == arg.EventID {
+			return WebhookDelivery{}, sql.ErrNoRows
+		}
+	}
+
+	now := currentTime()
+	store.nextWebhookDeliveryID++
+	delivery := WebhookDelivery{
+		ID:             store.nextWebhookDeliveryID,
+		SubscriptionID: arg.SubscriptionID,
+		EventID:        arg.EventID,
+		EventType:      arg.EventType,
+		Payload:        append(json.RawMessage{}, arg.Payload...),
+		Status:         WebhookDeliveryPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+	store.webhookDeliveries[delivery.ID] = delivery
+
+	return delivery, nil
+}
+
+func (store *MemoryStore) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	deliveries := []WebhookDelivery{}
+	for _, delivery := range store.webhookDeliveries {
+		if delivery.SubscriptionID == arg.SubscriptionID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID > deliveries[j].ID })
+
+	start, end := paginate(len(deliveries), arg.Limit, arg.Offset)
+	return deliveries[start:end], nil
+}
+
+func (store *MemoryStore) ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := currentTime()
+	deliveries := []WebhookDelivery{}
+	for _, delivery := range store.webhookDeliveries {
+		if delivery.Status == WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		if deliveries[i].NextAttemptAt.Equal(deliveries[j].NextAttemptAt) {
+			return deliveries[i].ID < deliveries[j].ID
+		}
+		return deliveries[i].NextAttemptAt.Before(deliveries[j].NextAttemptAt)
+	})
+
+	start, end := paginate(len(deliveries), arg.Limit, 0)
+	deliveries = deliveries[start:end]
+	for i := range deliveries {
+		deliveries[i].NextAttemptAt = arg.LockedUntil
+		store.webhookDeliveries[deliveries[i].ID] = deliveries[i]
+	}
+
+	return deliveries, nil
+}
+
+func (store *MemoryStore) RecordWebhookDeliveryAttempt(ctx context.Context, arg RecordWebhookDeliveryAttemptParams) (WebhookDelivery, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delivery, ok := store.webhookDeliveries[arg.ID]
+	if !ok {
+		return WebhookDelivery{}, sql.ErrNoRows
+	}
+
+	delivery.Status = arg.Status
+	delivery.Attempts++
+	delivery.NextAttemptAt = arg.NextAttemptAt
+	delivery.LastStatusCode = arg.LastStatusCode
+	delivery.LastError = arg.LastError
+	delivery.DeliveredAt = arg.DeliveredAt
+	store.webhookDeliveries[delivery.ID] = delivery
+
+	return delivery, nil
+}
+
+func (store *MemoryStore) ReplayWebhookDelivery(ctx context.Context, arg ReplayWebhookDeliveryParams) (WebhookDelivery, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delivery, ok := store.webhookDeliveries[arg.ID]
+	if !ok || delivery.SubscriptionID != arg.SubscriptionID {
+		return WebhookDelivery{}, sql.ErrNoRows
+	}
+
+	delivery.Status = WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = currentTime()
+	delivery.LastStatusCode = sql.NullInt32{}
+	delivery.LastError = sql.NullString{}
+	delivery.DeliveredAt = sql.NullTime{}
+	store.webhookDeliveries[delivery.ID] = delivery
+
+	return delivery, nil
+}
+
+func (store *MemoryStore) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (Outbox, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.createOutboxEvent(arg), nil
+}
+
+func (store *MemoryStore) createOutboxEvent(arg CreateOutboxEventParams) Outbox {
+	aggregate := arg.AggregateType + "/" + arg.AggregateID
+	store.outboxSequences[aggregate]++
+
+	now := currentTime()
+	store.nextOutboxID++
+	event := Outbox{
+		ID:            store.nextOutboxID,
+		AggregateType: arg.AggregateType,
+		AggregateID:   arg.AggregateID,
+		EventType:     arg.EventType,
+		Payload:       append(json.RawMessage{}, arg.Payload...),
+		CreatedAt:     now,
+		Sequence:      store.outboxSequences[aggregate],
+		NextAttemptAt: now,
+	}
+	store.outbox[event.ID] = event
+
+	return event
+}
+
+// ClaimOutboxEvents pushes back and returns the due unpublished events that are first in their aggregate
+func (store *MemoryStore) ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]Outbox, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	heads := make(map[string]Outbox)
+	for _, event := range store.outbox {
+		if event.PublishedAt.Valid {
+			continue
+		}
+
+		aggregate := event.AggregateType + "/" + event.AggregateID
+		if head, ok := heads[aggregate]; !ok || event.Sequence < head.Sequence {
+			heads[aggregate] = event
+		}
+	}
+
+	now := currentTime()
+	events := []Outbox{}
+	for _, event := range heads {
+		if !event.NextAttemptAt.After(now) {
+			events = append(events, event)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ID < events[j].ID
+	})
+
+	start, end := paginate(len(events), arg.Limit, 0)
+	events = events[start:end]
+
+	for i := range events {
+		events[i].NextAttemptAt = arg.LockedUntil
+		store.outbox[events[i].ID] = events[i]
+	}
+
+	return events, nil
+}
+
+func (store *MemoryStore) MarkOutboxEventsPublished(ctx context.Context, ids []int64) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := currentTime()
+	for _, id := range ids {
+		event, ok := store.outbox[id]
+		if !ok {
+			continue
+		}
+
+		event.PublishedAt = sql.NullTime{Time: now, Valid: true}
+		store.outbox[id] = event
+	}
+
+	return nil
+}
+
+func (store *MemoryStore) RecordOutboxEventFailure(ctx context.Context, arg RecordOutboxEventFailureParams) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	event, ok := store.outbox[arg.ID]
+	if !ok {
+		return nil
+	}
+
+	event.Attempts++
+	event.NextAttemptAt = arg.NextAttemptAt
+	event.LastError = arg.LastError
+	store.outbox[arg.ID] = event
+
+	return nil
+}
+
+func (store *MemoryStore) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.createAccount(arg)
+}
+
+func (store *MemoryStore) createAccount(arg CreateAccountParams) (Account, error) {
+	if _, ok := store.users[arg.Owner]; !ok {
+		return Account{}, constraintError(foreignKeyViolation, "accounts", "accounts_owner_fkey")
+	}
+
+	product, ok := store.accountProducts[arg.ProductCode]
+	if !ok {
+		return Account{}, constraintError(foreignKeyViolation, "accounts", "accounts_product_code_fkey")
+	}
+
+	if !product.MultiplePerCurrency {
+		for _, account := range store.accounts {
+			if !account.MultiplePerCurrency && account.Owner == arg.Owner && account.Currency == arg.Currency && account.ProductCode == arg.ProductCode {
+				return Account{}, constraintError(uniqueViolation, "accounts", accountsOwnerCurrencyProductCodeKey)
+			}
+		}
+	}
+
+	store.nextAccountID++
+	account := Account{
+		ID:                  store.nextAccountID,
+		Owner:               arg.Owner,
+		Balance:             arg.Balance,
+		Currency:            arg.Currency,
+		CreatedAt:           currentTime(),
+		ProductCode:         arg.ProductCode,
+		MultiplePerCurrency: product.MultiplePerCurrency,
+	}
+	store.accounts[account.ID] = account
+
+	return account, nil
+}
+
+func (store *MemoryStore) GetAccount(ctx context.Context, id int64) (Account, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	account, ok := store.accounts[id]
+	if !ok {
+		return Account{}, sql.ErrNoRows
+	}
+
+	return account, nil
+}
+
+// GetAccountForUpdate behaves like GetAccount, every write already holds the store lock
+func (store *MemoryStore) GetAccountForUpdate(ctx context.Context, id int64) (Account, error) {
+	return store.GetAccount(ctx, id)
+}
+
+func (store *MemoryStore) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	accounts := make([]Account, 0)
+	for _, account := range store.accounts {
+		if account.Owner == arg.Owner {
+			accounts = append(accounts, account)
+		}
+	}
+
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].ID < accounts[j].ID
+	})
+
+	start, end := paginate(len(accounts), arg.Limit, arg.Offset)
+	return accounts[start:end], nil
+}
+
+func (store *MemoryStore) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	account, ok := store.accounts[arg.ID]
+	if !ok {
+		return Account{}, sql.ErrNoRows
+	}
+
+	account.Balance = arg.Balance
+	store.accounts[account.ID] = account
+
+	return account, nil
+}
+
+func (store *MemoryStore) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.addAccountBalance(arg)
+}
+
+func (store *MemoryStore) addAccountBalance(arg AddAccountBalanceParams) (Account, error) {
+	account, ok := store.accounts[arg.ID]
+	if !ok {
+		return Account{}, sql.ErrNoRows
+	}
+
+	balance, err := util.NewMoney(account.Balance, account.Currency).Add(util.NewMoney(arg.Amount, account.Currency))
+	if err != nil {
+		return Account{}, outOfRangeError()
+	}
+
+	account.Balance = balance.Amount
+	store.accounts[account.ID] = account
+
+	return account, nil
+}
+
+func (store *MemoryStore) FreezeAccount(ctx context.Context, id int64) (Account, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.freezeAccount(id)
+}
+
+func (store *MemoryStore) freezeAccount(id int64) (Account, error) {
+	account, ok := store.accounts[id]
+	if !ok {
+		return Account{}, sql.ErrNoRows
+	}
+
+	account.Frozen = true
+	store.accounts[account.ID] = account
+
+	return account, nil
+}
+
+func (store *MemoryStore) DeleteAccount(ctx context.Context, id int64) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, entry := range store.entries {
+		if entry.AccountID == id {
+			return constraintError(foreignKeyViolation, "entries", "entries_account_id_fkey")
+		}
+	}
+
+	for _, transfer := range store.transfers {
+		if transfer.FromAccountID == id {
+			return constraintError(foreignKeyViolation, "transfers", "transfers_from_account_id_fkey")
+		}
+		if transfer.ToAccountID == id {
+			return constraintError(foreignKeyViolation, "transfers", "transfers_to_account_id_fkey")
+		}
+	}
+
+	for _, product := range store.savingsProducts {
+		if product.ExpenseAccountID == id {
+			return constraintError(foreignKeyViolation, "savings_products", "savings_products_expense_account_id_fkey")
+		}
+	}
+
+	if _, ok := store.savingsAccounts[id]; ok {
+		return constraintError(foreignKeyViolation, "savings_accounts", "savings_accounts_account_id_fkey")
+	}
+
+	for key := range store.interestAccruals {
+		if key.accountID == id {
+			return constraintError(foreignKeyViolation, "interest_accruals", "interest_accruals_account_id_fkey")
+		}
+	}
+
+	for key := range store.interestPostings {
+		if key.accountID == id {
+			return constraintError(foreignKeyViolation, "interest_postings", "interest_postings_account_id_fkey")
+		}
+	}
+
+	delete(store.accounts, id)
+	return nil
+}
+
+func (store *MemoryStore) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.createEntry(arg)
+}
+
+func (store *MemoryStore) createEntry(arg CreateEntryParams) (Entry, error) {
+	if _, ok := store.accounts[arg.AccountID]; !ok {
+		return Entry{}, constraintError(foreignKeyViolation, "entries", "entries_account_id_fkey")
+	}
+
+	if _, ok := store.transfers[arg.TransferID.Int64]; arg.TransferID.Valid && !ok {
+		return Entry{}, constraintError(foreignKeyViolation, "entries", "entries_transfer_id_fkey")
+	}
+
+	store.entrySequences[arg.AccountID]++
+
+	store.nextEntryID++
+	entry := Entry{
+		ID:         store.nextEntryID,
+		AccountID:  arg.AccountID,
+		Amount:     arg.Amount,
+		CreatedAt:  currentTime(),
+		TransferID: arg.TransferID,
+		Sequence:   store.entrySequences[arg.AccountID],
+	}
+	store.entries[entry.ID] = entry
+	store.entryHub.publish(entry)
+
+	return entry, nil
+}
+
+func (store *MemoryStore) GetLastEntrySequence(ctx context.Context, accountID int64) (int64, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.entrySequences[accountID], nil
+}
+
+func (store *MemoryStore) ListEntriesAfter(ctx context.Context, arg ListEntriesAfterParams) ([]Entry, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	entries := []Entry{}
+	for _, entry := range store.entries {
+		if entry.AccountID == arg.AccountID && entry.Sequence > arg.Sequence {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Sequence < entries[j].Sequence
+	})
+
+	start, end := paginate(len(entries), arg.Limit, 0)
+	return entries[start:end], nil
+}
+
+func (store *MemoryStore) GetEntry(ctx context.Context, id int64) (Entry, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	entry, ok := store.entries[id]
+	if !ok {
+		return Entry{}, sql.ErrNoRows
+	}
+
+	return entry, nil
+}
+
+func (store *MemoryStore) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	entries := []Entry{}
+	for _, entry := range store.entries {
+		if entry.AccountID == arg.AccountID {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+
+	start, end := paginate(len(entries), arg.Limit, arg.Offset)
+	return entries[start:end], nil
+}
+
+func (store *MemoryStore) ListEntriesBetween(ctx context.Context, arg ListEntriesBetweenParams) ([]Entry, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.listEntriesBetween(arg), nil
+}
+
+func (store *MemoryStore) listEntriesBetween(arg ListEntriesBetweenParams) []Entry {
+	entries := []Entry{}
+	for _, entry := range store.entries {
+		if entry.AccountID == arg.AccountID && !entry.CreatedAt.Before(arg.FromTime) && entry.CreatedAt.Before(arg.ToTime) {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries
+}
+
+func (store *MemoryStore) SumEntriesSince(ctx context.Context, arg SumEntriesSinceParams) (int64, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.sumEntriesSince(arg), nil
+}
+
+func (store *MemoryStore) sumEntriesSince(arg SumEntriesSinceParams) int64 {
+	var total int64
+	for _, entry := range store.entries {
+		if entry.AccountID == arg.AccountID && !entry.CreatedAt.Before(arg.CreatedAt) {
+			total += entry.Amount
+		}
+	}
+
+	return total
+}
+
+func (store *MemoryStore) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.createTransfer(arg)
+}
+
+func (store *MemoryStore) createTransfer(arg CreateTransferParams) (Transfer, error) {
+	if _, ok := store.accounts[arg.FromAccountID]; !ok {
+		return Transfer{}, constraintError(foreignKeyViolation, "transfers", "transfers_from_account_id_fkey")
+	}
+
+	if _, ok := store.accounts[arg.ToAccountID]; !ok {
+		return Transfer{}, constraintError(foreignKeyViolation, "transfers", "transfers_to_account_id_fkey")
+	}
+
+	store.nextTransferID++
+	transfer := Transfer{
+		ID:            store.nextTransferID,
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		CreatedAt:     currentTime(),
+	}
+	store.transfers[transfer.ID] = transfer
+
+	return transfer, nil
+}
+
+func (store *MemoryStore) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	transfer, ok := store.transfers[id]
+	if !ok {
+		return Transfer{}, sql.ErrNoRows
+	}
+
+	return transfer, nil
+}
+
+func (store *MemoryStore) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	transfers := []Transfer{}
+	for _, transfer := range store.transfers {
+		if transfer.FromAccountID == arg.FromAccountID || transfer.ToAccountID == arg.ToAccountID {
+			transfers = append(transfers, transfer)
+		}
+	}
+
+	sort.Slice(transfers, func(i, j int) bool { return transfers[i].ID < transfers[j].ID })
+
+	start, end := paginate(len(transfers), arg.Limit, arg.Offset)
+	return transfers[start:end], nil
+}
+
+func (store *MemoryStore) ListTransfersByIDs(ctx context.Context, ids []int64) ([]Transfer, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.listTransfersByIDs(ids), nil
+}
+
+func (store *MemoryStore) listTransfersByIDs(ids []int64) []Transfer {
+	transfers := []Transfer{}
+	for _, id := range ids {
+		if transfer, ok := store.transfers[id]; ok {
+			transfers = append(transfers, transfer)
+		}
+	}
+
+	sort.Slice(transfers, func(i, j int) bool { return transfers[i].ID < transfers[j].ID })
+
+	// ANY matches each transfer once however many times its id is listed
+	unique := transfers[:0]
+	for i, transfer := range transfers {
+		if i == 0 || transfer.ID != transfers[i-1].ID {
+			unique = append(unique, transfer)
+		}
+	}
+
+	return unique
+}
+
+// TransferTx performs a money transfer atomically, nothing is written when any step fails
+func (store *MemoryStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	// missing accounts fail the foreign key checks of the transfer instead
+	if err := checkTransferAccounts(store.accounts[arg.FromAccountID], store.accounts[arg.ToAccountID]); err != nil {
+		return TransferTxResult{}, err
+	}
+
+	if fromAccount, ok := store.accounts[arg.FromAccountID]; ok {
+		err := checkTransferSource(fromAccount.Balance, arg.Amount, store.accountProducts[fromAccount.ProductCode])
+		if err != nil {
+			return TransferTxResult{}, err
+		}
+	}
+
+	return store.transfer(arg)
+}
+
+// transfer performs the writes of a transfer, store.mu must be held
+func (store *MemoryStore) transfer(arg TransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	// postgres rolls the transfer back when a balance update overflows, nothing is written here in that case
+	if arg.FromAccountID != arg.ToAccountID {
+		if err := store.checkBalanceUpdate(arg.FromAccountID, -arg.Amount); err != nil {
+			return result, err
+		}
+		if err := store.checkBalanceUpdate(arg.ToAccountID, arg.Amount); err != nil {
+			return result, err
+		}
+	}
+
+	// the transfer insert performs the same foreign key checks every later step relies on
+	transfer, err := store.createTransfer(CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+	})
+	if err != nil {
+		return result, err
+	}
+	result.Transfer = transfer
+
+	result.FromEntry, _ = store.createEntry(CreateEntryParams{
+		AccountID:  arg.FromAccountID,
+		Amount:     -arg.Amount,
+		TransferID: sql.NullInt64{Int64: transfer.ID, Valid: true},
+	})
+
+	result.ToEntry, _ = store.createEntry(CreateEntryParams{
+		AccountID:  arg.ToAccountID,
+		Amount:     arg.Amount,
+		TransferID: sql.NullInt64{Int64: transfer.ID, Valid: true},
+	})
+
+	// update balances in the same id order as SQLStore so self transfers report identical accounts
+	debit := AddAccountBalanceParams{ID: arg.FromAccountID, Amount: -arg.Amount}
+	credit := AddAccountBalanceParams{ID: arg.ToAccountID, Amount: arg.Amount}
+
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, _ = store.addAccountBalance(debit)
+		result.ToAccount, _ = store.addAccountBalance(credit)
+	} else {
+		result.ToAccount, _ = store.addAccountBalance(credit)
+		result.FromAccount, _ = store.addAccountBalance(debit)
+	}
+
+	events, err := newTransferCreatedEvents(result)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+	for _, event := range events {
+		store.createOutboxEvent(event)
+	}
+
+	return result, nil
+}
+
+// checkBalanceUpdate reports whether adding amount to the balance of an account would overflow,
+// missing accounts are left to the foreign key checks of the caller
+func (store *MemoryStore) checkBalanceUpdate(id int64, amount int64) error {
+	account, ok := store.accounts[id]
+	if !ok {
+		return nil
+	}
+
+	if _, err := util.NewMoney(account.Balance, account.Currency).Add(util.NewMoney(amount, account.Currency)); err != nil {
+		return outOfRangeError()
+	}
+
+	return nil
+}
+
+func (store *MemoryStore) CreateTransferBatch(ctx context.Context, arg CreateTransferBatchParams) (TransferBatch, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.createTransferBatch(arg)
+}
+
+func (store *MemoryStore) createTransferBatch(arg CreateTransferBatchParams) (TransferBatch, error) {
+	if _, ok := store.users[arg.Owner]; !ok {
+		return TransferBatch{}, constraintError(foreignKeyViolation, "transfer_batches", "transfer_batches_owner_fkey")
+	}
+
+	if _, ok := store.accounts[arg.FromAccountID]; !ok {
+		return TransferBatch{}, constraintError(foreignKeyViolation, "transfer_batches", "transfer_batches_from_account_id_fkey")
+	}
+
+	store.nextTransferBatchID++
+	batch := TransferBatch{
+		ID:            store.nextTransferBatchID,
+		Owner:         arg.Owner,
+		FromAccountID: arg.FromAccountID,
+		Mode:          arg.Mode,
+		Status:        TransferBatchPending,
+		ItemCount:     arg.ItemCount,
+		TotalAmount:   arg.TotalAmount,
+		CreatedAt:     currentTime(),
+	}
+	store.transferBatches[batch.ID] = batch
+
+	return batch, nil
+}
+
+func (store *MemoryStore) GetTransferBatch(ctx context.Context, id int64) (TransferBatch, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	batch, ok := store.transferBatches[id]
+	if !ok {
+		return TransferBatch{}, sql.ErrNoRows
+	}
+
+	return batch, nil
+}
+
+func (store *MemoryStore) CompleteTransferBatch(ctx context.Context, arg CompleteTransferBatchParams) (TransferBatch, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.completeTransferBatch(arg)
+}
+
+func (store *MemoryStore) completeTransferBatch(arg CompleteTransferBatchParams) (TransferBatch, error) {
+	batch, ok := store.transferBatches[arg.ID]
+	if !ok {
+		return TransferBatch{}, sql.ErrNoRows
+	}
+
+	batch.Status = arg.Status
+	batch.SucceededCount = arg.SucceededCount
+	store.transferBatches[batch.ID] = batch
+
+	return batch, nil
+}
+
+func (store *MemoryStore) CreateTransferBatchItem(ctx context.Context, arg CreateTransferBatchItemParams) (TransferBatchItem, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.createTransferBatchItem(arg)
+}
+
+func (store *MemoryStore) createTransferBatchItem(arg CreateTransferBatchItemParams) (TransferBatchItem, error) {
+	if _, ok := store.transferBatches[arg.BatchID]; !ok {
+		return TransferBatchItem{}, constraintError(foreignKeyViolation, "transfer_batch_items", "transfer_batch_items_batch_id_fkey")
+	}
+
+	if _, ok := store.transfers[arg.TransferID.Int64]; arg.TransferID.Valid && !ok {
+		return TransferBatchItem{}, constraintError(foreignKeyViolation, "transfer_batch_items", "transfer_batch_items_transfer_id_fkey")
+	}
+
+	for _, item := range store.transferBatchItems {
+		if item.BatchID == arg.BatchID && item.Position == arg.Position {
+			return TransferBatchItem{}, constraintError(uniqueViolation, "transfer_batch_items", "transfer_batch_items_batch_id_position_idx")
+		}
+	}
+
+	store.nextTransferBatchItemID++
+	item := TransferBatchItem{
+		ID:          store.nextTransferBatchItemID,
+		BatchID:     arg.BatchID,
+		Position:    arg.Position,
+		ToAccountID: arg.ToAccountID,
+		Amount:      arg.Amount,
+		TransferID:  arg.TransferID,
+		Error:       arg.Error,
+	}
+	store.transferBatchItems[item.ID] = item
+
+	return item, nil
+}
+
+func (store *MemoryStore) ListTransferBatchItems(ctx context.Context, batchID int64) ([]TransferBatchItem, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	items := []TransferBatchItem{}
+	for _, item := range store.transferBatchItems {
+		if item.BatchID == batchID {
+			items = append(items, item)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Position < items[j].Position })
+	return items, nil
+}
+
+// TransferBatchTx transfers the items of a batch like SQLStore. Nothing of an atomic batch is transferred
+// unless every item can be, a failed atomic batch is recorded with the item that failed
+func (store *MemoryStore) TransferBatchTx(ctx context.Context, arg TransferBatchTxParams) (TransferBatchTxResult, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var result TransferBatchTxResult
+
+	params, err := newTransferBatchParams(arg)
+	if err != nil {
+		return result, err
+	}
+
+	batch, err := store.createTransferBatch(params)
+	if err != nil {
+		return result, err
+	}
+	result.Batch = batch
+
+	product := store.accountProducts[store.accounts[arg.FromAccountID].ProductCode]
+
+	failed := -1
+	var failure error
+	if arg.Mode == TransferBatchAtomic {
+		balance := store.accounts[arg.FromAccountID].Balance
+		for i, item := range arg.Items {
+			failure = store.checkBatchItem(store.accounts[arg.FromAccountID], balance, product, item)
+			if failure != nil {
+				failed = i
+				break
+			}
+			if item.ToAccountID != arg.FromAccountID {
+				balance -= item.Amount
+			}
+		}
+	}
+
+	for i, item := range arg.Items {
+		itemParams := newTransferBatchItemParams(batch.ID, i, item)
+
+		err := failure
+		switch {
+		case failed >= 0 && i != failed:
+			err = errBatchRolledBack
+		case failed < 0:
+			err = store.checkBatchItem(store.accounts[arg.FromAccountID], store.accounts[arg.FromAccountID].Balance, product, item)
+		}
+
+		if err == nil {
+			transfer, _ := store.transfer(TransferTxParams{
+				FromAccountID: arg.FromAccountID,
+				ToAccountID:   item.ToAccountID,
+				Amount:        item.Amount,
+			})
+			result.Transfers = append(result.Transfers, transfer)
+			itemParams.TransferID = sql.NullInt64{Int64: transfer.Transfer.ID, Valid: true}
+		} else {
+			message, ok := batchItemError(err)
+			if !ok {
+				message = err.Error()
+			}
+			itemParams.Error = sql.NullString{String: message, Valid: true}
+		}
+
+		batchItem, _ := store.createTransferBatchItem(itemParams)
+		result.Items = append(result.Items, batchItem)
+	}
+
+	result.Batch, _ = store.completeTransferBatch(CompleteTransferBatchParams{
+		ID:             batch.ID,
+		Status:         transferBatchStatus(len(result.Transfers), len(arg.Items)),
+		SucceededCount: int32(len(result.Transfers)),
+	})
+
+	return result, nil
+}
+
+// checkBatchItem returns the error transferring an item from an account of a product with balance fails with,
+// store.mu must be held
+func (store *MemoryStore) checkBatchItem(fromAccount Account, balance int64, product AccountProduct, item TransferBatchItemParams) error {
+	if err := checkTransferSource(balance, item.Amount, product); err != nil {
+		return err
+	}
+
+	toAccount, ok := store.accounts[item.ToAccountID]
+	if !ok {
+		return constraintError(foreignKeyViolation, "transfers", "transfers_to_account_id_fkey")
+	}
+
+	if err := checkTransferAccounts(fromAccount, toAccount); err != nil {
+		return err
+	}
+
+	return store.checkBalanceUpdate(item.ToAccountID, item.Amount)
+}
+
+// AccountStatementTx reads the statement of an account like SQLStore, from a single snapshot of the store
+func (store *MemoryStore) AccountStatementTx(ctx context.Context, arg AccountStatementParams) (AccountStatement, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	statement := AccountStatement{From: arg.From, To: arg.To}
+
+	account, ok := store.accounts[arg.AccountID]
+	if !ok {
+		return statement, sql.ErrNoRows
+	}
+	statement.Account = account
+
+	since := store.sumEntriesSince(SumEntriesSinceParams{AccountID: arg.AccountID, CreatedAt: arg.To})
+	entries := store.listEntriesBetween(ListEntriesBetweenParams{AccountID: arg.AccountID, FromTime: arg.From, ToTime: arg.To})
+
+	var transferIDs []int64
+	for _, entry := range entries {
+		if entry.TransferID.Valid {
+			transferIDs = append(transferIDs, entry.TransferID.Int64)
+		}
+	}
+	transfers := store.listTransfersByIDs(transferIDs)
+
+	parties := newStatementParties(account)
+	for _, transfer := range transfers {
+		parties.accounts[transfer.FromAccountID] = store.accounts[transfer.FromAccountID]
+		parties.accounts[transfer.ToAccountID] = store.accounts[transfer.ToAccountID]
+	}
+
+	for _, account := range parties.accounts {
+		parties.names[account.Owner] = store.users[account.Owner].FullName
+	}
+
+	statement.fill(since, entries, transfers, parties)
+	return statement, nil
+}
+
+func (store *MemoryStore) CreateAccountProduct(ctx context.Context, arg CreateAccountProductParams) (AccountProduct, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.createAccountProduct(arg)
+}
+
+func (store *MemoryStore) createAccountProduct(arg CreateAccountProductParams) (AccountProduct, error) {
+	if _, ok := store.accountProducts[arg.Code]; ok {
+		return AccountProduct{}, constraintError(uniqueViolation, "account_products", "account_products_pkey")
+	}
+
+	if arg.OverdraftLimit < 0 {
+		return AccountProduct{}, constraintError(checkViolation, "account_products", "account_products_overdraft_limit_check")
+	}
+
+	product := AccountProduct{
+		Code:                 arg.Code,
+		Name:                 arg.Name,
+		Currencies:           append([]string{}, arg.Currencies...),
+		OverdraftLimit:       arg.OverdraftLimit,
+		FeeSchedule:          arg.FeeSchedule,
+		CanInitiateTransfers: arg.CanInitiateTransfers,
+		MultiplePerCurrency:  arg.MultiplePerCurrency,
+		CreatedAt:            currentTime(),
+	}
+	store.accountProducts[product.Code] = product
+
+	return product, nil
+}
+
+func (store *MemoryStore) GetAccountProduct(ctx context.Context, code string) (AccountProduct, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	product, ok := store.accountProducts[code]
+	if !ok {
+		return AccountProduct{}, sql.ErrNoRows
+	}
+
+	return product, nil
+}
+
+func (store *MemoryStore) ListAccountProducts(ctx context.Context) ([]AccountProduct, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	products := make([]AccountProduct, 0, len(store.accountProducts))
+	for _, product := range store.accountProducts {
+		products = append(products, product)
+	}
+
+	sort.Slice(products, func(i, j int) bool { return products[i].Code < products[j].Code })
+	return products, nil
+}
+
+func (store *MemoryStore) CreateSavingsProduct(ctx context.Context, arg CreateSavingsProductParams) (SavingsProduct, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.savingsProducts[arg.Code]; ok {
+		return SavingsProduct{}, constraintError(uniqueViolation, "savings_products", "savings_products_pkey")
+	}
+
+	if _, ok := store.accounts[arg.ExpenseAccountID]; !ok {
+		return SavingsProduct{}, constraintError(foreignKeyViolation, "savings_products", "savings_products_expense_account_id_fkey")
+	}
+
+	product := SavingsProduct{
+		Code:             arg.Code,
+		Name:             arg.Name,
+		Currency:         arg.Currency,
+		AnnualRateBps:    arg.AnnualRateBps,
+		DayCount:         arg.DayCount,
+		ExpenseAccountID: arg.ExpenseAccountID,
+		CreatedAt:        currentTime(),
+	}
+	store.savingsProducts[product.Code] = product
+
+	return product, nil
+}
+
+func (store *MemoryStore) GetSavingsProduct(ctx context.Context, code string) (SavingsProduct, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	product, ok := store.savingsProducts[code]
+	if !ok {
+		return SavingsProduct{}, sql.ErrNoRows
+	}
+
+	return product, nil
+}
+
+func (store *MemoryStore) ListSavingsProducts(ctx context.Context) ([]SavingsProduct, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	products := make([]SavingsProduct, 0, len(store.savingsProducts))
+	for _, product := range store.savingsProducts {
+		products = append(products, product)
+	}
+
+	sort.Slice(products, func(i, j int) bool { return products[i].Code < products[j].Code })
+	return products, nil
+}
+
+func (store *MemoryStore) CreateSavingsAccount(ctx context.Context, arg CreateSavingsAccountParams) (SavingsAccount, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.savingsAccounts[arg.AccountID]; ok {
+		return SavingsAccount{}, constraintError(uniqueViolation, "savings_accounts", "savings_accounts_pkey")
+	}
+
+	if _, ok := store.accounts[arg.AccountID]; !ok {
+		return SavingsAccount{}, constraintError(foreignKeyViolation, "savings_accounts", "savings_accounts_account_id_fkey")
+	}
+
+	if _, ok := store.savingsProducts[arg.ProductCode]; !ok {
+		return SavingsAccount{}, constraintError(foreignKeyViolation, "savings_accounts", "savings_accounts_product_code_fkey")
+	}
+
+	savings := SavingsAccount{
+		AccountID:   arg.AccountID,
+		ProductCode: arg.ProductCode,
+		CreatedAt:   currentTime(),
+	}
+	store.savingsAccounts[savings.AccountID] = savings
+
+	return savings, nil
+}
+
+func (store *MemoryStore) GetSavingsAccount(ctx context.Context, accountID int64) (SavingsAccount, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	savings, ok := store.savingsAccounts[accountID]
+	if !ok {
+		return SavingsAccount{}, sql.ErrNoRows
+	}
+
+	return savings, nil
+}
+
+func (store *MemoryStore) ListSavingsAccounts(ctx context.Context, arg ListSavingsAccountsParams) ([]SavingsAccount, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	accounts := []SavingsAccount{}
+	for _, savings := range store.savingsAccounts {
+		if savings.AccountID > arg.AccountID {
+			accounts = append(accounts, savings)
+		}
+	}
+
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].AccountID < accounts[j].AccountID })
+
+	_, end := paginate(len(accounts), arg.Limit, 0)
+	return accounts[:end], nil
+}
+
+// interestKey identifies the accrual of a day or the posting of a month of an account
+type interestKey struct {
+	accountID int64
+	date      time.Time
+}
+
+// CreateInterestAccrual returns sql.ErrNoRows when the day was accrued before, like the ON CONFLICT DO NOTHING
+// clause of the query
+func (store *MemoryStore) CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) (InterestAccrual, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.createInterestAccrual(arg)
+}
+
+func (store *MemoryStore) createInterestAccrual(arg CreateInterestAccrualParams) (InterestAccrual, error) {
+	if _, ok := store.accounts[arg.AccountID]; !ok {
+		return InterestAccrual{}, constraintError(foreignKeyViolation, "interest_accruals", "interest_accruals_account_id_fkey")
+	}
+
+	key := interestKey{accountID: arg.AccountID, date: interestDate(arg.AccrualDate)}
+	if _, ok := store.interestAccruals[key]; ok {
+		return InterestAccrual{}, sql.ErrNoRows
+	}
+
+	accrual := InterestAccrual{
+		AccountID:     arg.AccountID,
+		AccrualDate:   key.date,
+		Balance:       arg.Balance,
+		AnnualRateBps: arg.AnnualRateBps,
+		DayCount:      arg.DayCount,
+		AmountMicros:  arg.AmountMicros,
+		CreatedAt:     currentTime(),
+	}
+	store.interestAccruals[key] = accrual
+
+	return accrual, nil
+}
+
+func (store *MemoryStore) SumInterestAccruals(ctx context.Context, arg SumInterestAccrualsParams) (int64, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.sumInterestAccruals(arg), nil
+}
+
+func (store *MemoryStore) sumInterestAccruals(arg SumInterestAccrualsParams) int64 {
+	var total int64
+	for key, accrual := range store.interestAccruals {
+		if key.accountID == arg.AccountID && key.date.Before(interestDate(arg.AccrualDate)) {
+			total += accrual.AmountMicros
+		}
+	}
+
+	return total
+}
+
+func (store *MemoryStore) CountInterestAccruals(ctx context.Context, arg CountInterestAccrualsParams) (int64, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	from, to := interestDate(arg.FromDate), interestDate(arg.ToDate)
+
+	var count int64
+	for key := range store.interestAccruals {
+		if key.accountID == arg.AccountID && !key.date.Before(from) && key.date.Before(to) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// CreateInterestPosting returns sql.ErrNoRows when the month was posted before, like the ON CONFLICT DO NOTHING
+// clause of the query
+func (store *MemoryStore) CreateInterestPosting(ctx context.Context, arg CreateInterestPostingParams) (InterestPosting, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.createInterestPosting(arg)
+}
+
+func (store *MemoryStore) createInterestPosting(arg CreateInterestPostingParams) (InterestPosting, error) {
+	if _, ok := store.accounts[arg.AccountID]; !ok {
+		return InterestPosting{}, constraintError(foreignKeyViolation, "interest_postings", "interest_postings_account_id_fkey")
+	}
+
+	key := interestKey{accountID: arg.AccountID, date: interestDate(arg.Period)}
+	if _, ok := store.interestPostings[key]; ok {
+		return InterestPosting{}, sql.ErrNoRows
+	}
+
+	posting := InterestPosting{
+		AccountID: arg.AccountID,
+		Period:    key.date,
+		Amount:    arg.Amount,
+		CreatedAt: currentTime(),
+	}
+	store.interestPostings[key] = posting
+
+	return posting, nil
+}
+
+func (store *MemoryStore) SetInterestPostingTransfer(ctx context.Context, arg SetInterestPostingTransferParams) (InterestPosting, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.setInterestPostingTransfer(arg)
+}
+
+func (store *MemoryStore) setInterestPostingTransfer(arg SetInterestPostingTransferParams) (InterestPosting, error) {
+	key := interestKey{accountID: arg.AccountID, date: interestDate(arg.Period)}
+	posting, ok := store.interestPostings[key]
+	if !ok {
+		return InterestPosting{}, sql.ErrNoRows
+	}
+
+	if _, ok := store.transfers[arg.TransferID.Int64]; arg.TransferID.Valid && !ok {
+		return InterestPosting{}, constraintError(foreignKeyViolation, "interest_postings", "interest_postings_transfer_id_fkey")
+	}
+
+	posting.TransferID = arg.TransferID
+	store.interestPostings[key] = posting
+
+	return posting, nil
+}
+
+func (store *MemoryStore) SumInterestPostings(ctx context.Context, accountID int64) (int64, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.sumInterestPostings(accountID), nil
+}
+
+func (store *MemoryStore) sumInterestPostings(accountID int64) int64 {
+	var total int64
+	for key, posting := range store.interestPostings {
+		if key.accountID == accountID {
+			total += posting.Amount
+		}
+	}
+
+	return total
+}
+
+// AccrueInterestTx accrues a day of interest on a savings account like SQLStore
+func (store *MemoryStore) AccrueInterestTx(ctx context.Context, arg AccrueInterestTxParams) (AccrueInterestTxResult, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	savings, ok := store.savingsAccounts[arg.AccountID]
+	if !ok {
+		return AccrueInterestTxResult{}, sql.ErrNoRows
+	}
+
+	date := interestDate(arg.Date)
+	since := store.sumEntriesSince(SumEntriesSinceParams{AccountID: arg.AccountID, CreatedAt: date.AddDate(0, 0, 1)})
+
+	params, err := newInterestAccrualParams(store.accounts[arg.AccountID], store.savingsProducts[savings.ProductCode], date, since)
+	if err != nil {
+		return AccrueInterestTxResult{}, err
+	}
+
+	accrual, err := store.createInterestAccrual(params)
+	if err == sql.ErrNoRows {
+		return AccrueInterestTxResult{}, nil
+	}
+	if err != nil {
+		return AccrueInterestTxResult{}, err
+	}
+
+	return AccrueInterestTxResult{Accrual: accrual, Accrued: true}, nil
+}
+
+// PostInterestTx posts a month of interest on a savings account like SQLStore. Nothing is posted unless the
+// transfer from the expense account succeeds
+func (store *MemoryStore) PostInterestTx(ctx context.Context, arg PostInterestTxParams) (PostInterestTxResult, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var result PostInterestTxResult
+
+	savings, ok := store.savingsAccounts[arg.AccountID]
+	if !ok {
+		return result, sql.ErrNoRows
+	}
+	product := store.savingsProducts[savings.ProductCode]
+
+	period := interestPeriod(arg.Period)
+	if _, ok := store.interestPostings[interestKey{accountID: savings.AccountID, date: period}]; ok {
+		return result, nil
+	}
+
+	accrued := store.sumInterestAccruals(SumInterestAccrualsParams{AccountID: savings.AccountID, AccrualDate: period.AddDate(0, 1, 0)})
+	amount := interestDue(accrued, store.sumInterestPostings(savings.AccountID))
+
+	if amount > 0 {
+		transfer, err := store.transfer(TransferTxParams{
+			FromAccountID: product.ExpenseAccountID,
+			ToAccountID:   savings.AccountID,
+			Amount:        amount,
+		})
+		if err != nil {
+			return result, err
+		}
+		result.Transfer = &transfer
+	}
+
+	result.Posting, _ = store.createInterestPosting(CreateInterestPostingParams{
+		AccountID: savings.AccountID,
+		Period:    period,
+		Amount:    amount,
+	})
+	if result.Transfer != nil {
+		result.Posting, _ = store.setInterestPostingTransfer(SetInterestPostingTransferParams{
+			AccountID:  savings.AccountID,
+			Period:     period,
+			TransferID: sql.NullInt64{Int64: result.Transfer.Transfer.ID, Valid: true},
+		})
+	}
+	result.Posted = true
+
+	return result, nil
+}
+
+// ResetPasswordTx consumes a password reset token, invalidates the other reset tokens of its user and sets
+// the new password atomically
+func (store *MemoryStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (User, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	resetToken, err := store.usePasswordResetToken(arg.TokenHash)
+	if err != nil {
+		return User{}, err
+	}
+	store.invalidatePasswordResetTokens(resetToken.Username)
+
+	return store.updateUserPassword(UpdateUserPasswordParams{
+		Username:          resetToken.Username,
+		HashedPassword:    arg.HashedPassword,
+		PasswordChangedAt: arg.PasswordChangedAt,
+	})
+}
+
+// VerifyEmailTx consumes an email verification token and marks the email as verified atomically,
+// the token is left unused when the user changed their email since it was issued
+func (store *MemoryStore) VerifyEmailTx(ctx context.Context, tokenHash string) (User, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, verificationToken := range store.emailVerificationTokens {
+		if verificationToken.TokenHash != tokenHash {
+			continue
+		}
+
+		user, ok := store.users[verificationToken.Username]
+		if !ok || user.Email != verificationToken.Email {
+			return User{}, sql.ErrNoRows
+		}
+	}
+
+	verificationToken, err := store.useEmailVerificationToken(tokenHash)
+	if err != nil {
+		return User{}, err
+	}
+
+	return store.verifyUserEmail(VerifyUserEmailParams{
+		Username: verificationToken.Username,
+		Email:    verificationToken.Email,
+	})
+}
+
+// ConfirmTOTPTx consumes the confirming time step, enables TOTP and replaces the recovery codes atomically
+func (store *MemoryStore) ConfirmTOTPTx(ctx context.Context, arg ConfirmTOTPTxParams) (User, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	user, ok := store.users[arg.Username]
+	if !ok || !user.TotpSecret.Valid || user.TotpLastStep >= arg.Step {
+		return User{}, sql.ErrNoRows
+	}
+
+	// validate the recovery codes first so a failure leaves the store untouched
+	seen := make(map[string]bool, len(arg.RecoveryCodeHashes))
+	for _, codeHash := range arg.RecoveryCodeHashes {
+		if seen[codeHash] {
+			return User{}, constraintError(uniqueViolation, "totp_recovery_codes", "totp_recovery_codes_username_code_hash_idx")
+		}
+		seen[codeHash] = true
+	}
+
+	_, _ = store.useTOTPStep(UseTOTPStepParams{Username: arg.Username, TotpLastStep: arg.Step})
+	user, _ = store.enableUserTOTP(arg.Username)
+
+	store.deleteTOTPRecoveryCodes(arg.Username)
+	for _, codeHash := range arg.RecoveryCodeHashes {
+		_, _ = store.createTOTPRecoveryCode(CreateTOTPRecoveryCodeParams{Username: arg.Username, CodeHash: codeHash})
+	}
+
+	return user, nil
+}
+
+// CreateUserTx creates a user and writes user.created to the outbox atomically
+func (store *MemoryStore) CreateUserTx(ctx context.Context, arg CreateUserParams) (User, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	user, err := store.createUser(arg)
+	if err != nil {
+		return User{}, err
+	}
+
+	event, err := newUserCreatedEvent(user)
+	if err != nil {
+		return User{}, err
+	}
+
+	store.createOutboxEvent(event)
+	return user, nil
+}
+
+// CreateAccountTx creates an account and writes account.created to the outbox atomically, with the same
+// errors as SQLStore
+func (store *MemoryStore) CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.accountProducts[arg.ProductCode]; !ok {
+		return Account{}, sql.ErrNoRows
+	}
+
+	account, err := store.createAccount(arg)
+	if err != nil {
+		return Account{}, accountExistsError(err)
+	}
+
+	event, err := newAccountCreatedEvent(account)
+	if err != nil {
+		return Account{}, err
+	}
+
+	store.createOutboxEvent(event)
+	return account, nil
+}
+
+// FreezeAccountTx freezes an account and writes account.frozen to the outbox atomically
+func (store *MemoryStore) FreezeAccountTx(ctx context.Context, id int64) (Account, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	current, ok := store.accounts[id]
+	if !ok {
+		return Account{}, sql.ErrNoRows
+	}
+
+	if current.Frozen {
+		return Account{}, ErrAccountAlreadyFrozen
+	}
+
+	account, err := store.freezeAccount(id)
+	if err != nil {
+		return Account{}, err
+	}
+
+	event, err := newAccountFrozenEvent(account)
+	if err != nil {
+		return Account{}, err
+	}
+
+	store.createOutboxEvent(event)
+	return account, nil
+}
+
+// SubscribeEntries returns a channel receiving the entries of an account as they are created
+func (store *MemoryStore) SubscribeEntries(ctx context.Context, accountID int64) (<-chan Entry, error) {
+	return store.entryHub.subscribe(ctx, accountID), nil
+}
+
+func (store *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+// MigrationVersion reports the latest embedded migration, the in-memory schema is always current
+func (store *MemoryStore) MigrationVersion(ctx context.Context) (int64, bool, error) {
+	version, err := migration.LatestVersion()
+	if err != nil {
+		return 0, false, err
+	}
+
+	return int64(version), false, nil
+}
@@ -5,15 +5,64 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type Account struct {
-	ID        int64     `json:"id"`
-	Owner     string    `json:"owner"`
-	Balance   int64     `json:"balance"`
-	Currency  string    `json:"currency"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID          int64     `json:"id"`
+	Owner       string    `json:"owner"`
+	Balance     int64     `json:"balance"`
+	Currency    string    `json:"currency"`
+	CreatedAt   time.Time `json:"createdAt"`
+	ProductCode string    `json:"productCode"`
+	Frozen      bool      `json:"frozen"`
+	// multiple_per_currency of the account product when the account was opened
+	MultiplePerCurrency bool `json:"multiplePerCurrency"`
+}
+
+type AccountProduct struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// currencies accounts can be opened in, any currency when empty
+	Currencies []string `json:"currencies"`
+	// how far below zero the balance may go, in the minor unit of the account currency
+	OverdraftLimit int64 `json:"overdraftLimit"`
+	// reference of the fee schedule charged to the accounts
+	FeeSchedule          sql.NullString `json:"feeSchedule"`
+	CanInitiateTransfers bool           `json:"canInitiateTransfers"`
+	// owners may open more than one account of the product in a currency
+	MultiplePerCurrency bool      `json:"multiplePerCurrency"`
+	CreatedAt           time.Time `json:"createdAt"`
+}
+
+type ApiKey struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	// public part of the key, shown to identify it
+	Prefix string `json:"prefix"`
+	// sha256 of the whole key
+	KeyHash    string       `json:"keyHash"`
+	Scopes     []string     `json:"scopes"`
+	ExpiresAt  sql.NullTime `json:"expiresAt"`
+	LastUsedAt sql.NullTime `json:"lastUsedAt"`
+	RevokedAt  sql.NullTime `json:"revokedAt"`
+	CreatedAt  time.Time    `json:"createdAt"`
+}
+
+type EmailVerificationToken struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	// sha256 of the token sent to the user
+	TokenHash string       `json:"tokenHash"`
+	ExpiresAt time.Time    `json:"expiresAt"`
+	UsedAt    sql.NullTime `json:"usedAt"`
+	CreatedAt time.Time    `json:"createdAt"`
 }
 
 type Entry struct {
@@ -22,6 +71,98 @@ type Entry struct {
 	// can be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"createdAt"`
+	// transfer the entry was written for, if any
+	TransferID sql.NullInt64 `json:"transferID"`
+	// position of the entry in its account, the entries of an account commit in sequence order
+	Sequence int64 `json:"sequence"`
+}
+
+type EntrySequence struct {
+	AccountID    int64 `json:"accountID"`
+	LastSequence int64 `json:"lastSequence"`
+}
+
+type InterestAccrual struct {
+	AccountID   int64     `json:"accountID"`
+	AccrualDate time.Time `json:"accrualDate"`
+	// end of day balance the interest accrued on
+	Balance       int64  `json:"balance"`
+	AnnualRateBps int32  `json:"annualRateBps"`
+	DayCount      string `json:"dayCount"`
+	// accrued interest in millionths of the minor unit
+	AmountMicros int64     `json:"amountMicros"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+type InterestPosting struct {
+	AccountID int64 `json:"accountID"`
+	// first day of the month the interest was posted for
+	Period time.Time `json:"period"`
+	Amount int64     `json:"amount"`
+	// transfer from the expense account, not set when nothing was due
+	TransferID sql.NullInt64 `json:"transferID"`
+	CreatedAt  time.Time     `json:"createdAt"`
+}
+
+type Outbox struct {
+	// unique, increases with every event
+	ID            int64           `json:"id"`
+	AggregateType string          `json:"aggregateType"`
+	AggregateID   string          `json:"aggregateID"`
+	EventType     string          `json:"eventType"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	PublishedAt   sql.NullTime    `json:"publishedAt"`
+	// position of the event in its aggregate, events of an aggregate are published in sequence order
+	Sequence int64 `json:"sequence"`
+	Attempts int32 `json:"attempts"`
+	// not published before, pushed back while a relay publishes the event and after failed attempts
+	NextAttemptAt time.Time      `json:"nextAttemptAt"`
+	LastError     sql.NullString `json:"lastError"`
+}
+
+type OutboxSequence struct {
+	AggregateType string `json:"aggregateType"`
+	AggregateID   string `json:"aggregateID"`
+	LastSequence  int64  `json:"lastSequence"`
+}
+
+type PasswordResetToken struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// sha256 of the token sent to the user
+	TokenHash string       `json:"tokenHash"`
+	ExpiresAt time.Time    `json:"expiresAt"`
+	UsedAt    sql.NullTime `json:"usedAt"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+type SavingsAccount struct {
+	AccountID   int64     `json:"accountID"`
+	ProductCode string    `json:"productCode"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type SavingsProduct struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Currency string `json:"currency"`
+	// annual interest rate in basis points, 425 is 4.25%
+	AnnualRateBps int32 `json:"annualRateBps"`
+	// actual/365, actual/360 or actual/actual
+	DayCount string `json:"dayCount"`
+	// system account interest is paid from
+	ExpenseAccountID int64     `json:"expenseAccountID"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+type TotpRecoveryCode struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// sha256 of the recovery code
+	CodeHash  string       `json:"codeHash"`
+	UsedAt    sql.NullTime `json:"usedAt"`
+	CreatedAt time.Time    `json:"createdAt"`
 }
 
 type Transfer struct {
@@ -33,11 +174,72 @@ type Transfer struct {
 	CreatedAt time.Time `json:"createdAt"`
 }
 
+type TransferBatch struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"fromAccountID"`
+	// atomic or best_effort
+	Mode string `json:"mode"`
+	// pending, completed, partially_completed or failed
+	Status         string    `json:"status"`
+	ItemCount      int32     `json:"itemCount"`
+	TotalAmount    int64     `json:"totalAmount"`
+	SucceededCount int32     `json:"succeededCount"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type TransferBatchItem struct {
+	ID          int64 `json:"id"`
+	BatchID     int64 `json:"batchID"`
+	Position    int32 `json:"position"`
+	ToAccountID int64 `json:"toAccountID"`
+	Amount      int64 `json:"amount"`
+	// set when the item was transferred, error is set otherwise
+	TransferID sql.NullInt64  `json:"transferID"`
+	Error      sql.NullString `json:"error"`
+}
+
 type User struct {
-	Username          string    `json:"username"`
-	HashedPassword    string    `json:"hashedPassword"`
-	FullName          string    `json:"fullName"`
-	Email             string    `json:"email"`
-	PasswordChangedAt time.Time `json:"passwordChangedAt"`
-	CreatedAt         time.Time `json:"createdAt"`
+	Username            string       `json:"username"`
+	HashedPassword      string       `json:"hashedPassword"`
+	FullName            string       `json:"fullName"`
+	Email               string       `json:"email"`
+	PasswordChangedAt   time.Time    `json:"passwordChangedAt"`
+	CreatedAt           time.Time    `json:"createdAt"`
+	IsEmailVerified     bool         `json:"isEmailVerified"`
+	Role                string       `json:"role"`
+	FailedLoginAttempts int32        `json:"failedLoginAttempts"`
+	LastFailedLoginAt   sql.NullTime `json:"lastFailedLoginAt"`
+	LockedUntil         sql.NullTime `json:"lockedUntil"`
+	// encrypted with the TOTP encryption key
+	TotpSecret  sql.NullString `json:"totpSecret"`
+	TotpEnabled bool           `json:"totpEnabled"`
+	// last accepted time step, codes cannot be replayed
+	TotpLastStep int64 `json:"totpLastStep"`
+}
+
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	SubscriptionID int64           `json:"subscriptionID"`
+	EventID        uuid.UUID       `json:"eventID"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload"`
+	// pending, succeeded or dead
+	Status         string         `json:"status"`
+	Attempts       int32          `json:"attempts"`
+	NextAttemptAt  time.Time      `json:"nextAttemptAt"`
+	LastStatusCode sql.NullInt32  `json:"lastStatusCode"`
+	LastError      sql.NullString `json:"lastError"`
+	DeliveredAt    sql.NullTime   `json:"deliveredAt"`
+	CreatedAt      time.Time      `json:"createdAt"`
+}
+
+type WebhookSubscription struct {
+	ID         int64    `json:"id"`
+	Username   string   `json:"username"`
+	Url        string   `json:"url"`
+	EventTypes []string `json:"eventTypes"`
+	// encrypted with the webhook encryption key
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"createdAt"`
 }
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Aggregates outbox events belong to. Every event gets the next sequence of its aggregate in the transaction
+// writing it, and the events of one aggregate are published in sequence order
+const (
+	AggregateUser    = "user"
+	AggregateAccount = "account"
+)
+
+// Types of the events written to the outbox
+const (
+	EventUserCreated     = "user.created"
+	EventAccountCreated  = "account.created"
+	EventAccountFrozen   = "account.frozen"
+	EventTransferCreated = "transfer.created"
+)
+
+// UserCreatedEvent is the payload of user.created, it leaves out every credential of the user
+type UserCreatedEvent struct {
+	Username  string    `json:"username"`
+	FullName  string    `json:"full_name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AccountCreatedEvent is the payload of account.created
+type AccountCreatedEvent struct {
+	ID        int64     `json:"id"`
+	Owner     string    `json:"owner"`
+	Balance   int64     `json:"balance"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AccountFrozenEvent is the payload of account.frozen
+type AccountFrozenEvent struct {
+	ID       int64  `json:"id"`
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+}
+
+// TransferCreatedEvent is the payload of transfer.created, it leaves out the balances of the accounts
+type TransferCreatedEvent struct {
+	ID            int64     `json:"id"`
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func newOutboxEvent(aggregateType, aggregateID, eventType string, data interface{}) (CreateOutboxEventParams, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return CreateOutboxEventParams{}, err
+	}
+
+	return CreateOutboxEventParams{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       payload,
+	}, nil
+}
+
+func newUserCreatedEvent(user User) (CreateOutboxEventParams, error) {
+	return newOutboxEvent(AggregateUser, user.Username, EventUserCreated, UserCreatedEvent{
+		Username:  user.Username,
+		FullName:  user.FullName,
+		Email:     user.Email,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+	})
+}
+
+func newAccountCreatedEvent(account Account) (CreateOutboxEventParams, error) {
+	return newOutboxEvent(AggregateAccount, strconv.FormatInt(account.ID, 10), EventAccountCreated, AccountCreatedEvent{
+		ID:        account.ID,
+		Owner:     account.Owner,
+		Balance:   account.Balance,
+		Currency:  account.Currency,
+		CreatedAt: account.CreatedAt,
+	})
+}
+
+func newAccountFrozenEvent(account Account) (CreateOutboxEventParams, error) {
+	return newOutboxEvent(AggregateAccount, strconv.FormatInt(account.ID, 10), EventAccountFrozen, AccountFrozenEvent{
+		ID:       account.ID,
+		Owner:    account.Owner,
+		Currency: account.Currency,
+	})
+}
+
+// newTransferCreatedEvents returns a transfer.created event for each account of the transfer, in account id order
+// like the balance updates. Keying them by account keeps them in order with the other events of the accounts
+func newTransferCreatedEvents(result TransferTxResult) ([]CreateOutboxEventParams, error) {
+	transfer := result.Transfer
+
+	accountIDs := []int64{transfer.FromAccountID, transfer.ToAccountID}
+	if transfer.ToAccountID < transfer.FromAccountID {
+		accountIDs[0], accountIDs[1] = accountIDs[1], accountIDs[0]
+	}
+	if accountIDs[0] == accountIDs[1] {
+		accountIDs = accountIDs[:1]
+	}
+
+	events := make([]CreateOutboxEventParams, len(accountIDs))
+	for i, accountID := range accountIDs {
+		event, err := newOutboxEvent(AggregateAccount, strconv.FormatInt(accountID, 10), EventTransferCreated, TransferCreatedEvent{
+			ID:            transfer.ID,
+			FromAccountID: transfer.FromAccountID,
+			ToAccountID:   transfer.ToAccountID,
+			Amount:        transfer.Amount,
+			Currency:      result.FromAccount.Currency,
+			CreatedAt:     transfer.CreatedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		events[i] = event
+	}
+
+	return events, nil
+}
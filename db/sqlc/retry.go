@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"math/rand"
+	"time"
+)
+
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// RetryPolicy controls how transactions failing with a retryable postgres error are retried
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy is used by stores created without WithRetryPolicy
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   10 * time.Millisecond,
+	MaxDelay:    200 * time.Millisecond,
+}
+
+// IsRetryableError reports whether err is a serialization failure or deadlock that is safe to retry
+func IsRetryableError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	switch pqErr.Code {
+	case serializationFailure, deadlockDetected:
+		return true
+	}
+
+	return false
+}
+
+// ParseIsolationLevel converts a config value such as "serializable" into a sql.IsolationLevel
+func ParseIsolationLevel(level string) (sql.IsolationLevel, error) {
+	switch level {
+	case "", "default":
+		return sql.LevelDefault, nil
+	case "read_committed":
+		return sql.LevelReadCommitted, nil
+	case "repeatable_read":
+		return sql.LevelRepeatableRead, nil
+	case "serializable":
+		return sql.LevelSerializable, nil
+	}
+
+	return sql.LevelDefault, fmt.Errorf("unsupported isolation level: %s", level)
+}
+
+// backoff returns a random delay between zero and the exponential delay for the given attempt
+func (policy RetryPolicy) backoff(attempt int) time.Duration {
+	delay := policy.BaseDelay << attempt
+	if delay <= 0 || delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// retry runs fn until it succeeds, fails with a non retryable error or the attempts are exhausted
+func (policy RetryPolicy) retry(ctx context.Context, fn func() error) error {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	span := trace.SpanFromContext(ctx)
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || !IsRetryableError(err) {
+			return err
+		}
+
+		if attempt >= maxAttempts {
+			zerolog.Ctx(ctx).Warn().Err(err).Int("attempts", attempt).Msg("transaction retries exhausted")
+			return err
+		}
+
+		delay := policy.backoff(attempt - 1)
+
+		zerolog.Ctx(ctx).Info().Err(err).Int("attempt", attempt).Dur("delay", delay).Msg("retrying transaction")
+		span.AddEvent("tx.retry", trace.WithAttributes(
+			attribute.Int("tx.attempt", attempt),
+			attribute.String("tx.error", err.Error()),
+		))
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	require.True(t, IsRetryableError(&pq.Error{Code: serializationFailure}))
+	require.True(t, IsRetryableError(&pq.Error{Code: deadlockDetected}))
+	require.True(t, IsRetryableError(fmt.Errorf("tx err: %w, rb err: %v", &pq.Error{Code: deadlockDetected}, sql.ErrTxDone)))
+	require.False(t, IsRetryableError(&pq.Error{Code: "23505"}))
+	require.False(t, IsRetryableError(sql.ErrNoRows))
+	require.False(t, IsRetryableError(nil))
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+
+	for attempt := 0; attempt < 10; attempt++ {
+		delay := policy.backoff(attempt)
+		require.True(t, delay >= 0)
+		require.True(t, delay <= policy.MaxDelay)
+	}
+
+	require.Zero(t, RetryPolicy{}.backoff(3))
+}
+
+func TestRetryPolicyRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	testCases := []struct {
+		name     string
+		errs     []error
+		attempts int
+		wantErr  bool
+	}{
+		{
+			name:     "Succeeds First Time",
+			errs:     []error{nil},
+			attempts: 1,
+		},
+		{
+			name:     "Succeeds After Retry",
+			errs:     []error{&pq.Error{Code: serializationFailure}, &pq.Error{Code: deadlockDetected}, nil},
+			attempts: 3,
+		},
+		{
+			name:     "Exhausts Attempts",
+			errs:     []error{&pq.Error{Code: serializationFailure}, &pq.Error{Code: serializationFailure}, &pq.Error{Code: serializationFailure}},
+			attempts: 3,
+			wantErr:  true,
+		},
+		{
+			name:     "Non Retryable Error",
+			errs:     []error{sql.ErrConnDone},
+			attempts: 1,
+			wantErr:  true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			err := policy.retry(context.Background(), func() error {
+				err := tc.errs[attempts]
+				attempts++
+				return err
+			})
+
+			require.Equal(t, tc.attempts, attempts)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -4,40 +4,98 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	TransferBatchTx(ctx context.Context, arg TransferBatchTxParams) (TransferBatchTxResult, error)
+	AccountStatementTx(ctx context.Context, arg AccountStatementParams) (AccountStatement, error)
+	AccrueInterestTx(ctx context.Context, arg AccrueInterestTxParams) (AccrueInterestTxResult, error)
+	PostInterestTx(ctx context.Context, arg PostInterestTxParams) (PostInterestTxResult, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (User, error)
+	VerifyEmailTx(ctx context.Context, tokenHash string) (User, error)
+	ConfirmTOTPTx(ctx context.Context, arg ConfirmTOTPTxParams) (User, error)
+	CreateUserTx(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error)
+	FreezeAccountTx(ctx context.Context, id int64) (Account, error)
+	SubscribeEntries(ctx context.Context, accountID int64) (<-chan Entry, error)
+	Ping(ctx context.Context) error
+	MigrationVersion(ctx context.Context) (version int64, dirty bool, err error)
 }
 
 // SQLStore provides all functions to execute db queries and transactions
 type SQLStore struct {
 	*Queries
-	db *sql.DB
+	db                *sql.DB
+	retryPolicy       RetryPolicy
+	transferIsolation sql.IsolationLevel
+	entries           *entryHub
+}
+
+// StoreOption configures optional behaviour of a SQLStore
+type StoreOption func(store *SQLStore)
+
+// WithRetryPolicy sets how transactions failing with serialization failures or deadlocks are retried
+func WithRetryPolicy(policy RetryPolicy) StoreOption {
+	return func(store *SQLStore) {
+		store.retryPolicy = policy
+	}
+}
+
+// WithTransferIsolation sets the isolation level transfer transactions run with
+func WithTransferIsolation(level sql.IsolationLevel) StoreOption {
+	return func(store *SQLStore) {
+		store.transferIsolation = level
+	}
 }
 
 // NewStore creates a new store
-func NewStore(db *sql.DB) Store {
-	return &SQLStore{
-		db:      db,
-		Queries: New(db),
+func NewStore(db *sql.DB, options ...StoreOption) Store {
+	store := &SQLStore{
+		db:          db,
+		Queries:     New(newInstrumentedDB(db)),
+		retryPolicy: DefaultRetryPolicy,
 	}
+
+	for _, option := range options {
+		option(store)
+	}
+
+	return store
+}
+
+// execTx executes a function within a database transaction with the given isolation level.
+// The whole transaction is retried when it fails with a serialization failure or deadlock,
+// so fn must be safe to run more than once
+func (store *SQLStore) execTx(ctx context.Context, isolation sql.IsolationLevel, fn func(queries *Queries) error) (err error) {
+	ctx, span := startTxSpan(ctx, "execTx", attribute.String("tx.isolation", isolation.String()))
+	defer func() {
+		recordError(span, err)
+		span.End()
+	}()
+
+	return store.retryPolicy.retry(ctx, func() error {
+		return store.runTx(ctx, &sql.TxOptions{Isolation: isolation}, fn)
+	})
 }
 
-// execTx executes a function within a database transaction
-func (store *SQLStore) execTx(ctx context.Context, fn func(queries *Queries) error) error {
-	tx, err := store.db.BeginTx(ctx, nil)
+// runTx runs a single attempt of a transaction
+func (store *SQLStore) runTx(ctx context.Context, options *sql.TxOptions, fn func(queries *Queries) error) error {
+	tx, err := store.db.BeginTx(ctx, options)
 	if err != nil {
 		return err
 	}
 
-	q := New(tx)
+	q := New(newInstrumentedDB(tx))
 	err = fn(q)
 	if err != nil {
 		rbErr := tx.Rollback()
 		if rbErr != nil {
-			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+			zerolog.Ctx(ctx).Error().Err(rbErr).AnErr("tx_error", err).Msg("cannot rollback transaction")
+			return fmt.Errorf("tx err: %w, rb err: %v", err, rbErr)
 		}
 		return err
 	}
@@ -64,56 +122,116 @@ type TransferTxResult struct {
 /*
 	TransferTx performs a money transfer from one account to another
 	It creates a transfer record, add account entries, update accounts balances within a transaction
+	The product of the source account must allow it to initiate transfers and its overdraft limit must cover the amount,
+	ErrTransfersNotAllowed or ErrInsufficientFunds is returned otherwise. ErrAccountFrozen is returned when either
+	account is frozen
 */
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 	var err error
 
-	err = store.execTx(ctx, func(q *Queries) error {
-		// create transfer record
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
+	ctx, span := startTxSpan(ctx, "TransferTx",
+		attribute.Int64("transfer.from_account_id", arg.FromAccountID),
+		attribute.Int64("transfer.to_account_id", arg.ToAccountID),
+		attribute.Int64("transfer.amount", arg.Amount),
+	)
+	defer span.End()
+
+	err = store.execTx(ctx, store.transferIsolation, func(q *Queries) error {
+		result, err = transferTx(ctx, q, arg)
 		if err != nil {
 			return err
 		}
 
-		// add FromAccount entry, amount will be negative since it is deduction
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
-
-		// add ToAccount entry, amount will be negative since it is deduction
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
-
-		/**
-		Prevent transaction deadlock by running the transactions by order of the id
-		*/
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(context.Background(), q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-			if err != nil {
-				return err
-			}
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(context.Background(), q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
-			if err != nil {
-				return err
-			}
+		// the accounts are checked once their balance updates locked them, a failing check rolls the transfer back
+		if err := checkTransferAccounts(result.FromAccount, result.ToAccount); err != nil {
+			return err
+		}
 
+		product, err := q.GetAccountProduct(ctx, result.FromAccount.ProductCode)
+		if err != nil {
+			return err
 		}
 
-		return nil
+		balance := result.FromAccount.Balance
+		if arg.FromAccountID != arg.ToAccountID {
+			balance += arg.Amount
+		}
+
+		return checkTransferSource(balance, arg.Amount, product)
 	})
+	recordError(span, err)
 
 	return result, err
 }
 
+// transferTx runs the statements of a transfer, writing transfer.created to the outbox, within the transaction of q
+func transferTx(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+	var err error
+
+	// create transfer record
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	/**
+	Prevent transaction deadlock by running the transactions by order of the id
+	*/
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
+		if err != nil {
+			return result, err
+		}
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+		if err != nil {
+			return result, err
+		}
+
+	}
+
+	// add FromAccount entry, amount will be negative since it is deduction. The entries are written once the balance
+	// updates locked both accounts, so the sequences they take from their account follow the order transfers commit in
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  arg.FromAccountID,
+		Amount:     -arg.Amount,
+		TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// add ToAccount entry, amount will be negative since it is deduction
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  arg.ToAccountID,
+		Amount:     arg.Amount,
+		TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+	})
+	if err != nil {
+		return result, err
+	}
+
+	events, err := newTransferCreatedEvents(result)
+	if err != nil {
+		return result, err
+	}
+
+	for _, event := range events {
+		_, err = q.CreateOutboxEvent(ctx, event)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
 func addMoney(
 	ctx context.Context,
 	q *Queries,
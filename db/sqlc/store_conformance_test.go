@@ -0,0 +1,1587 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestMemoryStoreConformance runs the store conformance suite against the in-memory store
+func TestMemoryStoreConformance(t *testing.T) {
+	testStoreConformance(t, NewMemoryStore())
+}
+
+// TestSQLStoreConformance runs the store conformance suite against postgres
+func TestSQLStoreConformance(t *testing.T) {
+	testStoreConformance(t, NewStore(testDb))
+}
+
+// testStoreConformance checks the behaviour every Store implementation must share.
+// Tests only rely on rows they create, so they can run against a database that already holds data
+func testStoreConformance(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	newUser := func(t *testing.T) User {
+		user, err := store.CreateUser(ctx, CreateUserParams{
+			Username:       util.RandomOwner() + util.RandomString(6),
+			HashedPassword: util.RandomString(32),
+			FullName:       util.RandomOwner(),
+			Email:          util.RandomEmail(),
+		})
+		require.NoError(t, err)
+		return user
+	}
+
+	newAccount := func(t *testing.T, balance int64) Account {
+		account, err := store.CreateAccount(ctx, CreateAccountParams{
+			Owner:       newUser(t).Username,
+			Balance:     balance,
+			Currency:    util.USD,
+			ProductCode: AccountProductChecking,
+		})
+		require.NoError(t, err)
+		return account
+	}
+
+	requireConstraint := func(t *testing.T, err error, code pq.ErrorCode, constraint string) {
+		pqErr, ok := err.(*pq.Error)
+		require.True(t, ok, "expected *pq.Error, got %T", err)
+		require.Equal(t, code, pqErr.Code)
+		require.Equal(t, constraint, pqErr.Constraint)
+	}
+
+	t.Run("CreateUser", func(t *testing.T) {
+		user := newUser(t)
+		require.True(t, user.PasswordChangedAt.IsZero())
+		require.NotZero(t, user.CreatedAt)
+
+		found, err := store.GetUser(ctx, user.Username)
+		require.NoError(t, err)
+		require.Equal(t, user.Email, found.Email)
+	})
+
+	t.Run("CreateUser Duplicate Username", func(t *testing.T) {
+		user := newUser(t)
+
+		_, err := store.CreateUser(ctx, CreateUserParams{
+			Username:       user.Username,
+			HashedPassword: util.RandomString(32),
+			FullName:       util.RandomOwner(),
+			Email:          util.RandomEmail(),
+		})
+		requireConstraint(t, err, "23505", "users_pkey")
+	})
+
+	t.Run("CreateUser Duplicate Email", func(t *testing.T) {
+		user := newUser(t)
+
+		_, err := store.CreateUser(ctx, CreateUserParams{
+			Username:       util.RandomOwner() + util.RandomString(6),
+			HashedPassword: util.RandomString(32),
+			FullName:       util.RandomOwner(),
+			Email:          user.Email,
+		})
+		requireConstraint(t, err, "23505", "users_email_key")
+	})
+
+	t.Run("GetUser Not Found", func(t *testing.T) {
+		_, err := store.GetUser(ctx, util.RandomString(20))
+		require.Equal(t, sql.ErrNoRows, err)
+	})
+
+	t.Run("CreateAccount Unknown Owner", func(t *testing.T) {
+		_, err := store.CreateAccount(ctx, CreateAccountParams{
+			Owner:       util.RandomString(20),
+			Currency:    util.USD,
+			ProductCode: AccountProductChecking,
+		})
+		requireConstraint(t, err, "23503", "accounts_owner_fkey")
+	})
+
+	t.Run("CreateAccount Unknown Product", func(t *testing.T) {
+		owner := newUser(t).Username
+
+		_, err := store.CreateAccount(ctx, CreateAccountParams{
+			Owner:       owner,
+			Currency:    util.USD,
+			ProductCode: util.RandomString(20),
+		})
+		requireConstraint(t, err, "23503", "accounts_product_code_fkey")
+
+		_, err = store.CreateAccountTx(ctx, CreateAccountParams{
+			Owner:       owner,
+			Currency:    util.USD,
+			ProductCode: util.RandomString(20),
+		})
+		require.Equal(t, sql.ErrNoRows, err)
+	})
+
+	t.Run("CreateAccountTx Duplicate Product", func(t *testing.T) {
+		account := newAccount(t, 0)
+
+		_, err := store.CreateAccountTx(ctx, CreateAccountParams{
+			Owner:       account.Owner,
+			Currency:    account.Currency,
+			ProductCode: AccountProductChecking,
+		})
+		require.ErrorIs(t, err, ErrAccountExists)
+
+		// the same currency with another product, or another currency with the same product
+		for _, arg := range []CreateAccountParams{
+			{Owner: account.Owner, Currency: account.Currency, ProductCode: AccountProductSavings},
+			{Owner: account.Owner, Currency: util.CAD, ProductCode: AccountProductChecking},
+		} {
+			other, err := store.CreateAccountTx(ctx, arg)
+			require.NoError(t, err)
+			require.NotEqual(t, account.ID, other.ID)
+		}
+
+		// business accounts can be opened more than once per currency
+		for i := 0; i < 2; i++ {
+			business, err := store.CreateAccountTx(ctx, CreateAccountParams{
+				Owner:       account.Owner,
+				Currency:    account.Currency,
+				ProductCode: AccountProductBusiness,
+			})
+			require.NoError(t, err)
+			require.True(t, business.MultiplePerCurrency)
+		}
+
+		accounts, err := store.ListAccounts(ctx, ListAccountsParams{Owner: account.Owner, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, accounts, 5)
+	})
+
+	t.Run("CreateAccount Duplicate Product", func(t *testing.T) {
+		account := newAccount(t, 0)
+		require.False(t, account.MultiplePerCurrency)
+
+		// the database enforces the rule for writes bypassing CreateAccountTx
+		_, err := store.CreateAccount(ctx, CreateAccountParams{
+			Owner:       account.Owner,
+			Currency:    account.Currency,
+			ProductCode: account.ProductCode,
+		})
+		requireConstraint(t, err, "23505", "accounts_owner_currency_product_code_key")
+	})
+
+	t.Run("GetAccount Not Found", func(t *testing.T) {
+		_, err := store.GetAccount(ctx, 1<<62)
+		require.Equal(t, sql.ErrNoRows, err)
+
+		_, err = store.GetAccountForUpdate(ctx, 1<<62)
+		require.Equal(t, sql.ErrNoRows, err)
+	})
+
+	t.Run("Update Balances", func(t *testing.T) {
+		account := newAccount(t, 100)
+
+		updated, err := store.UpdateAccount(ctx, UpdateAccountParams{ID: account.ID, Balance: 50})
+		require.NoError(t, err)
+		require.Equal(t, int64(50), updated.Balance)
+
+		updated, err = store.AddAccountBalance(ctx, AddAccountBalanceParams{ID: account.ID, Amount: -20})
+		require.NoError(t, err)
+		require.Equal(t, int64(30), updated.Balance)
+
+		_, err = store.AddAccountBalance(ctx, AddAccountBalanceParams{ID: 1 << 62, Amount: 1})
+		require.Equal(t, sql.ErrNoRows, err)
+	})
+
+	t.Run("DeleteAccount", func(t *testing.T) {
+		account := newAccount(t, 0)
+
+		err := store.DeleteAccount(ctx, account.ID)
+		require.NoError(t, err)
+
+		_, err = store.GetAccount(ctx, account.ID)
+		require.Equal(t, sql.ErrNoRows, err)
+	})
+
+	t.Run("DeleteAccount With Entries", func(t *testing.T) {
+		account := newAccount(t, 0)
+
+		_, err := store.CreateEntry(ctx, CreateEntryParams{AccountID: account.ID, Amount: 10})
+		require.NoError(t, err)
+
+		err = store.DeleteAccount(ctx, account.ID)
+		requireConstraint(t, err, "23503", "entries_account_id_fkey")
+	})
+
+	t.Run("ListAccounts", func(t *testing.T) {
+		account := newAccount(t, 0)
+		newAccount(t, 0)
+
+		other, err := store.CreateAccount(ctx, CreateAccountParams{
+			Owner:       account.Owner,
+			Balance:     0,
+			Currency:    util.CAD,
+			ProductCode: AccountProductChecking,
+		})
+		require.NoError(t, err)
+
+		accounts, err := store.ListAccounts(ctx, ListAccountsParams{Owner: account.Owner, Limit: 5, Offset: 0})
+		require.NoError(t, err)
+		require.Len(t, accounts, 2)
+		require.Equal(t, account.ID, accounts[0].ID)
+		require.Equal(t, other.ID, accounts[1].ID)
+	})
+
+	t.Run("Entries", func(t *testing.T) {
+		account := newAccount(t, 0)
+
+		_, err := store.CreateEntry(ctx, CreateEntryParams{AccountID: 1 << 62, Amount: 10})
+		requireConstraint(t, err, "23503", "entries_account_id_fkey")
+
+		var created []Entry
+		for i := 0; i < 3; i++ {
+			entry, err := store.CreateEntry(ctx, CreateEntryParams{AccountID: account.ID, Amount: int64(i + 1)})
+			require.NoError(t, err)
+			created = append(created, entry)
+		}
+
+		found, err := store.GetEntry(ctx, created[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, created[0].Amount, found.Amount)
+
+		entries, err := store.ListEntries(ctx, ListEntriesParams{AccountID: account.ID, Limit: 2, Offset: 1})
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		require.Equal(t, created[1].ID, entries[0].ID)
+		require.Equal(t, created[2].ID, entries[1].ID)
+
+		_, err = store.GetEntry(ctx, 1<<62)
+		require.Equal(t, sql.ErrNoRows, err)
+
+		// the entries of an account are numbered from 1
+		for i, entry := range created {
+			require.Equal(t, int64(i+1), entry.Sequence)
+		}
+
+		entries, err = store.ListEntriesAfter(ctx, ListEntriesAfterParams{AccountID: account.ID, Sequence: created[0].Sequence, Limit: 5})
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		require.Equal(t, created[1].ID, entries[0].ID)
+		require.Equal(t, created[2].ID, entries[1].ID)
+
+		lastSequence, err := store.GetLastEntrySequence(ctx, account.ID)
+		require.NoError(t, err)
+		require.Equal(t, created[2].Sequence, lastSequence)
+
+		other := newAccount(t, 0)
+		lastSequence, err = store.GetLastEntrySequence(ctx, other.ID)
+		require.NoError(t, err)
+		require.Zero(t, lastSequence)
+
+		entry, err := store.CreateEntry(ctx, CreateEntryParams{AccountID: other.ID, Amount: 1})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), entry.Sequence)
+
+		_, err = store.CreateEntry(ctx, CreateEntryParams{
+			AccountID:  account.ID,
+			Amount:     10,
+			TransferID: sql.NullInt64{Int64: 1 << 62, Valid: true},
+		})
+		requireConstraint(t, err, "23503", "entries_transfer_id_fkey")
+
+		now := time.Now()
+		entries, err = store.ListEntriesBetween(ctx, ListEntriesBetweenParams{
+			AccountID: account.ID,
+			FromTime:  now.Add(-time.Hour),
+			ToTime:    now.Add(time.Hour),
+		})
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		require.Equal(t, created[0].ID, entries[0].ID)
+
+		total, err := store.SumEntriesSince(ctx, SumEntriesSinceParams{AccountID: account.ID, CreatedAt: now.Add(-time.Hour)})
+		require.NoError(t, err)
+		require.Equal(t, int64(6), total)
+
+		total, err = store.SumEntriesSince(ctx, SumEntriesSinceParams{AccountID: account.ID, CreatedAt: now.Add(time.Hour)})
+		require.NoError(t, err)
+		require.Zero(t, total)
+	})
+
+	t.Run("Transfers", func(t *testing.T) {
+		accountOne := newAccount(t, 0)
+		accountTwo := newAccount(t, 0)
+
+		_, err := store.CreateTransfer(ctx, CreateTransferParams{FromAccountID: accountOne.ID, ToAccountID: 1 << 62, Amount: 10})
+		requireConstraint(t, err, "23503", "transfers_to_account_id_fkey")
+
+		_, err = store.CreateTransfer(ctx, CreateTransferParams{FromAccountID: 1 << 62, ToAccountID: accountTwo.ID, Amount: 10})
+		requireConstraint(t, err, "23503", "transfers_from_account_id_fkey")
+
+		transfer, err := store.CreateTransfer(ctx, CreateTransferParams{FromAccountID: accountOne.ID, ToAccountID: accountTwo.ID, Amount: 10})
+		require.NoError(t, err)
+
+		found, err := store.GetTransfer(ctx, transfer.ID)
+		require.NoError(t, err)
+		require.Equal(t, transfer.Amount, found.Amount)
+
+		transfers, err := store.ListTransfers(ctx, ListTransfersParams{
+			FromAccountID: accountOne.ID,
+			ToAccountID:   accountOne.ID,
+			Limit:         5,
+		})
+		require.NoError(t, err)
+		require.Len(t, transfers, 1)
+		require.Equal(t, transfer.ID, transfers[0].ID)
+
+		_, err = store.GetTransfer(ctx, 1<<62)
+		require.Equal(t, sql.ErrNoRows, err)
+
+		transfers, err = store.ListTransfersByIDs(ctx, []int64{transfer.ID, 1 << 62, transfer.ID})
+		require.NoError(t, err)
+		require.Len(t, transfers, 1)
+		require.Equal(t, transfer.ID, transfers[0].ID)
+	})
+
+	t.Run("TransferTx", func(t *testing.T) {
+		accountOne := newAccount(t, 100)
+		accountTwo := newAccount(t, 100)
+
+		result, err := store.TransferTx(ctx, TransferTxParams{
+			FromAccountID: accountOne.ID,
+			ToAccountID:   accountTwo.ID,
+			Amount:        30,
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(70), result.FromAccount.Balance)
+		require.Equal(t, int64(130), result.ToAccount.Balance)
+		require.Equal(t, int64(-30), result.FromEntry.Amount)
+		require.Equal(t, int64(30), result.ToEntry.Amount)
+		require.Equal(t, accountOne.ID, result.Transfer.FromAccountID)
+		require.Equal(t, sql.NullInt64{Int64: result.Transfer.ID, Valid: true}, result.FromEntry.TransferID)
+		require.Equal(t, sql.NullInt64{Int64: result.Transfer.ID, Valid: true}, result.ToEntry.TransferID)
+	})
+
+	t.Run("AccountStatementTx", func(t *testing.T) {
+		account := newAccount(t, 100)
+		other := newAccount(t, 100)
+
+		_, err := store.TransferTx(ctx, TransferTxParams{FromAccountID: account.ID, ToAccountID: other.ID, Amount: 30})
+		require.NoError(t, err)
+		_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: other.ID, ToAccountID: account.ID, Amount: 5})
+		require.NoError(t, err)
+		entry, err := store.CreateEntry(ctx, CreateEntryParams{AccountID: account.ID, Amount: 1})
+		require.NoError(t, err)
+		_, err = store.AddAccountBalance(ctx, AddAccountBalanceParams{ID: account.ID, Amount: 1})
+		require.NoError(t, err)
+
+		owner, err := store.GetUser(ctx, other.Owner)
+		require.NoError(t, err)
+
+		now := time.Now()
+		statement, err := store.AccountStatementTx(ctx, AccountStatementParams{
+			AccountID: account.ID,
+			From:      now.Add(-time.Hour),
+			To:        now.Add(time.Hour),
+		})
+		require.NoError(t, err)
+		require.Equal(t, account.ID, statement.Account.ID)
+		require.Equal(t, int64(76), statement.Account.Balance)
+		require.Equal(t, int64(100), statement.OpeningBalance)
+		require.Equal(t, int64(76), statement.ClosingBalance)
+
+		require.Len(t, statement.Entries, 3)
+		require.Equal(t, int64(-30), statement.Entries[0].Entry.Amount)
+		require.Equal(t, &StatementParty{AccountID: other.ID, Owner: other.Owner, Name: owner.FullName}, statement.Entries[0].Counterparty)
+		require.Equal(t, int64(5), statement.Entries[1].Entry.Amount)
+		require.Equal(t, other.ID, statement.Entries[1].Counterparty.AccountID)
+		require.Equal(t, entry.ID, statement.Entries[2].Entry.ID)
+		require.Nil(t, statement.Entries[2].Counterparty)
+
+		// the balances of a period before the entries exclude them
+		statement, err = store.AccountStatementTx(ctx, AccountStatementParams{
+			AccountID: account.ID,
+			From:      now.Add(-2 * time.Hour),
+			To:        now.Add(-time.Hour),
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(100), statement.OpeningBalance)
+		require.Equal(t, int64(100), statement.ClosingBalance)
+		require.Empty(t, statement.Entries)
+
+		_, err = store.AccountStatementTx(ctx, AccountStatementParams{AccountID: 1 << 62, From: now, To: now})
+		require.Equal(t, sql.ErrNoRows, err)
+	})
+
+	t.Run("TransferTx Unknown Account", func(t *testing.T) {
+		account := newAccount(t, 100)
+
+		_, err := store.TransferTx(ctx, TransferTxParams{
+			FromAccountID: account.ID,
+			ToAccountID:   1 << 62,
+			Amount:        30,
+		})
+		require.Error(t, err)
+
+		// nothing from the failed transfer may be persisted
+		found, err := store.GetAccount(ctx, account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, found.Balance)
+
+		entries, err := store.ListEntries(ctx, ListEntriesParams{AccountID: account.ID, Limit: 5})
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+
+	t.Run("TransferTx Concurrent", func(t *testing.T) {
+		accountOne := newAccount(t, 100)
+		accountTwo := newAccount(t, 100)
+
+		n := 10
+		errs := make(chan error)
+
+		for i := 0; i < n; i++ {
+			fromAccountID, toAccountID := accountOne.ID, accountTwo.ID
+			if i%2 == 1 {
+				fromAccountID, toAccountID = accountTwo.ID, accountOne.ID
+			}
+
+			go func() {
+				_, err := store.TransferTx(ctx, TransferTxParams{
+					FromAccountID: fromAccountID,
+					ToAccountID:   toAccountID,
+					Amount:        10,
+				})
+				errs <- err
+			}()
+		}
+
+		for i := 0; i < n; i++ {
+			require.NoError(t, <-errs)
+		}
+
+		updated, err := store.GetAccount(ctx, accountOne.ID)
+		require.NoError(t, err)
+		require.Equal(t, accountOne.Balance, updated.Balance)
+	})
+
+	batchItemErrors := func(items []TransferBatchItem) []string {
+		errs := make([]string, len(items))
+		for i, item := range items {
+			errs[i] = item.Error.String
+		}
+		return errs
+	}
+
+	t.Run("TransferBatchTx Atomic", func(t *testing.T) {
+		source := newAccount(t, 100)
+		accountOne := newAccount(t, 0)
+		accountTwo := newAccount(t, 0)
+
+		result, err := store.TransferBatchTx(ctx, TransferBatchTxParams{
+			Owner:         source.Owner,
+			FromAccountID: source.ID,
+			Mode:          TransferBatchAtomic,
+			Items: []TransferBatchItemParams{
+				{ToAccountID: accountOne.ID, Amount: 30},
+				{ToAccountID: accountTwo.ID, Amount: 70},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, TransferBatchCompleted, result.Batch.Status)
+		require.Equal(t, int32(2), result.Batch.ItemCount)
+		require.Equal(t, int32(2), result.Batch.SucceededCount)
+		require.Equal(t, int64(100), result.Batch.TotalAmount)
+		require.Len(t, result.Transfers, 2)
+		require.Equal(t, int64(0), result.Transfers[1].FromAccount.Balance)
+
+		found, err := store.GetTransferBatch(ctx, result.Batch.ID)
+		require.NoError(t, err)
+		require.Equal(t, result.Batch, found)
+
+		items, err := store.ListTransferBatchItems(ctx, result.Batch.ID)
+		require.NoError(t, err)
+		require.Equal(t, result.Items, items)
+		require.Equal(t, result.Transfers[0].Transfer.ID, items[0].TransferID.Int64)
+		require.Equal(t, int32(1), items[1].Position)
+		require.False(t, items[1].Error.Valid)
+	})
+
+	t.Run("TransferBatchTx Atomic Failure", func(t *testing.T) {
+		source := newAccount(t, 100)
+		account := newAccount(t, 0)
+
+		result, err := store.TransferBatchTx(ctx, TransferBatchTxParams{
+			Owner:         source.Owner,
+			FromAccountID: source.ID,
+			Mode:          TransferBatchAtomic,
+			Items: []TransferBatchItemParams{
+				{ToAccountID: account.ID, Amount: 30},
+				{ToAccountID: 1 << 62, Amount: 10},
+				{ToAccountID: account.ID, Amount: 10},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, TransferBatchFailed, result.Batch.Status)
+		require.Zero(t, result.Batch.SucceededCount)
+		require.Empty(t, result.Transfers)
+		require.Equal(t, []string{errBatchRolledBack.Error(), errBatchAccountNotFound.Error(), errBatchRolledBack.Error()}, batchItemErrors(result.Items))
+
+		// nothing of the batch was transferred
+		found, err := store.GetAccount(ctx, source.ID)
+		require.NoError(t, err)
+		require.Equal(t, source.Balance, found.Balance)
+
+		entries, err := store.ListEntries(ctx, ListEntriesParams{AccountID: account.ID, Limit: 5})
+		require.NoError(t, err)
+		require.Empty(t, entries)
+
+		items, err := store.ListTransferBatchItems(ctx, result.Batch.ID)
+		require.NoError(t, err)
+		require.Len(t, items, 3)
+	})
+
+	t.Run("TransferBatchTx Best Effort", func(t *testing.T) {
+		source := newAccount(t, 100)
+		account := newAccount(t, 0)
+
+		result, err := store.TransferBatchTx(ctx, TransferBatchTxParams{
+			Owner:         source.Owner,
+			FromAccountID: source.ID,
+			Mode:          TransferBatchBestEffort,
+			Items: []TransferBatchItemParams{
+				{ToAccountID: account.ID, Amount: 60},
+				{ToAccountID: 1 << 62, Amount: 10},
+				{ToAccountID: account.ID, Amount: 50},
+				{ToAccountID: account.ID, Amount: 40},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, TransferBatchPartiallyCompleted, result.Batch.Status)
+		require.Equal(t, int32(2), result.Batch.SucceededCount)
+		require.Len(t, result.Transfers, 2)
+		require.Equal(t, []string{"", errBatchAccountNotFound.Error(), ErrInsufficientFunds.Error(), ""}, batchItemErrors(result.Items))
+		require.True(t, result.Items[3].TransferID.Valid)
+
+		found, err := store.GetAccount(ctx, source.ID)
+		require.NoError(t, err)
+		require.Zero(t, found.Balance)
+
+		found, err = store.GetAccount(ctx, account.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(100), found.Balance)
+	})
+
+	t.Run("Balance Overflow", func(t *testing.T) {
+		account := newAccount(t, 100)
+		full := newAccount(t, math.MaxInt64)
+
+		_, err := store.AddAccountBalance(ctx, AddAccountBalanceParams{ID: full.ID, Amount: 1})
+		pqErr, ok := err.(*pq.Error)
+		require.True(t, ok, "expected *pq.Error, got %T", err)
+		require.Equal(t, pq.ErrorCode(numericValueOutOfRange), pqErr.Code)
+
+		_, err = store.TransferTx(ctx, TransferTxParams{
+			FromAccountID: account.ID,
+			ToAccountID:   full.ID,
+			Amount:        30,
+		})
+		pqErr, ok = err.(*pq.Error)
+		require.True(t, ok, "expected *pq.Error, got %T", err)
+		require.Equal(t, pq.ErrorCode(numericValueOutOfRange), pqErr.Code)
+
+		// the overflowing transfer is rolled back as a whole
+		found, err := store.GetAccount(ctx, account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, found.Balance)
+
+		entries, err := store.ListEntries(ctx, ListEntriesParams{AccountID: account.ID, Limit: 5})
+		require.NoError(t, err)
+		require.Empty(t, entries)
+
+		result, err := store.TransferBatchTx(ctx, TransferBatchTxParams{
+			Owner:         account.Owner,
+			FromAccountID: account.ID,
+			Mode:          TransferBatchBestEffort,
+			Items:         []TransferBatchItemParams{{ToAccountID: full.ID, Amount: 10}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, TransferBatchFailed, result.Batch.Status)
+		require.Equal(t, []string{errBatchBalanceOverflows.Error()}, batchItemErrors(result.Items))
+	})
+
+	t.Run("FreezeAccountTx", func(t *testing.T) {
+		account := newAccount(t, 100)
+		other := newAccount(t, 100)
+
+		frozen, err := store.FreezeAccountTx(ctx, account.ID)
+		require.NoError(t, err)
+		require.True(t, frozen.Frozen)
+		require.Equal(t, account.Balance, frozen.Balance)
+
+		_, err = store.FreezeAccountTx(ctx, account.ID)
+		require.ErrorIs(t, err, ErrAccountAlreadyFrozen)
+
+		_, err = store.FreezeAccountTx(ctx, 1<<62)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		// frozen accounts can neither send nor receive, the failed transfers are rolled back
+		_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: account.ID, ToAccountID: other.ID, Amount: 10})
+		require.ErrorIs(t, err, ErrAccountFrozen)
+
+		_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: other.ID, ToAccountID: account.ID, Amount: 10})
+		require.ErrorIs(t, err, ErrAccountFrozen)
+
+		for _, id := range []int64{account.ID, other.ID} {
+			found, err := store.GetAccount(ctx, id)
+			require.NoError(t, err)
+			require.Equal(t, int64(100), found.Balance)
+		}
+
+		result, err := store.TransferBatchTx(ctx, TransferBatchTxParams{
+			Owner:         other.Owner,
+			FromAccountID: other.ID,
+			Mode:          TransferBatchBestEffort,
+			Items: []TransferBatchItemParams{
+				{ToAccountID: account.ID, Amount: 10},
+				{ToAccountID: newAccount(t, 0).ID, Amount: 10},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, TransferBatchPartiallyCompleted, result.Batch.Status)
+		require.Equal(t, []string{ErrAccountFrozen.Error(), ""}, batchItemErrors(result.Items))
+	})
+
+	t.Run("TransferBatchTx Unknown Source", func(t *testing.T) {
+		user := newUser(t)
+
+		_, err := store.TransferBatchTx(ctx, TransferBatchTxParams{
+			Owner:         user.Username,
+			FromAccountID: 1 << 62,
+			Mode:          TransferBatchBestEffort,
+			Items:         []TransferBatchItemParams{{ToAccountID: 1, Amount: 10}},
+		})
+		requireConstraint(t, err, "23503", "transfer_batches_from_account_id_fkey")
+	})
+
+	t.Run("TransferBatchTx Total Overflows", func(t *testing.T) {
+		account := newAccount(t, 100)
+
+		for _, mode := range []string{TransferBatchAtomic, TransferBatchBestEffort} {
+			_, err := store.TransferBatchTx(ctx, TransferBatchTxParams{
+				Owner:         account.Owner,
+				FromAccountID: account.ID,
+				Mode:          mode,
+				Items: []TransferBatchItemParams{
+					{ToAccountID: newAccount(t, 0).ID, Amount: math.MaxInt64},
+					{ToAccountID: newAccount(t, 0).ID, Amount: 1},
+				},
+			})
+			require.ErrorIs(t, err, util.ErrAmountOverflow)
+		}
+
+		// nothing was transferred
+		found, err := store.GetAccount(ctx, account.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(100), found.Balance)
+	})
+
+	t.Run("GetTransferBatch Not Found", func(t *testing.T) {
+		_, err := store.GetTransferBatch(ctx, 1<<62)
+		require.Equal(t, sql.ErrNoRows, err)
+	})
+
+	t.Run("Account Products", func(t *testing.T) {
+		products, err := store.ListAccountProducts(ctx)
+		require.NoError(t, err)
+
+		codes := make([]string, len(products))
+		for i, product := range products {
+			codes[i] = product.Code
+		}
+		require.Subset(t, codes, []string{AccountProductChecking, AccountProductSavings, AccountProductBusiness, AccountProductEscrow})
+
+		escrow, err := store.GetAccountProduct(ctx, AccountProductEscrow)
+		require.NoError(t, err)
+		require.False(t, escrow.CanInitiateTransfers)
+		require.True(t, escrow.MultiplePerCurrency)
+
+		_, err = store.GetAccountProduct(ctx, util.RandomString(20))
+		require.Equal(t, sql.ErrNoRows, err)
+
+		_, err = store.CreateAccountProduct(ctx, CreateAccountProductParams{Code: AccountProductChecking, Name: "Checking", Currencies: []string{}})
+		requireConstraint(t, err, uniqueViolation, "account_products_pkey")
+
+		_, err = store.CreateAccountProduct(ctx, CreateAccountProductParams{
+			Code:           util.RandomString(12),
+			Name:           "Negative",
+			Currencies:     []string{},
+			OverdraftLimit: -1,
+		})
+		requireConstraint(t, err, checkViolation, "account_products_overdraft_limit_check")
+
+		overdraft, err := store.CreateAccountProduct(ctx, CreateAccountProductParams{
+			Code:                 util.RandomString(12),
+			Name:                 "Overdraft",
+			Currencies:           []string{util.USD},
+			OverdraftLimit:       100,
+			FeeSchedule:          sql.NullString{String: "standard", Valid: true},
+			CanInitiateTransfers: true,
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{util.USD}, overdraft.Currencies)
+
+		account, err := store.CreateAccountTx(ctx, CreateAccountParams{
+			Owner:       newUser(t).Username,
+			Balance:     50,
+			Currency:    util.USD,
+			ProductCode: overdraft.Code,
+		})
+		require.NoError(t, err)
+		require.Equal(t, overdraft.Code, account.ProductCode)
+
+		other := newAccount(t, 0)
+
+		// the balance goes down to minus the overdraft limit and no further
+		result, err := store.TransferTx(ctx, TransferTxParams{FromAccountID: account.ID, ToAccountID: other.ID, Amount: 150})
+		require.NoError(t, err)
+		require.Equal(t, int64(-100), result.FromAccount.Balance)
+
+		_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: account.ID, ToAccountID: other.ID, Amount: 1})
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+
+		// checking accounts have no overdraft
+		_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: other.ID, ToAccountID: account.ID, Amount: 151})
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+
+		// failed transfers are rolled back
+		updated, err := store.GetAccount(ctx, account.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(-100), updated.Balance)
+
+		updated, err = store.GetAccount(ctx, other.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(150), updated.Balance)
+
+		// escrow accounts receive transfers but cannot send them
+		escrowAccount, err := store.CreateAccountTx(ctx, CreateAccountParams{
+			Owner:       other.Owner,
+			Balance:     100,
+			Currency:    util.USD,
+			ProductCode: AccountProductEscrow,
+		})
+		require.NoError(t, err)
+
+		_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: other.ID, ToAccountID: escrowAccount.ID, Amount: 10})
+		require.NoError(t, err)
+
+		_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: escrowAccount.ID, ToAccountID: other.ID, Amount: 10})
+		require.ErrorIs(t, err, ErrTransfersNotAllowed)
+
+		batch, err := store.TransferBatchTx(ctx, TransferBatchTxParams{
+			Owner:         escrowAccount.Owner,
+			FromAccountID: escrowAccount.ID,
+			Mode:          TransferBatchBestEffort,
+			Items:         []TransferBatchItemParams{{ToAccountID: other.ID, Amount: 10}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, TransferBatchFailed, batch.Batch.Status)
+		require.Equal(t, []string{ErrTransfersNotAllowed.Error()}, batchItemErrors(batch.Items))
+
+		// batches from accounts without an overdraft cannot go below zero either
+		batch, err = store.TransferBatchTx(ctx, TransferBatchTxParams{
+			Owner:         other.Owner,
+			FromAccountID: other.ID,
+			Mode:          TransferBatchAtomic,
+			Items:         []TransferBatchItemParams{{ToAccountID: account.ID, Amount: 100}, {ToAccountID: account.ID, Amount: 50}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, TransferBatchFailed, batch.Batch.Status)
+		require.Equal(t, []string{errBatchRolledBack.Error(), ErrInsufficientFunds.Error()}, batchItemErrors(batch.Items))
+	})
+
+	t.Run("Savings Interest", func(t *testing.T) {
+		expense := newAccount(t, 1000000)
+		account := newAccount(t, 100000)
+
+		// 3.65% on 1000.00 accrues 10 cents a day
+		productParams := CreateSavingsProductParams{
+			Code:             "savings-" + util.RandomString(8),
+			Name:             "Savings",
+			Currency:         util.USD,
+			AnnualRateBps:    365,
+			DayCount:         DayCountActual365,
+			ExpenseAccountID: 1 << 62,
+		}
+		_, err := store.CreateSavingsProduct(ctx, productParams)
+		requireConstraint(t, err, foreignKeyViolation, "savings_products_expense_account_id_fkey")
+
+		productParams.ExpenseAccountID = expense.ID
+		product, err := store.CreateSavingsProduct(ctx, productParams)
+		require.NoError(t, err)
+		require.Equal(t, productParams.Code, product.Code)
+		require.Equal(t, DayCountActual365, product.DayCount)
+
+		_, err = store.CreateSavingsProduct(ctx, productParams)
+		requireConstraint(t, err, uniqueViolation, "savings_products_pkey")
+
+		found, err := store.GetSavingsProduct(ctx, product.Code)
+		require.NoError(t, err)
+		require.Equal(t, product.ExpenseAccountID, found.ExpenseAccountID)
+
+		_, err = store.AccrueInterestTx(ctx, AccrueInterestTxParams{AccountID: account.ID, Date: time.Now()})
+		require.Equal(t, sql.ErrNoRows, err)
+
+		_, err = store.CreateSavingsAccount(ctx, CreateSavingsAccountParams{AccountID: account.ID, ProductCode: "missing-" + util.RandomString(8)})
+		requireConstraint(t, err, foreignKeyViolation, "savings_accounts_product_code_fkey")
+
+		savings, err := store.CreateSavingsAccount(ctx, CreateSavingsAccountParams{AccountID: account.ID, ProductCode: product.Code})
+		require.NoError(t, err)
+		require.Equal(t, product.Code, savings.ProductCode)
+
+		_, err = store.CreateSavingsAccount(ctx, CreateSavingsAccountParams{AccountID: account.ID, ProductCode: product.Code})
+		requireConstraint(t, err, uniqueViolation, "savings_accounts_pkey")
+
+		listed, err := store.ListSavingsAccounts(ctx, ListSavingsAccountsParams{AccountID: account.ID - 1, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		require.Equal(t, account.ID, listed[0].AccountID)
+
+		// an entry created today is not part of the end of day balances of earlier days
+		_, err = store.AddAccountBalance(ctx, AddAccountBalanceParams{ID: account.ID, Amount: 500})
+		require.NoError(t, err)
+		_, err = store.CreateEntry(ctx, CreateEntryParams{AccountID: account.ID, Amount: 500})
+		require.NoError(t, err)
+
+		for _, day := range []int{30, 31} {
+			date := time.Date(2026, 1, day, 0, 0, 0, 0, time.UTC)
+
+			result, err := store.AccrueInterestTx(ctx, AccrueInterestTxParams{AccountID: account.ID, Date: date})
+			require.NoError(t, err)
+			require.True(t, result.Accrued)
+			require.Equal(t, date.Format("2006-01-02"), result.Accrual.AccrualDate.Format("2006-01-02"))
+			require.Equal(t, int64(100000), result.Accrual.Balance)
+			require.Equal(t, int64(10000000), result.Accrual.AmountMicros)
+
+			// a day accrues once
+			result, err = store.AccrueInterestTx(ctx, AccrueInterestTxParams{AccountID: account.ID, Date: date.Add(12 * time.Hour)})
+			require.NoError(t, err)
+			require.False(t, result.Accrued)
+		}
+
+		total, err := store.SumInterestAccruals(ctx, SumInterestAccrualsParams{AccountID: account.ID, AccrualDate: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)})
+		require.NoError(t, err)
+		require.Equal(t, int64(10000000), total)
+
+		count, err := store.CountInterestAccruals(ctx, CountInterestAccrualsParams{
+			AccountID: account.ID,
+			FromDate:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			ToDate:    time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(2), count)
+
+		posting, err := store.PostInterestTx(ctx, PostInterestTxParams{AccountID: account.ID, Period: time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)})
+		require.NoError(t, err)
+		require.True(t, posting.Posted)
+		require.Equal(t, "2026-01-01", posting.Posting.Period.Format("2006-01-02"))
+		require.Equal(t, int64(20), posting.Posting.Amount)
+		require.NotNil(t, posting.Transfer)
+		require.Equal(t, expense.ID, posting.Transfer.Transfer.FromAccountID)
+		require.Equal(t, account.ID, posting.Transfer.Transfer.ToAccountID)
+		require.True(t, posting.Posting.TransferID.Valid)
+		require.Equal(t, posting.Transfer.Transfer.ID, posting.Posting.TransferID.Int64)
+
+		// a month posts once
+		posting, err = store.PostInterestTx(ctx, PostInterestTxParams{AccountID: account.ID, Period: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)})
+		require.NoError(t, err)
+		require.False(t, posting.Posted)
+		require.Nil(t, posting.Transfer)
+
+		credited, err := store.GetAccount(ctx, account.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(100520), credited.Balance)
+
+		debited, err := store.GetAccount(ctx, expense.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(1000000-20), debited.Balance)
+
+		// the posting of today is not part of the end of day balance of February 1st either
+		result, err := store.AccrueInterestTx(ctx, AccrueInterestTxParams{AccountID: account.ID, Date: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)})
+		require.NoError(t, err)
+		require.Equal(t, int64(100000), result.Accrual.Balance)
+
+		posted, err := store.SumInterestPostings(ctx, account.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(20), posted)
+
+		_, err = store.PostInterestTx(ctx, PostInterestTxParams{AccountID: expense.ID, Period: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)})
+		require.Equal(t, sql.ErrNoRows, err)
+	})
+
+	t.Run("GetUserByEmail", func(t *testing.T) {
+		user := newUser(t)
+
+		found, err := store.GetUserByEmail(ctx, user.Email)
+		require.NoError(t, err)
+		require.Equal(t, user.Username, found.Username)
+
+		_, err = store.GetUserByEmail(ctx, util.RandomEmail())
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("CreateUser Default Role", func(t *testing.T) {
+		user := newUser(t)
+		require.Equal(t, util.DepositorRole, user.Role)
+		require.False(t, user.IsEmailVerified)
+	})
+
+	t.Run("UpdateUser", func(t *testing.T) {
+		user := newUser(t)
+		tokenHash := util.RandomString(64)
+
+		_, err := store.CreateEmailVerificationToken(ctx, CreateEmailVerificationTokenParams{
+			Username:  user.Username,
+			Email:     user.Email,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+		user, err = store.VerifyEmailTx(ctx, tokenHash)
+		require.NoError(t, err)
+
+		fullName := util.RandomOwner()
+		updated, err := store.UpdateUser(ctx, UpdateUserParams{
+			Username: user.Username,
+			FullName: fullName,
+			Email:    user.Email,
+		})
+		require.NoError(t, err)
+		require.Equal(t, fullName, updated.FullName)
+		require.True(t, updated.IsEmailVerified)
+
+		email := util.RandomEmail()
+		updated, err = store.UpdateUser(ctx, UpdateUserParams{
+			Username: user.Username,
+			FullName: fullName,
+			Email:    email,
+		})
+		require.NoError(t, err)
+		require.Equal(t, email, updated.Email)
+		require.False(t, updated.IsEmailVerified)
+	})
+
+	t.Run("UpdateUser Duplicate Email", func(t *testing.T) {
+		user := newUser(t)
+		other := newUser(t)
+
+		_, err := store.UpdateUser(ctx, UpdateUserParams{
+			Username: user.Username,
+			FullName: user.FullName,
+			Email:    other.Email,
+		})
+		requireConstraint(t, err, "23505", "users_email_key")
+	})
+
+	t.Run("UpdateUser Not Found", func(t *testing.T) {
+		_, err := store.UpdateUser(ctx, UpdateUserParams{
+			Username: util.RandomOwner() + util.RandomString(6),
+			FullName: util.RandomOwner(),
+			Email:    util.RandomEmail(),
+		})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Login Attempts", func(t *testing.T) {
+		user := newUser(t)
+		require.Zero(t, user.FailedLoginAttempts)
+		require.False(t, user.LockedUntil.Valid)
+
+		for i := 1; i <= 2; i++ {
+			updated, err := store.RecordFailedLogin(ctx, user.Username)
+			require.NoError(t, err)
+			require.Equal(t, int32(i), updated.FailedLoginAttempts)
+			require.True(t, updated.LastFailedLoginAt.Valid)
+		}
+
+		lockedUntil := time.Now().Add(time.Hour).Truncate(time.Microsecond)
+		locked, err := store.LockUser(ctx, LockUserParams{
+			Username:    user.Username,
+			LockedUntil: sql.NullTime{Time: lockedUntil, Valid: true},
+		})
+		require.NoError(t, err)
+		require.True(t, locked.LockedUntil.Valid)
+		require.WithinDuration(t, lockedUntil, locked.LockedUntil.Time, time.Microsecond)
+
+		reset, err := store.ResetLoginAttempts(ctx, user.Username)
+		require.NoError(t, err)
+		require.Zero(t, reset.FailedLoginAttempts)
+		require.False(t, reset.LastFailedLoginAt.Valid)
+		require.False(t, reset.LockedUntil.Valid)
+
+		_, err = store.ResetLoginAttempts(ctx, util.RandomOwner()+util.RandomString(6))
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("ConfirmTOTPTx", func(t *testing.T) {
+		user := newUser(t)
+		require.False(t, user.TotpEnabled)
+
+		// nothing to confirm before a secret is enrolled
+		_, err := store.ConfirmTOTPTx(ctx, ConfirmTOTPTxParams{Username: user.Username, Step: 1})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		secret := sql.NullString{String: util.RandomString(32), Valid: true}
+		enrolled, err := store.SetUserTOTPSecret(ctx, SetUserTOTPSecretParams{Username: user.Username, TotpSecret: secret})
+		require.NoError(t, err)
+		require.Equal(t, secret, enrolled.TotpSecret)
+		require.False(t, enrolled.TotpEnabled)
+
+		codeHashes := []string{util.RandomString(64), util.RandomString(64)}
+		confirmed, err := store.ConfirmTOTPTx(ctx, ConfirmTOTPTxParams{
+			Username:           user.Username,
+			Step:               100,
+			RecoveryCodeHashes: codeHashes,
+		})
+		require.NoError(t, err)
+		require.True(t, confirmed.TotpEnabled)
+
+		// time steps cannot be replayed
+		_, err = store.UseTOTPStep(ctx, UseTOTPStepParams{Username: user.Username, TotpLastStep: 100})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		stepped, err := store.UseTOTPStep(ctx, UseTOTPStepParams{Username: user.Username, TotpLastStep: 101})
+		require.NoError(t, err)
+		require.Equal(t, int64(101), stepped.TotpLastStep)
+
+		// recovery codes are single use
+		recoveryCode, err := store.UseTOTPRecoveryCode(ctx, UseTOTPRecoveryCodeParams{Username: user.Username, CodeHash: codeHashes[0]})
+		require.NoError(t, err)
+		require.True(t, recoveryCode.UsedAt.Valid)
+
+		_, err = store.UseTOTPRecoveryCode(ctx, UseTOTPRecoveryCodeParams{Username: user.Username, CodeHash: codeHashes[0]})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		// codes of other users are not accepted
+		_, err = store.UseTOTPRecoveryCode(ctx, UseTOTPRecoveryCodeParams{Username: newUser(t).Username, CodeHash: codeHashes[1]})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		// confirming again replaces the recovery codes
+		_, err = store.SetUserTOTPSecret(ctx, SetUserTOTPSecretParams{Username: user.Username, TotpSecret: secret})
+		require.NoError(t, err)
+		_, err = store.ConfirmTOTPTx(ctx, ConfirmTOTPTxParams{
+			Username:           user.Username,
+			Step:               200,
+			RecoveryCodeHashes: []string{util.RandomString(64)},
+		})
+		require.NoError(t, err)
+
+		_, err = store.UseTOTPRecoveryCode(ctx, UseTOTPRecoveryCodeParams{Username: user.Username, CodeHash: codeHashes[1]})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("API Keys", func(t *testing.T) {
+		user := newUser(t)
+
+		_, err := store.CreateAPIKey(ctx, CreateAPIKeyParams{
+			Username: util.RandomOwner() + util.RandomString(6),
+			Name:     util.RandomOwner(),
+			Prefix:   util.RandomString(12),
+			KeyHash:  util.RandomString(64),
+			Scopes:   []string{util.AccountsReadScope},
+		})
+		requireConstraint(t, err, foreignKeyViolation, "api_keys_username_fkey")
+
+		arg := CreateAPIKeyParams{
+			Username:  user.Username,
+			Name:      util.RandomOwner(),
+			Prefix:    util.RandomString(12),
+			KeyHash:   util.RandomString(64),
+			Scopes:    []string{util.AccountsReadScope, util.TransfersWriteScope},
+			ExpiresAt: sql.NullTime{Time: time.Now().Add(time.Hour).Truncate(time.Microsecond), Valid: true},
+		}
+		apiKey, err := store.CreateAPIKey(ctx, arg)
+		require.NoError(t, err)
+		require.NotZero(t, apiKey.ID)
+		require.Equal(t, arg.Scopes, apiKey.Scopes)
+		require.WithinDuration(t, arg.ExpiresAt.Time, apiKey.ExpiresAt.Time, time.Microsecond)
+		require.False(t, apiKey.LastUsedAt.Valid)
+		require.False(t, apiKey.RevokedAt.Valid)
+
+		_, err = store.CreateAPIKey(ctx, CreateAPIKeyParams{
+			Username: user.Username,
+			Name:     util.RandomOwner(),
+			Prefix:   util.RandomString(12),
+			KeyHash:  arg.KeyHash,
+			Scopes:   []string{util.AccountsReadScope},
+		})
+		requireConstraint(t, err, uniqueViolation, "api_keys_key_hash_idx")
+
+		found, err := store.GetAPIKeyByHash(ctx, arg.KeyHash)
+		require.NoError(t, err)
+		require.Equal(t, apiKey.ID, found.ID)
+
+		_, err = store.GetAPIKeyByHash(ctx, util.RandomString(64))
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		// last use is recorded at most once a minute
+		err = store.UpdateAPIKeyLastUsed(ctx, apiKey.ID)
+		require.NoError(t, err)
+		used, err := store.GetAPIKeyByHash(ctx, arg.KeyHash)
+		require.NoError(t, err)
+		require.True(t, used.LastUsedAt.Valid)
+
+		err = store.UpdateAPIKeyLastUsed(ctx, apiKey.ID)
+		require.NoError(t, err)
+		usedAgain, err := store.GetAPIKeyByHash(ctx, arg.KeyHash)
+		require.NoError(t, err)
+		require.Equal(t, used.LastUsedAt.Time, usedAgain.LastUsedAt.Time)
+
+		second, err := store.CreateAPIKey(ctx, CreateAPIKeyParams{
+			Username: user.Username,
+			Name:     util.RandomOwner(),
+			Prefix:   util.RandomString(12),
+			KeyHash:  util.RandomString(64),
+			Scopes:   []string{util.AccountsReadScope},
+		})
+		require.NoError(t, err)
+
+		apiKeys, err := store.ListAPIKeys(ctx, user.Username)
+		require.NoError(t, err)
+		require.Len(t, apiKeys, 2)
+		require.Equal(t, apiKey.ID, apiKeys[0].ID)
+		require.Equal(t, second.ID, apiKeys[1].ID)
+
+		// keys of other users cannot be revoked
+		_, err = store.RevokeAPIKey(ctx, RevokeAPIKeyParams{ID: apiKey.ID, Username: newUser(t).Username})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		revoked, err := store.RevokeAPIKey(ctx, RevokeAPIKeyParams{ID: apiKey.ID, Username: user.Username})
+		require.NoError(t, err)
+		require.True(t, revoked.RevokedAt.Valid)
+
+		_, err = store.RevokeAPIKey(ctx, RevokeAPIKeyParams{ID: apiKey.ID, Username: user.Username})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		apiKeys, err = store.ListAPIKeys(ctx, user.Username)
+		require.NoError(t, err)
+		require.Len(t, apiKeys, 1)
+		require.Equal(t, second.ID, apiKeys[0].ID)
+	})
+
+	t.Run("Webhooks", func(t *testing.T) {
+		user := newUser(t)
+
+		_, err := store.CreateWebhookSubscription(ctx, CreateWebhookSubscriptionParams{
+			Username:   util.RandomOwner() + util.RandomString(6),
+			Url:        "https://example.com/hook",
+			EventTypes: []string{"transfer.created"},
+			Secret:     util.RandomString(32),
+		})
+		requireConstraint(t, err, foreignKeyViolation, "webhook_subscriptions_username_fkey")
+
+		subscription, err := store.CreateWebhookSubscription(ctx, CreateWebhookSubscriptionParams{
+			Username:   user.Username,
+			Url:        "https://example.com/hook",
+			EventTypes: []string{"transfer.created", "account.created"},
+			Secret:     util.RandomString(32),
+		})
+		require.NoError(t, err)
+		require.NotZero(t, subscription.ID)
+
+		other, err := store.CreateWebhookSubscription(ctx, CreateWebhookSubscriptionParams{
+			Username:   user.Username,
+			Url:        "https://example.com/other",
+			EventTypes: []string{"account.created"},
+			Secret:     util.RandomString(32),
+		})
+		require.NoError(t, err)
+
+		subscriptions, err := store.ListWebhookSubscriptions(ctx, user.Username)
+		require.NoError(t, err)
+		require.Len(t, subscriptions, 2)
+
+		subscriptions, err = store.ListWebhookSubscriptionsForEvent(ctx, ListWebhookSubscriptionsForEventParams{
+			Username:  user.Username,
+			EventType: "transfer.created",
+		})
+		require.NoError(t, err)
+		require.Len(t, subscriptions, 1)
+		require.Equal(t, subscription.ID, subscriptions[0].ID)
+
+		_, err = store.CreateWebhookDelivery(ctx, CreateWebhookDeliveryParams{
+			SubscriptionID: subscription.ID + other.ID + 1000,
+			EventID:        uuid.New(),
+			EventType:      "transfer.created",
+			Payload:        json.RawMessage(`{}`),
+		})
+		requireConstraint(t, err, foreignKeyViolation, "webhook_deliveries_subscription_id_fkey")
+
+		payload := json.RawMessage(`{"type":"transfer.created"}`)
+		delivery, err := store.CreateWebhookDelivery(ctx, CreateWebhookDeliveryParams{
+			SubscriptionID: subscription.ID,
+			EventID:        uuid.New(),
+			EventType:      "transfer.created",
+			Payload:        payload,
+		})
+		require.NoError(t, err)
+		require.Equal(t, WebhookDeliveryPending, delivery.Status)
+		require.Zero(t, delivery.Attempts)
+		require.JSONEq(t, string(payload), string(delivery.Payload))
+
+		// an event is queued once per subscription
+		_, err = store.CreateWebhookDelivery(ctx, CreateWebhookDeliveryParams{
+			SubscriptionID: subscription.ID,
+			EventID:        delivery.EventID,
+			EventType:      "transfer.created",
+			Payload:        payload,
+		})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		// claimed deliveries are hidden from other workers until the lock expires
+		lockedUntil := time.Now().Add(time.Minute).Truncate(time.Microsecond)
+		claimed, err := store.ClaimWebhookDeliveries(ctx, ClaimWebhookDeliveriesParams{LockedUntil: lockedUntil, Limit: 1000})
+		require.NoError(t, err)
+		require.Contains(t, webhookDeliveryIDs(claimed), delivery.ID)
+
+		claimed, err = store.ClaimWebhookDeliveries(ctx, ClaimWebhookDeliveriesParams{LockedUntil: lockedUntil, Limit: 1000})
+		require.NoError(t, err)
+		require.NotContains(t, webhookDeliveryIDs(claimed), delivery.ID)
+
+		failed, err := store.RecordWebhookDeliveryAttempt(ctx, RecordWebhookDeliveryAttemptParams{
+			ID:             delivery.ID,
+			Status:         WebhookDeliveryDead,
+			NextAttemptAt:  lockedUntil,
+			LastStatusCode: sql.NullInt32{Int32: 500, Valid: true},
+			LastError:      sql.NullString{String: "internal server error", Valid: true},
+		})
+		require.NoError(t, err)
+		require.Equal(t, WebhookDeliveryDead, failed.Status)
+		require.Equal(t, int32(1), failed.Attempts)
+		require.Equal(t, int32(500), failed.LastStatusCode.Int32)
+
+		_, err = store.ReplayWebhookDelivery(ctx, ReplayWebhookDeliveryParams{ID: delivery.ID, SubscriptionID: other.ID})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		replayed, err := store.ReplayWebhookDelivery(ctx, ReplayWebhookDeliveryParams{ID: delivery.ID, SubscriptionID: subscription.ID})
+		require.NoError(t, err)
+		require.Equal(t, WebhookDeliveryPending, replayed.Status)
+		require.Zero(t, replayed.Attempts)
+		require.False(t, replayed.LastStatusCode.Valid)
+		require.False(t, replayed.LastError.Valid)
+
+		deliveries, err := store.ListWebhookDeliveries(ctx, ListWebhookDeliveriesParams{SubscriptionID: subscription.ID, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, delivery.ID, deliveries[0].ID)
+
+		// only the owner can delete a subscription, its deliveries are deleted with it
+		_, err = store.DeleteWebhookSubscription(ctx, DeleteWebhookSubscriptionParams{ID: subscription.ID, Username: newUser(t).Username})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		_, err = store.DeleteWebhookSubscription(ctx, DeleteWebhookSubscriptionParams{ID: subscription.ID, Username: user.Username})
+		require.NoError(t, err)
+
+		_, err = store.GetWebhookSubscription(ctx, subscription.ID)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		deliveries, err = store.ListWebhookDeliveries(ctx, ListWebhookDeliveriesParams{SubscriptionID: subscription.ID, Limit: 10})
+		require.NoError(t, err)
+		require.Empty(t, deliveries)
+	})
+
+	t.Run("Outbox", func(t *testing.T) {
+		user, err := store.CreateUserTx(ctx, CreateUserParams{
+			Username:       util.RandomOwner() + util.RandomString(6),
+			HashedPassword: util.RandomString(32),
+			FullName:       util.RandomOwner(),
+			Email:          util.RandomEmail(),
+		})
+		require.NoError(t, err)
+
+		account, err := store.CreateAccountTx(ctx, CreateAccountParams{
+			Owner:       user.Username,
+			Balance:     100,
+			Currency:    util.USD,
+			ProductCode: AccountProductChecking,
+		})
+		require.NoError(t, err)
+
+		// a failed transaction writes no event
+		_, err = store.CreateAccountTx(ctx, CreateAccountParams{Owner: user.Username, Currency: util.USD, ProductCode: AccountProductChecking})
+		require.ErrorIs(t, err, ErrAccountExists)
+
+		toAccount := newAccount(t, 0)
+		result, err := store.TransferTx(ctx, TransferTxParams{
+			FromAccountID: account.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        10,
+		})
+		require.NoError(t, err)
+
+		userAggregate := AggregateUser + "/" + user.Username
+		accountAggregate := AggregateAccount + "/" + strconv.FormatInt(account.ID, 10)
+		toAccountAggregate := AggregateAccount + "/" + strconv.FormatInt(toAccount.ID, 10)
+
+		isOurs := func(event Outbox) bool {
+			aggregate := event.AggregateType + "/" + event.AggregateID
+			return aggregate == userAggregate || aggregate == accountAggregate || aggregate == toAccountAggregate
+		}
+
+		// only the first unpublished event of an aggregate is claimed, and claimed events are hidden until their lock expires
+		claimed, err := store.ClaimOutboxEvents(ctx, ClaimOutboxEventsParams{LockedUntil: time.Now().Add(time.Minute), Limit: 1000})
+		require.NoError(t, err)
+
+		heads := make(map[string]Outbox)
+		for _, event := range claimed {
+			if isOurs(event) {
+				heads[event.AggregateType+"/"+event.AggregateID] = event
+			}
+		}
+		require.Len(t, heads, 3)
+		require.Equal(t, EventAccountCreated, heads[accountAggregate].EventType)
+
+		claimed, err = store.ClaimOutboxEvents(ctx, ClaimOutboxEventsParams{LockedUntil: time.Now().Add(time.Minute), Limit: 1000})
+		require.NoError(t, err)
+		for _, event := range claimed {
+			require.False(t, isOurs(event))
+		}
+
+		// a failed event is attempted again once its next attempt is due
+		for _, head := range heads {
+			err = store.RecordOutboxEventFailure(ctx, RecordOutboxEventFailureParams{
+				ID:            head.ID,
+				NextAttemptAt: time.Now().Add(-time.Second),
+				LastError:     sql.NullString{String: "broker unavailable", Valid: true},
+			})
+			require.NoError(t, err)
+		}
+
+		// publish everything pending, other tests may have left events behind
+		events := make(map[string][]Outbox)
+		for {
+			batch, err := store.ClaimOutboxEvents(ctx, ClaimOutboxEventsParams{LockedUntil: time.Now().Add(time.Minute), Limit: 1000})
+			require.NoError(t, err)
+			if len(batch) == 0 {
+				break
+			}
+
+			ids := make([]int64, 0, len(batch))
+			for _, event := range batch {
+				aggregate := event.AggregateType + "/" + event.AggregateID
+				events[aggregate] = append(events[aggregate], event)
+				ids = append(ids, event.ID)
+			}
+
+			err = store.MarkOutboxEventsPublished(ctx, ids)
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, int32(1), events[accountAggregate][0].Attempts)
+		require.Equal(t, "broker unavailable", events[accountAggregate][0].LastError.String)
+		require.Zero(t, events[accountAggregate][1].Attempts)
+
+		eventTypes := func(events []Outbox) []string {
+			types := make([]string, len(events))
+			for i, event := range events {
+				types[i] = event.EventType
+			}
+			return types
+		}
+
+		// the events of an aggregate are numbered from 1 and published in sequence order
+		require.Equal(t, []string{EventUserCreated}, eventTypes(events[userAggregate]))
+		require.Equal(t, []string{EventAccountCreated, EventTransferCreated}, eventTypes(events[accountAggregate]))
+		require.Equal(t, []string{EventTransferCreated}, eventTypes(events[toAccountAggregate]))
+		for _, aggregate := range []string{userAggregate, accountAggregate, toAccountAggregate} {
+			for i, event := range events[aggregate] {
+				require.Equal(t, int64(i+1), event.Sequence)
+				require.False(t, event.PublishedAt.Valid)
+			}
+		}
+
+		require.NotContains(t, string(events[userAggregate][0].Payload), user.HashedPassword)
+
+		// both accounts get the same transfer.created
+		require.JSONEq(t, string(events[accountAggregate][1].Payload), string(events[toAccountAggregate][0].Payload))
+
+		var transferEvent TransferCreatedEvent
+		require.NoError(t, json.Unmarshal(events[accountAggregate][1].Payload, &transferEvent))
+		require.Equal(t, result.Transfer.ID, transferEvent.ID)
+		require.Equal(t, account.ID, transferEvent.FromAccountID)
+		require.Equal(t, toAccount.ID, transferEvent.ToAccountID)
+		require.Equal(t, int64(10), transferEvent.Amount)
+		require.Equal(t, util.USD, transferEvent.Currency)
+	})
+
+	t.Run("UpdateUserPassword", func(t *testing.T) {
+		user := newUser(t)
+		hashedPassword := util.RandomString(32)
+		// the change is stored at the time given by the application rather than the database clock
+		passwordChangedAt := time.Now().Add(-time.Hour).Truncate(time.Microsecond)
+
+		updated, err := store.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			Username:          user.Username,
+			HashedPassword:    hashedPassword,
+			PasswordChangedAt: passwordChangedAt,
+		})
+		require.NoError(t, err)
+		require.Equal(t, hashedPassword, updated.HashedPassword)
+		require.True(t, passwordChangedAt.Equal(updated.PasswordChangedAt))
+	})
+
+	t.Run("CreatePasswordResetToken Unknown User", func(t *testing.T) {
+		_, err := store.CreatePasswordResetToken(ctx, CreatePasswordResetTokenParams{
+			Username:  util.RandomOwner() + util.RandomString(6),
+			TokenHash: util.RandomString(64),
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		requireConstraint(t, err, "23503", "password_reset_tokens_username_fkey")
+	})
+
+	t.Run("ResetPasswordTx", func(t *testing.T) {
+		user := newUser(t)
+		tokenHash := util.RandomString(64)
+
+		_, err := store.CreatePasswordResetToken(ctx, CreatePasswordResetTokenParams{
+			Username:  user.Username,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+
+		hashedPassword := util.RandomString(32)
+		passwordChangedAt := time.Now().Truncate(time.Microsecond)
+		updated, err := store.ResetPasswordTx(ctx, ResetPasswordTxParams{
+			TokenHash:         tokenHash,
+			HashedPassword:    hashedPassword,
+			PasswordChangedAt: passwordChangedAt,
+		})
+		require.NoError(t, err)
+		require.Equal(t, user.Username, updated.Username)
+		require.Equal(t, hashedPassword, updated.HashedPassword)
+		require.True(t, passwordChangedAt.Equal(updated.PasswordChangedAt))
+
+		// reset tokens are single use
+		_, err = store.ResetPasswordTx(ctx, ResetPasswordTxParams{TokenHash: tokenHash, HashedPassword: util.RandomString(32)})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("ResetPasswordTx Invalidates Other Tokens", func(t *testing.T) {
+		user := newUser(t)
+		other := newUser(t)
+
+		tokenHashes := make([]string, 3)
+		for i, username := range []string{user.Username, user.Username, other.Username} {
+			tokenHashes[i] = util.RandomString(64)
+			_, err := store.CreatePasswordResetToken(ctx, CreatePasswordResetTokenParams{
+				Username:  username,
+				TokenHash: tokenHashes[i],
+				ExpiresAt: time.Now().Add(time.Hour),
+			})
+			require.NoError(t, err)
+		}
+
+		_, err := store.ResetPasswordTx(ctx, ResetPasswordTxParams{
+			TokenHash:         tokenHashes[0],
+			HashedPassword:    util.RandomString(32),
+			PasswordChangedAt: time.Now(),
+		})
+		require.NoError(t, err)
+
+		// an earlier reset email can no longer change the password
+		_, err = store.ResetPasswordTx(ctx, ResetPasswordTxParams{
+			TokenHash:         tokenHashes[1],
+			HashedPassword:    util.RandomString(32),
+			PasswordChangedAt: time.Now(),
+		})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		// the tokens of other users are left alone
+		_, err = store.ResetPasswordTx(ctx, ResetPasswordTxParams{
+			TokenHash:         tokenHashes[2],
+			HashedPassword:    util.RandomString(32),
+			PasswordChangedAt: time.Now(),
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("ResetPasswordTx Expired Token", func(t *testing.T) {
+		user := newUser(t)
+		tokenHash := util.RandomString(64)
+
+		_, err := store.CreatePasswordResetToken(ctx, CreatePasswordResetTokenParams{
+			Username:  user.Username,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(-time.Minute),
+		})
+		require.NoError(t, err)
+
+		_, err = store.ResetPasswordTx(ctx, ResetPasswordTxParams{TokenHash: tokenHash, HashedPassword: util.RandomString(32)})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		found, err := store.GetUser(ctx, user.Username)
+		require.NoError(t, err)
+		require.Equal(t, user.HashedPassword, found.HashedPassword)
+	})
+
+	t.Run("VerifyEmailTx", func(t *testing.T) {
+		user := newUser(t)
+		require.False(t, user.IsEmailVerified)
+		tokenHash := util.RandomString(64)
+
+		_, err := store.CreateEmailVerificationToken(ctx, CreateEmailVerificationTokenParams{
+			Username:  user.Username,
+			Email:     user.Email,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+
+		verified, err := store.VerifyEmailTx(ctx, tokenHash)
+		require.NoError(t, err)
+		require.True(t, verified.IsEmailVerified)
+
+		_, err = store.VerifyEmailTx(ctx, tokenHash)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("VerifyEmailTx Stale Email", func(t *testing.T) {
+		user := newUser(t)
+		tokenHash := util.RandomString(64)
+
+		_, err := store.CreateEmailVerificationToken(ctx, CreateEmailVerificationTokenParams{
+			Username:  user.Username,
+			Email:     util.RandomEmail(),
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+
+		_, err = store.VerifyEmailTx(ctx, tokenHash)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		found, err := store.GetUser(ctx, user.Username)
+		require.NoError(t, err)
+		require.False(t, found.IsEmailVerified)
+	})
+
+	t.Run("Health", func(t *testing.T) {
+		require.NoError(t, store.Ping(ctx))
+
+		_, dirty, err := store.MigrationVersion(ctx)
+		require.NoError(t, err)
+		require.False(t, dirty)
+	})
+}
+
+func webhookDeliveryIDs(deliveries []WebhookDelivery) []int64 {
+	ids := make([]int64, len(deliveries))
+	for i, delivery := range deliveries {
+		ids[i] = delivery.ID
+	}
+	return ids
+}
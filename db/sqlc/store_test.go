@@ -2,9 +2,11 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestTransferTx(t *testing.T) {
@@ -177,3 +179,56 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, accountOne.Balance, updatedAccountOne.Balance)
 	require.Equal(t, accountTwo.Balance, updatedAccountTwo.Balance)
 }
+
+func TestTransferTxSerializable(t *testing.T) {
+	store := NewStore(testDb,
+		WithTransferIsolation(sql.LevelSerializable),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 10, BaseDelay: 5 * time.Millisecond, MaxDelay: 100 * time.Millisecond}),
+	)
+
+	accountOne := createRandomAccount(t)
+	accountTwo := createRandomAccount(t)
+
+	/**
+	Concurrent serializable transfers between the same accounts fail with serialization errors,
+	the store must retry them so that every transfer eventually succeeds
+	*/
+	n := 10
+	amount := int64(10)
+
+	errs := make(chan error)
+
+	for i := 0; i < n; i++ {
+		fromAccountID := accountOne.ID
+		toAccountID := accountTwo.ID
+
+		if i%2 == 1 {
+			fromAccountID = accountTwo.ID
+			toAccountID = accountOne.ID
+		}
+
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: fromAccountID,
+				ToAccountID:   toAccountID,
+				Amount:        amount,
+			})
+
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		err := <-errs
+		require.NoError(t, err)
+	}
+
+	updatedAccountOne, err := testQueries.GetAccount(context.Background(), accountOne.ID)
+	require.NoError(t, err)
+
+	updatedAccountTwo, err := testQueries.GetAccount(context.Background(), accountTwo.ID)
+	require.NoError(t, err)
+
+	require.Equal(t, accountOne.Balance, updatedAccountOne.Balance)
+	require.Equal(t, accountTwo.Balance, updatedAccountTwo.Balance)
+}
@@ -0,0 +1,353 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Modes of transfer_batches
+const (
+	// TransferBatchAtomic batches transfer every item or none of them
+	TransferBatchAtomic = "atomic"
+	// TransferBatchBestEffort batches transfer each item on its own, failed items do not stop the others
+	TransferBatchBestEffort = "best_effort"
+)
+
+// Statuses of transfer_batches
+const (
+	// TransferBatchPending batches are being transferred, or were interrupted by an error of the database
+	TransferBatchPending = "pending"
+	// TransferBatchCompleted batches transferred every item
+	TransferBatchCompleted = "completed"
+	// TransferBatchPartiallyCompleted batches transferred some of their items
+	TransferBatchPartiallyCompleted = "partially_completed"
+	// TransferBatchFailed batches transferred none of their items
+	TransferBatchFailed = "failed"
+)
+
+var (
+	// ErrInsufficientFunds is returned when the balance and overdraft limit of the source account cannot cover a transfer
+	ErrInsufficientFunds = errors.New("insufficient funds")
+
+	errBatchAccountNotFound  = errors.New("account not found")
+	errBatchRolledBack       = errors.New("not transferred, the batch was rolled back")
+	errBatchBalanceOverflows = errors.New("destination balance would overflow")
+)
+
+// TransferBatchItemParams is a transfer of a batch
+type TransferBatchItemParams struct {
+	ToAccountID int64 `json:"to_account_id"`
+	Amount      int64 `json:"amount"`
+}
+
+// TransferBatchTxParams contains the input of a transfer batch, every item is sent from the same account
+type TransferBatchTxParams struct {
+	Owner         string                    `json:"owner"`
+	FromAccountID int64                     `json:"from_account_id"`
+	Mode          string                    `json:"mode"`
+	Items         []TransferBatchItemParams `json:"items"`
+}
+
+// TransferBatchTxResult is the batch record, its items in order and the transfers that succeeded
+type TransferBatchTxResult struct {
+	Batch     TransferBatch       `json:"batch"`
+	Items     []TransferBatchItem `json:"items"`
+	Transfers []TransferTxResult  `json:"transfers"`
+}
+
+// newTransferBatchParams records a batch with the total of its items, util.ErrAmountOverflow is returned
+// when the total does not fit in int64
+func newTransferBatchParams(arg TransferBatchTxParams) (CreateTransferBatchParams, error) {
+	var total util.Money
+	for _, item := range arg.Items {
+		var err error
+		total, err = total.Add(util.Money{Amount: item.Amount})
+		if err != nil {
+			return CreateTransferBatchParams{}, fmt.Errorf("%w: total of the batch items", util.ErrAmountOverflow)
+		}
+	}
+
+	return CreateTransferBatchParams{
+		Owner:         arg.Owner,
+		FromAccountID: arg.FromAccountID,
+		Mode:          arg.Mode,
+		ItemCount:     int32(len(arg.Items)),
+		TotalAmount:   total.Amount,
+	}, nil
+}
+
+func newTransferBatchItemParams(batchID int64, position int, item TransferBatchItemParams) CreateTransferBatchItemParams {
+	return CreateTransferBatchItemParams{
+		BatchID:     batchID,
+		Position:    int32(position),
+		ToAccountID: item.ToAccountID,
+		Amount:      item.Amount,
+	}
+}
+
+// transferBatchStatus is the status of a batch once succeeded of its items were transferred
+func transferBatchStatus(succeeded int, items int) string {
+	switch succeeded {
+	case items:
+		return TransferBatchCompleted
+	case 0:
+		return TransferBatchFailed
+	default:
+		return TransferBatchPartiallyCompleted
+	}
+}
+
+// batchItemError returns the message recorded for an item failing with err, and false when err
+// is not caused by the item itself and should end the batch instead
+func batchItemError(err error) (string, bool) {
+	if errors.Is(err, ErrInsufficientFunds) || errors.Is(err, ErrTransfersNotAllowed) || errors.Is(err, ErrAccountFrozen) {
+		return err.Error(), true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case foreignKeyViolation:
+			return errBatchAccountNotFound.Error(), true
+		case numericValueOutOfRange:
+			return errBatchBalanceOverflows.Error(), true
+		}
+	}
+
+	return "", false
+}
+
+// TransferBatchTx transfers the items of a batch from one account and records the batch and the result of each item.
+// Atomic batches run in a single transaction, when an item fails nothing is transferred and the batch is recorded
+// as failed. Best-effort batches transfer each item in its own transaction. Items fail when the source account cannot
+// cover them or cannot initiate transfers, either account is frozen, the destination account does not exist or its
+// balance would overflow, any other error is returned
+func (store *SQLStore) TransferBatchTx(ctx context.Context, arg TransferBatchTxParams) (TransferBatchTxResult, error) {
+	ctx, span := startTxSpan(ctx, "TransferBatchTx",
+		attribute.Int64("transfer_batch.from_account_id", arg.FromAccountID),
+		attribute.String("transfer_batch.mode", arg.Mode),
+		attribute.Int("transfer_batch.item_count", len(arg.Items)),
+	)
+	defer span.End()
+
+	var result TransferBatchTxResult
+	var err error
+	if arg.Mode == TransferBatchAtomic {
+		result, err = store.atomicTransferBatch(ctx, arg)
+	} else {
+		result, err = store.bestEffortTransferBatch(ctx, arg)
+	}
+	recordError(span, err)
+
+	return result, err
+}
+
+func (store *SQLStore) atomicTransferBatch(ctx context.Context, arg TransferBatchTxParams) (TransferBatchTxResult, error) {
+	var result TransferBatchTxResult
+	var failed int
+
+	err := store.execTx(ctx, store.transferIsolation, func(q *Queries) error {
+		result = TransferBatchTxResult{}
+		failed = -1
+
+		params, err := newTransferBatchParams(arg)
+		if err != nil {
+			return err
+		}
+
+		batch, err := q.CreateTransferBatch(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		// the source is locked for the whole batch so its balance covers each item when it is transferred
+		fromAccount, err := q.GetAccountForUpdate(ctx, arg.FromAccountID)
+		if err != nil {
+			return err
+		}
+		balance := fromAccount.Balance
+
+		product, err := q.GetAccountProduct(ctx, fromAccount.ProductCode)
+		if err != nil {
+			return err
+		}
+
+		for i, item := range arg.Items {
+			failed = i
+			if err := checkTransferSource(balance, item.Amount, product); err != nil {
+				return err
+			}
+
+			transfer, err := transferTx(ctx, q, TransferTxParams{
+				FromAccountID: arg.FromAccountID,
+				ToAccountID:   item.ToAccountID,
+				Amount:        item.Amount,
+			})
+			if err != nil {
+				return err
+			}
+			if err := checkTransferAccounts(transfer.FromAccount, transfer.ToAccount); err != nil {
+				return err
+			}
+			balance = transfer.FromAccount.Balance
+
+			itemParams := newTransferBatchItemParams(batch.ID, i, item)
+			itemParams.TransferID = sql.NullInt64{Int64: transfer.Transfer.ID, Valid: true}
+
+			batchItem, err := q.CreateTransferBatchItem(ctx, itemParams)
+			if err != nil {
+				return err
+			}
+
+			result.Items = append(result.Items, batchItem)
+			result.Transfers = append(result.Transfers, transfer)
+		}
+		failed = -1
+
+		result.Batch, err = q.CompleteTransferBatch(ctx, CompleteTransferBatchParams{
+			ID:             batch.ID,
+			Status:         TransferBatchCompleted,
+			SucceededCount: int32(len(arg.Items)),
+		})
+		return err
+	})
+	if err == nil {
+		return result, nil
+	}
+
+	message, ok := batchItemError(err)
+	if failed < 0 || !ok {
+		return TransferBatchTxResult{}, err
+	}
+
+	return store.failedTransferBatch(ctx, arg, failed, message)
+}
+
+// failedTransferBatch records an atomic batch that was rolled back because of the item at position failed
+func (store *SQLStore) failedTransferBatch(ctx context.Context, arg TransferBatchTxParams, failed int, message string) (TransferBatchTxResult, error) {
+	var result TransferBatchTxResult
+
+	err := store.execTx(ctx, sql.LevelDefault, func(q *Queries) error {
+		result = TransferBatchTxResult{}
+
+		params, err := newTransferBatchParams(arg)
+		if err != nil {
+			return err
+		}
+
+		batch, err := q.CreateTransferBatch(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		for i, item := range arg.Items {
+			itemError := errBatchRolledBack.Error()
+			if i == failed {
+				itemError = message
+			}
+
+			itemParams := newTransferBatchItemParams(batch.ID, i, item)
+			itemParams.Error = sql.NullString{String: itemError, Valid: true}
+
+			batchItem, err := q.CreateTransferBatchItem(ctx, itemParams)
+			if err != nil {
+				return err
+			}
+			result.Items = append(result.Items, batchItem)
+		}
+
+		result.Batch, err = q.CompleteTransferBatch(ctx, CompleteTransferBatchParams{
+			ID:     batch.ID,
+			Status: TransferBatchFailed,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+func (store *SQLStore) bestEffortTransferBatch(ctx context.Context, arg TransferBatchTxParams) (TransferBatchTxResult, error) {
+	var result TransferBatchTxResult
+
+	// the batch is recorded first so it can be found even if the batch is interrupted
+	params, err := newTransferBatchParams(arg)
+	if err != nil {
+		return result, err
+	}
+
+	batch, err := store.CreateTransferBatch(ctx, params)
+	if err != nil {
+		return result, err
+	}
+	result.Batch = batch
+
+	for i, item := range arg.Items {
+		var transfer TransferTxResult
+		var batchItem TransferBatchItem
+
+		err = store.execTx(ctx, store.transferIsolation, func(q *Queries) error {
+			fromAccount, err := q.GetAccountForUpdate(ctx, arg.FromAccountID)
+			if err != nil {
+				return err
+			}
+
+			product, err := q.GetAccountProduct(ctx, fromAccount.ProductCode)
+			if err != nil {
+				return err
+			}
+
+			if err := checkTransferSource(fromAccount.Balance, item.Amount, product); err != nil {
+				return err
+			}
+
+			transfer, err = transferTx(ctx, q, TransferTxParams{
+				FromAccountID: arg.FromAccountID,
+				ToAccountID:   item.ToAccountID,
+				Amount:        item.Amount,
+			})
+			if err != nil {
+				return err
+			}
+			if err := checkTransferAccounts(transfer.FromAccount, transfer.ToAccount); err != nil {
+				return err
+			}
+
+			itemParams := newTransferBatchItemParams(batch.ID, i, item)
+			itemParams.TransferID = sql.NullInt64{Int64: transfer.Transfer.ID, Valid: true}
+
+			batchItem, err = q.CreateTransferBatchItem(ctx, itemParams)
+			return err
+		})
+		if err != nil {
+			message, ok := batchItemError(err)
+			if !ok {
+				return result, err
+			}
+
+			itemParams := newTransferBatchItemParams(batch.ID, i, item)
+			itemParams.Error = sql.NullString{String: message, Valid: true}
+
+			batchItem, err = store.CreateTransferBatchItem(ctx, itemParams)
+			if err != nil {
+				return result, err
+			}
+		} else {
+			result.Transfers = append(result.Transfers, transfer)
+		}
+
+		result.Items = append(result.Items, batchItem)
+	}
+
+	result.Batch, err = store.CompleteTransferBatch(ctx, CompleteTransferBatchParams{
+		ID:             batch.ID,
+		Status:         transferBatchStatus(len(result.Transfers), len(arg.Items)),
+		SucceededCount: int32(len(result.Transfers)),
+	})
+
+	return result, err
+}
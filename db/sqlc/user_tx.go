@@ -0,0 +1,151 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ResetPasswordTxParams contains the input required to reset a password with a reset token
+type ResetPasswordTxParams struct {
+	TokenHash         string    `json:"token_hash"`
+	HashedPassword    string    `json:"hashed_password"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+}
+
+// ResetPasswordTx consumes a password reset token and sets the new password of its user, the other reset
+// tokens of the user are invalidated with it. It returns sql.ErrNoRows when the token is unknown, expired
+// or already used
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (User, error) {
+	var user User
+
+	ctx, span := startTxSpan(ctx, "ResetPasswordTx")
+	defer span.End()
+
+	err := store.execTx(ctx, sql.LevelDefault, func(q *Queries) error {
+		resetToken, err := q.UsePasswordResetToken(ctx, arg.TokenHash)
+		if err != nil {
+			return err
+		}
+
+		err = q.InvalidatePasswordResetTokens(ctx, resetToken.Username)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			Username:          resetToken.Username,
+			HashedPassword:    arg.HashedPassword,
+			PasswordChangedAt: arg.PasswordChangedAt,
+		})
+		return err
+	})
+	recordError(span, err)
+
+	return user, err
+}
+
+// VerifyEmailTx consumes an email verification token and marks the email of its user as verified.
+// It returns sql.ErrNoRows when the token is unknown, expired, already used or was issued
+// for an email the user no longer has
+func (store *SQLStore) VerifyEmailTx(ctx context.Context, tokenHash string) (User, error) {
+	var user User
+
+	ctx, span := startTxSpan(ctx, "VerifyEmailTx")
+	defer span.End()
+
+	err := store.execTx(ctx, sql.LevelDefault, func(q *Queries) error {
+		verificationToken, err := q.UseEmailVerificationToken(ctx, tokenHash)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.VerifyUserEmail(ctx, VerifyUserEmailParams{
+			Username: verificationToken.Username,
+			Email:    verificationToken.Email,
+		})
+		return err
+	})
+	recordError(span, err)
+
+	return user, err
+}
+
+// ConfirmTOTPTxParams contains the input required to confirm a TOTP enrollment
+type ConfirmTOTPTxParams struct {
+	Username           string   `json:"username"`
+	Step               int64    `json:"step"`
+	RecoveryCodeHashes []string `json:"recovery_code_hashes"`
+}
+
+// ConfirmTOTPTx enables TOTP for a user once they proved they can generate codes for the enrolled
+// secret, and replaces their recovery codes. The time step of the confirming code is consumed,
+// so it returns sql.ErrNoRows when the code was already used or no secret is enrolled
+func (store *SQLStore) ConfirmTOTPTx(ctx context.Context, arg ConfirmTOTPTxParams) (User, error) {
+	var user User
+
+	ctx, span := startTxSpan(ctx, "ConfirmTOTPTx")
+	defer span.End()
+
+	err := store.execTx(ctx, sql.LevelDefault, func(q *Queries) error {
+		_, err := q.UseTOTPStep(ctx, UseTOTPStepParams{
+			Username:     arg.Username,
+			TotpLastStep: arg.Step,
+		})
+		if err != nil {
+			return err
+		}
+
+		user, err = q.EnableUserTOTP(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteTOTPRecoveryCodes(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		for _, codeHash := range arg.RecoveryCodeHashes {
+			_, err = q.CreateTOTPRecoveryCode(ctx, CreateTOTPRecoveryCodeParams{
+				Username: arg.Username,
+				CodeHash: codeHash,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	recordError(span, err)
+
+	return user, err
+}
+
+// CreateUserTx creates a user and writes user.created to the outbox in the same transaction
+func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserParams) (User, error) {
+	var user User
+
+	ctx, span := startTxSpan(ctx, "CreateUserTx")
+	defer span.End()
+
+	err := store.execTx(ctx, sql.LevelDefault, func(q *Queries) error {
+		var err error
+		user, err = q.CreateUser(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		event, err := newUserCreatedEvent(user)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateOutboxEvent(ctx, event)
+		return err
+	})
+	recordError(span, err)
+
+	return user, err
+}
@@ -0,0 +1,11 @@
+package db
+
+// Statuses of webhook_deliveries, the queries claiming and replaying deliveries use the same values
+const (
+	// WebhookDeliveryPending deliveries are attempted once their next_attempt_at has passed
+	WebhookDeliveryPending = "pending"
+	// WebhookDeliverySucceeded deliveries were acknowledged by the receiver with a 2xx response
+	WebhookDeliverySucceeded = "succeeded"
+	// WebhookDeliveryDead deliveries failed too many times and are only attempted again when replayed
+	WebhookDeliveryDead = "dead"
+)
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/AbdRaqeeb/simple_bank/paymentfile"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/rs/zerolog/log"
+	"io"
+	"os"
+)
+
+const importPaymentsUsage = "usage: simple_bank import-payments [-format csv|pain.001] [-owner USERNAME] [-dry-run] FILE"
+
+// runImportPayments executes the import-payments subcommand, submitting the transfers of a payment file
+// and writing the report of every row to out as JSON
+func runImportPayments(config util.Config, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("import-payments", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	format := flags.String("format", "", "format of the file, detected from its extension when empty")
+	owner := flags.String("owner", "", "only accept source accounts of this user")
+	dryRun := flags.Bool("dry-run", false, "validate the file without transferring anything")
+
+	err := flags.Parse(args)
+	if err != nil || flags.NArg() != 1 {
+		return errors.New(importPaymentsUsage)
+	}
+	name := flags.Arg(0)
+
+	if *format == "" {
+		*format, err = paymentfile.DetectFormat(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	instructions, rowErrors, err := paymentfile.Parse(*format, file)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", name, err)
+	}
+
+	store, _, err := newStore(config)
+	if err != nil {
+		return err
+	}
+
+	report := paymentfile.Import(context.Background(), store, instructions, rowErrors, paymentfile.Options{
+		Owner:  *owner,
+		DryRun: *dryRun,
+	})
+
+	log.Info().
+		Str("file", name).
+		Int("total", report.Total).
+		Int("valid", report.Valid).
+		Int("submitted", report.Submitted).
+		Int("rejected", report.Rejected).
+		Int("failed", report.Failed).
+		Msg("payment file imported")
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,158 @@
+package interest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/rs/zerolog"
+	"time"
+)
+
+// pageSize is how many savings accounts are read at once
+const pageSize = 100
+
+// ErrDayNotOver is returned for days that have not ended yet, their end of day balances are not final
+var ErrDayNotOver = errors.New("interest cannot accrue before the end of the day")
+
+// Report counts what a run did for the savings accounts opened by the end of its day
+type Report struct {
+	Date           string `json:"date"`
+	Accounts       int    `json:"accounts"`
+	Accrued        int    `json:"accrued"`
+	AlreadyAccrued int    `json:"already_accrued"`
+	Backfilled     int    `json:"backfilled"`
+	Posted         int    `json:"posted"`
+	AlreadyPosted  int    `json:"already_posted"`
+	Failed         int    `json:"failed"`
+}
+
+// Job accrues the daily interest of savings accounts and posts it at the end of every month
+type Job struct {
+	store  db.Store
+	logger zerolog.Logger
+	now    func() time.Time
+}
+
+// NewJob creates a job accruing and posting interest with store
+func NewJob(store db.Store, logger zerolog.Logger) *Job {
+	return &Job{store: store, logger: logger, now: time.Now}
+}
+
+// Run accrues a day of interest on every savings account opened by the end of the UTC day of date, then posts the
+// month when date is its last day, accruing the days of the month no run accrued first. Every account accrues and
+// posts in its own transactions so a failing account does not stop the others. Days accrue and months post once,
+// rerunning a day completes the accounts that failed
+func (job *Job) Run(ctx context.Context, date time.Time) (Report, error) {
+	year, month, day := date.UTC().Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 1)
+
+	report := Report{Date: start.Format("2006-01-02")}
+	if job.now().Before(end) {
+		return report, fmt.Errorf("%w: %s", ErrDayNotOver, report.Date)
+	}
+
+	// the month is posted with its last day, once every day of it accrued
+	post := end.Day() == 1
+
+	var after int64
+	for {
+		accounts, err := job.store.ListSavingsAccounts(ctx, db.ListSavingsAccountsParams{AccountID: after, Limit: pageSize})
+		if err != nil {
+			return report, err
+		}
+
+		for _, savings := range accounts {
+			if savings.CreatedAt.Before(end) {
+				job.runAccount(ctx, savings, start, post, &report)
+			}
+		}
+
+		if len(accounts) < pageSize {
+			return report, nil
+		}
+		after = accounts[len(accounts)-1].AccountID
+	}
+}
+
+func (job *Job) runAccount(ctx context.Context, savings db.SavingsAccount, date time.Time, post bool, report *Report) {
+	report.Accounts++
+	accountID := savings.AccountID
+
+	accrual, err := job.store.AccrueInterestTx(ctx, db.AccrueInterestTxParams{AccountID: accountID, Date: date})
+	if err != nil {
+		report.Failed++
+		job.logger.Error().Err(err).Int64("account_id", accountID).Time("date", date).Msg("cannot accrue interest")
+		return
+	}
+
+	if accrual.Accrued {
+		report.Accrued++
+	} else {
+		report.AlreadyAccrued++
+	}
+
+	if !post {
+		return
+	}
+
+	err = job.accrueMissedDays(ctx, savings, date, report)
+	if err != nil {
+		report.Failed++
+		job.logger.Error().Err(err).Int64("account_id", accountID).Time("period", date).Msg("cannot accrue missed interest")
+		return
+	}
+
+	posting, err := job.store.PostInterestTx(ctx, db.PostInterestTxParams{AccountID: accountID, Period: date})
+	if err != nil {
+		report.Failed++
+		job.logger.Error().Err(err).Int64("account_id", accountID).Time("period", date).Msg("cannot post interest")
+		return
+	}
+
+	if posting.Posted {
+		report.Posted++
+	} else {
+		report.AlreadyPosted++
+	}
+}
+
+// accrueMissedDays accrues the days before date in its month that have no accrual, from the day the account was
+// opened when that is later. The accruals are counted first so a month no run missed accrues nothing here
+func (job *Job) accrueMissedDays(ctx context.Context, savings db.SavingsAccount, date time.Time, report *Report) error {
+	first := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	year, month, day := savings.CreatedAt.UTC().Date()
+	if opened := time.Date(year, month, day, 0, 0, 0, 0, time.UTC); opened.After(first) {
+		first = opened
+	}
+
+	end := date.AddDate(0, 0, 1)
+	days := int64(end.Sub(first) / (24 * time.Hour))
+
+	accrued, err := job.store.CountInterestAccruals(ctx, db.CountInterestAccrualsParams{
+		AccountID: savings.AccountID,
+		FromDate:  first,
+		ToDate:    end,
+	})
+	if err != nil {
+		return err
+	}
+	if accrued >= days {
+		return nil
+	}
+
+	for day := first; day.Before(date); day = day.AddDate(0, 0, 1) {
+		accrual, err := job.store.AccrueInterestTx(ctx, db.AccrueInterestTxParams{AccountID: savings.AccountID, Date: day})
+		if err != nil {
+			return fmt.Errorf("cannot accrue %s: %w", day.Format("2006-01-02"), err)
+		}
+
+		if accrual.Accrued {
+			report.Backfilled++
+		}
+	}
+
+	return nil
+}
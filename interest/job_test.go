@@ -0,0 +1,197 @@
+package interest
+
+import (
+	"context"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createAccount(t *testing.T, store db.Store, balance int64) db.Account {
+	user, err := store.CreateUser(context.Background(), db.CreateUserParams{
+		Username:       util.RandomOwner() + util.RandomString(6),
+		HashedPassword: util.RandomString(32),
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	account, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:       user.Username,
+		Balance:     balance,
+		Currency:    util.USD,
+		ProductCode: db.AccountProductChecking,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestJobRun(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+
+	expense := createAccount(t, store, 1000000)
+	savings := createAccount(t, store, 100000)
+	empty := createAccount(t, store, 0)
+	checking := createAccount(t, store, 100000)
+
+	// 3.65% on 1000.00 accrues 10 cents a day
+	product, err := store.CreateSavingsProduct(ctx, db.CreateSavingsProductParams{
+		Code:             "savings",
+		Name:             "Savings",
+		Currency:         util.USD,
+		AnnualRateBps:    365,
+		DayCount:         db.DayCountActual365,
+		ExpenseAccountID: expense.ID,
+	})
+	require.NoError(t, err)
+
+	for _, account := range []db.Account{savings, empty} {
+		_, err = store.CreateSavingsAccount(ctx, db.CreateSavingsAccountParams{AccountID: account.ID, ProductCode: product.Code})
+		require.NoError(t, err)
+	}
+
+	job := NewJob(store, zerolog.Nop())
+
+	// the accounts were opened today, they accrue from today to the end of the month
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	last := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+
+	_, err = job.Run(ctx, today)
+	require.ErrorIs(t, err, ErrDayNotOver)
+
+	job.now = func() time.Time { return last.AddDate(0, 0, 1) }
+
+	report, err := job.Run(ctx, today.AddDate(0, 0, -1))
+	require.NoError(t, err)
+	require.Zero(t, report.Accounts)
+
+	var days int64
+	for date := today; !date.After(last); date = date.AddDate(0, 0, 1) {
+		report, err = job.Run(ctx, date)
+		require.NoError(t, err)
+		require.Equal(t, date.Format("2006-01-02"), report.Date)
+		require.Equal(t, 2, report.Accounts)
+		require.Equal(t, 2, report.Accrued)
+		require.Zero(t, report.Failed)
+		days++
+	}
+	require.Equal(t, 2, report.Posted)
+
+	found, err := store.GetAccount(ctx, savings.ID)
+	require.NoError(t, err)
+	require.Equal(t, savings.Balance+10*days, found.Balance)
+
+	found, err = store.GetAccount(ctx, expense.ID)
+	require.NoError(t, err)
+	require.Equal(t, expense.Balance-10*days, found.Balance)
+
+	found, err = store.GetAccount(ctx, checking.ID)
+	require.NoError(t, err)
+	require.Equal(t, checking.Balance, found.Balance)
+
+	// nothing was due on the empty account, its month is posted without a transfer
+	found, err = store.GetAccount(ctx, empty.ID)
+	require.NoError(t, err)
+	require.Zero(t, found.Balance)
+
+	posted, err := store.SumInterestPostings(ctx, empty.ID)
+	require.NoError(t, err)
+	require.Zero(t, posted)
+
+	// rerunning the last day of the month accrues and posts nothing
+	report, err = job.Run(ctx, last)
+	require.NoError(t, err)
+	require.Equal(t, Report{Date: last.Format("2006-01-02"), Accounts: 2, AlreadyAccrued: 2, AlreadyPosted: 2}, report)
+
+	found, err = store.GetAccount(ctx, savings.ID)
+	require.NoError(t, err)
+	require.Equal(t, savings.Balance+10*days, found.Balance)
+}
+
+func TestJobRunAccruesMissedDays(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+
+	expense := createAccount(t, store, 1000000)
+	savings := createAccount(t, store, 100000)
+
+	product, err := store.CreateSavingsProduct(ctx, db.CreateSavingsProductParams{
+		Code:             "savings",
+		Name:             "Savings",
+		Currency:         util.USD,
+		AnnualRateBps:    365,
+		DayCount:         db.DayCountActual365,
+		ExpenseAccountID: expense.ID,
+	})
+	require.NoError(t, err)
+
+	_, err = store.CreateSavingsAccount(ctx, db.CreateSavingsAccountParams{AccountID: savings.ID, ProductCode: product.Code})
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	last := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+	days := int64(last.Sub(today)/(24*time.Hour)) + 1
+
+	job := NewJob(store, zerolog.Nop())
+	job.now = func() time.Time { return last.AddDate(0, 0, 1) }
+
+	// only the last day of the month runs, the days before it accrue before the month posts
+	report, err := job.Run(ctx, last)
+	require.NoError(t, err)
+	require.Equal(t, Report{
+		Date:       last.Format("2006-01-02"),
+		Accounts:   1,
+		Accrued:    1,
+		Backfilled: int(days - 1),
+		Posted:     1,
+	}, report)
+
+	accrued, err := store.CountInterestAccruals(ctx, db.CountInterestAccrualsParams{
+		AccountID: savings.ID,
+		FromDate:  today,
+		ToDate:    last.AddDate(0, 0, 1),
+	})
+	require.NoError(t, err)
+	require.Equal(t, days, accrued)
+
+	found, err := store.GetAccount(ctx, savings.ID)
+	require.NoError(t, err)
+	require.Equal(t, savings.Balance+10*days, found.Balance)
+}
+
+func TestJobRunFailure(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+
+	expense := createAccount(t, store, 0)
+	savings := createAccount(t, store, 100000)
+
+	product, err := store.CreateSavingsProduct(ctx, db.CreateSavingsProductParams{
+		Code:             "savings",
+		Name:             "Savings",
+		Currency:         util.USD,
+		AnnualRateBps:    365,
+		DayCount:         "30/360",
+		ExpenseAccountID: expense.ID,
+	})
+	require.NoError(t, err)
+
+	_, err = store.CreateSavingsAccount(ctx, db.CreateSavingsAccountParams{AccountID: savings.ID, ProductCode: product.Code})
+	require.NoError(t, err)
+
+	job := NewJob(store, zerolog.Nop())
+	job.now = func() time.Time { return time.Now().AddDate(0, 0, 1) }
+
+	// the account fails, the run completes and can be rerun
+	report, err := job.Run(ctx, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Accounts)
+	require.Equal(t, 1, report.Failed)
+	require.Zero(t, report.Accrued)
+}
@@ -0,0 +1,103 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/rs/zerolog"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	EmailerLog  = "log"
+	EmailerFile = "file"
+)
+
+// Emailer sends emails to users
+type Emailer interface {
+	// SendEmail delivers a plain text email to a single recipient
+	SendEmail(ctx context.Context, to, subject, body string) error
+}
+
+// NewEmailer creates the emailer selected by the configuration
+func NewEmailer(config util.Config) (Emailer, error) {
+	switch config.Emailer {
+	case "", EmailerLog:
+		return NewLogEmailer(), nil
+	case EmailerFile:
+		if config.EmailOutboxPath == "" {
+			return nil, fmt.Errorf("the %s emailer requires EMAIL_OUTBOX_PATH", EmailerFile)
+		}
+		return NewFileEmailer(config.EmailOutboxPath), nil
+	default:
+		return nil, fmt.Errorf("unsupported emailer: %s", config.Emailer)
+	}
+}
+
+// LogEmailer writes emails to the logger of the request context, for local development
+type LogEmailer struct{}
+
+// NewLogEmailer creates an emailer that logs every email instead of sending it
+func NewLogEmailer() Emailer {
+	return LogEmailer{}
+}
+
+func (LogEmailer) SendEmail(ctx context.Context, to, subject, body string) error {
+	zerolog.Ctx(ctx).Info().
+		Str("to", to).
+		Str("subject", subject).
+		Str("body", body).
+		Msg("email sent")
+
+	return nil
+}
+
+// Email is a message written by the FileEmailer
+type Email struct {
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// FileEmailer appends every email as a json line to an outbox file
+type FileEmailer struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileEmailer creates an emailer writing to the outbox file at path, the file is created when missing
+func NewFileEmailer(path string) Emailer {
+	return &FileEmailer{path: path}
+}
+
+func (emailer *FileEmailer) SendEmail(ctx context.Context, to, subject, body string) error {
+	data, err := json.Marshal(Email{
+		To:      to,
+		Subject: subject,
+		Body:    body,
+		SentAt:  time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	emailer.mu.Lock()
+	defer emailer.mu.Unlock()
+
+	file, err := os.OpenFile(emailer.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("cannot open outbox: %w", err)
+	}
+
+	_, err = file.Write(append(data, '\n'))
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("cannot write email: %w", err)
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,53 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileEmailer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	emailer := NewFileEmailer(path)
+
+	to := util.RandomEmail()
+	for i := 0; i < 2; i++ {
+		err := emailer.SendEmail(context.Background(), to, "subject", util.RandomString(10))
+		require.NoError(t, err)
+	}
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var emails []Email
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var email Email
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &email))
+		emails = append(emails, email)
+	}
+	require.NoError(t, scanner.Err())
+
+	require.Len(t, emails, 2)
+	require.Equal(t, to, emails[0].To)
+	require.Equal(t, "subject", emails[1].Subject)
+	require.NotZero(t, emails[1].SentAt)
+}
+
+func TestNewEmailer(t *testing.T) {
+	emailer, err := NewEmailer(util.Config{})
+	require.NoError(t, err)
+	require.IsType(t, LogEmailer{}, emailer)
+
+	_, err = NewEmailer(util.Config{Emailer: EmailerFile})
+	require.Error(t, err)
+
+	_, err = NewEmailer(util.Config{Emailer: "smtp"})
+	require.Error(t, err)
+}
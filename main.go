@@ -1,33 +1,190 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"github.com/AbdRaqeeb/simple_bank/api"
 	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/outbox"
+	"github.com/AbdRaqeeb/simple_bank/telemetry"
 	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/AbdRaqeeb/simple_bank/webhook"
 	_ "github.com/lib/pq"
-	"log"
+	"github.com/rs/zerolog/log"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
+// memoryDriver selects the in-memory store, data is lost when the process exits
+const memoryDriver = "memory"
+
 func main() {
 	config, err := util.LoadConfig(".")
 	if err != nil {
-		log.Fatal("cannot load config:", err)
+		log.Fatal().Err(err).Msg("cannot load config")
 	}
 
-	conn, err := sql.Open(config.DbDriver, config.DbSource)
+	logger, err := util.NewLogger(config.LogLevel)
 	if err != nil {
-		log.Fatal("can't connect to database", err)
+		log.Fatal().Err(err).Msg("cannot create logger")
+	}
+	log.Logger = logger
+
+	currencies, err := util.ParseCurrencyCodes(config.Currencies)
+	if err == nil {
+		err = util.EnableCurrencies(currencies)
+	}
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot enable currencies")
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = runMigrate(config, os.Args[2:])
+		if err != nil {
+			log.Fatal().Err(err).Msg("migration failed")
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "import-payments" {
+		err = runImportPayments(config, os.Args[2:], os.Stdout)
+		if err != nil {
+			log.Fatal().Err(err).Msg("payment import failed")
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "accrue-interest" {
+		err = runAccrueInterest(config, os.Args[2:], os.Stdout)
+		if err != nil {
+			log.Fatal().Err(err).Msg("interest accrual failed")
+		}
+		return
+	}
+
+	if config.AutoMigrate && config.DbDriver != memoryDriver {
+		err = autoMigrate(config)
+		if err != nil {
+			log.Fatal().Err(err).Msg("cannot migrate database")
+		}
+	}
+
+	shutdownTracing, err := telemetry.SetupTracing(context.Background(), config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot setup tracing")
+	}
+	defer shutdownTracing(context.Background())
+
+	store, entryListener, err := newStore(config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create store")
 	}
 
-	store := db.NewStore(conn)
 	server, err := api.NewServer(config, store)
 	if err != nil {
-		log.Fatal("cannot create server:", err)
+		log.Fatal().Err(err).Msg("cannot create server")
+	}
+
+	dispatcher, err := webhook.NewDispatcher(config, store)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create webhook dispatcher")
+	}
+
+	publisher, err := outbox.NewPublisher(config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create outbox publisher")
+	}
+
+	// webhook deliveries are queued from the outbox, so changes made outside the server reach webhooks too
+	relay, err := outbox.NewRelay(config, store, outbox.NewFanoutPublisher(publisher, webhook.NewPublisher(store)))
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create outbox relay")
 	}
 
-	err = server.Start(config.ServerAddress)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	dispatcherDone := make(chan struct{})
+	go func() {
+		defer close(dispatcherDone)
+		dispatcher.Run(ctx)
+	}()
+
+	if entryListener != nil {
+		go entryListener.Run(ctx)
+	}
+
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		relay.Run(ctx)
+	}()
+
+	go func() {
+		log.Info().Str("address", config.ServerAddress).Msg("starting server")
+		err := server.Start(config.ServerAddress)
+		if err != nil {
+			log.Fatal().Err(err).Msg("cannot start server")
+		}
+	}()
+
+	<-ctx.Done()
+	log.Info().Msg("shutting down server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownDelay+config.ShutdownTimeout)
+	defer cancel()
+
+	err = server.Shutdown(shutdownCtx)
 	if err != nil {
-		log.Fatal("cannot start server:", err)
+		log.Error().Err(err).Msg("cannot shutdown server gracefully")
+	}
+
+	// deliveries interrupted by the shutdown are attempted again once their claim expires
+	select {
+	case <-dispatcherDone:
+	case <-shutdownCtx.Done():
+		log.Error().Msg("webhook dispatcher did not stop in time")
+	}
+
+	// events published but not yet marked are published again, consumers drop the duplicates
+	select {
+	case <-relayDone:
+	case <-shutdownCtx.Done():
+		log.Error().Msg("outbox relay did not stop in time")
 	}
 }
+
+// newStore creates the store selected by the configured database driver, and for postgres the listener
+// streaming new entries, which must be run for the store to stream them
+func newStore(config util.Config) (db.Store, *db.EntryListener, error) {
+	if config.DbDriver == memoryDriver {
+		log.Warn().Msg("using in-memory store, data will not be persisted")
+		return db.NewMemoryStore(), nil, nil
+	}
+
+	conn, err := sql.Open(config.DbDriver, config.DbSource)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't connect to database: %w", err)
+	}
+
+	transferIsolation, err := db.ParseIsolationLevel(config.TransferIsolation)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entryListener := db.NewEntryListener(config.DbSource, log.Logger)
+
+	store := db.NewStore(conn,
+		db.WithEntryListener(entryListener),
+		db.WithTransferIsolation(transferIsolation),
+		db.WithRetryPolicy(db.RetryPolicy{
+			MaxAttempts: config.TxMaxAttempts,
+			BaseDelay:   config.TxRetryBaseDelay,
+			MaxDelay:    config.TxRetryMaxDelay,
+		}),
+	)
+
+	return store, entryListener, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/AbdRaqeeb/simple_bank/db/migration"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/rs/zerolog/log"
+	"strconv"
+)
+
+const migrateUsage = "usage: simple_bank migrate up [N] | down [N|all] | version | force VERSION"
+
+// runMigrate executes the migrate subcommand against the configured database
+func runMigrate(config util.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := migration.New(config.DbSource)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch args[0] {
+	case "up":
+		steps, err := parseSteps(args[1:], 0)
+		if err != nil {
+			return err
+		}
+
+		err = migrator.Up(steps)
+		if err != nil {
+			return fmt.Errorf("cannot migrate up: %w", err)
+		}
+	case "down":
+		// rolling back everything must be asked for explicitly
+		steps := 1
+		if len(args) > 1 && args[1] == "all" {
+			steps = 0
+		} else if steps, err = parseSteps(args[1:], 1); err != nil {
+			return err
+		}
+
+		err = migrator.Down(steps)
+		if err != nil {
+			return fmt.Errorf("cannot migrate down: %w", err)
+		}
+	case "version":
+	case "force":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", args[1], err)
+		}
+
+		err = migrator.Force(version)
+		if err != nil {
+			return fmt.Errorf("cannot force version: %w", err)
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		return fmt.Errorf("cannot read version: %w", err)
+	}
+
+	log.Info().Uint("version", version).Bool("dirty", dirty).Msg("database schema version")
+	return nil
+}
+
+// autoMigrate applies pending migrations on startup
+func autoMigrate(config util.Config) error {
+	migrator, err := migration.New(config.DbSource)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	err = migrator.Up(0)
+	if err != nil {
+		return fmt.Errorf("cannot migrate up: %w", err)
+	}
+
+	version, _, err := migrator.Version()
+	if err != nil {
+		return fmt.Errorf("cannot read version: %w", err)
+	}
+
+	log.Info().Uint("version", version).Msg("database migrated")
+	return nil
+}
+
+func parseSteps(args []string, defaultSteps int) (int, error) {
+	if len(args) == 0 {
+		return defaultSteps, nil
+	}
+
+	steps, err := strconv.Atoi(args[0])
+	if err != nil || steps < 1 {
+		return 0, fmt.Errorf("invalid number of steps %q", args[0])
+	}
+
+	return steps, nil
+}
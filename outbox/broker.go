@@ -0,0 +1,132 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxResponseBytes bounds how much of a broker response is read
+const maxResponseBytes = 64 << 10
+
+// BrokerClient sends a message to a NATS subject or Kafka topic. The messages of one key must stay in order,
+// Kafka clients use it as the partition key. id is unique per message, a NATS JetStream client sets it as
+// Nats-Msg-Id so the server drops redeliveries
+type BrokerClient interface {
+	Send(ctx context.Context, topic, key, id string, value []byte) error
+}
+
+// BrokerPublisher publishes messages through a NATS or Kafka compatible client. Every aggregate type has its
+// own topic, named after it with the topic prefix, and messages are keyed by aggregate so consumers receive
+// the events of an aggregate in order
+type BrokerPublisher struct {
+	client      BrokerClient
+	topicPrefix string
+}
+
+// NewBrokerPublisher creates a publisher sending messages with client to topics starting with topicPrefix
+func NewBrokerPublisher(client BrokerClient, topicPrefix string) *BrokerPublisher {
+	return &BrokerPublisher{
+		client:      client,
+		topicPrefix: topicPrefix,
+	}
+}
+
+func (publisher *BrokerPublisher) Publish(ctx context.Context, message Message) error {
+	value, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	topic := message.AggregateType
+	if publisher.topicPrefix != "" {
+		topic = publisher.topicPrefix + "." + topic
+	}
+
+	return publisher.client.Send(ctx, topic, message.AggregateID, strconv.FormatInt(message.ID, 10), value)
+}
+
+// KafkaRESTClient sends messages to Kafka through the v2 API of a Kafka REST proxy, which Redpanda also serves
+type KafkaRESTClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewKafkaRESTClient creates a client for the REST proxy at baseURL
+func NewKafkaRESTClient(baseURL string, timeout time.Duration) *KafkaRESTClient {
+	return &KafkaRESTClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+type kafkaRecord struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+type kafkaProduceRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		Partition int32  `json:"partition"`
+		Offset    int64  `json:"offset"`
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// Send produces value to topic with key as record key. The REST proxy has no record headers,
+// so consumers deduplicate by the id inside the value
+func (client *KafkaRESTClient) Send(ctx context.Context, topic, key, id string, value []byte) error {
+	body, err := json.Marshal(kafkaProduceRequest{
+		Records: []kafkaRecord{{Key: key, Value: value}},
+	})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, client.baseURL+"/topics/"+url.PathEscape(topic), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	request.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	response, err := client.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(response.Body, maxResponseBytes))
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("kafka rest proxy responded with status %d: %s", response.StatusCode, bytes.TrimSpace(data))
+	}
+
+	var rsp kafkaProduceResponse
+	if err = json.Unmarshal(data, &rsp); err != nil {
+		return fmt.Errorf("cannot decode kafka rest proxy response: %w", err)
+	}
+
+	for _, offset := range rsp.Offsets {
+		if offset.ErrorCode != nil {
+			return fmt.Errorf("kafka rest proxy rejected the record with error %d: %s", *offset.ErrorCode, offset.Error)
+		}
+	}
+
+	return nil
+}
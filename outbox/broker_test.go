@@ -0,0 +1,120 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// sentRecord is a message received by testBrokerClient
+type sentRecord struct {
+	topic string
+	key   string
+	id    string
+	value []byte
+}
+
+type testBrokerClient struct {
+	records []sentRecord
+}
+
+func (client *testBrokerClient) Send(ctx context.Context, topic, key, id string, value []byte) error {
+	client.records = append(client.records, sentRecord{topic: topic, key: key, id: id, value: value})
+	return nil
+}
+
+func TestBrokerPublisher(t *testing.T) {
+	client := &testBrokerClient{}
+	publisher := NewBrokerPublisher(client, "simple_bank")
+
+	message := Message{
+		ID:            42,
+		AggregateType: "account",
+		AggregateID:   "7",
+		Type:          "account.created",
+		CreatedAt:     time.Now().UTC().Truncate(time.Microsecond),
+		Data:          json.RawMessage(`{"id":7}`),
+	}
+	require.NoError(t, publisher.Publish(context.Background(), message))
+
+	require.Len(t, client.records, 1)
+	record := client.records[0]
+	require.Equal(t, "simple_bank.account", record.topic)
+	require.Equal(t, "7", record.key)
+	require.Equal(t, "42", record.id)
+
+	var sent Message
+	require.NoError(t, json.Unmarshal(record.value, &sent))
+	require.Equal(t, message, sent)
+}
+
+func TestKafkaRESTClient(t *testing.T) {
+	testCases := []struct {
+		name          string
+		status        int
+		response      string
+		checkResponse func(t *testing.T, err error)
+	}{
+		{
+			name:     "OK",
+			status:   http.StatusOK,
+			response: `{"offsets":[{"partition":0,"offset":12}]}`,
+			checkResponse: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:     "Record Rejected",
+			status:   http.StatusOK,
+			response: `{"offsets":[{"partition":null,"offset":null,"error_code":50003,"error":"not leader"}]}`,
+			checkResponse: func(t *testing.T, err error) {
+				require.ErrorContains(t, err, "50003")
+			},
+		},
+		{
+			name:     "Unknown Topic",
+			status:   http.StatusNotFound,
+			response: `{"error_code":40401,"message":"Topic not found"}`,
+			checkResponse: func(t *testing.T, err error) {
+				require.ErrorContains(t, err, "status 404")
+			},
+		},
+		{
+			name:     "Invalid Response",
+			status:   http.StatusOK,
+			response: `<html>`,
+			checkResponse: func(t *testing.T, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodPost, r.Method)
+				require.Equal(t, "/topics/simple_bank.account", r.URL.Path)
+				require.Equal(t, "application/vnd.kafka.json.v2+json", r.Header.Get("Content-Type"))
+
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				require.JSONEq(t, `{"records":[{"key":"7","value":{"id":7}}]}`, string(body))
+
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			client := NewKafkaRESTClient(server.URL+"/", time.Second)
+			err := client.Send(context.Background(), "simple_bank.account", "7", "42", []byte(`{"id":7}`))
+			tc.checkResponse(t, err)
+		})
+	}
+}
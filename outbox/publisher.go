@@ -0,0 +1,149 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/rs/zerolog"
+	"sync"
+	"time"
+)
+
+// Publishers selectable with OUTBOX_PUBLISHER
+const (
+	LogPublisherName       = "log"
+	KafkaRESTPublisherName = "kafka-rest"
+)
+
+// Message is an outbox event as it is handed to a publisher. ID is unique and increases with every event,
+// consumers use it to drop the duplicates at-least-once delivery can produce. Sequence numbers the events
+// of an aggregate from 1 in the order they are published
+type Message struct {
+	ID            int64           `json:"id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	Sequence      int64           `json:"sequence"`
+	Type          string          `json:"type"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Data          json.RawMessage `json:"data"`
+}
+
+func newMessage(event db.Outbox) Message {
+	return Message{
+		ID:            event.ID,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		Sequence:      event.Sequence,
+		Type:          event.EventType,
+		CreatedAt:     event.CreatedAt,
+		Data:          event.Payload,
+	}
+}
+
+// Publisher sends messages to consumers. Publish returns once the message is accepted,
+// a message it fails to publish is published again later
+type Publisher interface {
+	Publish(ctx context.Context, message Message) error
+}
+
+// NewPublisher creates the publisher selected by OUTBOX_PUBLISHER
+func NewPublisher(config util.Config) (Publisher, error) {
+	switch config.OutboxPublisher {
+	case LogPublisherName:
+		logger, err := util.NewLogger(config.LogLevel)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create logger: %w", err)
+		}
+		return NewLogPublisher(logger), nil
+	case KafkaRESTPublisherName:
+		if config.OutboxBrokerURL == "" {
+			return nil, fmt.Errorf("OUTBOX_BROKER_URL is required by the %s publisher", KafkaRESTPublisherName)
+		}
+		client := NewKafkaRESTClient(config.OutboxBrokerURL, config.OutboxBrokerTimeout)
+		return NewBrokerPublisher(client, config.OutboxTopicPrefix), nil
+	default:
+		return nil, fmt.Errorf("unsupported outbox publisher %q", config.OutboxPublisher)
+	}
+}
+
+// FanoutPublisher publishes every message with each of its publishers in turn. A message fails when one of them
+// fails, it is then published again with all of them, so they must tolerate duplicates
+type FanoutPublisher struct {
+	publishers []Publisher
+}
+
+// NewFanoutPublisher creates a publisher handing every message to each of publishers
+func NewFanoutPublisher(publishers ...Publisher) *FanoutPublisher {
+	return &FanoutPublisher{publishers: publishers}
+}
+
+func (publisher *FanoutPublisher) Publish(ctx context.Context, message Message) error {
+	for _, p := range publisher.publishers {
+		if err := p.Publish(ctx, message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LogPublisher writes messages to a logger, for local development
+type LogPublisher struct {
+	logger zerolog.Logger
+}
+
+// NewLogPublisher creates a publisher logging messages with logger
+func NewLogPublisher(logger zerolog.Logger) *LogPublisher {
+	return &LogPublisher{logger: logger}
+}
+
+func (publisher *LogPublisher) Publish(ctx context.Context, message Message) error {
+	publisher.logger.Info().
+		Int64("id", message.ID).
+		Str("aggregate_type", message.AggregateType).
+		Str("aggregate_id", message.AggregateID).
+		Int64("sequence", message.Sequence).
+		Str("type", message.Type).
+		RawJSON("data", message.Data).
+		Msg("outbox event")
+
+	return nil
+}
+
+// MemoryPublisher keeps published messages in memory, for tests
+type MemoryPublisher struct {
+	mu       sync.Mutex
+	messages []Message
+
+	// Fail, when set, is called before a message is kept and its error fails the publish
+	Fail func(message Message) error
+}
+
+// NewMemoryPublisher creates an empty in-memory publisher
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (publisher *MemoryPublisher) Publish(ctx context.Context, message Message) error {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	if publisher.Fail != nil {
+		if err := publisher.Fail(message); err != nil {
+			return err
+		}
+	}
+
+	publisher.messages = append(publisher.messages, message)
+	return nil
+}
+
+// Messages returns the published messages in the order they were published
+func (publisher *MemoryPublisher) Messages() []Message {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	return append([]Message{}, publisher.messages...)
+}
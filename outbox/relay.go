@@ -0,0 +1,158 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/rs/zerolog"
+	"time"
+)
+
+// maxErrorLength bounds the error stored with a failed attempt
+const maxErrorLength = 512
+
+// Relay publishes the events written to the outbox. An event is marked published only after the publisher
+// accepted it, so every event is published at least once. Only the first unpublished event of each aggregate is
+// claimed, so the events of an aggregate are published in sequence order even by several relays, and an event that
+// fails is retried with exponential backoff while the events of other aggregates go on
+type Relay struct {
+	store     db.Store
+	publisher Publisher
+	logger    zerolog.Logger
+
+	pollInterval time.Duration
+	batchSize    int32
+	timeout      time.Duration
+	baseDelay    time.Duration
+	maxDelay     time.Duration
+}
+
+// NewRelay creates a relay configured by the OUTBOX_ settings publishing events with publisher
+func NewRelay(config util.Config, store db.Store, publisher Publisher) (*Relay, error) {
+	logger, err := util.NewLogger(config.LogLevel)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create logger: %w", err)
+	}
+
+	if config.OutboxBatchSize <= 0 {
+		return nil, fmt.Errorf("OUTBOX_BATCH_SIZE must be positive")
+	}
+
+	relay := &Relay{
+		store:        store,
+		publisher:    publisher,
+		logger:       logger,
+		pollInterval: config.OutboxPollInterval,
+		batchSize:    config.OutboxBatchSize,
+		timeout:      config.OutboxBrokerTimeout,
+		baseDelay:    config.OutboxBackoffBaseDelay,
+		maxDelay:     config.OutboxBackoffMaxDelay,
+	}
+
+	return relay, nil
+}
+
+// Run publishes pending events every poll interval until ctx is done
+func (relay *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(relay.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := relay.PublishPending(ctx)
+			if err != nil && ctx.Err() == nil {
+				relay.logger.Error().Err(err).Msg("cannot publish outbox events")
+			}
+
+			// keep draining while events are published, each batch holds one event per aggregate
+			if err != nil || n == 0 {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishPending claims a batch of due events, the first unpublished one of each aggregate, and publishes them.
+// It returns how many were published. Claimed events are hidden from other relays until their lock expires,
+// which only happens when this one stopped before marking them published or recording the failure
+func (relay *Relay) PublishPending(ctx context.Context) (int, error) {
+	lockedUntil := time.Now().Add(2*relay.timeout + time.Minute)
+
+	events, err := relay.store.ClaimOutboxEvents(ctx, db.ClaimOutboxEventsParams{
+		LockedUntil: lockedUntil,
+		Limit:       relay.batchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	published := make([]int64, 0, len(events))
+	var recordErr error
+	for _, event := range events {
+		if ctx.Err() != nil {
+			break
+		}
+
+		err = relay.publisher.Publish(ctx, newMessage(event))
+		if err == nil {
+			published = append(published, event.ID)
+			continue
+		}
+
+		if ctx.Err() != nil {
+			// shutting down, the event is published again once its lock expires
+			break
+		}
+
+		attempts := event.Attempts + 1
+		relay.logger.Error().Err(err).
+			Int64("id", event.ID).
+			Str("aggregate", event.AggregateType+"/"+event.AggregateID).
+			Str("type", event.EventType).
+			Int32("attempts", attempts).
+			Msg("cannot publish outbox event")
+
+		err = relay.store.RecordOutboxEventFailure(ctx, db.RecordOutboxEventFailureParams{
+			ID:            event.ID,
+			NextAttemptAt: time.Now().Add(relay.backoff(attempts)),
+			LastError:     sql.NullString{String: truncate(err.Error(), maxErrorLength), Valid: true},
+		})
+		if err != nil && recordErr == nil {
+			recordErr = err
+		}
+	}
+
+	if len(published) > 0 {
+		err = relay.store.MarkOutboxEventsPublished(ctx, published)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(published), recordErr
+}
+
+// backoff returns the delay before an event is published again, doubling from the base delay up to the max delay
+func (relay *Relay) backoff(attempts int32) time.Duration {
+	delay := relay.baseDelay << (attempts - 1)
+	if delay <= 0 || delay > relay.maxDelay {
+		delay = relay.maxDelay
+	}
+
+	return delay
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,259 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newTestRelay(t *testing.T, store db.Store, publisher Publisher, batchSize int32) *Relay {
+	relay, err := NewRelay(util.Config{
+		LogLevel:           "disabled",
+		OutboxPollInterval: 10 * time.Millisecond,
+		OutboxBatchSize:    batchSize,
+	}, store, publisher)
+	require.NoError(t, err)
+
+	return relay
+}
+
+// newTestAccount creates an account with its owner, writing user.created and account.created
+func newTestAccount(t *testing.T, store db.Store) db.Account {
+	ctx := context.Background()
+
+	user, err := store.CreateUserTx(ctx, db.CreateUserParams{
+		Username:       util.RandomOwner() + util.RandomString(6),
+		HashedPassword: util.RandomString(32),
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	account, err := store.CreateAccountTx(ctx, db.CreateAccountParams{
+		Owner:       user.Username,
+		Balance:     100,
+		Currency:    util.USD,
+		ProductCode: db.AccountProductChecking,
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+func messageTypes(messages []Message) []string {
+	types := make([]string, len(messages))
+	for i, message := range messages {
+		types[i] = message.Type
+	}
+	return types
+}
+
+// aggregateMessages groups messages by aggregate, keeping the order they were published in
+func aggregateMessages(messages []Message) map[string][]Message {
+	aggregates := make(map[string][]Message)
+	for _, message := range messages {
+		aggregate := message.AggregateType + "/" + message.AggregateID
+		aggregates[aggregate] = append(aggregates[aggregate], message)
+	}
+	return aggregates
+}
+
+func TestRelayPublishesInOrder(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+	publisher := NewMemoryPublisher()
+
+	account1 := newTestAccount(t, store)
+	account2 := newTestAccount(t, store)
+
+	_, err := store.TransferTx(ctx, db.TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10})
+	require.NoError(t, err)
+
+	relay := newTestRelay(t, store, publisher, 2)
+
+	for i := 0; i < 3; i++ {
+		n, err := relay.PublishPending(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+	}
+
+	n, err := relay.PublishPending(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	messages := aggregateMessages(publisher.Messages())
+	require.Len(t, messages, 4)
+
+	// the events of an aggregate are published in sequence order
+	for _, aggregate := range messages {
+		for i, message := range aggregate {
+			require.Equal(t, int64(i+1), message.Sequence)
+		}
+	}
+
+	// the transfer is published to both accounts, after their account.created
+	for _, account := range []db.Account{account1, account2} {
+		aggregate := messages[db.AggregateAccount+"/"+strconv.FormatInt(account.ID, 10)]
+		require.Equal(t, []string{db.EventAccountCreated, db.EventTransferCreated}, messageTypes(aggregate))
+
+		var accountEvent db.AccountCreatedEvent
+		require.NoError(t, json.Unmarshal(aggregate[0].Data, &accountEvent))
+		require.Equal(t, account.ID, accountEvent.ID)
+		require.Equal(t, account.Owner, accountEvent.Owner)
+
+		var transferEvent db.TransferCreatedEvent
+		require.NoError(t, json.Unmarshal(aggregate[1].Data, &transferEvent))
+		require.Equal(t, account1.ID, transferEvent.FromAccountID)
+		require.Equal(t, account2.ID, transferEvent.ToAccountID)
+
+		user := messages[db.AggregateUser+"/"+account.Owner]
+		require.Equal(t, []string{db.EventUserCreated}, messageTypes(user))
+	}
+}
+
+func TestRelayRetriesFailedEvents(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+	publisher := NewMemoryPublisher()
+
+	account1 := newTestAccount(t, store)
+	account2 := newTestAccount(t, store)
+	account1ID := strconv.FormatInt(account1.ID, 10)
+
+	// the first account.created of account1 fails once
+	failures := map[int64]int{}
+	publisher.Fail = func(message Message) error {
+		if message.Type == db.EventAccountCreated && message.AggregateID == account1ID && failures[message.ID] == 0 {
+			failures[message.ID]++
+			return errors.New("broker unavailable")
+		}
+		return nil
+	}
+
+	// a later event of the failed aggregate
+	_, err := store.CreateOutboxEvent(ctx, db.CreateOutboxEventParams{
+		AggregateType: db.AggregateAccount,
+		AggregateID:   account1ID,
+		EventType:     "account.updated",
+		Payload:       []byte(`{}`),
+	})
+	require.NoError(t, err)
+
+	relay := newTestRelay(t, store, publisher, 10)
+
+	// other aggregates are published, the later event of the failed one waits
+	n, err := relay.PublishPending(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, []string{db.EventUserCreated, db.EventUserCreated, db.EventAccountCreated}, messageTypes(publisher.Messages()))
+	require.Equal(t, strconv.FormatInt(account2.ID, 10), publisher.Messages()[2].AggregateID)
+
+	// the test relay has no backoff, so the failed event is published again right away, still ahead of the later one
+	for _, eventType := range []string{db.EventAccountCreated, "account.updated"} {
+		n, err = relay.PublishPending(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		messages := publisher.Messages()
+		require.Equal(t, eventType, messages[len(messages)-1].Type)
+		require.Equal(t, account1ID, messages[len(messages)-1].AggregateID)
+	}
+
+	n, err = relay.PublishPending(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestRelayFailingEventDoesNotBlockOtherAggregates(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+	publisher := NewMemoryPublisher()
+
+	account1 := newTestAccount(t, store)
+	account1ID := strconv.FormatInt(account1.ID, 10)
+
+	// the events of account1 never publish, and more of them are pending than fit in a batch
+	attempts := 0
+	publisher.Fail = func(message Message) error {
+		if message.AggregateID == account1ID {
+			attempts++
+			return errors.New("message too large")
+		}
+		return nil
+	}
+
+	for i := 0; i < 5; i++ {
+		_, err := store.CreateOutboxEvent(ctx, db.CreateOutboxEventParams{
+			AggregateType: db.AggregateAccount,
+			AggregateID:   account1ID,
+			EventType:     "account.updated",
+			Payload:       []byte(`{}`),
+		})
+		require.NoError(t, err)
+	}
+
+	account2 := newTestAccount(t, store)
+
+	relay := newTestRelay(t, store, publisher, 2)
+	relay.baseDelay = time.Hour
+	relay.maxDelay = time.Hour
+
+	published := 0
+	for i := 0; i < 5; i++ {
+		n, err := relay.PublishPending(ctx)
+		require.NoError(t, err)
+		published += n
+	}
+
+	// the failed event waits for its backoff, the other aggregates are published around it
+	require.Equal(t, 3, published)
+	require.Equal(t, 1, attempts)
+
+	messages := aggregateMessages(publisher.Messages())
+	require.NotContains(t, messages, db.AggregateAccount+"/"+account1ID)
+	require.Equal(t, []string{db.EventAccountCreated}, messageTypes(messages[db.AggregateAccount+"/"+strconv.FormatInt(account2.ID, 10)]))
+	require.Equal(t, []string{db.EventUserCreated}, messageTypes(messages[db.AggregateUser+"/"+account1.Owner]))
+	require.Equal(t, []string{db.EventUserCreated}, messageTypes(messages[db.AggregateUser+"/"+account2.Owner]))
+}
+
+func TestRelayBackoff(t *testing.T) {
+	relay := &Relay{baseDelay: time.Second, maxDelay: time.Minute}
+
+	require.Equal(t, time.Second, relay.backoff(1))
+	require.Equal(t, 4*time.Second, relay.backoff(3))
+	require.Equal(t, time.Minute, relay.backoff(7))
+	require.Equal(t, time.Minute, relay.backoff(100))
+}
+
+func TestRelayRun(t *testing.T) {
+	store := db.NewMemoryStore()
+	publisher := NewMemoryPublisher()
+
+	newTestAccount(t, store)
+	relay := newTestRelay(t, store, publisher, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		relay.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(publisher.Messages()) == 2
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+func TestNewRelayInvalidBatchSize(t *testing.T) {
+	_, err := NewRelay(util.Config{LogLevel: "disabled"}, db.NewMemoryStore(), NewMemoryPublisher())
+	require.Error(t, err)
+}
@@ -0,0 +1,98 @@
+package paymentfile
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvColumns are the columns CSV payment files must have, a reference column is optional
+var csvColumns = []string{"from_account_id", "to_account_id", "amount", "currency"}
+
+// ParseCSV reads a CSV payment file. The first line names the columns from_account_id, to_account_id, amount,
+// currency and optionally reference, in any order. Amounts are decimals in the major unit of the currency
+func ParseCSV(r io.Reader) ([]Instruction, []RowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil, errors.New("csv file is empty")
+		}
+		return nil, nil, fmt.Errorf("cannot read csv header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range csvColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("csv header is missing the %s column", name)
+		}
+	}
+
+	var instructions []Instruction
+	var rowErrors []RowError
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot read csv: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		instruction := Instruction{
+			Row:       line,
+			Reference: field("reference"),
+			Currency:  strings.ToUpper(field("currency")),
+		}
+
+		err = parseCSVRecord(&instruction, field)
+		if err != nil {
+			rowErrors = append(rowErrors, RowError{Row: line, Reference: instruction.Reference, Err: err})
+			continue
+		}
+
+		instructions = append(instructions, instruction)
+	}
+
+	return instructions, rowErrors, nil
+}
+
+func parseCSVRecord(instruction *Instruction, field func(name string) string) error {
+	var err error
+
+	instruction.FromAccountID, err = parseAccountID(field("from_account_id"))
+	if err != nil {
+		return fmt.Errorf("from_account_id: %w", err)
+	}
+
+	instruction.ToAccountID, err = parseAccountID(field("to_account_id"))
+	if err != nil {
+		return fmt.Errorf("to_account_id: %w", err)
+	}
+
+	if instruction.Currency == "" {
+		return errors.New("currency is missing")
+	}
+
+	instruction.Amount, err = parseAmount(field("amount"), instruction.Currency)
+	return err
+}
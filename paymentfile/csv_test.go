@@ -0,0 +1,81 @@
+package paymentfile
+
+import (
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	file := strings.Join([]string{
+		"Reference,From_Account_ID,To_Account_ID,Amount,Currency",
+		"salary-1,1,2,1250.50,usd",
+		"salary-2,1,3,10,USD",
+		"",
+		"salary-3,1,abc,10,USD",
+		"salary-4,1,4,10.505,USD",
+		"salary-5,1,5,0,USD",
+		"salary-6,1,6",
+		`"salary, 7",1,7,0.5,CAD`,
+	}, "\n")
+
+	instructions, rowErrors, err := ParseCSV(strings.NewReader(file))
+	require.NoError(t, err)
+
+	require.Equal(t, []Instruction{
+		{Row: 2, Reference: "salary-1", FromAccountID: 1, ToAccountID: 2, Amount: 125050, Currency: "USD"},
+		{Row: 3, Reference: "salary-2", FromAccountID: 1, ToAccountID: 3, Amount: 1000, Currency: "USD"},
+		{Row: 9, Reference: "salary, 7", FromAccountID: 1, ToAccountID: 7, Amount: 50, Currency: "CAD"},
+	}, instructions)
+
+	require.Len(t, rowErrors, 4)
+	require.Equal(t, []int{5, 6, 7, 8}, []int{rowErrors[0].Row, rowErrors[1].Row, rowErrors[2].Row, rowErrors[3].Row})
+	require.Equal(t, "salary-3", rowErrors[0].Reference)
+	require.ErrorContains(t, rowErrors[0], "to_account_id")
+	require.ErrorContains(t, rowErrors[1], "invalid amount")
+	require.ErrorContains(t, rowErrors[2], "must be positive")
+}
+
+func TestParseCSVInvalidFile(t *testing.T) {
+	testCases := []struct {
+		name string
+		file string
+	}{
+		{name: "Empty", file: ""},
+		{name: "Missing Column", file: "from_account_id,to_account_id,amount\n1,2,10\n"},
+		{name: "Malformed", file: "from_account_id,to_account_id,amount,currency\n1,2,\"10,USD\n"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := ParseCSV(strings.NewReader(tc.file))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	for s, expected := range map[string]int64{"12": 1200, "12.5": 1250, "12.50": 1250, "0.01": 1, " 7.00 ": 700} {
+		amount, err := parseAmount(s, util.USD)
+		require.NoError(t, err, s)
+		require.Equal(t, expected, amount, s)
+	}
+
+	for _, s := range []string{"", ".5", "12.", "1.234", "-1", "1e3", "0.00", "92233720368547758.08", "1,000"} {
+		_, err := parseAmount(s, util.USD)
+		require.Error(t, err, s)
+	}
+
+	// amounts have the decimals of their currency
+	amount, err := parseAmount("1500", "JPY")
+	require.NoError(t, err)
+	require.Equal(t, int64(1500), amount)
+
+	for _, currency := range []string{"JPY", "XYZ"} {
+		_, err = parseAmount("12.50", currency)
+		require.Error(t, err, currency)
+	}
+}
@@ -0,0 +1,174 @@
+package paymentfile
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"sort"
+)
+
+// Statuses of the rows of an import report
+const (
+	// RowRejected rows could not be read or failed validation, nothing was transferred
+	RowRejected = "rejected"
+	// RowValid rows passed validation in a dry run
+	RowValid = "valid"
+	// RowSubmitted rows were transferred
+	RowSubmitted = "submitted"
+	// RowFailed rows passed validation but their transfer failed
+	RowFailed = "failed"
+)
+
+// Options change how instructions are imported
+type Options struct {
+	// Owner, when set, is the user every source account must belong to
+	Owner string
+	// DryRun validates the instructions without transferring anything
+	DryRun bool
+}
+
+// RowResult is the outcome of a row of a payment file
+type RowResult struct {
+	Row        int    `json:"row"`
+	Reference  string `json:"reference"`
+	Status     string `json:"status"`
+	TransferID int64  `json:"transfer_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+
+	// Transfer is the result of submitted rows
+	Transfer *db.TransferTxResult `json:"-"`
+}
+
+// Report is the outcome of every row of a payment file, in the order of the file
+type Report struct {
+	Total     int         `json:"total"`
+	Valid     int         `json:"valid"`
+	Submitted int         `json:"submitted"`
+	Rejected  int         `json:"rejected"`
+	Failed    int         `json:"failed"`
+	Rows      []RowResult `json:"rows"`
+}
+
+func (report *Report) add(row RowResult) {
+	report.Total++
+	switch row.Status {
+	case RowValid:
+		report.Valid++
+	case RowSubmitted:
+		report.Submitted++
+	case RowRejected:
+		report.Rejected++
+	case RowFailed:
+		report.Failed++
+	}
+
+	report.Rows = append(report.Rows, row)
+}
+
+// Import validates instructions against the accounts of store and submits the valid ones with TransferTx one by one.
+// Rows that could not be parsed are reported as rejected along with the instructions. An instruction is valid when
+// its currency is supported, both accounts exist in that currency, they differ, and the owner matches Options.Owner
+func Import(ctx context.Context, store db.Store, instructions []Instruction, rowErrors []RowError, options Options) Report {
+	var report Report
+	rows := make([]RowResult, 0, len(instructions)+len(rowErrors))
+
+	for _, rowErr := range rowErrors {
+		rows = append(rows, RowResult{
+			Row:       rowErr.Row,
+			Reference: rowErr.Reference,
+			Status:    RowRejected,
+			Error:     rowErr.Err.Error(),
+		})
+	}
+
+	validator := &instructionValidator{store: store, owner: options.Owner, accounts: make(map[int64]db.Account)}
+
+	for _, instruction := range instructions {
+		row := RowResult{Row: instruction.Row, Reference: instruction.Reference}
+
+		err := validator.validate(ctx, instruction)
+		switch {
+		case err != nil:
+			row.Status = RowRejected
+			row.Error = err.Error()
+		case options.DryRun:
+			row.Status = RowValid
+		default:
+			result, err := store.TransferTx(ctx, db.TransferTxParams{
+				FromAccountID: instruction.FromAccountID,
+				ToAccountID:   instruction.ToAccountID,
+				Amount:        instruction.Amount,
+			})
+			if err != nil {
+				row.Status = RowFailed
+				row.Error = err.Error()
+				break
+			}
+
+			row.Status = RowSubmitted
+			row.TransferID = result.Transfer.ID
+			row.Transfer = &result
+		}
+
+		rows = append(rows, row)
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Row < rows[j].Row })
+	for _, row := range rows {
+		report.add(row)
+	}
+
+	return report
+}
+
+// instructionValidator checks instructions, looking up each account once
+type instructionValidator struct {
+	store    db.Store
+	owner    string
+	accounts map[int64]db.Account
+}
+
+func (validator *instructionValidator) validate(ctx context.Context, instruction Instruction) error {
+	if !util.IsSupportedCurrency(instruction.Currency) {
+		return fmt.Errorf("unsupported currency %s", instruction.Currency)
+	}
+
+	if instruction.FromAccountID == instruction.ToAccountID {
+		return fmt.Errorf("cannot transfer from account [%d] to itself", instruction.FromAccountID)
+	}
+
+	fromAccount, err := validator.account(ctx, instruction.FromAccountID, instruction.Currency)
+	if err != nil {
+		return err
+	}
+
+	if validator.owner != "" && fromAccount.Owner != validator.owner {
+		return fmt.Errorf("account [%d] does not belong to %s", fromAccount.ID, validator.owner)
+	}
+
+	_, err = validator.account(ctx, instruction.ToAccountID, instruction.Currency)
+	return err
+}
+
+func (validator *instructionValidator) account(ctx context.Context, id int64, currency string) (db.Account, error) {
+	account, ok := validator.accounts[id]
+	if !ok {
+		var err error
+		account, err = validator.store.GetAccount(ctx, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return account, fmt.Errorf("account [%d] not found", id)
+			}
+			return account, err
+		}
+		validator.accounts[id] = account
+	}
+
+	if account.Currency != currency {
+		return account, fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
+	}
+
+	return account, nil
+}
@@ -0,0 +1,116 @@
+package paymentfile
+
+import (
+	"context"
+	"errors"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func newTestAccount(t *testing.T, store db.Store, currency string, balance int64) db.Account {
+	ctx := context.Background()
+
+	user, err := store.CreateUser(ctx, db.CreateUserParams{
+		Username:       util.RandomOwner() + util.RandomString(6),
+		HashedPassword: util.RandomString(32),
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	account, err := store.CreateAccount(ctx, db.CreateAccountParams{Owner: user.Username, Balance: balance, Currency: currency, ProductCode: db.AccountProductChecking})
+	require.NoError(t, err)
+
+	return account
+}
+
+func rowStatuses(report Report) []string {
+	statuses := make([]string, len(report.Rows))
+	for i, row := range report.Rows {
+		statuses[i] = row.Status
+	}
+	return statuses
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+
+	source := newTestAccount(t, store, util.USD, 1000)
+	other := newTestAccount(t, store, util.USD, 1000)
+	accountUSD := newTestAccount(t, store, util.USD, 0)
+	accountCAD := newTestAccount(t, store, util.CAD, 0)
+
+	instructions := []Instruction{
+		{Row: 2, FromAccountID: source.ID, ToAccountID: accountUSD.ID, Amount: 100, Currency: util.USD},
+		{Row: 4, FromAccountID: source.ID, ToAccountID: accountCAD.ID, Amount: 100, Currency: util.USD},
+		{Row: 5, FromAccountID: source.ID, ToAccountID: 1 << 62, Amount: 100, Currency: util.USD},
+		{Row: 6, FromAccountID: source.ID, ToAccountID: accountUSD.ID, Amount: 100, Currency: "EUR"},
+		{Row: 7, FromAccountID: other.ID, ToAccountID: accountUSD.ID, Amount: 100, Currency: util.USD},
+		{Row: 8, FromAccountID: source.ID, ToAccountID: source.ID, Amount: 100, Currency: util.USD},
+		{Row: 9, Reference: "last", FromAccountID: source.ID, ToAccountID: accountUSD.ID, Amount: 50, Currency: util.USD},
+	}
+	rowErrors := []RowError{{Row: 3, Reference: "unreadable", Err: errors.New("invalid amount")}}
+
+	options := Options{Owner: source.Owner, DryRun: true}
+	report := Import(ctx, store, instructions, rowErrors, options)
+	require.Equal(t, []string{RowValid, RowRejected, RowRejected, RowRejected, RowRejected, RowRejected, RowRejected, RowValid}, rowStatuses(report))
+	require.Equal(t, 8, report.Total)
+	require.Equal(t, 2, report.Valid)
+	require.Equal(t, 6, report.Rejected)
+
+	// a dry run transfers nothing
+	account, err := store.GetAccount(ctx, accountUSD.ID)
+	require.NoError(t, err)
+	require.Zero(t, account.Balance)
+
+	options.DryRun = false
+	report = Import(ctx, store, instructions, rowErrors, options)
+	require.Equal(t, 2, report.Submitted)
+	require.Equal(t, 6, report.Rejected)
+
+	rows := report.Rows
+	require.Equal(t, "unreadable", rows[1].Reference)
+	require.Equal(t, "invalid amount", rows[1].Error)
+	require.Contains(t, rows[2].Error, "currency mismatch")
+	require.Contains(t, rows[3].Error, "not found")
+	require.Contains(t, rows[4].Error, "unsupported currency")
+	require.Contains(t, rows[5].Error, "does not belong")
+	require.Contains(t, rows[6].Error, "to itself")
+
+	require.Equal(t, "last", rows[7].Reference)
+	require.NotZero(t, rows[7].TransferID)
+	require.Equal(t, rows[7].TransferID, rows[7].Transfer.Transfer.ID)
+
+	account, err = store.GetAccount(ctx, accountUSD.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(150), account.Balance)
+
+	// without an owner any source account is accepted
+	report = Import(ctx, store, instructions[4:5], nil, Options{})
+	require.Equal(t, []string{RowSubmitted}, rowStatuses(report))
+}
+
+func TestImportInsufficientFunds(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+
+	source := newTestAccount(t, store, util.USD, 100)
+	destination := newTestAccount(t, store, util.USD, 0)
+
+	instructions := []Instruction{
+		{Row: 2, FromAccountID: source.ID, ToAccountID: destination.ID, Amount: 80, Currency: util.USD},
+		{Row: 3, FromAccountID: source.ID, ToAccountID: destination.ID, Amount: 30, Currency: util.USD},
+	}
+
+	// rows are checked against the balance when they are transferred
+	report := Import(ctx, store, instructions, nil, Options{Owner: source.Owner})
+	require.Equal(t, []string{RowSubmitted, RowFailed}, rowStatuses(report))
+	require.Contains(t, report.Rows[1].Error, db.ErrInsufficientFunds.Error())
+
+	account, err := store.GetAccount(ctx, source.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(20), account.Balance)
+}
@@ -0,0 +1,92 @@
+// Package paymentfile reads bulk payment files, such as the CSV and ISO 20022 pain.001 exports of
+// accounting software, and submits the transfers they instruct
+package paymentfile
+
+import (
+	"errors"
+	"fmt"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Formats of payment files
+const (
+	FormatCSV     = "csv"
+	FormatPain001 = "pain.001"
+)
+
+// ErrUnsupportedFormat is returned when a payment file is not in one of the supported formats
+var ErrUnsupportedFormat = errors.New("unsupported payment file format")
+
+// Instruction is a transfer read from a payment file
+type Instruction struct {
+	// Row locates the instruction in its file, the line of CSV files and the position of the transaction in pain.001 files
+	Row           int    `json:"row"`
+	Reference     string `json:"reference"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+}
+
+// RowError is an instruction of a payment file that cannot be read
+type RowError struct {
+	Row       int    `json:"row"`
+	Reference string `json:"reference"`
+	Err       error  `json:"-"`
+}
+
+func (err RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", err.Row, err.Err)
+}
+
+// Parse reads the instructions of a payment file in format. Rows that cannot be read are returned as row errors,
+// the error is only set when the file as a whole cannot be read
+func Parse(format string, r io.Reader) ([]Instruction, []RowError, error) {
+	switch format {
+	case FormatCSV:
+		return ParseCSV(r)
+	case FormatPain001:
+		return ParsePain001(r)
+	}
+
+	return nil, nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+}
+
+// DetectFormat returns the format of a payment file from its name
+func DetectFormat(name string) (string, error) {
+	switch {
+	case strings.HasSuffix(strings.ToLower(name), ".csv"):
+		return FormatCSV, nil
+	case strings.HasSuffix(strings.ToLower(name), ".xml"):
+		return FormatPain001, nil
+	}
+
+	return "", fmt.Errorf("%w: cannot detect the format of %s", ErrUnsupportedFormat, name)
+}
+
+// parseAmount converts a decimal amount of currency such as "12.50" to minor units
+func parseAmount(s string, currency string) (int64, error) {
+	money, err := util.ParseMoney(s, currency)
+	if err != nil {
+		return 0, err
+	}
+
+	if money.Amount <= 0 {
+		return 0, fmt.Errorf("amount %q must be positive", s)
+	}
+
+	return money.Amount, nil
+}
+
+// parseAccountID reads the id of an account of the bank
+func parseAccountID(s string) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid account id %q", s)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,116 @@
+package paymentfile
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// pain001Namespace prefixes the namespaces of every version of the pain.001 schema
+const pain001Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.001."
+
+type pain001Document struct {
+	XMLName    xml.Name `xml:"Document"`
+	Initiation struct {
+		PaymentInfos []pain001PaymentInfo `xml:"PmtInf"`
+	} `xml:"CstmrCdtTrfInitn"`
+}
+
+type pain001PaymentInfo struct {
+	ID            string                `xml:"PmtInfId"`
+	DebtorAccount pain001Account        `xml:"DbtrAcct"`
+	TransferInfos []pain001TransferInfo `xml:"CdtTrfTxInf"`
+}
+
+type pain001TransferInfo struct {
+	EndToEndID string `xml:"PmtId>EndToEndId"`
+	Amount     struct {
+		Value    string `xml:",chardata"`
+		Currency string `xml:"Ccy,attr"`
+	} `xml:"Amt>InstdAmt"`
+	CreditorAccount pain001Account `xml:"CdtrAcct"`
+}
+
+// pain001Account identifies an account of the bank by its id in Id/Othr/Id
+type pain001Account struct {
+	IBAN  string `xml:"Id>IBAN"`
+	Other string `xml:"Id>Othr>Id"`
+}
+
+func (account pain001Account) id() (int64, error) {
+	if account.Other == "" {
+		if account.IBAN != "" {
+			return 0, errors.New("IBAN accounts are not supported, use Othr/Id with the account id")
+		}
+		return 0, errors.New("account id is missing")
+	}
+
+	return parseAccountID(account.Other)
+}
+
+// ParsePain001 reads a customer credit transfer initiation (pain.001) of any version. Accounts are identified
+// by their id in Id/Othr/Id. Each CdtTrfTxInf is an instruction numbered from 1 in the order of the file,
+// its EndToEndId is the reference
+func ParsePain001(r io.Reader) ([]Instruction, []RowError, error) {
+	var document pain001Document
+
+	err := xml.NewDecoder(r).Decode(&document)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read pain.001 document: %w", err)
+	}
+
+	if !strings.HasPrefix(document.XMLName.Space, pain001Namespace) {
+		return nil, nil, fmt.Errorf("%w: namespace %q is not pain.001", ErrUnsupportedFormat, document.XMLName.Space)
+	}
+
+	var instructions []Instruction
+	var rowErrors []RowError
+	row := 0
+
+	for _, paymentInfo := range document.Initiation.PaymentInfos {
+		fromAccountID, fromErr := paymentInfo.DebtorAccount.id()
+
+		for _, transferInfo := range paymentInfo.TransferInfos {
+			row++
+
+			instruction := Instruction{
+				Row:           row,
+				Reference:     strings.TrimSpace(transferInfo.EndToEndID),
+				FromAccountID: fromAccountID,
+				Currency:      strings.ToUpper(strings.TrimSpace(transferInfo.Amount.Currency)),
+			}
+
+			err = parsePain001Transfer(&instruction, transferInfo, fromErr)
+			if err != nil {
+				rowErrors = append(rowErrors, RowError{Row: row, Reference: instruction.Reference, Err: err})
+				continue
+			}
+
+			instructions = append(instructions, instruction)
+		}
+	}
+
+	return instructions, rowErrors, nil
+}
+
+func parsePain001Transfer(instruction *Instruction, transferInfo pain001TransferInfo, fromErr error) error {
+	var err error
+
+	if fromErr != nil {
+		return fmt.Errorf("DbtrAcct: %w", fromErr)
+	}
+
+	instruction.ToAccountID, err = transferInfo.CreditorAccount.id()
+	if err != nil {
+		return fmt.Errorf("CdtrAcct: %w", err)
+	}
+
+	if instruction.Currency == "" {
+		return errors.New("InstdAmt has no Ccy")
+	}
+
+	instruction.Amount, err = parseAmount(transferInfo.Amount.Value, instruction.Currency)
+	return err
+}
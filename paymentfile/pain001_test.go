@@ -0,0 +1,99 @@
+package paymentfile
+
+import (
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+const testPain001 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03">
+  <CstmrCdtTrfInitn>
+    <GrpHdr>
+      <MsgId>PAYROLL-2026-10</MsgId>
+      <CreDtTm>2026-10-19T09:00:00</CreDtTm>
+      <NbOfTxs>4</NbOfTxs>
+      <InitgPty><Nm>Acme</Nm></InitgPty>
+    </GrpHdr>
+    <PmtInf>
+      <PmtInfId>PAYROLL-1</PmtInfId>
+      <PmtMtd>TRF</PmtMtd>
+      <DbtrAcct><Id><Othr><Id>1</Id></Othr></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-1</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="USD">1250.50</InstdAmt></Amt>
+        <CdtrAcct><Id><Othr><Id>2</Id></Othr></Id></CdtrAcct>
+      </CdtTrfTxInf>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-2</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="USD">10</InstdAmt></Amt>
+        <CdtrAcct><Id><IBAN>DE89370400440532013000</IBAN></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+    <PmtInf>
+      <PmtInfId>PAYROLL-2</PmtInfId>
+      <PmtMtd>TRF</PmtMtd>
+      <DbtrAcct><Id><IBAN>DE89370400440532013000</IBAN></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-3</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="USD">5</InstdAmt></Amt>
+        <CdtrAcct><Id><Othr><Id>3</Id></Othr></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+    <PmtInf>
+      <PmtInfId>PAYROLL-3</PmtInfId>
+      <PmtMtd>TRF</PmtMtd>
+      <DbtrAcct><Id><Othr><Id>4</Id></Othr></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-4</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="cad">0.99</InstdAmt></Amt>
+        <CdtrAcct><Id><Othr><Id>5</Id></Othr></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>`
+
+func TestParsePain001(t *testing.T) {
+	instructions, rowErrors, err := ParsePain001(strings.NewReader(testPain001))
+	require.NoError(t, err)
+
+	require.Equal(t, []Instruction{
+		{Row: 1, Reference: "E2E-1", FromAccountID: 1, ToAccountID: 2, Amount: 125050, Currency: "USD"},
+		{Row: 4, Reference: "E2E-4", FromAccountID: 4, ToAccountID: 5, Amount: 99, Currency: "CAD"},
+	}, instructions)
+
+	require.Len(t, rowErrors, 2)
+	require.Equal(t, 2, rowErrors[0].Row)
+	require.Equal(t, "E2E-2", rowErrors[0].Reference)
+	require.ErrorContains(t, rowErrors[0], "CdtrAcct: IBAN")
+	require.Equal(t, 3, rowErrors[1].Row)
+	require.ErrorContains(t, rowErrors[1], "DbtrAcct: IBAN")
+}
+
+func TestParsePain001InvalidDocument(t *testing.T) {
+	_, _, err := ParsePain001(strings.NewReader(`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"></Document>`))
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	_, _, err = ParsePain001(strings.NewReader(`<Document`))
+	require.Error(t, err)
+}
+
+func TestParseFormat(t *testing.T) {
+	instructions, _, err := Parse(FormatPain001, strings.NewReader(testPain001))
+	require.NoError(t, err)
+	require.Len(t, instructions, 2)
+
+	_, _, err = Parse("mt101", strings.NewReader(""))
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	format, err := DetectFormat("payroll.CSV")
+	require.NoError(t, err)
+	require.Equal(t, FormatCSV, format)
+
+	format, err = DetectFormat("payroll.xml")
+	require.NoError(t, err)
+	require.Equal(t, FormatPain001, format)
+
+	_, err = DetectFormat("payroll.txt")
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+}
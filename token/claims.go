@@ -0,0 +1,75 @@
+package token
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrorInvalidIssuer    = errors.New("token has an invalid issuer")
+	ErrorInvalidAudience  = errors.New("token has an invalid audience")
+	ErrorTokenNotYetValid = errors.New("token is not valid yet")
+)
+
+// Option configures the registered claims a Maker issues tokens with
+type Option func(claims *registeredClaims)
+
+// WithIssuer sets the issuer of new tokens, only tokens from this issuer are accepted
+func WithIssuer(issuer string) Option {
+	return func(claims *registeredClaims) {
+		claims.issuer = issuer
+	}
+}
+
+// WithAudience sets the audience of new tokens, only tokens for this audience are accepted
+func WithAudience(audience string) Option {
+	return func(claims *registeredClaims) {
+		claims.audience = audience
+	}
+}
+
+// registeredClaims are the issuer and audience shared by every token of a maker, an empty
+// value is neither set nor checked
+type registeredClaims struct {
+	issuer   string
+	audience string
+}
+
+func newRegisteredClaims(options []Option) registeredClaims {
+	var claims registeredClaims
+	for _, option := range options {
+		option(&claims)
+	}
+
+	return claims
+}
+
+// newPayload creates a payload carrying the registered claims
+func (claims registeredClaims) newPayload(tokenType string, username string, duration time.Duration, scopes []string) (*Payload, error) {
+	payload, err := newPayload(tokenType, username, duration, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	payload.Issuer = claims.issuer
+	payload.Audience = claims.audience
+	return payload, nil
+}
+
+// verify checks the validity period and registered claims of a payload whose signature is valid
+func (claims registeredClaims) verify(payload *Payload) error {
+	err := payload.Valid()
+	if err != nil {
+		return err
+	}
+
+	if claims.issuer != "" && payload.Issuer != claims.issuer {
+		return ErrorInvalidIssuer
+	}
+
+	if claims.audience != "" && payload.Audience != claims.audience {
+		return ErrorInvalidAudience
+	}
+
+	return nil
+}
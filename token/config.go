@@ -0,0 +1,182 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"golang.org/x/crypto/chacha20poly1305"
+	"os"
+	"strings"
+)
+
+const (
+	// MakerPaseto issues paseto tokens, v2.local with a symmetric key or v4.public with an ed25519 key
+	MakerPaseto = "paseto"
+	// MakerJwt issues jwt tokens, HS256 with a symmetric key or RS256/EdDSA with a private key
+	MakerJwt = "jwt"
+
+	// KeyTypeSymmetric signs and verifies tokens with one secret key
+	KeyTypeSymmetric = "symmetric"
+	// KeyTypeAsymmetric signs tokens with a private key and verifies them with public keys
+	KeyTypeAsymmetric = "asymmetric"
+)
+
+// NewMaker creates the token maker selected by the config and loads its keys,
+// it fails when a key is missing, unreadable or too weak
+func NewMaker(config util.Config) (Maker, error) {
+	if config.TokenMaker != "" && config.TokenMaker != MakerPaseto && config.TokenMaker != MakerJwt {
+		return nil, fmt.Errorf("unsupported token maker: %s", config.TokenMaker)
+	}
+
+	options := []Option{WithIssuer(config.TokenIssuer), WithAudience(config.TokenAudience)}
+
+	switch config.TokenKeyType {
+	case "", KeyTypeSymmetric:
+		return newSymmetricMaker(config, options)
+	case KeyTypeAsymmetric:
+		return newAsymmetricMaker(config, options)
+	default:
+		return nil, fmt.Errorf("unsupported token key type: %s", config.TokenKeyType)
+	}
+}
+
+func newSymmetricMaker(config util.Config, options []Option) (Maker, error) {
+	key, err := util.LoadKey("TOKEN_SYMMETRIC_KEY", config.TokenSymmetricKeyFile, config.TokenSymmetricKeyBase64)
+	if err != nil {
+		return nil, err
+	}
+
+	if bits := util.KeyEntropyBits(key); bits < util.MinKeyEntropyBits {
+		return nil, fmt.Errorf("token symmetric key is too weak: about %d bits of entropy, at least %d required", int(bits), util.MinKeyEntropyBits)
+	}
+
+	if config.TokenMaker == MakerJwt {
+		return NewJwtMaker(string(key), options...)
+	}
+
+	if len(key) != chacha20poly1305.KeySize {
+		return nil, fmt.Errorf("paseto symmetric key must be exactly %d bytes, got %d", chacha20poly1305.KeySize, len(key))
+	}
+
+	return NewPasetoMaker(string(key), options...)
+}
+
+func newAsymmetricMaker(config util.Config, options []Option) (Maker, error) {
+	keyData, err := util.LoadKey("TOKEN_PRIVATE_KEY", config.TokenPrivateKeyFile, config.TokenPrivateKeyBase64)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := parsePrivateKey(keyData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token private key: %w", err)
+	}
+
+	keyID := config.TokenKeyID
+	if keyID == "" {
+		keyID, err = KeyID(privateKey.Public())
+		if err != nil {
+			return nil, fmt.Errorf("invalid token private key: %w", err)
+		}
+	}
+
+	keySet, err := NewKeySet(keyID, privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token private key: %w", err)
+	}
+
+	err = addVerificationKeys(keySet, config.TokenVerificationKeyFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.TokenMaker == MakerJwt {
+		return NewJwtKeySetMaker(keySet, options...)
+	}
+
+	return NewPasetoPublicMaker(keySet, options...)
+}
+
+// addVerificationKeys trusts the public keys of a comma separated list of KEY_ID=PATH pairs,
+// so tokens signed by previous private keys stay valid during a rotation
+func addVerificationKeys(keySet *KeySet, files string) error {
+	for _, pair := range strings.Split(files, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		keyID, path, ok := strings.Cut(pair, "=")
+		if !ok || keyID == "" || path == "" {
+			return fmt.Errorf("invalid TOKEN_VERIFICATION_KEY_FILES entry %q: want KEY_ID=PATH", pair)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("cannot read verification key %q: %w", keyID, err)
+		}
+
+		publicKey, err := parsePublicKey(data)
+		if err != nil {
+			return fmt.Errorf("invalid verification key %q: %w", keyID, err)
+		}
+
+		err = keySet.AddVerificationKey(keyID, publicKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parsePrivateKey parses a PKCS #8 or PKCS #1 private key, PEM or DER encoded
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(data)
+	if err != nil {
+		rsaKey, rsaErr := x509.ParsePKCS1PrivateKey(data)
+		if rsaErr != nil {
+			return nil, fmt.Errorf("want a PKCS #8 or PKCS #1 key: %w", err)
+		}
+		return rsaKey, nil
+	}
+
+	switch key := key.(type) {
+	case ed25519.PrivateKey:
+		return key, nil
+	case *rsa.PrivateKey:
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %T", key)
+}
+
+// parsePublicKey parses a PKIX public key, PEM or DER encoded
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+
+	return x509.ParsePKIXPublicKey(data)
+}
+
+// KeyID derives a stable key id from a public key, the first 96 bits of the sha256 of its PKIX encoding
+func KeyID(publicKey crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:12]), nil
+}
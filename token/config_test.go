@@ -0,0 +1,302 @@
+package token
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func randomKey(t *testing.T, size int) []byte {
+	key := make([]byte, size)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return key
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return path
+}
+
+func privateKeyPEM(t *testing.T, privateKey crypto.Signer) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func publicKeyPEM(t *testing.T, publicKey crypto.PublicKey) []byte {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestNewMaker(t *testing.T) {
+	ed25519Key := newEd25519Key(t)
+	rsaKey := newRSAKey(t)
+
+	testCases := []struct {
+		name       string
+		config     func(t *testing.T) util.Config
+		checkMaker func(t *testing.T, maker Maker, err error)
+	}{
+		{
+			name: "Paseto Symmetric Base64",
+			config: func(t *testing.T) util.Config {
+				return util.Config{
+					TokenMaker:              MakerPaseto,
+					TokenSymmetricKeyBase64: base64.StdEncoding.EncodeToString(randomKey(t, 32)),
+				}
+			},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &PasetoMaker{}, maker)
+			},
+		},
+		{
+			name: "Default Is Paseto Symmetric",
+			config: func(t *testing.T) util.Config {
+				return util.Config{TokenSymmetricKeyFile: writeFile(t, "token.key", randomKey(t, 32))}
+			},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &PasetoMaker{}, maker)
+			},
+		},
+		{
+			name: "Jwt Symmetric File",
+			config: func(t *testing.T) util.Config {
+				return util.Config{
+					TokenMaker:            MakerJwt,
+					TokenKeyType:          KeyTypeSymmetric,
+					TokenSymmetricKeyFile: writeFile(t, "token.key", randomKey(t, 64)),
+				}
+			},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &JwtMaker{}, maker)
+			},
+		},
+		{
+			name: "Paseto Asymmetric File",
+			config: func(t *testing.T) util.Config {
+				return util.Config{
+					TokenMaker:          MakerPaseto,
+					TokenKeyType:        KeyTypeAsymmetric,
+					TokenPrivateKeyFile: writeFile(t, "token.pem", privateKeyPEM(t, ed25519Key)),
+				}
+			},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &PasetoPublicMaker{}, maker)
+
+				keyID, err := KeyID(ed25519Key.Public())
+				require.NoError(t, err)
+				require.Equal(t, keyID, maker.(KeySetMaker).KeySet().JWKS().Keys[0].KeyID)
+			},
+		},
+		{
+			name: "Jwt Asymmetric Base64",
+			config: func(t *testing.T) util.Config {
+				return util.Config{
+					TokenMaker:            MakerJwt,
+					TokenKeyType:          KeyTypeAsymmetric,
+					TokenPrivateKeyBase64: base64.StdEncoding.EncodeToString(privateKeyPEM(t, rsaKey)),
+					TokenKeyID:            "rsa-1",
+				}
+			},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &JwtKeySetMaker{}, maker)
+				require.Equal(t, "rsa-1", maker.(KeySetMaker).KeySet().JWKS().Keys[0].KeyID)
+			},
+		},
+		{
+			name: "Unsupported Maker",
+			config: func(t *testing.T) util.Config {
+				return util.Config{
+					TokenMaker:              "macaroon",
+					TokenSymmetricKeyBase64: base64.StdEncoding.EncodeToString(randomKey(t, 32)),
+				}
+			},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.EqualError(t, err, "unsupported token maker: macaroon")
+			},
+		},
+		{
+			name: "Unsupported Key Type",
+			config: func(t *testing.T) util.Config {
+				return util.Config{TokenKeyType: "quantum"}
+			},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.EqualError(t, err, "unsupported token key type: quantum")
+			},
+		},
+		{
+			name: "Missing Key",
+			config: func(t *testing.T) util.Config {
+				return util.Config{TokenMaker: MakerPaseto}
+			},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.EqualError(t, err, "TOKEN_SYMMETRIC_KEY_FILE or TOKEN_SYMMETRIC_KEY_BASE64 is required")
+			},
+		},
+		{
+			name: "Both Key Sources",
+			config: func(t *testing.T) util.Config {
+				return util.Config{
+					TokenSymmetricKeyFile:   writeFile(t, "token.key", randomKey(t, 32)),
+					TokenSymmetricKeyBase64: base64.StdEncoding.EncodeToString(randomKey(t, 32)),
+				}
+			},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.EqualError(t, err, "set only one of TOKEN_SYMMETRIC_KEY_FILE and TOKEN_SYMMETRIC_KEY_BASE64")
+			},
+		},
+		{
+			name: "Missing Key File",
+			config: func(t *testing.T) util.Config {
+				return util.Config{TokenSymmetricKeyFile: filepath.Join(t.TempDir(), "missing.key")}
+			},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.ErrorIs(t, err, os.ErrNotExist)
+				require.Contains(t, err.Error(), "cannot read TOKEN_SYMMETRIC_KEY_FILE")
+			},
+		},
+		{
+			name: "Invalid Base64",
+			config: func(t *testing.T) util.Config {
+				return util.Config{TokenSymmetricKeyBase64: "not base64!"}
+			},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "TOKEN_SYMMETRIC_KEY_BASE64 is not valid base64")
+			},
+		},
+		{
+			name: "Weak Key",
+			config: func(t *testing.T) util.Config {
+				return util.Config{TokenSymmetricKeyFile: writeFile(t, "token.key", []byte("12345678901234567890123456789012"))}
+			},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "token symmetric key is too weak")
+			},
+		},
+		{
+			name: "Short Jwt Key",
+			config: func(t *testing.T) util.Config {
+				return util.Config{
+					TokenMaker:              MakerJwt,
+					TokenSymmetricKeyBase64: base64.StdEncoding.EncodeToString(randomKey(t, 16)),
+				}
+			},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "too weak")
+			},
+		},
+		{
+			name: "Paseto Key Of Wrong Size",
+			config: func(t *testing.T) util.Config {
+				return util.Config{TokenSymmetricKeyBase64: base64.StdEncoding.EncodeToString(randomKey(t, 48))}
+			},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.EqualError(t, err, "paseto symmetric key must be exactly 32 bytes, got 48")
+			},
+		},
+		{
+			name: "Paseto Asymmetric With RSA Key",
+			config: func(t *testing.T) util.Config {
+				return util.Config{
+					TokenKeyType:          KeyTypeAsymmetric,
+					TokenPrivateKeyBase64: base64.StdEncoding.EncodeToString(privateKeyPEM(t, rsaKey)),
+				}
+			},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "requires an ed25519 signing key")
+			},
+		},
+		{
+			name: "Invalid Private Key",
+			config: func(t *testing.T) util.Config {
+				return util.Config{
+					TokenKeyType:        KeyTypeAsymmetric,
+					TokenPrivateKeyFile: writeFile(t, "token.pem", publicKeyPEM(t, ed25519Key.Public())),
+				}
+			},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "invalid token private key")
+			},
+		},
+		{
+			name: "Invalid Verification Key Entry",
+			config: func(t *testing.T) util.Config {
+				return util.Config{
+					TokenKeyType:              KeyTypeAsymmetric,
+					TokenPrivateKeyFile:       writeFile(t, "token.pem", privateKeyPEM(t, ed25519Key)),
+					TokenVerificationKeyFiles: "old-key",
+				}
+			},
+			checkMaker: func(t *testing.T, maker Maker, err error) {
+				require.EqualError(t, err, `invalid TOKEN_VERIFICATION_KEY_FILES entry "old-key": want KEY_ID=PATH`)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			maker, err := NewMaker(tc.config(t))
+			tc.checkMaker(t, maker, err)
+		})
+	}
+}
+
+func TestNewMakerKeyRotation(t *testing.T) {
+	oldKey := newEd25519Key(t)
+	newKey := newEd25519Key(t)
+
+	oldMaker, err := NewMaker(util.Config{
+		TokenKeyType:          KeyTypeAsymmetric,
+		TokenPrivateKeyBase64: base64.StdEncoding.EncodeToString(privateKeyPEM(t, oldKey)),
+		TokenKeyID:            "2022-01",
+		TokenIssuer:           "simple_bank",
+	})
+	require.NoError(t, err)
+
+	oldToken, err := oldMaker.CreateToken(util.RandomOwner(), time.Minute)
+	require.NoError(t, err)
+
+	newMaker, err := NewMaker(util.Config{
+		TokenKeyType:              KeyTypeAsymmetric,
+		TokenPrivateKeyFile:       writeFile(t, "token.pem", privateKeyPEM(t, newKey)),
+		TokenKeyID:                "2022-02",
+		TokenVerificationKeyFiles: "2022-01=" + writeFile(t, "old.pem", publicKeyPEM(t, oldKey.Public())),
+		TokenIssuer:               "simple_bank",
+	})
+	require.NoError(t, err)
+
+	payload, err := newMaker.VerifyToken(oldToken)
+	require.NoError(t, err)
+	require.Equal(t, "simple_bank", payload.Issuer)
+
+	newToken, err := newMaker.CreateToken(util.RandomOwner(), time.Minute)
+	require.NoError(t, err)
+
+	footer, err := base64.RawURLEncoding.DecodeString(newToken[strings.LastIndex(newToken, ".")+1:])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"kid":"2022-02"}`, string(footer))
+}
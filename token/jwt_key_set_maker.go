@@ -0,0 +1,105 @@
+package token
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt"
+	"time"
+)
+
+// JwtKeySetMaker is a jsonwebtoken maker signing tokens with the RS256 or EdDSA keys of a key set
+type JwtKeySetMaker struct {
+	keySet *KeySet
+	claims registeredClaims
+}
+
+func NewJwtKeySetMaker(keySet *KeySet, options ...Option) (Maker, error) {
+	if keySet == nil {
+		return nil, errors.New("key set is required")
+	}
+
+	return &JwtKeySetMaker{keySet: keySet, claims: newRegisteredClaims(options)}, nil
+}
+
+// KeySet returns the keys tokens are signed and verified with
+func (maker *JwtKeySetMaker) KeySet() *KeySet {
+	return maker.keySet
+}
+
+// CreateToken generates a new token for a specific username, duration and scopes
+func (maker *JwtKeySetMaker) CreateToken(username string, duration time.Duration, scopes ...string) (string, error) {
+	payload, err := maker.claims.newPayload(TypeAccess, username, duration, scopes)
+	if err != nil {
+		return "", err
+	}
+
+	return maker.signPayload(payload)
+}
+
+// CreateChallengeToken generates a login challenge token for a specific username and duration
+func (maker *JwtKeySetMaker) CreateChallengeToken(username string, duration time.Duration) (string, error) {
+	payload, err := maker.claims.newPayload(TypeLoginChallenge, username, duration, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return maker.signPayload(payload)
+}
+
+func (maker *JwtKeySetMaker) signPayload(payload *Payload) (string, error) {
+	keyID, signingKey := maker.keySet.signer()
+
+	algorithm, err := keyAlgorithm(signingKey.Public())
+	if err != nil {
+		return "", err
+	}
+
+	jwtToken := jwt.NewWithClaims(jwt.GetSigningMethod(algorithm), payload)
+	jwtToken.Header["kid"] = keyID
+
+	return jwtToken.SignedString(signingKey)
+}
+
+// VerifyToken checks for token validity, the token must be signed by a key of the key set
+// with the algorithm of that key
+func (maker *JwtKeySetMaker) VerifyToken(token string) (*Payload, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		keyID, ok := token.Header["kid"].(string)
+		if !ok {
+			return nil, ErrorInvalidToken
+		}
+
+		publicKey, err := maker.keySet.publicKey(keyID)
+		if err != nil {
+			return nil, err
+		}
+
+		algorithm, err := keyAlgorithm(publicKey)
+		if err != nil || token.Method.Alg() != algorithm {
+			return nil, ErrorInvalidToken
+		}
+
+		return publicKey, nil
+	}
+
+	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
+	if err != nil {
+		verr, ok := err.(*jwt.ValidationError)
+		if ok && (errors.Is(verr.Inner, ErrorExpiredToken) || errors.Is(verr.Inner, ErrorTokenNotYetValid)) {
+			return nil, verr.Inner
+		}
+
+		return nil, ErrorInvalidToken
+	}
+
+	payload, ok := jwtToken.Claims.(*Payload)
+	if !ok {
+		return nil, ErrorInvalidToken
+	}
+
+	err = maker.claims.verify(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
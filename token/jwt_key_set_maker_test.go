@@ -0,0 +1,139 @@
+package token
+
+import (
+	"crypto"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestJwtKeySetMaker(t *testing.T) {
+	testCases := []struct {
+		name       string
+		signingKey func(t *testing.T) crypto.Signer
+		algorithm  string
+	}{
+		{
+			name:       "EdDSA",
+			signingKey: func(t *testing.T) crypto.Signer { return newEd25519Key(t) },
+			algorithm:  AlgorithmEdDSA,
+		},
+		{
+			name:       "RS256",
+			signingKey: func(t *testing.T) crypto.Signer { return newRSAKey(t) },
+			algorithm:  AlgorithmRS256,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			keySet, err := NewKeySet("key-1", tc.signingKey(t))
+			require.NoError(t, err)
+
+			maker, err := NewJwtKeySetMaker(keySet)
+			require.NoError(t, err)
+
+			username := util.RandomOwner()
+			issuedAt := time.Now()
+
+			token, err := maker.CreateToken(username, time.Minute)
+			require.NoError(t, err)
+
+			parsed, _, err := new(jwt.Parser).ParseUnverified(token, &Payload{})
+			require.NoError(t, err)
+			require.Equal(t, tc.algorithm, parsed.Method.Alg())
+			require.Equal(t, "key-1", parsed.Header["kid"])
+
+			payload, err := maker.VerifyToken(token)
+			require.NoError(t, err)
+			require.Equal(t, TypeAccess, payload.Type)
+			require.Equal(t, username, payload.Username)
+			require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+			require.WithinDuration(t, issuedAt.Add(time.Minute), payload.ExpiredAt, time.Second)
+
+			challengeToken, err := maker.CreateChallengeToken(username, time.Minute)
+			require.NoError(t, err)
+
+			payload, err = maker.VerifyToken(challengeToken)
+			require.NoError(t, err)
+			require.Equal(t, TypeLoginChallenge, payload.Type)
+		})
+	}
+}
+
+func TestJwtKeySetMakerRotation(t *testing.T) {
+	keySet, err := NewKeySet("key-1", newEd25519Key(t))
+	require.NoError(t, err)
+
+	maker, err := NewJwtKeySetMaker(keySet)
+	require.NoError(t, err)
+
+	oldToken, err := maker.CreateToken(util.RandomOwner(), time.Minute)
+	require.NoError(t, err)
+
+	err = keySet.Rotate("key-2", newRSAKey(t))
+	require.NoError(t, err)
+
+	newToken, err := maker.CreateToken(util.RandomOwner(), time.Minute)
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(oldToken)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(newToken)
+	require.NoError(t, err)
+
+	err = keySet.RemoveKey("key-1")
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(oldToken)
+	require.EqualError(t, err, ErrorInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestJwtKeySetMakerInvalidToken(t *testing.T) {
+	keySet, err := NewKeySet("key-1", newEd25519Key(t))
+	require.NoError(t, err)
+
+	maker, err := NewJwtKeySetMaker(keySet)
+	require.NoError(t, err)
+
+	// a token signed by a key outside the set
+	otherKeySet, err := NewKeySet("key-1", newEd25519Key(t))
+	require.NoError(t, err)
+
+	otherMaker, err := NewJwtKeySetMaker(otherKeySet)
+	require.NoError(t, err)
+
+	forgedToken, err := otherMaker.CreateToken(util.RandomOwner(), time.Minute)
+	require.NoError(t, err)
+
+	// an hmac token using the public key as secret must not be accepted
+	payload, err := NewPayload(util.RandomOwner(), time.Minute)
+	require.NoError(t, err)
+
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+	hmacToken.Header["kid"] = "key-1"
+	confusedToken, err := hmacToken.SignedString([]byte(keySet.JWKS().Keys[0].X))
+	require.NoError(t, err)
+
+	validToken, err := maker.CreateToken(util.RandomOwner(), time.Minute)
+	require.NoError(t, err)
+	tamperedToken := validToken[:len(validToken)-4] + "AAAA"
+
+	expiredToken, err := maker.CreateToken(util.RandomOwner(), -time.Minute)
+	require.NoError(t, err)
+
+	for _, token := range []string{forgedToken, confusedToken, tamperedToken} {
+		payload, err = maker.VerifyToken(token)
+		require.EqualError(t, err, ErrorInvalidToken.Error())
+		require.Nil(t, payload)
+	}
+
+	payload, err = maker.VerifyToken(expiredToken)
+	require.EqualError(t, err, ErrorExpiredToken.Error())
+	require.Nil(t, payload)
+}
@@ -12,23 +12,38 @@ const minSecretKeySize = 32
 // JwtMaker is a jsonwebtoken maker
 type JwtMaker struct {
 	secretKey string
+	claims    registeredClaims
 }
 
-func NewJwtMaker(secretKey string) (Maker, error) {
+func NewJwtMaker(secretKey string, options ...Option) (Maker, error) {
 	if len(secretKey) < minSecretKeySize {
 		return nil, fmt.Errorf("invalid key size: must be at least %d characters", minSecretKeySize)
 	}
 
-	return &JwtMaker{secretKey: secretKey}, nil
+	return &JwtMaker{secretKey: secretKey, claims: newRegisteredClaims(options)}, nil
 }
 
-// CreateToken generates a new token for a specific username and duration
-func (maker *JwtMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(username, duration)
+// CreateToken generates a new token for a specific username, duration and scopes
+func (maker *JwtMaker) CreateToken(username string, duration time.Duration, scopes ...string) (string, error) {
+	payload, err := maker.claims.newPayload(TypeAccess, username, duration, scopes)
 	if err != nil {
 		return "", err
 	}
 
+	return maker.signPayload(payload)
+}
+
+// CreateChallengeToken generates a login challenge token for a specific username and duration
+func (maker *JwtMaker) CreateChallengeToken(username string, duration time.Duration) (string, error) {
+	payload, err := maker.claims.newPayload(TypeLoginChallenge, username, duration, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return maker.signPayload(payload)
+}
+
+func (maker *JwtMaker) signPayload(payload *Payload) (string, error) {
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
 
 	return jwtToken.SignedString([]byte(maker.secretKey))
@@ -48,8 +63,8 @@ func (maker *JwtMaker) VerifyToken(token string) (*Payload, error) {
 	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
 	if err != nil {
 		verr, ok := err.(*jwt.ValidationError)
-		if ok && errors.Is(verr.Inner, ErrorExpiredToken) {
-			return nil, ErrorExpiredToken
+		if ok && (errors.Is(verr.Inner, ErrorExpiredToken) || errors.Is(verr.Inner, ErrorTokenNotYetValid)) {
+			return nil, verr.Inner
 		}
 
 		return nil, ErrorInvalidToken
@@ -60,5 +75,10 @@ func (maker *JwtMaker) VerifyToken(token string) (*Payload, error) {
 		return nil, ErrorInvalidToken
 	}
 
+	err = maker.claims.verify(payload)
+	if err != nil {
+		return nil, err
+	}
+
 	return payload, nil
 }
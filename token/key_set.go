@@ -0,0 +1,203 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+// minRSAKeyBits is the smallest RSA modulus accepted for signing or verifying tokens
+const minRSAKeyBits = 2048
+
+const (
+	// AlgorithmEdDSA signs tokens with Ed25519 keys
+	AlgorithmEdDSA = "EdDSA"
+	// AlgorithmRS256 signs tokens with RSA PKCS #1 v1.5 and SHA-256
+	AlgorithmRS256 = "RS256"
+)
+
+var ErrorUnknownKey = errors.New("token is signed with an unknown key")
+
+// KeySet holds the private key new tokens are signed with and the public keys tokens are verified with.
+// Every key is identified by a key id carried in the token, so a new signing key can be rotated in
+// while tokens signed by the previous ones stay valid until they expire
+type KeySet struct {
+	mu           sync.RWMutex
+	signingKeyID string
+	signingKey   crypto.Signer
+	publicKeys   map[string]crypto.PublicKey
+}
+
+// NewKeySet creates a key set signing with an Ed25519 or RSA private key
+func NewKeySet(signingKeyID string, signingKey crypto.Signer) (*KeySet, error) {
+	keySet := &KeySet{publicKeys: make(map[string]crypto.PublicKey)}
+
+	err := keySet.Rotate(signingKeyID, signingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return keySet, nil
+}
+
+// Rotate makes a new private key the signing key. The public key of the previous signing key is kept
+// for verification, remove it with RemoveKey once the tokens it signed have expired
+func (keySet *KeySet) Rotate(signingKeyID string, signingKey crypto.Signer) error {
+	if signingKey == nil {
+		return errors.New("signing key is required")
+	}
+
+	publicKey := signingKey.Public()
+
+	keySet.mu.Lock()
+	defer keySet.mu.Unlock()
+
+	err := keySet.addPublicKey(signingKeyID, publicKey)
+	if err != nil {
+		return err
+	}
+
+	keySet.signingKeyID = signingKeyID
+	keySet.signingKey = signingKey
+	return nil
+}
+
+// AddVerificationKey trusts tokens signed by the private key of publicKey, for example a key
+// another instance has already rotated to
+func (keySet *KeySet) AddVerificationKey(keyID string, publicKey crypto.PublicKey) error {
+	keySet.mu.Lock()
+	defer keySet.mu.Unlock()
+
+	return keySet.addPublicKey(keyID, publicKey)
+}
+
+// RemoveKey stops trusting tokens signed with a key, the current signing key cannot be removed
+func (keySet *KeySet) RemoveKey(keyID string) error {
+	keySet.mu.Lock()
+	defer keySet.mu.Unlock()
+
+	if keyID == keySet.signingKeyID {
+		return fmt.Errorf("cannot remove signing key %q", keyID)
+	}
+
+	delete(keySet.publicKeys, keyID)
+	return nil
+}
+
+func (keySet *KeySet) addPublicKey(keyID string, publicKey crypto.PublicKey) error {
+	if keyID == "" {
+		return errors.New("key id is required")
+	}
+
+	if _, err := keyAlgorithm(publicKey); err != nil {
+		return fmt.Errorf("invalid key %q: %w", keyID, err)
+	}
+
+	existing, ok := keySet.publicKeys[keyID]
+	if ok && !publicKeysEqual(existing, publicKey) {
+		return fmt.Errorf("key id %q is already used by another key", keyID)
+	}
+
+	keySet.publicKeys[keyID] = publicKey
+	return nil
+}
+
+// signer returns the id and private key new tokens are signed with
+func (keySet *KeySet) signer() (string, crypto.Signer) {
+	keySet.mu.RLock()
+	defer keySet.mu.RUnlock()
+
+	return keySet.signingKeyID, keySet.signingKey
+}
+
+// publicKey returns the public key of a key id
+func (keySet *KeySet) publicKey(keyID string) (crypto.PublicKey, error) {
+	keySet.mu.RLock()
+	defer keySet.mu.RUnlock()
+
+	publicKey, ok := keySet.publicKeys[keyID]
+	if !ok {
+		return nil, ErrorUnknownKey
+	}
+
+	return publicKey, nil
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set, it lets other services verify tokens without holding any secret
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns every verification key of the set, sorted by key id
+func (keySet *KeySet) JWKS() JWKS {
+	keySet.mu.RLock()
+	defer keySet.mu.RUnlock()
+
+	jwks := JWKS{Keys: make([]JWK, 0, len(keySet.publicKeys))}
+	for keyID, publicKey := range keySet.publicKeys {
+		jwks.Keys = append(jwks.Keys, newJWK(keyID, publicKey))
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].KeyID < jwks.Keys[j].KeyID })
+	return jwks
+}
+
+func newJWK(keyID string, publicKey crypto.PublicKey) JWK {
+	jwk := JWK{KeyID: keyID, Use: "sig"}
+
+	switch key := publicKey.(type) {
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Algorithm = AlgorithmEdDSA
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(key)
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.Algorithm = AlgorithmRS256
+		jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	}
+
+	return jwk
+}
+
+// keyAlgorithm returns the signing algorithm of a supported public key
+func keyAlgorithm(publicKey crypto.PublicKey) (string, error) {
+	switch key := publicKey.(type) {
+	case ed25519.PublicKey:
+		if len(key) != ed25519.PublicKeySize {
+			return "", errors.New("invalid ed25519 key size")
+		}
+		return AlgorithmEdDSA, nil
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minRSAKeyBits {
+			return "", fmt.Errorf("rsa key must be at least %d bits", minRSAKeyBits)
+		}
+		return AlgorithmRS256, nil
+	}
+
+	return "", fmt.Errorf("unsupported key type %T", publicKey)
+}
+
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
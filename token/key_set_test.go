@@ -0,0 +1,78 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return privateKey
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	privateKey, err := rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+	require.NoError(t, err)
+	return privateKey
+}
+
+func TestKeySet(t *testing.T) {
+	keyOne := newEd25519Key(t)
+	keyTwo := newEd25519Key(t)
+
+	keySet, err := NewKeySet("key-1", keyOne)
+	require.NoError(t, err)
+
+	err = keySet.Rotate("key-2", keyTwo)
+	require.NoError(t, err)
+
+	keyID, signingKey := keySet.signer()
+	require.Equal(t, "key-2", keyID)
+	require.Equal(t, keyTwo, signingKey)
+
+	// the previous signing key still verifies
+	publicKey, err := keySet.publicKey("key-1")
+	require.NoError(t, err)
+	require.Equal(t, keyOne.Public(), publicKey)
+
+	jwks := keySet.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	require.Equal(t, "key-1", jwks.Keys[0].KeyID)
+	require.Equal(t, "OKP", jwks.Keys[0].KeyType)
+	require.Equal(t, AlgorithmEdDSA, jwks.Keys[0].Algorithm)
+	require.NotEmpty(t, jwks.Keys[0].X)
+
+	// a key id cannot be reused for another key
+	err = keySet.AddVerificationKey("key-1", keyTwo.Public())
+	require.Error(t, err)
+
+	err = keySet.RemoveKey("key-2")
+	require.Error(t, err)
+
+	err = keySet.RemoveKey("key-1")
+	require.NoError(t, err)
+
+	_, err = keySet.publicKey("key-1")
+	require.ErrorIs(t, err, ErrorUnknownKey)
+}
+
+func TestKeySetRSA(t *testing.T) {
+	keySet, err := NewKeySet("rsa", newRSAKey(t))
+	require.NoError(t, err)
+
+	jwks := keySet.JWKS()
+	require.Len(t, jwks.Keys, 1)
+	require.Equal(t, "RSA", jwks.Keys[0].KeyType)
+	require.Equal(t, AlgorithmRS256, jwks.Keys[0].Algorithm)
+	require.Equal(t, "AQAB", jwks.Keys[0].E)
+
+	weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	_, err = NewKeySet("weak", weakKey)
+	require.Error(t, err)
+}
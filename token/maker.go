@@ -4,9 +4,21 @@ import "time"
 
 // Maker is an interface to manage tokens
 type Maker interface {
-	// CreateToken generates a new token for a specific username and duration
-	CreateToken(username string, duration time.Duration) (string, error)
+	// CreateToken generates a new access token for a specific username, duration and scopes
+	CreateToken(username string, duration time.Duration, scopes ...string) (string, error)
+
+	// CreateChallengeToken generates a login challenge token for a user who still has to pass a second factor
+	CreateChallengeToken(username string, duration time.Duration) (string, error)
 
 	// VerifyToken checks for token validity
 	VerifyToken(token string) (*Payload, error)
 }
+
+// KeySetMaker is a Maker signing tokens with asymmetric keys, other services can verify
+// its tokens with the public keys of its key set
+type KeySetMaker interface {
+	Maker
+
+	// KeySet returns the keys tokens are signed and verified with
+	KeySet() *KeySet
+}
@@ -0,0 +1,165 @@
+package token
+
+import (
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "simple_bank"
+	testAudience = "simple_bank_api"
+)
+
+// payloadSigner is implemented by every maker, it signs a payload as is so tests can forge claims
+type payloadSigner interface {
+	Maker
+	signPayload(payload *Payload) (string, error)
+}
+
+// newTestMakers returns every maker implementation configured with the test issuer and audience
+func newTestMakers(t *testing.T) map[string]payloadSigner {
+	options := []Option{WithIssuer(testIssuer), WithAudience(testAudience)}
+	symmetricKey := util.RandomString(32)
+
+	pasetoMaker, err := NewPasetoMaker(symmetricKey, options...)
+	require.NoError(t, err)
+
+	jwtMaker, err := NewJwtMaker(symmetricKey, options...)
+	require.NoError(t, err)
+
+	ed25519KeySet, err := NewKeySet("ed25519", newEd25519Key(t))
+	require.NoError(t, err)
+
+	pasetoPublicMaker, err := NewPasetoPublicMaker(ed25519KeySet, options...)
+	require.NoError(t, err)
+
+	rsaKeySet, err := NewKeySet("rsa", newRSAKey(t))
+	require.NoError(t, err)
+
+	jwtKeySetMaker, err := NewJwtKeySetMaker(rsaKeySet, options...)
+	require.NoError(t, err)
+
+	return map[string]payloadSigner{
+		"Paseto":       pasetoMaker.(payloadSigner),
+		"Jwt":          jwtMaker.(payloadSigner),
+		"PasetoPublic": pasetoPublicMaker.(payloadSigner),
+		"JwtKeySet":    jwtKeySetMaker.(payloadSigner),
+	}
+}
+
+func TestMakerClaims(t *testing.T) {
+	for name, maker := range newTestMakers(t) {
+		maker := maker
+
+		t.Run(name, func(t *testing.T) {
+			username := util.RandomOwner()
+
+			token, err := maker.CreateToken(username, time.Minute, "accounts:read", "transfers:write")
+			require.NoError(t, err)
+
+			payload, err := maker.VerifyToken(token)
+			require.NoError(t, err)
+			require.Equal(t, username, payload.Username)
+			require.Equal(t, testIssuer, payload.Issuer)
+			require.Equal(t, testAudience, payload.Audience)
+			require.Equal(t, []string{"accounts:read", "transfers:write"}, payload.Scopes)
+			require.True(t, payload.HasScope("transfers:write"))
+			require.False(t, payload.HasScope("accounts:write"))
+			require.WithinDuration(t, time.Now(), payload.NotBefore, time.Second)
+
+			challengeToken, err := maker.CreateChallengeToken(username, time.Minute)
+			require.NoError(t, err)
+
+			payload, err = maker.VerifyToken(challengeToken)
+			require.NoError(t, err)
+			require.Empty(t, payload.Scopes)
+			require.Equal(t, testIssuer, payload.Issuer)
+		})
+	}
+}
+
+func TestMakerInvalidClaims(t *testing.T) {
+	testCases := []struct {
+		name        string
+		editPayload func(payload *Payload)
+		err         error
+	}{
+		{
+			name:        "Expired",
+			editPayload: func(payload *Payload) { payload.ExpiredAt = time.Now().Add(-time.Minute) },
+			err:         ErrorExpiredToken,
+		},
+		{
+			name:        "Not Yet Valid",
+			editPayload: func(payload *Payload) { payload.NotBefore = time.Now().Add(time.Minute) },
+			err:         ErrorTokenNotYetValid,
+		},
+		{
+			name:        "Wrong Issuer",
+			editPayload: func(payload *Payload) { payload.Issuer = "other_bank" },
+			err:         ErrorInvalidIssuer,
+		},
+		{
+			name:        "Missing Issuer",
+			editPayload: func(payload *Payload) { payload.Issuer = "" },
+			err:         ErrorInvalidIssuer,
+		},
+		{
+			name:        "Wrong Audience",
+			editPayload: func(payload *Payload) { payload.Audience = "other_api" },
+			err:         ErrorInvalidAudience,
+		},
+		{
+			name:        "Missing Audience",
+			editPayload: func(payload *Payload) { payload.Audience = "" },
+			err:         ErrorInvalidAudience,
+		},
+	}
+
+	for name, maker := range newTestMakers(t) {
+		maker := maker
+
+		for i := range testCases {
+			tc := testCases[i]
+
+			t.Run(name+"/"+tc.name, func(t *testing.T) {
+				payload, err := NewPayload(util.RandomOwner(), time.Minute)
+				require.NoError(t, err)
+
+				payload.Issuer = testIssuer
+				payload.Audience = testAudience
+				tc.editPayload(payload)
+
+				token, err := maker.signPayload(payload)
+				require.NoError(t, err)
+
+				verified, err := maker.VerifyToken(token)
+				require.EqualError(t, err, tc.err.Error())
+				require.Nil(t, verified)
+			})
+		}
+	}
+}
+
+func TestMakerWithoutRegisteredClaims(t *testing.T) {
+	symmetricKey := util.RandomString(32)
+
+	maker, err := NewPasetoMaker(symmetricKey)
+	require.NoError(t, err)
+
+	issuerMaker, err := NewPasetoMaker(symmetricKey, WithIssuer(testIssuer))
+	require.NoError(t, err)
+
+	token, err := maker.CreateToken(util.RandomOwner(), time.Minute)
+	require.NoError(t, err)
+
+	// a maker without an issuer neither sets nor checks it
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Empty(t, payload.Issuer)
+
+	_, err = issuerMaker.VerifyToken(token)
+	require.EqualError(t, err, ErrorInvalidIssuer.Error())
+}
@@ -11,9 +11,10 @@ import (
 type PasetoMaker struct {
 	paseto       *paseto.V2
 	symmetricKey []byte
+	claims       registeredClaims
 }
 
-func NewPasetoMaker(symmetricKey string) (Maker, error) {
+func NewPasetoMaker(symmetricKey string, options ...Option) (Maker, error) {
 	if len(symmetricKey) < chacha20poly1305.KeySize {
 		return nil, fmt.Errorf("invalid key size: must be at least %d characters", chacha20poly1305.KeySize)
 	}
@@ -21,17 +22,31 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 	maker := &PasetoMaker{
 		paseto:       paseto.NewV2(),
 		symmetricKey: []byte(symmetricKey),
+		claims:       newRegisteredClaims(options),
 	}
 
 	return maker, nil
 }
 
-func (maker *PasetoMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(username, duration)
+func (maker *PasetoMaker) CreateToken(username string, duration time.Duration, scopes ...string) (string, error) {
+	payload, err := maker.claims.newPayload(TypeAccess, username, duration, scopes)
 	if err != nil {
 		return "", err
 	}
 
+	return maker.signPayload(payload)
+}
+
+func (maker *PasetoMaker) CreateChallengeToken(username string, duration time.Duration) (string, error) {
+	payload, err := maker.claims.newPayload(TypeLoginChallenge, username, duration, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return maker.signPayload(payload)
+}
+
+func (maker *PasetoMaker) signPayload(payload *Payload) (string, error) {
 	return maker.paseto.Encrypt(maker.symmetricKey, payload, nil)
 }
 
@@ -43,10 +58,10 @@ func (maker *PasetoMaker) VerifyToken(token string) (*Payload, error) {
 		return nil, ErrorInvalidToken
 	}
 
-	err = payload.Valid()
+	err = maker.claims.verify(payload)
 	if err != nil {
 		return nil, err
 	}
 
-	return payload, err
+	return payload, nil
 }
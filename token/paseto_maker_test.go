@@ -26,8 +26,10 @@ func TestPasetoMaker(t *testing.T) {
 	require.NotEmpty(t, payload)
 
 	require.NotZero(t, payload.ID)
+	require.Equal(t, TypeAccess, payload.Type)
 	require.Equal(t, payload.Username, username)
 	require.WithinDuration(t, payload.IssuedAt, issuedAt, time.Second)
+	require.WithinDuration(t, payload.AuthTime, issuedAt, time.Second)
 	require.WithinDuration(t, payload.ExpiredAt, expiredAt, time.Second)
 }
 
@@ -54,3 +56,18 @@ func TestPasetoInvalidToken(t *testing.T) {
 	require.EqualError(t, err, ErrorInvalidToken.Error())
 	require.Nil(t, payload)
 }
+
+func TestPasetoChallengeToken(t *testing.T) {
+	maker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	username := util.RandomOwner()
+
+	token, err := maker.CreateChallengeToken(username, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, TypeLoginChallenge, payload.Type)
+	require.Equal(t, username, payload.Username)
+}
@@ -0,0 +1,167 @@
+package token
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// pasetoV4PublicHeader prefixes every v4.public token
+const pasetoV4PublicHeader = "v4.public."
+
+// PasetoPublicMaker is a paseto v4.public token maker, it signs tokens with the Ed25519 keys
+// of a key set and puts the key id in the authenticated footer
+type PasetoPublicMaker struct {
+	keySet *KeySet
+	claims registeredClaims
+}
+
+func NewPasetoPublicMaker(keySet *KeySet, options ...Option) (Maker, error) {
+	if keySet == nil {
+		return nil, errors.New("key set is required")
+	}
+
+	_, signingKey := keySet.signer()
+	if _, ok := signingKey.(ed25519.PrivateKey); !ok {
+		return nil, fmt.Errorf("paseto v4.public requires an ed25519 signing key, got %T", signingKey)
+	}
+
+	return &PasetoPublicMaker{keySet: keySet, claims: newRegisteredClaims(options)}, nil
+}
+
+// pasetoFooter is the json footer of the tokens
+type pasetoFooter struct {
+	KeyID string `json:"kid"`
+}
+
+// KeySet returns the keys tokens are signed and verified with
+func (maker *PasetoPublicMaker) KeySet() *KeySet {
+	return maker.keySet
+}
+
+func (maker *PasetoPublicMaker) CreateToken(username string, duration time.Duration, scopes ...string) (string, error) {
+	payload, err := maker.claims.newPayload(TypeAccess, username, duration, scopes)
+	if err != nil {
+		return "", err
+	}
+
+	return maker.signPayload(payload)
+}
+
+func (maker *PasetoPublicMaker) CreateChallengeToken(username string, duration time.Duration) (string, error) {
+	payload, err := maker.claims.newPayload(TypeLoginChallenge, username, duration, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return maker.signPayload(payload)
+}
+
+func (maker *PasetoPublicMaker) signPayload(payload *Payload) (string, error) {
+	keyID, signingKey := maker.keySet.signer()
+
+	privateKey, ok := signingKey.(ed25519.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("paseto v4.public requires an ed25519 signing key, got %T", signingKey)
+	}
+
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	footer, err := json.Marshal(pasetoFooter{KeyID: keyID})
+	if err != nil {
+		return "", err
+	}
+
+	signature := ed25519.Sign(privateKey, preAuthEncode([]byte(pasetoV4PublicHeader), message, footer, nil))
+
+	token := pasetoV4PublicHeader +
+		base64.RawURLEncoding.EncodeToString(append(message, signature...)) + "." +
+		base64.RawURLEncoding.EncodeToString(footer)
+
+	return token, nil
+}
+
+func (maker *PasetoPublicMaker) VerifyToken(token string) (*Payload, error) {
+	if !strings.HasPrefix(token, pasetoV4PublicHeader) {
+		return nil, ErrorInvalidToken
+	}
+
+	parts := strings.Split(strings.TrimPrefix(token, pasetoV4PublicHeader), ".")
+	if len(parts) != 2 {
+		return nil, ErrorInvalidToken
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(body) < ed25519.SignatureSize {
+		return nil, ErrorInvalidToken
+	}
+
+	footer, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrorInvalidToken
+	}
+
+	var decodedFooter pasetoFooter
+	err = json.Unmarshal(footer, &decodedFooter)
+	if err != nil {
+		return nil, ErrorInvalidToken
+	}
+
+	publicKey, err := maker.keySet.publicKey(decodedFooter.KeyID)
+	if err != nil {
+		return nil, ErrorInvalidToken
+	}
+
+	ed25519Key, ok := publicKey.(ed25519.PublicKey)
+	if !ok {
+		return nil, ErrorInvalidToken
+	}
+
+	message := body[:len(body)-ed25519.SignatureSize]
+	signature := body[len(body)-ed25519.SignatureSize:]
+	if !ed25519.Verify(ed25519Key, preAuthEncode([]byte(pasetoV4PublicHeader), message, footer, nil), signature) {
+		return nil, ErrorInvalidToken
+	}
+
+	payload := &Payload{}
+	err = json.Unmarshal(message, payload)
+	if err != nil {
+		return nil, ErrorInvalidToken
+	}
+
+	err = maker.claims.verify(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// preAuthEncode is the paseto pre-authentication encoding, it binds the header, message, footer
+// and implicit assertion together so none of them can be changed without breaking the signature
+func preAuthEncode(pieces ...[]byte) []byte {
+	var buffer bytes.Buffer
+
+	writeLength := func(n int) {
+		var length [8]byte
+		binary.LittleEndian.PutUint64(length[:], uint64(n)&(1<<63-1))
+		buffer.Write(length[:])
+	}
+
+	writeLength(len(pieces))
+	for _, piece := range pieces {
+		writeLength(len(piece))
+		buffer.Write(piece)
+	}
+
+	return buffer.Bytes()
+}
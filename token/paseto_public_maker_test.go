@@ -0,0 +1,133 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPasetoPublicMaker(t *testing.T) {
+	keySet, err := NewKeySet("key-1", newEd25519Key(t))
+	require.NoError(t, err)
+
+	maker, err := NewPasetoPublicMaker(keySet)
+	require.NoError(t, err)
+
+	username := util.RandomOwner()
+	issuedAt := time.Now()
+
+	token, err := maker.CreateToken(username, time.Minute)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(token, "v4.public."))
+
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotZero(t, payload.ID)
+	require.Equal(t, TypeAccess, payload.Type)
+	require.Equal(t, username, payload.Username)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, issuedAt.Add(time.Minute), payload.ExpiredAt, time.Second)
+
+	challengeToken, err := maker.CreateChallengeToken(username, time.Minute)
+	require.NoError(t, err)
+
+	payload, err = maker.VerifyToken(challengeToken)
+	require.NoError(t, err)
+	require.Equal(t, TypeLoginChallenge, payload.Type)
+}
+
+func TestPasetoPublicMakerRequiresEd25519(t *testing.T) {
+	keySet, err := NewKeySet("rsa", newRSAKey(t))
+	require.NoError(t, err)
+
+	maker, err := NewPasetoPublicMaker(keySet)
+	require.Error(t, err)
+	require.Nil(t, maker)
+}
+
+func TestPasetoPublicMakerRotation(t *testing.T) {
+	keySet, err := NewKeySet("key-1", newEd25519Key(t))
+	require.NoError(t, err)
+
+	maker, err := NewPasetoPublicMaker(keySet)
+	require.NoError(t, err)
+
+	oldToken, err := maker.CreateToken(util.RandomOwner(), time.Minute)
+	require.NoError(t, err)
+
+	err = keySet.Rotate("key-2", newEd25519Key(t))
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(oldToken)
+	require.NoError(t, err)
+
+	err = keySet.RemoveKey("key-1")
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(oldToken)
+	require.EqualError(t, err, ErrorInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestPasetoPublicMakerInvalidToken(t *testing.T) {
+	keySet, err := NewKeySet("key-1", newEd25519Key(t))
+	require.NoError(t, err)
+
+	maker, err := NewPasetoPublicMaker(keySet)
+	require.NoError(t, err)
+
+	token, err := maker.CreateToken(util.RandomOwner(), time.Minute)
+	require.NoError(t, err)
+
+	parts := strings.Split(token, ".")
+	otherFooter := base64.RawURLEncoding.EncodeToString([]byte(`{"kid":"key-2"}`))
+
+	otherKeySet, err := NewKeySet("key-1", newEd25519Key(t))
+	require.NoError(t, err)
+
+	otherMaker, err := NewPasetoPublicMaker(otherKeySet)
+	require.NoError(t, err)
+
+	forgedToken, err := otherMaker.CreateToken(util.RandomOwner(), time.Minute)
+	require.NoError(t, err)
+
+	invalidTokens := []string{
+		util.RandomString(16),
+		strings.Join(parts[:3], "."),
+		strings.Join([]string{parts[0], parts[1], parts[2], otherFooter}, "."),
+		strings.Join([]string{"v4", "local", parts[2], parts[3]}, "."),
+		forgedToken,
+	}
+
+	for _, invalidToken := range invalidTokens {
+		payload, err := maker.VerifyToken(invalidToken)
+		require.EqualError(t, err, ErrorInvalidToken.Error())
+		require.Nil(t, payload)
+	}
+
+	expiredToken, err := maker.CreateToken(util.RandomOwner(), -time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(expiredToken)
+	require.EqualError(t, err, ErrorExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+// TestPasetoV4PublicVector signs the 4-S-1 test vector of the paseto specification
+func TestPasetoV4PublicVector(t *testing.T) {
+	secretKey, err := hex.DecodeString("b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a3774" +
+		"1eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2")
+	require.NoError(t, err)
+
+	message := []byte(`{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`)
+	signature := ed25519.Sign(secretKey, preAuthEncode([]byte(pasetoV4PublicHeader), message, nil, nil))
+
+	token := pasetoV4PublicHeader + base64.RawURLEncoding.EncodeToString(append(message, signature...))
+	require.Equal(t, "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9"+
+		"bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA", token)
+}
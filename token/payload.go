@@ -11,34 +11,75 @@ var (
 	ErrorExpiredToken = errors.New("token has expired")
 )
 
+const (
+	// TypeAccess tokens authenticate api requests
+	TypeAccess = "access"
+	// TypeLoginChallenge tokens prove the password step of a two-factor login and cannot access the api
+	TypeLoginChallenge = "login_challenge"
+)
+
 type Payload struct {
 	ID        uuid.UUID `json:"id"`
+	Type      string    `json:"type"`
 	Username  string    `json:"username"`
+	Issuer    string    `json:"issuer"`
+	Audience  string    `json:"audience"`
+	Scopes    []string  `json:"scopes"`
 	IssuedAt  time.Time `json:"issued_at"`
+	NotBefore time.Time `json:"not_before"`
 	ExpiredAt time.Time `json:"expired_at"`
+	// AuthTime is when the user last proved their credentials, sensitive operations require it to be recent
+	AuthTime time.Time `json:"auth_time"`
+}
+
+// NewPayload creates a new access token payload with username, specific duration and scopes
+func NewPayload(username string, duration time.Duration, scopes ...string) (*Payload, error) {
+	return newPayload(TypeAccess, username, duration, scopes)
 }
 
-// NewPayload creates a new token payload with username and specific duration
-func NewPayload(username string, duration time.Duration) (*Payload, error) {
+func newPayload(tokenType string, username string, duration time.Duration, scopes []string) (*Payload, error) {
 	id, err := uuid.NewRandom()
 	if err != nil {
 		return &Payload{}, err
 	}
 
+	now := time.Now()
 	payload := &Payload{
 		ID:        id,
+		Type:      tokenType,
 		Username:  username,
-		IssuedAt:  time.Now(),
-		ExpiredAt: time.Now().Add(duration),
+		Scopes:    scopes,
+		IssuedAt:  now,
+		NotBefore: now,
+		ExpiredAt: now.Add(duration),
+		AuthTime:  now,
 	}
 
 	return payload, nil
 }
 
+// Valid checks the validity period of the payload
 func (p *Payload) Valid() error {
-	if time.Now().After(p.ExpiredAt) {
+	now := time.Now()
+
+	if now.After(p.ExpiredAt) {
 		return ErrorExpiredToken
 	}
 
+	if now.Before(p.NotBefore) {
+		return ErrorTokenNotYetValid
+	}
+
 	return nil
 }
+
+// HasScope reports whether the token grants a scope
+func (p *Payload) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -12,9 +12,73 @@ import (
 type Config struct {
 	DbDriver            string        `mapstructure:"DB_DRIVER"`
 	DbSource            string        `mapstructure:"DB_SOURCE"`
+	AutoMigrate         bool          `mapstructure:"AUTO_MIGRATE"`
 	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
-	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	TrustedProxies      string        `mapstructure:"TRUSTED_PROXIES"`
 	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	TracingExporter     string        `mapstructure:"TRACING_EXPORTER"`
+	OtlpEndpoint        string        `mapstructure:"OTLP_ENDPOINT"`
+	LogLevel            string        `mapstructure:"LOG_LEVEL"`
+	ShutdownDelay       time.Duration `mapstructure:"SHUTDOWN_DELAY"`
+	ShutdownTimeout     time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	TxMaxAttempts       int           `mapstructure:"TX_MAX_ATTEMPTS"`
+	TxRetryBaseDelay    time.Duration `mapstructure:"TX_RETRY_BASE_DELAY"`
+	TxRetryMaxDelay     time.Duration `mapstructure:"TX_RETRY_MAX_DELAY"`
+	TransferIsolation   string        `mapstructure:"TRANSFER_ISOLATION_LEVEL"`
+	Emailer             string        `mapstructure:"EMAILER"`
+	EmailOutboxPath     string        `mapstructure:"EMAIL_OUTBOX_PATH"`
+
+	TokenMaker                string `mapstructure:"TOKEN_MAKER"`
+	TokenKeyType              string `mapstructure:"TOKEN_KEY_TYPE"`
+	TokenSymmetricKeyFile     string `mapstructure:"TOKEN_SYMMETRIC_KEY_FILE"`
+	TokenSymmetricKeyBase64   string `mapstructure:"TOKEN_SYMMETRIC_KEY_BASE64"`
+	TokenPrivateKeyFile       string `mapstructure:"TOKEN_PRIVATE_KEY_FILE"`
+	TokenPrivateKeyBase64     string `mapstructure:"TOKEN_PRIVATE_KEY_BASE64"`
+	TokenKeyID                string `mapstructure:"TOKEN_KEY_ID"`
+	TokenVerificationKeyFiles string `mapstructure:"TOKEN_VERIFICATION_KEY_FILES"`
+	TokenIssuer               string `mapstructure:"TOKEN_ISSUER"`
+	TokenAudience             string `mapstructure:"TOKEN_AUDIENCE"`
+
+	PasswordResetTokenDuration     time.Duration `mapstructure:"PASSWORD_RESET_TOKEN_DURATION"`
+	EmailVerificationTokenDuration time.Duration `mapstructure:"EMAIL_VERIFICATION_TOKEN_DURATION"`
+
+	LoginMaxAttempts      int32         `mapstructure:"LOGIN_MAX_ATTEMPTS"`
+	LoginLockoutDuration  time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	LoginBackoffBaseDelay time.Duration `mapstructure:"LOGIN_BACKOFF_BASE_DELAY"`
+	LoginBackoffMaxDelay  time.Duration `mapstructure:"LOGIN_BACKOFF_MAX_DELAY"`
+	LoginIPMaxAttempts    int           `mapstructure:"LOGIN_IP_MAX_ATTEMPTS"`
+	LoginIPWindow         time.Duration `mapstructure:"LOGIN_IP_WINDOW"`
+
+	TotpIssuer              string        `mapstructure:"TOTP_ISSUER"`
+	TotpEncryptionKeyFile   string        `mapstructure:"TOTP_ENCRYPTION_KEY_FILE"`
+	TotpEncryptionKeyBase64 string        `mapstructure:"TOTP_ENCRYPTION_KEY_BASE64"`
+	LoginChallengeDuration  time.Duration `mapstructure:"LOGIN_CHALLENGE_DURATION"`
+
+	Currencies string `mapstructure:"CURRENCIES"`
+
+	StepUpMaxAge             time.Duration `mapstructure:"STEP_UP_MAX_AGE"`
+	StepUpTransferThresholds string        `mapstructure:"STEP_UP_TRANSFER_THRESHOLDS"`
+
+	WebhookEncryptionKeyFile    string        `mapstructure:"WEBHOOK_ENCRYPTION_KEY_FILE"`
+	WebhookEncryptionKeyBase64  string        `mapstructure:"WEBHOOK_ENCRYPTION_KEY_BASE64"`
+	WebhookPollInterval         time.Duration `mapstructure:"WEBHOOK_POLL_INTERVAL"`
+	WebhookBatchSize            int32         `mapstructure:"WEBHOOK_BATCH_SIZE"`
+	WebhookTimeout              time.Duration `mapstructure:"WEBHOOK_TIMEOUT"`
+	WebhookMaxAttempts          int32         `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`
+	WebhookBackoffBaseDelay     time.Duration `mapstructure:"WEBHOOK_BACKOFF_BASE_DELAY"`
+	WebhookBackoffMaxDelay      time.Duration `mapstructure:"WEBHOOK_BACKOFF_MAX_DELAY"`
+	WebhookAllowPrivateNetworks bool          `mapstructure:"WEBHOOK_ALLOW_PRIVATE_NETWORKS"`
+
+	OutboxPublisher        string        `mapstructure:"OUTBOX_PUBLISHER"`
+	OutboxBrokerURL        string        `mapstructure:"OUTBOX_BROKER_URL"`
+	OutboxBrokerTimeout    time.Duration `mapstructure:"OUTBOX_BROKER_TIMEOUT"`
+	OutboxTopicPrefix      string        `mapstructure:"OUTBOX_TOPIC_PREFIX"`
+	OutboxPollInterval     time.Duration `mapstructure:"OUTBOX_POLL_INTERVAL"`
+	OutboxBatchSize        int32         `mapstructure:"OUTBOX_BATCH_SIZE"`
+	OutboxBackoffBaseDelay time.Duration `mapstructure:"OUTBOX_BACKOFF_BASE_DELAY"`
+	OutboxBackoffMaxDelay  time.Duration `mapstructure:"OUTBOX_BACKOFF_MAX_DELAY"`
+
+	EventStreamHeartbeatInterval time.Duration `mapstructure:"EVENT_STREAM_HEARTBEAT_INTERVAL"`
 }
 
 // LoadConfig reads configuration from file or environment variables
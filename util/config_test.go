@@ -0,0 +1,32 @@
+package util
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	config, err := LoadConfig("..")
+	require.NoError(t, err)
+
+	require.Equal(t, "read_committed", config.TransferIsolation)
+	require.Equal(t, "log", config.Emailer)
+	require.Equal(t, "paseto", config.TokenMaker)
+	require.Equal(t, "symmetric", config.TokenKeyType)
+	require.NotEmpty(t, config.TokenSymmetricKeyFile)
+	require.Empty(t, config.TokenSymmetricKeyBase64)
+	require.NotEmpty(t, config.TotpEncryptionKeyFile)
+	require.Empty(t, config.TotpEncryptionKeyBase64)
+	require.Equal(t, 30*time.Minute, config.AccessTokenDuration)
+	require.Empty(t, config.TrustedProxies)
+	require.Equal(t, "USD,CAD,NGN", config.Currencies)
+	require.Equal(t, 5*time.Minute, config.StepUpMaxAge)
+	require.NotEmpty(t, config.WebhookEncryptionKeyFile)
+	require.Empty(t, config.WebhookEncryptionKeyBase64)
+	require.Equal(t, int32(8), config.WebhookMaxAttempts)
+	require.Equal(t, "log", config.OutboxPublisher)
+	require.Equal(t, int32(100), config.OutboxBatchSize)
+	require.Equal(t, time.Second, config.OutboxBackoffBaseDelay)
+	require.Equal(t, 5*time.Minute, config.OutboxBackoffMaxDelay)
+}
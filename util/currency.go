@@ -1,16 +1,148 @@
 package util
 
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
 const (
 	USD = "USD"
 	CAD = "CAD"
-	NAR = "NAR"
+	NGN = "NGN"
 )
 
-// IsSupportedCurrency returns if a currency is supported or not
+// DefaultCurrencies are the currencies enabled until EnableCurrencies is called
+var DefaultCurrencies = []string{USD, CAD, NGN}
+
+// Currency is a currency of ISO 4217
+type Currency struct {
+	// Code is the alphabetic code, such as USD
+	Code string `json:"code"`
+	// Number is the three digit numeric code, such as 840
+	Number string `json:"number"`
+	// Exponent is the number of decimals of the minor unit, amounts are stored in minor units
+	Exponent int    `json:"exponent"`
+	Name     string `json:"name"`
+}
+
+var currencies = make(map[string]Currency, len(iso4217Currencies))
+
+// enabledCurrencies are the currencies accounts and transfers may use
+var enabledCurrencies = struct {
+	sync.RWMutex
+	codes map[string]bool
+}{}
+
+func init() {
+	for _, currency := range iso4217Currencies {
+		currencies[currency.Code] = currency
+	}
+
+	if err := EnableCurrencies(DefaultCurrencies); err != nil {
+		panic(err)
+	}
+}
+
+// LookupCurrency returns the ISO 4217 currency of a code, enabled or not
+func LookupCurrency(code string) (Currency, bool) {
+	currency, ok := currencies[code]
+	return currency, ok
+}
+
+// EnableCurrencies replaces the enabled currencies, every code must be an ISO 4217 currency
+func EnableCurrencies(codes []string) error {
+	enabled := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		if _, ok := currencies[code]; !ok {
+			return fmt.Errorf("%s is not an ISO 4217 currency", code)
+		}
+		enabled[code] = true
+	}
+
+	if len(enabled) == 0 {
+		return errors.New("no currency is enabled")
+	}
+
+	enabledCurrencies.Lock()
+	defer enabledCurrencies.Unlock()
+
+	enabledCurrencies.codes = enabled
+	return nil
+}
+
+// EnabledCurrencies returns the enabled currencies sorted by code
+func EnabledCurrencies() []Currency {
+	enabledCurrencies.RLock()
+	defer enabledCurrencies.RUnlock()
+
+	enabled := make([]Currency, 0, len(enabledCurrencies.codes))
+	for code := range enabledCurrencies.codes {
+		enabled = append(enabled, currencies[code])
+	}
+
+	sort.Slice(enabled, func(i, j int) bool { return enabled[i].Code < enabled[j].Code })
+	return enabled
+}
+
+// IsSupportedCurrency returns if a currency is enabled or not
 func IsSupportedCurrency(currency string) bool {
-	switch currency {
-	case USD, CAD, NAR:
-		return true
+	enabledCurrencies.RLock()
+	defer enabledCurrencies.RUnlock()
+
+	return enabledCurrencies.codes[currency]
+}
+
+// ParseCurrencyCodes parses a comma separated list of currency codes such as "USD,CAD", codes are upper cased
+func ParseCurrencyCodes(s string) ([]string, error) {
+	var codes []string
+
+	for _, code := range strings.Split(s, ",") {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code == "" {
+			continue
+		}
+
+		if _, ok := currencies[code]; !ok {
+			return nil, fmt.Errorf("%s is not an ISO 4217 currency", code)
+		}
+
+		codes = append(codes, code)
 	}
-	return false
+
+	return codes, nil
+}
+
+// ParseCurrencyAmounts parses a comma separated list of CURRENCY:AMOUNT pairs such as "USD:100000,CAD:150000"
+func ParseCurrencyAmounts(s string) (map[string]int64, error) {
+	amounts := make(map[string]int64)
+
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		currency, amount, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid currency amount %q: want CURRENCY:AMOUNT", pair)
+		}
+
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		if !IsSupportedCurrency(currency) {
+			return nil, fmt.Errorf("invalid currency amount %q: unsupported currency %s", pair, currency)
+		}
+
+		value, err := strconv.ParseInt(strings.TrimSpace(amount), 10, 64)
+		if err != nil || value < 0 {
+			return nil, fmt.Errorf("invalid currency amount %q: amount must be a non-negative integer", pair)
+		}
+
+		amounts[currency] = value
+	}
+
+	return amounts, nil
 }
@@ -0,0 +1,94 @@
+package util
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseCurrencyAmounts(t *testing.T) {
+	amounts, err := ParseCurrencyAmounts(" usd:100000, CAD:250 ,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{USD: 100000, CAD: 250}, amounts)
+
+	amounts, err = ParseCurrencyAmounts("")
+	require.NoError(t, err)
+	require.Empty(t, amounts)
+
+	for _, invalid := range []string{"USD", "EUR:100", "USD:abc", "USD:-1"} {
+		_, err = ParseCurrencyAmounts(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestISO4217Currencies(t *testing.T) {
+	numbers := make(map[string]string)
+	for _, currency := range iso4217Currencies {
+		require.Regexp(t, "^[A-Z]{3}$", currency.Code)
+		require.Regexp(t, "^[0-9]{3}$", currency.Number, currency.Code)
+		require.Contains(t, []int{0, 2, 3}, currency.Exponent, currency.Code)
+		require.NotEmpty(t, currency.Name, currency.Code)
+
+		other, ok := numbers[currency.Number]
+		require.False(t, ok, "%s and %s share number %s", currency.Code, other, currency.Number)
+		numbers[currency.Number] = currency.Code
+	}
+	require.Len(t, currencies, len(iso4217Currencies))
+}
+
+func TestLookupCurrency(t *testing.T) {
+	currency, ok := LookupCurrency(NGN)
+	require.True(t, ok)
+	require.Equal(t, Currency{Code: NGN, Number: "566", Exponent: 2, Name: "Naira"}, currency)
+
+	currency, ok = LookupCurrency("JPY")
+	require.True(t, ok)
+	require.Zero(t, currency.Exponent)
+
+	currency, ok = LookupCurrency("KWD")
+	require.True(t, ok)
+	require.Equal(t, 3, currency.Exponent)
+
+	for _, code := range []string{"NAR", "usd", "XAU", ""} {
+		_, ok = LookupCurrency(code)
+		require.False(t, ok, code)
+	}
+}
+
+func TestEnableCurrencies(t *testing.T) {
+	t.Cleanup(func() {
+		require.NoError(t, EnableCurrencies(DefaultCurrencies))
+	})
+
+	for _, code := range DefaultCurrencies {
+		require.True(t, IsSupportedCurrency(code), code)
+	}
+	require.False(t, IsSupportedCurrency("EUR"))
+	require.False(t, IsSupportedCurrency("NAR"))
+
+	require.NoError(t, EnableCurrencies([]string{"JPY", "EUR", "EUR"}))
+	require.True(t, IsSupportedCurrency("EUR"))
+	require.False(t, IsSupportedCurrency(USD))
+
+	enabled := EnabledCurrencies()
+	require.Len(t, enabled, 2)
+	require.Equal(t, "EUR", enabled[0].Code)
+	require.Equal(t, "JPY", enabled[1].Code)
+
+	// a failed call leaves the enabled currencies as they were
+	require.Error(t, EnableCurrencies([]string{"EUR", "NAR"}))
+	require.Error(t, EnableCurrencies(nil))
+	require.True(t, IsSupportedCurrency("JPY"))
+}
+
+func TestParseCurrencyCodes(t *testing.T) {
+	codes, err := ParseCurrencyCodes(" usd, NGN ,,eur")
+	require.NoError(t, err)
+	require.Equal(t, []string{USD, NGN, "EUR"}, codes)
+
+	codes, err = ParseCurrencyCodes("")
+	require.NoError(t, err)
+	require.Empty(t, codes)
+
+	_, err = ParseCurrencyCodes("USD,NAR")
+	require.Error(t, err)
+}
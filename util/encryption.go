@@ -0,0 +1,81 @@
+package util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+const encryptionKeySize = 32
+
+// Encryptor encrypts secrets stored at rest with AES-256-GCM
+type Encryptor struct {
+	aead cipher.AEAD
+}
+
+// NewEncryptor creates an encryptor from a 32 character key
+func NewEncryptor(key string) (*Encryptor, error) {
+	if len(key) != encryptionKeySize {
+		return nil, fmt.Errorf("invalid key size: must be exactly %d characters", encryptionKeySize)
+	}
+
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Encryptor{aead: aead}, nil
+}
+
+// LoadEncryptor creates an encryptor from a key read with LoadKey, refusing keys with too little entropy
+func LoadEncryptor(name string, path string, base64Value string) (*Encryptor, error) {
+	key, err := LoadKey(name, path, base64Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if bits := KeyEntropyBits(key); bits < MinKeyEntropyBits {
+		return nil, fmt.Errorf("%s is too weak: about %d bits of entropy, at least %d required", name, int(bits), MinKeyEntropyBits)
+	}
+
+	return NewEncryptor(string(key))
+}
+
+// Encrypt returns the base64 encoded random nonce followed by the sealed plaintext
+func (encryptor *Encryptor) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, encryptor.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("unable to generate nonce: %w", err)
+	}
+
+	sealed := encryptor.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt, it fails when the value was tampered with
+func (encryptor *Encryptor) Decrypt(ciphertext string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("invalid ciphertext: %w", err)
+	}
+
+	nonceSize := encryptor.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", errors.New("invalid ciphertext: too short")
+	}
+
+	plaintext, err := encryptor.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", fmt.Errorf("cannot decrypt: %w", err)
+	}
+
+	return string(plaintext), nil
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestEncryptor(t *testing.T) {
+	encryptor, err := NewEncryptor(RandomString(32))
+	require.NoError(t, err)
+
+	secret := RandomString(20)
+
+	ciphertext, err := encryptor.Encrypt(secret)
+	require.NoError(t, err)
+	require.NotContains(t, ciphertext, secret)
+
+	// every encryption uses a fresh nonce
+	other, err := encryptor.Encrypt(secret)
+	require.NoError(t, err)
+	require.NotEqual(t, ciphertext, other)
+
+	plaintext, err := encryptor.Decrypt(ciphertext)
+	require.NoError(t, err)
+	require.Equal(t, secret, plaintext)
+
+	wrongKey, err := NewEncryptor(RandomString(32))
+	require.NoError(t, err)
+	_, err = wrongKey.Decrypt(ciphertext)
+	require.Error(t, err)
+
+	_, err = encryptor.Decrypt("not base64!")
+	require.Error(t, err)
+
+	_, err = NewEncryptor(RandomString(16))
+	require.Error(t, err)
+}
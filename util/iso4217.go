@@ -0,0 +1,162 @@
+package util
+
+// iso4217Currencies are the active currencies of ISO 4217. Funds, precious metals and the other codes without
+// a minor unit are left out since accounts cannot hold them
+var iso4217Currencies = []Currency{
+	{Code: "AED", Number: "784", Exponent: 2, Name: "UAE Dirham"},
+	{Code: "AFN", Number: "971", Exponent: 2, Name: "Afghani"},
+	{Code: "ALL", Number: "008", Exponent: 2, Name: "Lek"},
+	{Code: "AMD", Number: "051", Exponent: 2, Name: "Armenian Dram"},
+	{Code: "AOA", Number: "973", Exponent: 2, Name: "Kwanza"},
+	{Code: "ARS", Number: "032", Exponent: 2, Name: "Argentine Peso"},
+	{Code: "AUD", Number: "036", Exponent: 2, Name: "Australian Dollar"},
+	{Code: "AWG", Number: "533", Exponent: 2, Name: "Aruban Florin"},
+	{Code: "AZN", Number: "944", Exponent: 2, Name: "Azerbaijan Manat"},
+	{Code: "BAM", Number: "977", Exponent: 2, Name: "Convertible Mark"},
+	{Code: "BBD", Number: "052", Exponent: 2, Name: "Barbados Dollar"},
+	{Code: "BDT", Number: "050", Exponent: 2, Name: "Taka"},
+	{Code: "BGN", Number: "975", Exponent: 2, Name: "Bulgarian Lev"},
+	{Code: "BHD", Number: "048", Exponent: 3, Name: "Bahraini Dinar"},
+	{Code: "BIF", Number: "108", Exponent: 0, Name: "Burundi Franc"},
+	{Code: "BMD", Number: "060", Exponent: 2, Name: "Bermudian Dollar"},
+	{Code: "BND", Number: "096", Exponent: 2, Name: "Brunei Dollar"},
+	{Code: "BOB", Number: "068", Exponent: 2, Name: "Boliviano"},
+	{Code: "BRL", Number: "986", Exponent: 2, Name: "Brazilian Real"},
+	{Code: "BSD", Number: "044", Exponent: 2, Name: "Bahamian Dollar"},
+	{Code: "BTN", Number: "064", Exponent: 2, Name: "Ngultrum"},
+	{Code: "BWP", Number: "072", Exponent: 2, Name: "Pula"},
+	{Code: "BYN", Number: "933", Exponent: 2, Name: "Belarusian Ruble"},
+	{Code: "BZD", Number: "084", Exponent: 2, Name: "Belize Dollar"},
+	{Code: "CAD", Number: "124", Exponent: 2, Name: "Canadian Dollar"},
+	{Code: "CDF", Number: "976", Exponent: 2, Name: "Congolese Franc"},
+	{Code: "CHF", Number: "756", Exponent: 2, Name: "Swiss Franc"},
+	{Code: "CLP", Number: "152", Exponent: 0, Name: "Chilean Peso"},
+	{Code: "CNY", Number: "156", Exponent: 2, Name: "Yuan Renminbi"},
+	{Code: "COP", Number: "170", Exponent: 2, Name: "Colombian Peso"},
+	{Code: "CRC", Number: "188", Exponent: 2, Name: "Costa Rican Colon"},
+	{Code: "CUP", Number: "192", Exponent: 2, Name: "Cuban Peso"},
+	{Code: "CVE", Number: "132", Exponent: 2, Name: "Cabo Verde Escudo"},
+	{Code: "CZK", Number: "203", Exponent: 2, Name: "Czech Koruna"},
+	{Code: "DJF", Number: "262", Exponent: 0, Name: "Djibouti Franc"},
+	{Code: "DKK", Number: "208", Exponent: 2, Name: "Danish Krone"},
+	{Code: "DOP", Number: "214", Exponent: 2, Name: "Dominican Peso"},
+	{Code: "DZD", Number: "012", Exponent: 2, Name: "Algerian Dinar"},
+	{Code: "EGP", Number: "818", Exponent: 2, Name: "Egyptian Pound"},
+	{Code: "ERN", Number: "232", Exponent: 2, Name: "Nakfa"},
+	{Code: "ETB", Number: "230", Exponent: 2, Name: "Ethiopian Birr"},
+	{Code: "EUR", Number: "978", Exponent: 2, Name: "Euro"},
+	{Code: "FJD", Number: "242", Exponent: 2, Name: "Fiji Dollar"},
+	{Code: "FKP", Number: "238", Exponent: 2, Name: "Falkland Islands Pound"},
+	{Code: "GBP", Number: "826", Exponent: 2, Name: "Pound Sterling"},
+	{Code: "GEL", Number: "981", Exponent: 2, Name: "Lari"},
+	{Code: "GHS", Number: "936", Exponent: 2, Name: "Ghana Cedi"},
+	{Code: "GIP", Number: "292", Exponent: 2, Name: "Gibraltar Pound"},
+	{Code: "GMD", Number: "270", Exponent: 2, Name: "Dalasi"},
+	{Code: "GNF", Number: "324", Exponent: 0, Name: "Guinean Franc"},
+	{Code: "GTQ", Number: "320", Exponent: 2, Name: "Quetzal"},
+	{Code: "GYD", Number: "328", Exponent: 2, Name: "Guyana Dollar"},
+	{Code: "HKD", Number: "344", Exponent: 2, Name: "Hong Kong Dollar"},
+	{Code: "HNL", Number: "340", Exponent: 2, Name: "Lempira"},
+	{Code: "HTG", Number: "332", Exponent: 2, Name: "Gourde"},
+	{Code: "HUF", Number: "348", Exponent: 2, Name: "Forint"},
+	{Code: "IDR", Number: "360", Exponent: 2, Name: "Rupiah"},
+	{Code: "ILS", Number: "376", Exponent: 2, Name: "New Israeli Sheqel"},
+	{Code: "INR", Number: "356", Exponent: 2, Name: "Indian Rupee"},
+	{Code: "IQD", Number: "368", Exponent: 3, Name: "Iraqi Dinar"},
+	{Code: "IRR", Number: "364", Exponent: 2, Name: "Iranian Rial"},
+	{Code: "ISK", Number: "352", Exponent: 0, Name: "Iceland Krona"},
+	{Code: "JMD", Number: "388", Exponent: 2, Name: "Jamaican Dollar"},
+	{Code: "JOD", Number: "400", Exponent: 3, Name: "Jordanian Dinar"},
+	{Code: "JPY", Number: "392", Exponent: 0, Name: "Yen"},
+	{Code: "KES", Number: "404", Exponent: 2, Name: "Kenyan Shilling"},
+	{Code: "KGS", Number: "417", Exponent: 2, Name: "Som"},
+	{Code: "KHR", Number: "116", Exponent: 2, Name: "Riel"},
+	{Code: "KMF", Number: "174", Exponent: 0, Name: "Comorian Franc"},
+	{Code: "KPW", Number: "408", Exponent: 2, Name: "North Korean Won"},
+	{Code: "KRW", Number: "410", Exponent: 0, Name: "Won"},
+	{Code: "KWD", Number: "414", Exponent: 3, Name: "Kuwaiti Dinar"},
+	{Code: "KYD", Number: "136", Exponent: 2, Name: "Cayman Islands Dollar"},
+	{Code: "KZT", Number: "398", Exponent: 2, Name: "Tenge"},
+	{Code: "LAK", Number: "418", Exponent: 2, Name: "Lao Kip"},
+	{Code: "LBP", Number: "422", Exponent: 2, Name: "Lebanese Pound"},
+	{Code: "LKR", Number: "144", Exponent: 2, Name: "Sri Lanka Rupee"},
+	{Code: "LRD", Number: "430", Exponent: 2, Name: "Liberian Dollar"},
+	{Code: "LSL", Number: "426", Exponent: 2, Name: "Loti"},
+	{Code: "LYD", Number: "434", Exponent: 3, Name: "Libyan Dinar"},
+	{Code: "MAD", Number: "504", Exponent: 2, Name: "Moroccan Dirham"},
+	{Code: "MDL", Number: "498", Exponent: 2, Name: "Moldovan Leu"},
+	{Code: "MGA", Number: "969", Exponent: 2, Name: "Malagasy Ariary"},
+	{Code: "MKD", Number: "807", Exponent: 2, Name: "Denar"},
+	{Code: "MMK", Number: "104", Exponent: 2, Name: "Kyat"},
+	{Code: "MNT", Number: "496", Exponent: 2, Name: "Tugrik"},
+	{Code: "MOP", Number: "446", Exponent: 2, Name: "Pataca"},
+	{Code: "MRU", Number: "929", Exponent: 2, Name: "Ouguiya"},
+	{Code: "MUR", Number: "480", Exponent: 2, Name: "Mauritius Rupee"},
+	{Code: "MVR", Number: "462", Exponent: 2, Name: "Rufiyaa"},
+	{Code: "MWK", Number: "454", Exponent: 2, Name: "Malawi Kwacha"},
+	{Code: "MXN", Number: "484", Exponent: 2, Name: "Mexican Peso"},
+	{Code: "MYR", Number: "458", Exponent: 2, Name: "Malaysian Ringgit"},
+	{Code: "MZN", Number: "943", Exponent: 2, Name: "Mozambique Metical"},
+	{Code: "NAD", Number: "516", Exponent: 2, Name: "Namibia Dollar"},
+	{Code: "NGN", Number: "566", Exponent: 2, Name: "Naira"},
+	{Code: "NIO", Number: "558", Exponent: 2, Name: "Cordoba Oro"},
+	{Code: "NOK", Number: "578", Exponent: 2, Name: "Norwegian Krone"},
+	{Code: "NPR", Number: "524", Exponent: 2, Name: "Nepalese Rupee"},
+	{Code: "NZD", Number: "554", Exponent: 2, Name: "New Zealand Dollar"},
+	{Code: "OMR", Number: "512", Exponent: 3, Name: "Rial Omani"},
+	{Code: "PAB", Number: "590", Exponent: 2, Name: "Balboa"},
+	{Code: "PEN", Number: "604", Exponent: 2, Name: "Sol"},
+	{Code: "PGK", Number: "598", Exponent: 2, Name: "Kina"},
+	{Code: "PHP", Number: "608", Exponent: 2, Name: "Philippine Peso"},
+	{Code: "PKR", Number: "586", Exponent: 2, Name: "Pakistan Rupee"},
+	{Code: "PLN", Number: "985", Exponent: 2, Name: "Zloty"},
+	{Code: "PYG", Number: "600", Exponent: 0, Name: "Guarani"},
+	{Code: "QAR", Number: "634", Exponent: 2, Name: "Qatari Rial"},
+	{Code: "RON", Number: "946", Exponent: 2, Name: "Romanian Leu"},
+	{Code: "RSD", Number: "941", Exponent: 2, Name: "Serbian Dinar"},
+	{Code: "RUB", Number: "643", Exponent: 2, Name: "Russian Ruble"},
+	{Code: "RWF", Number: "646", Exponent: 0, Name: "Rwanda Franc"},
+	{Code: "SAR", Number: "682", Exponent: 2, Name: "Saudi Riyal"},
+	{Code: "SBD", Number: "090", Exponent: 2, Name: "Solomon Islands Dollar"},
+	{Code: "SCR", Number: "690", Exponent: 2, Name: "Seychelles Rupee"},
+	{Code: "SDG", Number: "938", Exponent: 2, Name: "Sudanese Pound"},
+	{Code: "SEK", Number: "752", Exponent: 2, Name: "Swedish Krona"},
+	{Code: "SGD", Number: "702", Exponent: 2, Name: "Singapore Dollar"},
+	{Code: "SHP", Number: "654", Exponent: 2, Name: "Saint Helena Pound"},
+	{Code: "SLE", Number: "925", Exponent: 2, Name: "Leone"},
+	{Code: "SOS", Number: "706", Exponent: 2, Name: "Somali Shilling"},
+	{Code: "SRD", Number: "968", Exponent: 2, Name: "Surinam Dollar"},
+	{Code: "SSP", Number: "728", Exponent: 2, Name: "South Sudanese Pound"},
+	{Code: "STN", Number: "930", Exponent: 2, Name: "Dobra"},
+	{Code: "SVC", Number: "222", Exponent: 2, Name: "El Salvador Colon"},
+	{Code: "SYP", Number: "760", Exponent: 2, Name: "Syrian Pound"},
+	{Code: "SZL", Number: "748", Exponent: 2, Name: "Lilangeni"},
+	{Code: "THB", Number: "764", Exponent: 2, Name: "Baht"},
+	{Code: "TJS", Number: "972", Exponent: 2, Name: "Somoni"},
+	{Code: "TMT", Number: "934", Exponent: 2, Name: "Turkmenistan New Manat"},
+	{Code: "TND", Number: "788", Exponent: 3, Name: "Tunisian Dinar"},
+	{Code: "TOP", Number: "776", Exponent: 2, Name: "Pa'anga"},
+	{Code: "TRY", Number: "949", Exponent: 2, Name: "Turkish Lira"},
+	{Code: "TTD", Number: "780", Exponent: 2, Name: "Trinidad and Tobago Dollar"},
+	{Code: "TWD", Number: "901", Exponent: 2, Name: "New Taiwan Dollar"},
+	{Code: "TZS", Number: "834", Exponent: 2, Name: "Tanzanian Shilling"},
+	{Code: "UAH", Number: "980", Exponent: 2, Name: "Hryvnia"},
+	{Code: "UGX", Number: "800", Exponent: 0, Name: "Uganda Shilling"},
+	{Code: "USD", Number: "840", Exponent: 2, Name: "US Dollar"},
+	{Code: "UYU", Number: "858", Exponent: 2, Name: "Peso Uruguayo"},
+	{Code: "UZS", Number: "860", Exponent: 2, Name: "Uzbekistan Sum"},
+	{Code: "VED", Number: "926", Exponent: 2, Name: "Bolívar Soberano"},
+	{Code: "VES", Number: "928", Exponent: 2, Name: "Bolívar Soberano"},
+	{Code: "VND", Number: "704", Exponent: 0, Name: "Dong"},
+	{Code: "VUV", Number: "548", Exponent: 0, Name: "Vatu"},
+	{Code: "WST", Number: "882", Exponent: 2, Name: "Tala"},
+	{Code: "XAF", Number: "950", Exponent: 0, Name: "CFA Franc BEAC"},
+	{Code: "XCD", Number: "951", Exponent: 2, Name: "East Caribbean Dollar"},
+	{Code: "XCG", Number: "532", Exponent: 2, Name: "Caribbean Guilder"},
+	{Code: "XOF", Number: "952", Exponent: 0, Name: "CFA Franc BCEAO"},
+	{Code: "XPF", Number: "953", Exponent: 0, Name: "CFP Franc"},
+	{Code: "YER", Number: "886", Exponent: 2, Name: "Yemeni Rial"},
+	{Code: "ZAR", Number: "710", Exponent: 2, Name: "Rand"},
+	{Code: "ZMW", Number: "967", Exponent: 2, Name: "Zambian Kwacha"},
+	{Code: "ZWG", Number: "924", Exponent: 2, Name: "Zimbabwe Gold"},
+}
@@ -0,0 +1,59 @@
+package util
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// MinKeyEntropyBits is the least entropy a symmetric key must show, estimated from its byte frequencies.
+// It rejects passwords, repeated characters and sequences like "1234567890..." of the right length
+const MinKeyEntropyBits = 128
+
+// LoadKey reads a key from either a file holding the raw key or a base64 encoded value
+func LoadKey(name string, path string, base64Value string) ([]byte, error) {
+	switch {
+	case path != "" && base64Value != "":
+		return nil, fmt.Errorf("set only one of %s_FILE and %s_BASE64", name, name)
+	case path != "":
+		key, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s_FILE: %w", name, err)
+		}
+		return key, nil
+	case base64Value != "":
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(base64Value))
+		if err != nil {
+			return nil, fmt.Errorf("%s_BASE64 is not valid base64: %w", name, err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("%s_FILE or %s_BASE64 is required", name, name)
+	}
+}
+
+// KeyEntropyBits estimates the entropy of a key as its length times the shannon entropy of its bytes
+func KeyEntropyBits(key []byte) float64 {
+	if len(key) == 0 {
+		return 0
+	}
+
+	var counts [256]int
+	for _, b := range key {
+		counts[b]++
+	}
+
+	var bitsPerByte float64
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+
+		p := float64(count) / float64(len(key))
+		bitsPerByte -= p * math.Log2(p)
+	}
+
+	return bitsPerByte * float64(len(key))
+}
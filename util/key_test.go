@@ -0,0 +1,51 @@
+package util
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func randomKey(t *testing.T) []byte {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return key
+}
+
+func TestKeyEntropyBits(t *testing.T) {
+	require.Zero(t, KeyEntropyBits(nil))
+	require.Zero(t, KeyEntropyBits([]byte(strings.Repeat("a", 64))))
+	require.Less(t, KeyEntropyBits([]byte("12345678901234567890123456789012")), float64(MinKeyEntropyBits))
+	require.GreaterOrEqual(t, KeyEntropyBits(randomKey(t)), float64(MinKeyEntropyBits))
+}
+
+func TestLoadEncryptor(t *testing.T) {
+	key := randomKey(t)
+
+	path := filepath.Join(t.TempDir(), "encryption.key")
+	require.NoError(t, os.WriteFile(path, key, 0o600))
+
+	fromFile, err := LoadEncryptor("ENCRYPTION_KEY", path, "")
+	require.NoError(t, err)
+
+	fromBase64, err := LoadEncryptor("ENCRYPTION_KEY", "", base64.StdEncoding.EncodeToString(key))
+	require.NoError(t, err)
+
+	ciphertext, err := fromFile.Encrypt("secret")
+	require.NoError(t, err)
+
+	plaintext, err := fromBase64.Decrypt(ciphertext)
+	require.NoError(t, err)
+	require.Equal(t, "secret", plaintext)
+
+	_, err = LoadEncryptor("ENCRYPTION_KEY", "", "")
+	require.Error(t, err)
+
+	_, err = LoadEncryptor("ENCRYPTION_KEY", "", base64.StdEncoding.EncodeToString([]byte("12345678901234567890123456789012")))
+	require.ErrorContains(t, err, "too weak")
+}
@@ -0,0 +1,31 @@
+package util
+
+import (
+	"fmt"
+	"github.com/rs/zerolog"
+	"os"
+	"time"
+)
+
+// NewLogger creates a json logger writing to stdout at the given level
+func NewLogger(level string) (zerolog.Logger, error) {
+	logLevel := zerolog.InfoLevel
+
+	if level != "" {
+		var err error
+		logLevel, err = zerolog.ParseLevel(level)
+		if err != nil {
+			return zerolog.Logger{}, fmt.Errorf("invalid log level %q: %w", level, err)
+		}
+	}
+
+	zerolog.TimeFieldFormat = time.RFC3339Nano
+
+	logger := zerolog.New(os.Stdout).
+		Level(logLevel).
+		With().
+		Timestamp().
+		Logger()
+
+	return logger, nil
+}
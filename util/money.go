@@ -0,0 +1,140 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrCurrencyMismatch is returned by arithmetic on amounts of different currencies
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	// ErrAmountOverflow is returned when the result of arithmetic does not fit in int64 minor units
+	ErrAmountOverflow = errors.New("amount overflows")
+	// ErrInvalidAmount is returned by ParseMoney for strings that are not a decimal amount of the currency
+	ErrInvalidAmount = errors.New("invalid amount")
+)
+
+// defaultExponent formats amounts of codes missing from the registry, such as accounts opened before a rename
+const defaultExponent = 2
+
+// Money is an amount in the minor unit of its currency, 1250 USD is 12.50 dollars
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// NewMoney returns amount minor units of currency
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney reads a decimal amount of the major unit such as "12.50" or "-3", with at most as many decimals as
+// the exponent of currency allows, and returns it in minor units
+func ParseMoney(s string, currency string) (Money, error) {
+	info, ok := LookupCurrency(currency)
+	if !ok {
+		return Money{}, fmt.Errorf("%s is not an ISO 4217 currency", currency)
+	}
+
+	value := strings.TrimSpace(s)
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+
+	units, fraction, hasFraction := strings.Cut(value, ".")
+	if units == "" || (hasFraction && (fraction == "" || len(fraction) > info.Exponent)) {
+		return Money{}, fmt.Errorf("%w %q: %s has %d decimals", ErrInvalidAmount, s, currency, info.Exponent)
+	}
+
+	for _, digits := range []string{units, fraction} {
+		for _, c := range digits {
+			if c < '0' || c > '9' {
+				return Money{}, fmt.Errorf("%w %q", ErrInvalidAmount, s)
+			}
+		}
+	}
+
+	amount, err := strconv.ParseInt(units, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w %q", ErrAmountOverflow, s)
+	}
+
+	for i := 0; i < info.Exponent; i++ {
+		digit := int64(0)
+		if i < len(fraction) {
+			digit = int64(fraction[i] - '0')
+		}
+
+		if amount > (math.MaxInt64-digit)/10 {
+			return Money{}, fmt.Errorf("%w %q", ErrAmountOverflow, s)
+		}
+		amount = amount*10 + digit
+	}
+
+	if negative {
+		amount = -amount
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// Add returns the sum of two amounts of the same currency
+func (money Money) Add(other Money) (Money, error) {
+	if money.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s vs %s", ErrCurrencyMismatch, money.Currency, other.Currency)
+	}
+
+	if (other.Amount > 0 && money.Amount > math.MaxInt64-other.Amount) ||
+		(other.Amount < 0 && money.Amount < math.MinInt64-other.Amount) {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrAmountOverflow, money, other)
+	}
+
+	return Money{Amount: money.Amount + other.Amount, Currency: money.Currency}, nil
+}
+
+// Sub returns the difference of two amounts of the same currency
+func (money Money) Sub(other Money) (Money, error) {
+	if money.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s vs %s", ErrCurrencyMismatch, money.Currency, other.Currency)
+	}
+
+	if (other.Amount < 0 && money.Amount > math.MaxInt64+other.Amount) ||
+		(other.Amount > 0 && money.Amount < math.MinInt64+other.Amount) {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrAmountOverflow, money, other)
+	}
+
+	return Money{Amount: money.Amount - other.Amount, Currency: money.Currency}, nil
+}
+
+// Decimal formats the amount in the major unit with the decimals of the currency, such as "12.50" or "-3"
+func (money Money) Decimal() string {
+	exponent := defaultExponent
+	if info, ok := LookupCurrency(money.Currency); ok {
+		exponent = info.Exponent
+	}
+
+	// the digits are those of the absolute value, which for MinInt64 does not fit in int64
+	digits := strconv.FormatUint(uint64(money.Amount), 10)
+	sign := ""
+	if money.Amount < 0 {
+		digits = strconv.FormatUint(-uint64(money.Amount), 10)
+		sign = "-"
+	}
+
+	if exponent == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+// String formats the amount with its currency, such as "12.50 USD"
+func (money Money) String() string {
+	return money.Decimal() + " " + money.Currency
+}
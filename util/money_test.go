@@ -0,0 +1,103 @@
+package util
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	for _, tc := range []struct {
+		s        string
+		currency string
+		amount   int64
+	}{
+		{"12.50", USD, 1250},
+		{"12.5", USD, 1250},
+		{" 12 ", USD, 1200},
+		{"0.01", USD, 1},
+		{"-3.10", CAD, -310},
+		{"1500", "JPY", 1500},
+		{"1.234", "KWD", 1234},
+		{"92233720368547758.07", USD, math.MaxInt64},
+	} {
+		money, err := ParseMoney(tc.s, tc.currency)
+		require.NoError(t, err, tc.s)
+		require.Equal(t, NewMoney(tc.amount, tc.currency), money, tc.s)
+	}
+
+	for _, tc := range []struct {
+		s        string
+		currency string
+		err      error
+	}{
+		{"12.505", USD, ErrInvalidAmount},
+		{"12.5", "JPY", ErrInvalidAmount},
+		{"12.", USD, ErrInvalidAmount},
+		{".5", USD, ErrInvalidAmount},
+		{"1e3", USD, ErrInvalidAmount},
+		{"+1", USD, ErrInvalidAmount},
+		{"", USD, ErrInvalidAmount},
+		{"92233720368547758.08", USD, ErrAmountOverflow},
+		{"99999999999999999999", "JPY", ErrAmountOverflow},
+	} {
+		_, err := ParseMoney(tc.s, tc.currency)
+		require.ErrorIs(t, err, tc.err, tc.s)
+	}
+
+	_, err := ParseMoney("1", "NAR")
+	require.Error(t, err)
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	for _, tc := range []struct {
+		money    Money
+		expected string
+	}{
+		{NewMoney(1250, USD), "12.50"},
+		{NewMoney(5, USD), "0.05"},
+		{NewMoney(0, USD), "0.00"},
+		{NewMoney(-310, CAD), "-3.10"},
+		{NewMoney(1500, "JPY"), "1500"},
+		{NewMoney(-7, "JPY"), "-7"},
+		{NewMoney(1234, "KWD"), "1.234"},
+		{NewMoney(math.MinInt64, USD), "-92233720368547758.08"},
+		{NewMoney(150, "NAR"), "1.50"},
+	} {
+		require.Equal(t, tc.expected, tc.money.Decimal())
+	}
+
+	require.Equal(t, "12.50 USD", NewMoney(1250, USD).String())
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	sum, err := NewMoney(1250, USD).Add(NewMoney(-50, USD))
+	require.NoError(t, err)
+	require.Equal(t, NewMoney(1200, USD), sum)
+
+	difference, err := NewMoney(1250, USD).Sub(NewMoney(2000, USD))
+	require.NoError(t, err)
+	require.Equal(t, NewMoney(-750, USD), difference)
+
+	_, err = NewMoney(1, USD).Add(NewMoney(1, CAD))
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = NewMoney(1, USD).Sub(NewMoney(1, CAD))
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = NewMoney(math.MaxInt64, USD).Add(NewMoney(1, USD))
+	require.ErrorIs(t, err, ErrAmountOverflow)
+
+	_, err = NewMoney(math.MinInt64, USD).Add(NewMoney(-1, USD))
+	require.ErrorIs(t, err, ErrAmountOverflow)
+
+	_, err = NewMoney(math.MinInt64, USD).Sub(NewMoney(1, USD))
+	require.ErrorIs(t, err, ErrAmountOverflow)
+
+	_, err = NewMoney(0, USD).Sub(NewMoney(math.MinInt64, USD))
+	require.ErrorIs(t, err, ErrAmountOverflow)
+
+	sum, err = NewMoney(math.MaxInt64-1, USD).Add(NewMoney(1, USD))
+	require.NoError(t, err)
+	require.Equal(t, int64(math.MaxInt64), sum.Amount)
+}
@@ -44,7 +44,7 @@ func RandomMoney() int64 {
 
 // RandomCurrency generates a random currency
 func RandomCurrency() string {
-	currencies := []string{CAD, NAR, USD}
+	currencies := []string{CAD, NGN, USD}
 	n := len(currencies)
 
 	return currencies[rand.Intn(n)]
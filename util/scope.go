@@ -0,0 +1,35 @@
+package util
+
+// Scopes limit what an access token may do, route groups require one of them
+const (
+	AccountsReadScope   = "accounts:read"
+	AccountsWriteScope  = "accounts:write"
+	TransfersReadScope  = "transfers:read"
+	TransfersWriteScope = "transfers:write"
+	UsersReadScope      = "users:read"
+	UsersWriteScope     = "users:write"
+	WebhooksReadScope   = "webhooks:read"
+	WebhooksWriteScope  = "webhooks:write"
+)
+
+// UserScopes are granted to the tokens users get by logging in
+var UserScopes = []string{
+	AccountsReadScope,
+	AccountsWriteScope,
+	TransfersReadScope,
+	TransfersWriteScope,
+	UsersReadScope,
+	UsersWriteScope,
+	WebhooksReadScope,
+	WebhooksWriteScope,
+}
+
+// IsSupportedScope returns if a scope exists or not
+func IsSupportedScope(scope string) bool {
+	for _, s := range UserScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// secretTokenBytes is the entropy of tokens sent to users, 256 bits cannot be guessed
+const secretTokenBytes = 32
+
+// apiKeyPrefix starts every api key so leaked keys are easy to recognise, for example by secret scanners
+const apiKeyPrefix = "sbk_"
+
+// NewSecretToken generates a url safe random token from a cryptographically secure source
+func NewSecretToken() (string, error) {
+	b := make([]byte, secretTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("unable to generate token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashSecretToken returns the hex encoded sha256 of a token, only the hash is ever stored
+func HashSecretToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewAPIKey generates an api key and its prefix. The prefix is not secret, it is stored and shown
+// so users can tell their keys apart, while only the hash of the whole key is stored
+func NewAPIKey() (key string, prefix string, err error) {
+	b := make([]byte, 4)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("unable to generate api key: %w", err)
+	}
+
+	secret, err := NewSecretToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	prefix = apiKeyPrefix + hex.EncodeToString(b)
+	return prefix + "_" + secret, prefix, nil
+}
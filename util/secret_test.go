@@ -0,0 +1,36 @@
+package util
+
+import (
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestSecretToken(t *testing.T) {
+	tokenOne, err := NewSecretToken()
+	require.NoError(t, err)
+	require.Len(t, tokenOne, 43)
+
+	tokenTwo, err := NewSecretToken()
+	require.NoError(t, err)
+	require.NotEqual(t, tokenOne, tokenTwo)
+
+	hash := HashSecretToken(tokenOne)
+	require.Len(t, hash, 64)
+	require.Equal(t, hash, HashSecretToken(tokenOne))
+	require.NotEqual(t, hash, HashSecretToken(tokenTwo))
+}
+
+func TestAPIKey(t *testing.T) {
+	key, prefix, err := NewAPIKey()
+	require.NoError(t, err)
+	require.Len(t, prefix, 12)
+	require.True(t, strings.HasPrefix(prefix, apiKeyPrefix))
+	require.True(t, strings.HasPrefix(key, prefix+"_"))
+	require.Len(t, key, len(prefix)+1+43)
+
+	otherKey, otherPrefix, err := NewAPIKey()
+	require.NoError(t, err)
+	require.NotEqual(t, key, otherKey)
+	require.NotEqual(t, prefix, otherPrefix)
+}
@@ -0,0 +1,87 @@
+package util
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTOTPCode checks the SHA1 test vectors of RFC 6238 appendix B, truncated to six digits
+func TestTOTPCode(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+	}
+
+	for _, tc := range testCases {
+		code, err := TOTPCode(secret, TOTPStep(time.Unix(tc.unix, 0)))
+		require.NoError(t, err)
+		require.Equal(t, tc.code, code)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	step := TOTPStep(now)
+
+	code, err := TOTPCode(secret, step-1)
+	require.NoError(t, err)
+
+	matched, ok, err := ValidateTOTP(secret, code, now)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, step-1, matched)
+
+	code, err = TOTPCode(secret, step-2)
+	require.NoError(t, err)
+
+	_, ok, err = ValidateTOTP(secret, code, now)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, ok, err = ValidateTOTP(secret, "12345", now)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	require.NoError(t, err)
+
+	uri, err := url.Parse(TOTPURI("SimpleBank", "alice", secret))
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", uri.Scheme)
+	require.Equal(t, "totp", uri.Host)
+	require.Equal(t, "/SimpleBank:alice", uri.Path)
+	require.Equal(t, secret, uri.Query().Get("secret"))
+	require.Equal(t, "SimpleBank", uri.Query().Get("issuer"))
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := NewRecoveryCodes(10)
+	require.NoError(t, err)
+	require.Len(t, codes, 10)
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		require.Len(t, code, 19)
+		require.False(t, seen[code])
+		seen[code] = true
+
+		typed := strings.ToUpper(strings.ReplaceAll(code, "-", " "))
+		require.Equal(t, code, NormalizeRecoveryCode(typed))
+	}
+}
@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+)
+
+// ErrDisallowedAddress is returned when a webhook url points to a loopback, private or otherwise reserved address
+var ErrDisallowedAddress = errors.New("webhook url must not point to a private or reserved address")
+
+// reservedNetworks are the ranges not covered by the net.IP helpers that webhooks must not reach
+var reservedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",       // this network
+	"100.64.0.0/10",   // carrier-grade NAT
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // TEST-NET-1
+	"198.18.0.0/15",   // benchmarking
+	"198.51.100.0/24", // TEST-NET-2
+	"203.0.113.0/24",  // TEST-NET-3
+	"240.0.0.0/4",     // reserved, broadcast included
+	"64:ff9b::/96",    // IPv4/IPv6 translation
+	"2001:db8::/32",   // documentation
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
+
+// IsPublicIP returns if webhooks may be delivered to an ip. Loopback, RFC 1918 and unique local, link-local
+// (the 169.254.169.254 metadata endpoint included), multicast, unspecified and reserved addresses are not public
+func IsPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateURL resolves the host of a webhook url and returns ErrDisallowedAddress unless every address it resolves
+// to is public. The dispatcher checks the address it connects to again, as the host may resolve differently later
+func ValidateURL(ctx context.Context, resolver *net.Resolver, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	addrs, err := resolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("cannot resolve webhook host: %w", err)
+	}
+
+	for _, addr := range addrs {
+		if !IsPublicIP(addr.IP) {
+			return ErrDisallowedAddress
+		}
+	}
+	return nil
+}
+
+// publicAddressControl is a net.Dialer control function refusing connections to addresses that are not public.
+// It runs after the host was resolved, so a host resolving to a public address when the subscription was created
+// and to a private one when the event is delivered is refused as well
+func publicAddressControl(network string, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !IsPublicIP(ip) {
+		return ErrDisallowedAddress
+	}
+	return nil
+}
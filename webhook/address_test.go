@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"net"
+	"testing"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	for _, ip := range []string{"93.184.216.34", "8.8.8.8", "2606:4700:4700::1111"} {
+		require.True(t, IsPublicIP(net.ParseIP(ip)), ip)
+	}
+
+	for _, ip := range []string{
+		"127.0.0.1",
+		"10.1.2.3",
+		"172.16.0.1",
+		"192.168.1.1",
+		"169.254.169.254",
+		"0.0.0.0",
+		"100.64.0.1",
+		"255.255.255.255",
+		"224.0.0.1",
+		"::1",
+		"::",
+		"fe80::1",
+		"fd00::1",
+		"::ffff:127.0.0.1",
+		"::ffff:169.254.169.254",
+	} {
+		require.False(t, IsPublicIP(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	ctx := context.Background()
+
+	require.NoError(t, ValidateURL(ctx, net.DefaultResolver, "https://93.184.216.34/hook"))
+
+	for _, rawURL := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://10.0.0.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+	} {
+		require.ErrorIs(t, ValidateURL(ctx, net.DefaultResolver, rawURL), ErrDisallowedAddress, rawURL)
+	}
+}
+
+func TestPublicAddressControl(t *testing.T) {
+	require.NoError(t, publicAddressControl("tcp4", "93.184.216.34:443", nil))
+	require.ErrorIs(t, publicAddressControl("tcp4", "127.0.0.1:443", nil), ErrDisallowedAddress)
+	require.ErrorIs(t, publicAddressControl("tcp6", "[fd00::1]:443", nil), ErrDisallowedAddress)
+}
@@ -0,0 +1,279 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/rs/zerolog"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	userAgent = "simple_bank-webhooks/1.0"
+
+	// maxErrorLength bounds the error stored with a failed attempt
+	maxErrorLength = 512
+	// maxResponseBytes bounds how much of a response body is read before the connection is reused
+	maxResponseBytes = 64 << 10
+)
+
+// Dispatcher delivers pending webhook deliveries. Deliveries are claimed with a lock so several
+// dispatchers can run side by side, failed attempts are retried with exponential backoff and
+// deliveries failing too many times are marked dead until a user replays them
+type Dispatcher struct {
+	store     db.Store
+	client    *http.Client
+	encryptor *util.Encryptor
+	logger    zerolog.Logger
+
+	pollInterval time.Duration
+	batchSize    int32
+	timeout      time.Duration
+	maxAttempts  int32
+	baseDelay    time.Duration
+	maxDelay     time.Duration
+}
+
+// NewDispatcher creates a dispatcher configured by the WEBHOOK_ settings
+func NewDispatcher(config util.Config, store db.Store) (*Dispatcher, error) {
+	encryptor, err := util.LoadEncryptor("WEBHOOK_ENCRYPTION_KEY", config.WebhookEncryptionKeyFile, config.WebhookEncryptionKeyBase64)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create webhook encryptor: %w", err)
+	}
+
+	logger, err := util.NewLogger(config.LogLevel)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create logger: %w", err)
+	}
+
+	if config.WebhookBatchSize <= 0 || config.WebhookMaxAttempts <= 0 {
+		return nil, fmt.Errorf("WEBHOOK_BATCH_SIZE and WEBHOOK_MAX_ATTEMPTS must be positive")
+	}
+
+	dispatcher := &Dispatcher{
+		store:     store,
+		encryptor: encryptor,
+		logger:    logger,
+		client: &http.Client{
+			Transport: newTransport(config.WebhookAllowPrivateNetworks),
+			Timeout:   config.WebhookTimeout,
+			// a redirect is reported as a failed attempt instead of posting the event somewhere else
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		pollInterval: config.WebhookPollInterval,
+		batchSize:    config.WebhookBatchSize,
+		timeout:      config.WebhookTimeout,
+		maxAttempts:  config.WebhookMaxAttempts,
+		baseDelay:    config.WebhookBackoffBaseDelay,
+		maxDelay:     config.WebhookBackoffMaxDelay,
+	}
+
+	return dispatcher, nil
+}
+
+// newTransport creates the transport deliveries are sent with. Unless private networks are allowed, it refuses to
+// connect to addresses that are not public and ignores proxies, which would otherwise be dialed instead
+func newTransport(allowPrivateNetworks bool) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if allowPrivateNetworks {
+		return transport
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   publicAddressControl,
+	}
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+
+	return transport
+}
+
+// Run delivers pending deliveries every poll interval until ctx is done
+func (dispatcher *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(dispatcher.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := dispatcher.DeliverPending(ctx)
+			if err != nil && ctx.Err() == nil {
+				dispatcher.logger.Error().Err(err).Msg("cannot deliver webhooks")
+			}
+
+			// keep draining while full batches are claimed
+			if err != nil || n < int(dispatcher.batchSize) {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverPending claims a batch of due deliveries and attempts each of them once, it returns how many deliveries
+// were attempted. A delivery whose attempt cannot be recorded does not stop the rest of the batch, it stays
+// claimed until its lock expires and the error is returned once the batch is done
+func (dispatcher *Dispatcher) DeliverPending(ctx context.Context) (int, error) {
+	// a claimed delivery is attempted again by any dispatcher once the lock expires,
+	// which only happens when this one stopped before recording the attempt
+	lockedUntil := time.Now().Add(2*dispatcher.timeout + time.Minute)
+
+	deliveries, err := dispatcher.store.ClaimWebhookDeliveries(ctx, db.ClaimWebhookDeliveriesParams{
+		LockedUntil: lockedUntil,
+		Limit:       dispatcher.batchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var failed int
+	var firstErr error
+	for _, delivery := range deliveries {
+		err = dispatcher.deliver(ctx, delivery)
+		if err != nil {
+			dispatcher.logger.Error().Err(err).Int64("delivery_id", delivery.ID).Msg("cannot deliver webhook")
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed++
+		}
+	}
+
+	if firstErr != nil {
+		return len(deliveries), fmt.Errorf("cannot deliver %d of %d webhooks: %w", failed, len(deliveries), firstErr)
+	}
+
+	return len(deliveries), nil
+}
+
+// deliver posts a delivery to its subscription and records the outcome of the attempt
+func (dispatcher *Dispatcher) deliver(ctx context.Context, delivery db.WebhookDelivery) error {
+	subscription, err := dispatcher.store.GetWebhookSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// the subscription was deleted together with its deliveries after the claim
+			return nil
+		}
+		return err
+	}
+
+	var statusCode int
+	secret, attemptErr := dispatcher.encryptor.Decrypt(subscription.Secret)
+	if attemptErr != nil {
+		// a secret that cannot be decrypted fails the attempt like an unreachable receiver, so the delivery is
+		// retried in case the key is fixed and dead-lettered otherwise
+		attemptErr = fmt.Errorf("cannot decrypt secret of webhook subscription %d: %w", subscription.ID, attemptErr)
+	} else {
+		statusCode, attemptErr = dispatcher.post(ctx, subscription.Url, secret, delivery)
+		if attemptErr != nil && ctx.Err() != nil {
+			// shutting down, the delivery is attempted again once its lock expires
+			return nil
+		}
+	}
+
+	now := time.Now()
+	arg := db.RecordWebhookDeliveryAttemptParams{
+		ID:            delivery.ID,
+		Status:        db.WebhookDeliverySucceeded,
+		NextAttemptAt: now,
+		DeliveredAt:   sql.NullTime{Time: now, Valid: true},
+	}
+
+	if statusCode != 0 {
+		arg.LastStatusCode = sql.NullInt32{Int32: int32(statusCode), Valid: true}
+	}
+
+	if attemptErr != nil {
+		attempts := delivery.Attempts + 1
+
+		arg.Status = db.WebhookDeliveryPending
+		arg.NextAttemptAt = now.Add(dispatcher.backoff(attempts))
+		arg.DeliveredAt = sql.NullTime{}
+		arg.LastError = sql.NullString{String: truncate(attemptErr.Error(), maxErrorLength), Valid: true}
+
+		if attempts >= dispatcher.maxAttempts {
+			arg.Status = db.WebhookDeliveryDead
+		}
+	}
+
+	recorded, err := dispatcher.store.RecordWebhookDeliveryAttempt(ctx, arg)
+	if err != nil {
+		return err
+	}
+
+	event := dispatcher.logger.Info()
+	if recorded.Status == db.WebhookDeliveryDead {
+		event = dispatcher.logger.Warn()
+	}
+
+	event.
+		Int64("delivery_id", recorded.ID).
+		Int64("subscription_id", subscription.ID).
+		Str("event_type", recorded.EventType).
+		Str("status", recorded.Status).
+		Int32("attempts", recorded.Attempts).
+		Int("status_code", statusCode).
+		Msg("webhook delivery attempted")
+
+	return nil
+}
+
+// post sends the event, any response other than a 2xx is an error
+func (dispatcher *Dispatcher) post(ctx context.Context, url string, secret string, delivery db.WebhookDelivery) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", userAgent)
+	request.Header.Set(EventTypeHeader, delivery.EventType)
+	request.Header.Set(DeliveryIDHeader, strconv.FormatInt(delivery.ID, 10))
+	request.Header.Set(SignatureHeader, Sign(secret, time.Now(), delivery.Payload))
+
+	response, err := dispatcher.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, maxResponseBytes))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+
+	return response.StatusCode, nil
+}
+
+// backoff returns the delay before the next attempt, doubling from the base delay up to the max delay
+func (dispatcher *Dispatcher) backoff(attempts int32) time.Duration {
+	delay := dispatcher.baseDelay << (attempts - 1)
+	if delay <= 0 || delay > dispatcher.maxDelay {
+		delay = dispatcher.maxDelay
+	}
+
+	return delay
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
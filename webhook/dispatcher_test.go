@@ -0,0 +1,397 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receivedDelivery is a request captured by testReceiver
+type receivedDelivery struct {
+	header http.Header
+	body   []byte
+}
+
+// testReceiver answers deliveries with the next status of statuses, then with 200
+type testReceiver struct {
+	mu         sync.Mutex
+	statuses   []int
+	deliveries []receivedDelivery
+}
+
+func (receiver *testReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+
+	receiver.deliveries = append(receiver.deliveries, receivedDelivery{header: r.Header.Clone(), body: body})
+
+	status := http.StatusOK
+	if len(receiver.statuses) > 0 {
+		status, receiver.statuses = receiver.statuses[0], receiver.statuses[1:]
+	}
+
+	if status == http.StatusFound {
+		w.Header().Set("Location", "/elsewhere")
+	}
+	w.WriteHeader(status)
+}
+
+// randomEncryptionKey returns 32 random bytes as a key for util.NewEncryptor
+func randomEncryptionKey(t *testing.T) string {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	return string(key)
+}
+
+func newTestDispatcher(t *testing.T, store db.Store, encryptionKey string) *Dispatcher {
+	dispatcher, err := NewDispatcher(util.Config{
+		LogLevel:                   "disabled",
+		WebhookEncryptionKeyBase64: base64.StdEncoding.EncodeToString([]byte(encryptionKey)),
+		WebhookPollInterval:        10 * time.Millisecond,
+		WebhookBatchSize:           10,
+		WebhookTimeout:             time.Second,
+		WebhookMaxAttempts:         3,
+		WebhookBackoffBaseDelay:    time.Minute,
+		WebhookBackoffMaxDelay:     time.Hour,
+		// the test receivers listen on loopback
+		WebhookAllowPrivateNetworks: true,
+	}, store)
+	require.NoError(t, err)
+
+	return dispatcher
+}
+
+// newTestSubscription creates a user subscribed to transfer.created events at url and returns it with its secret
+func newTestSubscription(t *testing.T, store db.Store, encryptor *util.Encryptor, url string) (db.WebhookSubscription, string) {
+	ctx := context.Background()
+
+	user, err := store.CreateUser(ctx, db.CreateUserParams{
+		Username:       util.RandomOwner() + util.RandomString(6),
+		HashedPassword: util.RandomString(32),
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	secret := util.RandomString(32)
+	encryptedSecret, err := encryptor.Encrypt(secret)
+	require.NoError(t, err)
+
+	subscription, err := store.CreateWebhookSubscription(ctx, db.CreateWebhookSubscriptionParams{
+		Username:   user.Username,
+		Url:        url,
+		EventTypes: []string{EventTransferCreated},
+		Secret:     encryptedSecret,
+	})
+	require.NoError(t, err)
+
+	return subscription, secret
+}
+
+func TestDispatcherDeliversSignedEvents(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+	encryptionKey := randomEncryptionKey(t)
+	encryptor, err := util.NewEncryptor(encryptionKey)
+	require.NoError(t, err)
+
+	receiver := &testReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	subscription, secret := newTestSubscription(t, store, encryptor, server.URL)
+
+	event, err := NewEvent(EventTransferCreated, map[string]int64{"amount": 10})
+	require.NoError(t, err)
+
+	// the owner of both accounts of a transfer is notified once
+	err = Enqueue(ctx, store, event, subscription.Username, subscription.Username, util.RandomOwner())
+	require.NoError(t, err)
+
+	dispatcher := newTestDispatcher(t, store, encryptionKey)
+	n, err := dispatcher.DeliverPending(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	require.Len(t, receiver.deliveries, 1)
+	received := receiver.deliveries[0]
+	require.Equal(t, EventTransferCreated, received.header.Get(EventTypeHeader))
+	require.Equal(t, "application/json", received.header.Get("Content-Type"))
+	require.NoError(t, VerifySignature(secret, received.header.Get(SignatureHeader), received.body, time.Minute, time.Now()))
+
+	var receivedEvent Event
+	require.NoError(t, json.Unmarshal(received.body, &receivedEvent))
+	require.Equal(t, event.ID, receivedEvent.ID)
+	require.JSONEq(t, `{"amount":10}`, string(receivedEvent.Data))
+
+	deliveries, err := store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{SubscriptionID: subscription.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, strconv.FormatInt(deliveries[0].ID, 10), received.header.Get(DeliveryIDHeader))
+	require.Equal(t, db.WebhookDeliverySucceeded, deliveries[0].Status)
+	require.Equal(t, int32(1), deliveries[0].Attempts)
+	require.Equal(t, int32(http.StatusOK), deliveries[0].LastStatusCode.Int32)
+	require.True(t, deliveries[0].DeliveredAt.Valid)
+
+	// succeeded deliveries are not attempted again
+	n, err = dispatcher.DeliverPending(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestDispatcherRetriesAndDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+	encryptionKey := randomEncryptionKey(t)
+	encryptor, err := util.NewEncryptor(encryptionKey)
+	require.NoError(t, err)
+
+	// a redirect is not followed and counts as a failure like any other non 2xx response
+	receiver := &testReceiver{statuses: []int{http.StatusInternalServerError, http.StatusFound, http.StatusServiceUnavailable}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	subscription, _ := newTestSubscription(t, store, encryptor, server.URL)
+
+	event, err := NewEvent(EventTransferCreated, map[string]int64{"amount": 10})
+	require.NoError(t, err)
+	require.NoError(t, Enqueue(ctx, store, event, subscription.Username))
+
+	dispatcher := newTestDispatcher(t, store, encryptionKey)
+	dispatcher.baseDelay = time.Millisecond
+	dispatcher.maxDelay = time.Millisecond
+
+	getDelivery := func() db.WebhookDelivery {
+		deliveries, err := store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{SubscriptionID: subscription.ID, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		return deliveries[0]
+	}
+
+	deliverAfterBackoff := func() {
+		time.Sleep(2 * time.Millisecond)
+		n, err := dispatcher.DeliverPending(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+	}
+
+	deliverAfterBackoff()
+	delivery := getDelivery()
+	require.Equal(t, db.WebhookDeliveryPending, delivery.Status)
+	require.Equal(t, int32(1), delivery.Attempts)
+	require.Equal(t, int32(http.StatusInternalServerError), delivery.LastStatusCode.Int32)
+	require.Equal(t, "unexpected status 500", delivery.LastError.String)
+	require.False(t, delivery.DeliveredAt.Valid)
+
+	deliverAfterBackoff()
+	delivery = getDelivery()
+	require.Equal(t, db.WebhookDeliveryPending, delivery.Status)
+	require.Equal(t, int32(2), delivery.Attempts)
+	require.Equal(t, int32(http.StatusFound), delivery.LastStatusCode.Int32)
+
+	// the last allowed attempt fails, the delivery is dead-lettered
+	deliverAfterBackoff()
+	delivery = getDelivery()
+	require.Equal(t, db.WebhookDeliveryDead, delivery.Status)
+	require.Equal(t, int32(3), delivery.Attempts)
+	require.Equal(t, int32(http.StatusServiceUnavailable), delivery.LastStatusCode.Int32)
+
+	time.Sleep(2 * time.Millisecond)
+	n, err := dispatcher.DeliverPending(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	// a replayed delivery is attempted again with the same event
+	_, err = store.ReplayWebhookDelivery(ctx, db.ReplayWebhookDeliveryParams{ID: delivery.ID, SubscriptionID: subscription.ID})
+	require.NoError(t, err)
+
+	deliverAfterBackoff()
+	delivery = getDelivery()
+	require.Equal(t, db.WebhookDeliverySucceeded, delivery.Status)
+	require.Equal(t, int32(1), delivery.Attempts)
+
+	require.Len(t, receiver.deliveries, 4)
+	for _, received := range receiver.deliveries {
+		require.Equal(t, receiver.deliveries[0].body, received.body)
+	}
+}
+
+func TestDispatcherUnreachableReceiver(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+	encryptionKey := randomEncryptionKey(t)
+	encryptor, err := util.NewEncryptor(encryptionKey)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(&testReceiver{})
+	server.Close()
+
+	subscription, _ := newTestSubscription(t, store, encryptor, server.URL)
+
+	event, err := NewEvent(EventTransferCreated, nil)
+	require.NoError(t, err)
+	require.NoError(t, Enqueue(ctx, store, event, subscription.Username))
+
+	dispatcher := newTestDispatcher(t, store, encryptionKey)
+	_, err = dispatcher.DeliverPending(ctx)
+	require.NoError(t, err)
+
+	deliveries, err := store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{SubscriptionID: subscription.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, db.WebhookDeliveryPending, deliveries[0].Status)
+	require.Equal(t, int32(1), deliveries[0].Attempts)
+	require.False(t, deliveries[0].LastStatusCode.Valid)
+	require.NotEmpty(t, deliveries[0].LastError.String)
+	require.WithinDuration(t, time.Now().Add(time.Minute), deliveries[0].NextAttemptAt, time.Second)
+}
+
+func TestDispatcherRefusesPrivateAddresses(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+	encryptionKey := randomEncryptionKey(t)
+	encryptor, err := util.NewEncryptor(encryptionKey)
+	require.NoError(t, err)
+
+	receiver := &testReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	subscription, _ := newTestSubscription(t, store, encryptor, server.URL)
+
+	event, err := NewEvent(EventTransferCreated, nil)
+	require.NoError(t, err)
+	require.NoError(t, Enqueue(ctx, store, event, subscription.Username))
+
+	dispatcher := newTestDispatcher(t, store, encryptionKey)
+	dispatcher.client.Transport = newTransport(false)
+
+	_, err = dispatcher.DeliverPending(ctx)
+	require.NoError(t, err)
+	require.Empty(t, receiver.deliveries)
+
+	deliveries, err := store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{SubscriptionID: subscription.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, db.WebhookDeliveryPending, deliveries[0].Status)
+	require.Contains(t, deliveries[0].LastError.String, ErrDisallowedAddress.Error())
+}
+
+func TestDispatcherDeadLettersUndecryptableSecrets(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+	encryptor, err := util.NewEncryptor(randomEncryptionKey(t))
+	require.NoError(t, err)
+
+	receiver := &testReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	// the secret was encrypted with another key than the dispatcher has
+	subscription, _ := newTestSubscription(t, store, encryptor, server.URL)
+
+	event, err := NewEvent(EventTransferCreated, nil)
+	require.NoError(t, err)
+	require.NoError(t, Enqueue(ctx, store, event, subscription.Username))
+
+	dispatcher := newTestDispatcher(t, store, randomEncryptionKey(t))
+	dispatcher.baseDelay = time.Millisecond
+	dispatcher.maxDelay = time.Millisecond
+
+	for attempt := int32(1); attempt <= dispatcher.maxAttempts; attempt++ {
+		time.Sleep(2 * time.Millisecond)
+		n, err := dispatcher.DeliverPending(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		deliveries, err := store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{SubscriptionID: subscription.ID, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, attempt, deliveries[0].Attempts)
+		require.Contains(t, deliveries[0].LastError.String, "cannot decrypt secret")
+	}
+
+	deliveries, err := store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{SubscriptionID: subscription.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, db.WebhookDeliveryDead, deliveries[0].Status)
+	require.Empty(t, receiver.deliveries)
+}
+
+// failingSubscriptionStore fails to get one webhook subscription
+type failingSubscriptionStore struct {
+	db.Store
+	subscriptionID int64
+}
+
+func (store *failingSubscriptionStore) GetWebhookSubscription(ctx context.Context, id int64) (db.WebhookSubscription, error) {
+	if id == store.subscriptionID {
+		return db.WebhookSubscription{}, sql.ErrConnDone
+	}
+	return store.Store.GetWebhookSubscription(ctx, id)
+}
+
+func TestDispatcherContinuesAfterErrors(t *testing.T) {
+	ctx := context.Background()
+	memoryStore := db.NewMemoryStore()
+	encryptionKey := randomEncryptionKey(t)
+	encryptor, err := util.NewEncryptor(encryptionKey)
+	require.NoError(t, err)
+
+	receiver := &testReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	failing, _ := newTestSubscription(t, memoryStore, encryptor, server.URL)
+	subscription, _ := newTestSubscription(t, memoryStore, encryptor, server.URL)
+
+	event, err := NewEvent(EventTransferCreated, nil)
+	require.NoError(t, err)
+	require.NoError(t, Enqueue(ctx, memoryStore, event, failing.Username, subscription.Username))
+
+	store := &failingSubscriptionStore{Store: memoryStore, subscriptionID: failing.ID}
+	dispatcher := newTestDispatcher(t, store, encryptionKey)
+
+	// the delivery of the failing subscription does not hold back the other one
+	n, err := dispatcher.DeliverPending(ctx)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.Contains(t, err.Error(), "cannot deliver 1 of 2 webhooks")
+	require.Equal(t, 2, n)
+	require.Len(t, receiver.deliveries, 1)
+
+	deliveries, err := store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{SubscriptionID: subscription.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, db.WebhookDeliverySucceeded, deliveries[0].Status)
+
+	deliveries, err = store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{SubscriptionID: failing.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, db.WebhookDeliveryPending, deliveries[0].Status)
+	require.Zero(t, deliveries[0].Attempts)
+}
+
+func TestDispatcherBackoff(t *testing.T) {
+	dispatcher := &Dispatcher{baseDelay: time.Minute, maxDelay: time.Hour}
+
+	require.Equal(t, time.Minute, dispatcher.backoff(1))
+	require.Equal(t, 2*time.Minute, dispatcher.backoff(2))
+	require.Equal(t, 32*time.Minute, dispatcher.backoff(6))
+	require.Equal(t, time.Hour, dispatcher.backoff(7))
+	require.Equal(t, time.Hour, dispatcher.backoff(100))
+}
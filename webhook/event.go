@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Event types users can subscribe to
+const (
+	EventTransferCreated = "transfer.created"
+	EventAccountCreated  = "account.created"
+	EventAccountFrozen   = "account.frozen"
+)
+
+// EventTypes are all the event types users can subscribe to
+var EventTypes = []string{
+	EventAccountCreated,
+	EventAccountFrozen,
+	EventTransferCreated,
+}
+
+// IsSupportedEventType returns if an event type exists or not
+func IsSupportedEventType(eventType string) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is the json body posted to subscribers, its id stays the same when a delivery is retried
+// or replayed so receivers can drop duplicates
+type Event struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewEvent creates an event of the given type carrying data as its json payload
+func NewEvent(eventType string, data interface{}) (Event, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return Event{}, err
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("cannot encode %s event: %w", eventType, err)
+	}
+
+	return Event{ID: id, Type: eventType, CreatedAt: time.Now().UTC(), Data: payload}, nil
+}
+
+// Enqueue creates a pending delivery of the event for every subscription of the users to its type.
+// A user is only notified once even when listed several times, like the owner of both sides of a transfer,
+// and enqueuing an event again leaves the deliveries already created for it untouched
+func Enqueue(ctx context.Context, store db.Store, event Event, usernames ...string) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(usernames))
+	for _, username := range usernames {
+		if seen[username] {
+			continue
+		}
+		seen[username] = true
+
+		subscriptions, err := store.ListWebhookSubscriptionsForEvent(ctx, db.ListWebhookSubscriptionsForEventParams{
+			Username:  username,
+			EventType: event.Type,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, subscription := range subscriptions {
+			_, err = store.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+				SubscriptionID: subscription.ID,
+				EventID:        event.ID,
+				EventType:      event.Type,
+				Payload:        body,
+			})
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/outbox"
+	"github.com/google/uuid"
+	"strconv"
+)
+
+// eventNamespace derives the ids of the events created from outbox messages
+var eventNamespace = uuid.MustParse("5b0f3c36-93a4-4c1e-9a51-3f0e5d2b7c48")
+
+// Publisher queues the outbox messages users can subscribe to as webhook events for the owner of their account,
+// so every change written to the outbox is also sent to webhooks whichever process made it. A message published
+// again yields the same event, which is only queued once per subscription
+type Publisher struct {
+	store db.Store
+}
+
+// NewPublisher creates a publisher queuing webhook deliveries in store
+func NewPublisher(store db.Store) *Publisher {
+	return &Publisher{store: store}
+}
+
+func (publisher *Publisher) Publish(ctx context.Context, message outbox.Message) error {
+	if !IsSupportedEventType(message.Type) || message.AggregateType != db.AggregateAccount {
+		return nil
+	}
+
+	accountID, err := strconv.ParseInt(message.AggregateID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid account id %q: %w", message.AggregateID, err)
+	}
+
+	account, err := publisher.store.GetAccount(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("cannot get account %d: %w", accountID, err)
+	}
+
+	id, err := eventID(message)
+	if err != nil {
+		return err
+	}
+
+	event := Event{
+		ID:        id,
+		Type:      message.Type,
+		CreatedAt: message.CreatedAt.UTC(),
+		Data:      message.Data,
+	}
+
+	return Enqueue(ctx, publisher.store, event, account.Owner)
+}
+
+// eventID derives the id of the event of a message. A transfer is written to the outbox once for each of its
+// accounts, both messages share the id of the transfer so the owner of both accounts is only notified once
+func eventID(message outbox.Message) (uuid.UUID, error) {
+	name := "outbox/" + strconv.FormatInt(message.ID, 10)
+
+	if message.Type == EventTransferCreated {
+		var transfer db.TransferCreatedEvent
+		if err := json.Unmarshal(message.Data, &transfer); err != nil {
+			return uuid.UUID{}, fmt.Errorf("cannot decode %s event: %w", message.Type, err)
+		}
+		name = "transfer/" + strconv.FormatInt(transfer.ID, 10)
+	}
+
+	return uuid.NewSHA1(eventNamespace, []byte(name)), nil
+}
@@ -0,0 +1,124 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	db "github.com/AbdRaqeeb/simple_bank/db/sqlc"
+	"github.com/AbdRaqeeb/simple_bank/outbox"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPublisherQueuesOutboxEvents(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+
+	user, err := store.CreateUserTx(ctx, db.CreateUserParams{
+		Username:       util.RandomOwner() + util.RandomString(6),
+		HashedPassword: util.RandomString(32),
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	subscription, err := store.CreateWebhookSubscription(ctx, db.CreateWebhookSubscriptionParams{
+		Username:   user.Username,
+		Url:        "https://example.com/hooks",
+		EventTypes: []string{EventAccountCreated, EventTransferCreated},
+		Secret:     util.RandomString(32),
+	})
+	require.NoError(t, err)
+
+	// both accounts of the transfer belong to the user
+	accounts := make([]db.Account, 2)
+	for i, currency := range []string{util.USD, util.CAD} {
+		accounts[i], err = store.CreateAccountTx(ctx, db.CreateAccountParams{
+			Owner:       user.Username,
+			Balance:     100,
+			Currency:    currency,
+			ProductCode: db.AccountProductChecking,
+		})
+		require.NoError(t, err)
+	}
+
+	// a change made outside the api, like by the import-payments command, only writes the outbox
+	result, err := store.TransferTx(ctx, db.TransferTxParams{FromAccountID: accounts[0].ID, ToAccountID: accounts[1].ID, Amount: 10})
+	require.NoError(t, err)
+
+	publisher := NewPublisher(store)
+	relay, err := outbox.NewRelay(util.Config{
+		LogLevel:           "disabled",
+		OutboxPollInterval: 10 * time.Millisecond,
+		OutboxBatchSize:    100,
+	}, store, outbox.NewFanoutPublisher(outbox.NewMemoryPublisher(), publisher))
+	require.NoError(t, err)
+
+	published := 0
+	for {
+		n, err := relay.PublishPending(ctx)
+		require.NoError(t, err)
+		if n == 0 {
+			break
+		}
+		published += n
+	}
+	require.Equal(t, 5, published)
+
+	deliveries, err := store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{SubscriptionID: subscription.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 3)
+
+	// the owner of both accounts gets the transfer once
+	byType := make(map[string][]db.WebhookDelivery)
+	for _, delivery := range deliveries {
+		byType[delivery.EventType] = append(byType[delivery.EventType], delivery)
+	}
+	require.Len(t, byType[EventAccountCreated], 2)
+	require.Len(t, byType[EventTransferCreated], 1)
+	transfer := byType[EventTransferCreated][0]
+
+	var event Event
+	require.NoError(t, json.Unmarshal(transfer.Payload, &event))
+	require.Equal(t, transfer.EventID, event.ID)
+
+	var data db.TransferCreatedEvent
+	require.NoError(t, json.Unmarshal(event.Data, &data))
+	require.Equal(t, result.Transfer.ID, data.ID)
+	require.Equal(t, int64(10), data.Amount)
+
+	// publishing a message again, as the relay does after a failure, queues nothing new
+	message := outbox.Message{
+		ID:            1000,
+		AggregateType: db.AggregateAccount,
+		AggregateID:   "0",
+		Type:          EventTransferCreated,
+		CreatedAt:     event.CreatedAt,
+		Data:          event.Data,
+	}
+	for _, account := range accounts {
+		message.AggregateID = strconv.FormatInt(account.ID, 10)
+		require.NoError(t, publisher.Publish(ctx, message))
+	}
+
+	deliveries, err = store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{SubscriptionID: subscription.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 3)
+}
+
+func TestPublisherIgnoresOtherEvents(t *testing.T) {
+	store := db.NewMemoryStore()
+	publisher := NewPublisher(store)
+
+	// user.created is not sent to webhooks, so its aggregate is never looked up
+	err := publisher.Publish(context.Background(), outbox.Message{
+		ID:            1,
+		AggregateType: db.AggregateUser,
+		AggregateID:   util.RandomOwner(),
+		Type:          db.EventUserCreated,
+		Data:          json.RawMessage(`{}`),
+	})
+	require.NoError(t, err)
+}
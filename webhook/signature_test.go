@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"fmt"
+	"github.com/AbdRaqeeb/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignature(t *testing.T) {
+	secret := util.RandomString(32)
+	body := []byte(`{"type":"transfer.created"}`)
+	now := time.Now()
+
+	header := Sign(secret, now, body)
+	require.Regexp(t, `^t=\d+,v1=[0-9a-f]{64}$`, header)
+
+	testCases := []struct {
+		name   string
+		secret string
+		header string
+		body   []byte
+		now    time.Time
+		err    error
+	}{
+		{
+			name:   "OK",
+			secret: secret,
+			header: header,
+			body:   body,
+			now:    now,
+		},
+		{
+			name:   "Wrong Secret",
+			secret: util.RandomString(32),
+			header: header,
+			body:   body,
+			now:    now,
+			err:    ErrInvalidSignature,
+		},
+		{
+			name:   "Tampered Body",
+			secret: secret,
+			header: header,
+			body:   []byte(`{"type":"account.created"}`),
+			now:    now,
+			err:    ErrInvalidSignature,
+		},
+		{
+			name:   "Tampered Timestamp",
+			secret: secret,
+			header: fmt.Sprintf("t=%d,%s", now.Add(time.Minute).Unix(), header[strings.Index(header, "v1="):]),
+			body:   body,
+			now:    now,
+			err:    ErrInvalidSignature,
+		},
+		{
+			name:   "Expired",
+			secret: secret,
+			header: header,
+			body:   body,
+			now:    now.Add(10 * time.Minute),
+			err:    ErrExpiredSignature,
+		},
+		{
+			name:   "Malformed",
+			secret: secret,
+			header: "v1",
+			body:   body,
+			now:    now,
+			err:    ErrInvalidSignature,
+		},
+		{
+			name:   "Missing Signature",
+			secret: secret,
+			header: header[:len("t=")+10],
+			body:   body,
+			now:    now,
+			err:    ErrInvalidSignature,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifySignature(tc.secret, tc.header, tc.body, 5*time.Minute, tc.now)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}